@@ -0,0 +1,68 @@
+package levtrie
+
+import (
+	"container/list"
+	"sync"
+)
+
+// absentCache is an LRU cache of keys that were recently looked up and found
+// missing from a Trie, see NegativeCache. It has its own lock, since the
+// lookups of a SyncTrie only hold a read lock.
+type absentCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List // Holds keys, most recently used first.
+	items map[string]*list.Element
+}
+
+func newAbsentCache(size int) *absentCache {
+	if size < 1 {
+		size = 1
+	}
+	return &absentCache{size: size, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// has returns true if key is known to be missing.
+func (c *absentCache) has(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		return true
+	}
+	return false
+}
+
+// add records that key is missing, forgetting the least recently used key if
+// the cache is full.
+func (c *absentCache) add(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[key]; ok {
+		return
+	}
+	c.items[key] = c.ll.PushFront(key)
+	if c.ll.Len() > c.size {
+		el := c.ll.Back()
+		c.ll.Remove(el)
+		delete(c.items, el.Value.(string))
+	}
+}
+
+// remove forgets key, which is no longer missing.
+func (c *absentCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.ll.Remove(el)
+		delete(c.items, key)
+	}
+}
+
+// clear forgets every key.
+func (c *absentCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+}
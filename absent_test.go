@@ -0,0 +1,44 @@
+package levtrie
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNegativeCache(t *testing.T) {
+	r := New(NegativeCache(2))
+	r.Set("tea", "green")
+	for _, key := range []string{"teh", "tae", "teh"} {
+		if r.Has(key) {
+			t.Errorf("Has(%v) = true", key)
+		}
+	}
+	if r.absent.items["teh"] == nil || r.absent.items["tae"] == nil {
+		t.Error("Misses weren't cached")
+	}
+	r.Get("te")
+	if r.absent.items["tae"] != nil || len(r.absent.items) != 2 {
+		t.Errorf("Cache has %v keys, want 2 without the least recently used", len(r.absent.items))
+	}
+	// Every way of adding a key forgets that it was missing.
+	r.Set("tae", "typo")
+	r.MSet([]KV{{"te", "short"}})
+	r.Values("ten")
+	r.Rename("tea", "ten")
+	r.SetWithTTL("gone", "soon", -time.Minute)
+	r.Get("gone")
+	r.SetWithTTL("gone", "back", time.Minute)
+	for _, key := range []string{"tae", "te", "ten", "gone"} {
+		if !r.Has(key) {
+			t.Errorf("Has(%v) = false after adding it", key)
+		}
+	}
+	if err := r.ValidateInvariants(); err != nil {
+		t.Error(err)
+	}
+	r.MGet([]string{"missing"})
+	r.Clear()
+	if len(r.absent.items) != 0 {
+		t.Error("Clear didn't clear the cache")
+	}
+}
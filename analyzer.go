@@ -0,0 +1,137 @@
+package levtrie
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Tokenizer splits text into words, returning the byte offsets of the start
+// and end of each word, in order. CorrectText uses the Tokenizer of a Trie's
+// Analyzer to find the words to check.
+type Tokenizer func(text string) [][2]int
+
+// WordTokenizer is the Tokenizer CorrectText uses by default: a word is a run
+// of letters and combining marks, possibly with apostrophes between them like
+// "don't".
+var WordTokenizer Tokenizer = func(text string) [][2]int {
+	var spans [][2]int
+	for _, tok := range tokenize(text) {
+		spans = append(spans, [2]int{tok.start, tok.end})
+	}
+	return spans
+}
+
+// Analyzer bundles the settings that decide how a Trie treats the text of a
+// language: how text is split into words, how queries are normalized and how
+// far queries of each length are searched. Registering an Analyzer under a
+// name with RegisterAnalyzer and creating tries with UseAnalyzer configures
+// every Trie for a language the same way, so a deployment that serves
+// several languages can set up "en", "de" and "ja" once and refer to them by
+// name.
+type Analyzer struct {
+	// Tokenizer splits text into words for CorrectText. If it's nil,
+	// WordTokenizer is used.
+	Tokenizer Tokenizer
+	// Normalize holds the QueryProcessors that queries pass through, as
+	// with PreprocessQueries. Keys should be normalized the same way before
+	// they're added, which Trie.Analyze does.
+	Normalize []QueryProcessor
+	// Policy chooses the distance of searches with a negative distance,
+	// as with AdaptiveDistance. If it's nil, DefaultDistancePolicy is used.
+	Policy DistancePolicy
+}
+
+var (
+	analyzersMu sync.RWMutex
+	analyzers   = make(map[string]Analyzer)
+)
+
+func init() {
+	RegisterAnalyzer("standard", Analyzer{Normalize: []QueryProcessor{TrimQuery, LowercaseQuery}})
+}
+
+// RegisterAnalyzer makes a available to UseAnalyzer under the given name.
+// RegisterAnalyzer panics if it's called twice with the same name.
+func RegisterAnalyzer(name string, a Analyzer) {
+	analyzersMu.Lock()
+	defer analyzersMu.Unlock()
+	if _, dup := analyzers[name]; dup {
+		panic("levtrie: RegisterAnalyzer called twice for " + name)
+	}
+	a.Normalize = append([]QueryProcessor(nil), a.Normalize...)
+	analyzers[name] = a
+}
+
+// LookupAnalyzer returns the Analyzer registered under the given name, or an
+// error if there isn't one. The "standard" Analyzer trims and lowercases
+// queries.
+func LookupAnalyzer(name string) (Analyzer, error) {
+	analyzersMu.RLock()
+	defer analyzersMu.RUnlock()
+	a, ok := analyzers[name]
+	if !ok {
+		return Analyzer{}, fmt.Errorf("levtrie: unknown analyzer %q", name)
+	}
+	a.Normalize = append([]QueryProcessor(nil), a.Normalize...)
+	return a, nil
+}
+
+// Analyzers returns the sorted names of the registered Analyzers.
+func Analyzers() []string {
+	analyzersMu.RLock()
+	defer analyzersMu.RUnlock()
+	names := make([]string, 0, len(analyzers))
+	for name := range analyzers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UseAnalyzer configures a Trie with the Analyzer registered under the given
+// name: its Normalize steps are added as with PreprocessQueries, and its
+// Tokenizer and Policy replace any set before, even if they're nil, which
+// restores the defaults. UseAnalyzer panics if there's no such Analyzer,
+// since that's a mistake in the program; check names that come from
+// configuration with LookupAnalyzer first.
+func UseAnalyzer(name string) Option {
+	a, err := LookupAnalyzer(name)
+	if err != nil {
+		panic(err)
+	}
+	return func(t *Trie) {
+		t.preprocessors = append(t.preprocessors, a.Normalize...)
+		t.tokenizer, t.policy = a.Tokenizer, a.Policy
+	}
+}
+
+// Analyze normalizes key the way the Trie normalizes queries, see
+// PreprocessQueries and UseAnalyzer, and returns the result and true, or
+// false if a step vetoed it. Passing keys through Analyze before adding them
+// makes them match the queries that look them up.
+func (t *Trie) Analyze(key string) (string, bool) {
+	return t.preprocess(key)
+}
+
+// tokens splits text into words with the Trie's Tokenizer.
+func (t *Trie) tokens(text string) []token {
+	if t.tokenizer == nil {
+		return tokenize(text)
+	}
+	spans := t.tokenizer(text)
+	tokens := make([]token, 0, len(spans))
+	for _, s := range spans {
+		if 0 <= s[0] && s[0] < s[1] && s[1] <= len(text) {
+			tokens = append(tokens, token{word: text[s[0]:s[1]], start: s[0], end: s[1]})
+		}
+	}
+	return tokens
+}
+
+// Analyze passes key through the analysis of the SyncTrie. See Trie.Analyze.
+func (s *SyncTrie) Analyze(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Analyze(key)
+}
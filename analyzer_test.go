@@ -0,0 +1,101 @@
+package levtrie
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzer(t *testing.T) {
+	RegisterAnalyzer("test-de", Analyzer{
+		Normalize: []QueryProcessor{LowercaseQuery, func(q string) (string, bool) {
+			return strings.ReplaceAll(q, "ß", "ss"), true
+		}},
+		Policy: DistancePolicy{0, 100},
+	})
+	// Splits text on commas, so words can contain spaces.
+	RegisterAnalyzer("test-csv", Analyzer{Tokenizer: func(text string) [][2]int {
+		var spans [][2]int
+		start := 0
+		for i, r := range text + "," {
+			if r == ',' {
+				spans = append(spans, [2]int{start, i})
+				start = i + 1
+			}
+		}
+		return spans
+	}})
+	names := Analyzers()
+	for _, name := range []string{"standard", "test-csv", "test-de"} {
+		if i := strings.Join(names, " "); !strings.Contains(i, name) {
+			t.Errorf("Analyzers() = %v, want %v in it", names, name)
+		}
+	}
+
+	r := New(UseAnalyzer("test-de"))
+	for _, key := range []string{"Straße", "Strasse", "Strand"} {
+		if key, ok := r.Analyze(key); ok {
+			r.Set(key, "")
+		}
+	}
+	if r.Len() != 2 || !r.Has("STRASSE") {
+		t.Errorf("Got %v keys, want strasse and strand", keystr(r.Suggest("", 127, 10)))
+	}
+	// The policy searches 6 runes within distance 1.
+	if got := keystr(r.Suggest("Straße", -1, 10)); got != "strasse" {
+		t.Errorf("Suggest(Straße, -1) = %v, want strasse", got)
+	}
+	if got := keystr(r.Suggest("Strase", -1, 10)); got != "strasse" {
+		t.Errorf("Suggest(Strase, -1) = %v, want strasse", got)
+	}
+
+	r = New(UseAnalyzer("test-csv"))
+	r.Set("new york", "")
+	r.Set("boston", "")
+	var words []string
+	for _, c := range r.CorrectText("new yrk,boston,bostn", CorrectOptions{Distance: 1}) {
+		words = append(words, c.Word+":"+strings.Join(c.Candidates, "|"))
+	}
+	if want := []string{"new yrk:new york", "bostn:boston"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("CorrectText() = %v, want %v", words, want)
+	}
+	if got := keystr(r.Freeze().Thaw().Suggest("bostn", 1, 10)); got != "boston" {
+		t.Errorf("Thawed Suggest(bostn) = %v, want boston", got)
+	}
+	if f := r.Freeze(); f.t.tokenizer == nil {
+		t.Errorf("Freeze() dropped the Tokenizer")
+	}
+	// An Analyzer without a Tokenizer or Policy restores the defaults.
+	r = New(AdaptiveDistance(DistancePolicy{0, 100}), UseAnalyzer("test-csv"), UseAnalyzer("standard"))
+	if r.tokenizer != nil || r.policy != nil {
+		t.Errorf("UseAnalyzer(standard) kept the Tokenizer or Policy set before")
+	}
+
+	if _, err := LookupAnalyzer("missing"); err == nil {
+		t.Errorf("LookupAnalyzer(missing) succeeded")
+	}
+	for name, f := range map[string]func(){
+		"UseAnalyzer(missing)":            func() { UseAnalyzer("missing") },
+		"RegisterAnalyzer(test-de) again": func() { RegisterAnalyzer("test-de", Analyzer{}) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%v didn't panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestWordTokenizer(t *testing.T) {
+	text := "Don't stop, 42 times!"
+	var words []string
+	for _, s := range WordTokenizer(text) {
+		words = append(words, text[s[0]:s[1]])
+	}
+	if want := []string{"Don't", "stop", "times"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("WordTokenizer(%q) = %v, want %v", text, words, want)
+	}
+}
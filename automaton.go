@@ -0,0 +1,54 @@
+package levtrie
+
+// Automaton is a Levenshtein automaton compiled for a query, an edit distance
+// and SuggestOptions, the same automaton the Suggest methods run while they
+// walk a Trie. Matches and Distance run it against plain strings, so one
+// compiled Automaton can check a stream of candidates that come from
+// somewhere other than a Trie, like a database scan or another index, without
+// building the automaton again for each one. Of the SuggestOptions, only the
+// ones that change which keys match apply: DeletionsOnly, InsertionsOnly,
+// EditCosts, AffixTolerance and Constrain. An Automaton keeps scratch space
+// for its simulation, so it isn't safe for concurrent use; compile one for
+// each goroutine instead. Don't create directly, use Compile() instead.
+type Automaton struct {
+	a   automaton
+	d   int8
+	cfg *searchConfig
+}
+
+// Compile returns an Automaton that accepts the strings within edit distance
+// d of query using the given SuggestOptions, the strings a Suggest for query
+// would return if they were keys in a Trie. If d is negative, the Automaton
+// doesn't accept anything.
+func Compile(query string, d int8, opts ...SuggestOption) *Automaton {
+	if d < 0 {
+		return &Automaton{d: -1}
+	}
+	cfg := newSearchConfig(query, opts)
+	// A string is checked against the Acceptor of Constrain in full before
+	// the simulation, so the automaton doesn't need to run it too.
+	cfg.prune = false
+	return &Automaton{a: newAutomaton(extractRunes(query), d, cfg), d: d, cfg: cfg}
+}
+
+// Matches returns true exactly when the Automaton accepts s.
+func (a *Automaton) Matches(s string) bool {
+	_, ok := a.Distance(s)
+	return ok
+}
+
+// Distance returns the edit distance between the query and s and true if the
+// Automaton accepts s, or 0 and false if it doesn't. With EditCosts or
+// AffixTolerance, the distance is the smallest total cost of the edits. The
+// simulation stops as soon as no prefix of s can be extended to a string the
+// Automaton accepts, so rejecting a long string that starts far from the
+// query is cheap.
+func (a *Automaton) Distance(s string) (int, bool) {
+	if a.d < 0 || !a.cfg.accepts(s) {
+		return 0, false
+	}
+	if dist := matchDistance(a.a, a.d, s); dist <= int(a.d) {
+		return dist, true
+	}
+	return 0, false
+}
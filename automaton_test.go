@@ -0,0 +1,35 @@
+package levtrie
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAutomaton(t *testing.T) {
+	rand.Seed(0)
+	keys := generateEdits(5, 300)
+	for _, query := range keys[:20] {
+		for d := int8(0); d <= 3; d++ {
+			a := Compile(query, d)
+			for _, key := range keys {
+				want := Distance(query, key)
+				if got, ok := a.Distance(key); ok != (want <= int(d)) || ok && got != want {
+					t.Fatalf("Compile(%q, %v).Distance(%q) = %v, %v, want %v", query, d, key, got, ok, want)
+				}
+				if a.Matches(key) != (want <= int(d)) {
+					t.Fatalf("Compile(%q, %v).Matches(%q) = %v", query, d, key, a.Matches(key))
+				}
+			}
+		}
+	}
+	a := Compile("color", 2, EditCosts(1, 3, 2))
+	if got, ok := a.Distance("colour"); !ok || got != 1 {
+		t.Errorf("Got %v, %v, want 1, true", got, ok)
+	}
+	if a.Matches("colr") {
+		t.Error("Matched colr, which needs a deletion that costs 3")
+	}
+	if Compile("abc", -1).Matches("abc") {
+		t.Error("An automaton with a negative distance matched")
+	}
+}
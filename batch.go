@@ -0,0 +1,99 @@
+package levtrie
+
+import "unicode/utf8"
+
+// cursor finds the nodes for a sequence of keys, starting each search from the
+// deepest node on the path to the previous key that's also on the path to the
+// next one. When consecutive keys share long prefixes, as they do when keys
+// are sorted or generated from a common stem, most of each search is skipped.
+type cursor struct {
+	path []*node // path[i] is the node for the first i runes of prev.
+	prev string
+}
+
+func newCursor(root *node) *cursor {
+	return &cursor{path: []*node{root}}
+}
+
+// reset forgets the path to the previous key, which is necessary when nodes on
+// the path may have been removed from the Trie.
+func (c *cursor) reset() {
+	c.path = c.path[:1]
+	c.prev = ""
+}
+
+// seek returns the node for key, creating it and any missing nodes on the way
+// if create is true. If create is false and there's no such node, it returns
+// nil.
+func (c *cursor) seek(key string, create bool) *node {
+	// Find the longest common prefix of prev and key that ends on a rune
+	// boundary, then limit it to the part of prev that the path covers.
+	i := 0
+	for i < len(c.prev) && i < len(key) && c.prev[i] == key[i] {
+		i++
+	}
+	for i > 0 && i < len(key) && !utf8.RuneStart(key[i]) {
+		i--
+	}
+	common := utf8.RuneCountInString(key[:i])
+	if common > len(c.path)-1 {
+		common, i = 0, 0
+		for common < len(c.path)-1 {
+			_, w := utf8.DecodeRuneInString(key[i:])
+			common, i = common+1, i+w
+		}
+	}
+	c.path = c.path[:common+1]
+	c.prev = key
+	for _, r := range key[i:] {
+		n := c.path[len(c.path)-1]
+		next, ok := n.child[r]
+		if !ok {
+			if !create {
+				return nil
+			}
+			next = &node{child: make(map[rune]*node)}
+			n.child[r] = next
+		}
+		c.path = append(c.path, next)
+	}
+	return c.path[len(c.path)-1]
+}
+
+// MGet returns a KV for each of the keys that's in the Trie, in the same order
+// as keys, holding the value that Get would return for the key. Keys that
+// aren't in the Trie are skipped. Looking up keys with common prefixes one
+// after the other, like keys in sorted order, is faster than calling Get for
+// each of them, since MGet only follows the part of each key that differs
+// from the previous one.
+func (t *Trie) MGet(keys []string) []KV {
+	results := make([]KV, 0, len(keys))
+	c := newCursor(t.tree())
+	for _, key := range keys {
+		key, ok := t.preprocess(key)
+		if !ok || !t.mayContain(key) {
+			continue
+		}
+		if n := c.seek(key, false); n != nil && n.data.live() {
+			t.touch(n.data)
+			results = append(results, KV{Key: key, Value: t.output(key, n.data.value())})
+		} else {
+			t.missed(key)
+		}
+	}
+	return results
+}
+
+// MSet calls Set for each KV in kvs, in order, but like MGet it only follows
+// the part of each key that differs from the previous one, so it's faster for
+// keys with common prefixes.
+func (t *Trie) MSet(kvs []KV) {
+	c := newCursor(t.ensureRoot())
+	for _, kv := range kvs {
+		t.set(t.upsertAt(c.seek(kv.Key, true), kv.Key), kv.Value)
+		if t.maxKeys > 0 && t.size >= t.maxKeys {
+			// Eviction may have removed nodes on the path.
+			c.reset()
+		}
+	}
+}
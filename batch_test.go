@@ -0,0 +1,72 @@
+package levtrie
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestMGet(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock = func() time.Time { return now }
+	defer func() { clock = time.Now }()
+	r := New(BloomFilter(10, 0.01))
+	r.Set("help", "1")
+	r.Set("hello", "2")
+	r.Add("he", "3")
+	r.Add("he", "4")
+	r.SetWithTTL("helm", "5", time.Second)
+	r.Set("", "6")
+	now = now.Add(time.Minute)
+	got := r.MGet([]string{"hello", "helm", "help", "h", "he", "", "hello", "héllo", "world"})
+	want := []KV{{"hello", "2"}, {"help", "1"}, {"he", "3"}, {"", "6"}, {"hello", "2"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	if got := r.MGet(nil); len(got) != 0 {
+		t.Errorf("Got %v, want nothing", got)
+	}
+}
+
+func TestMGetMatchesGet(t *testing.T) {
+	keys := generateEdits(5, 1000)
+	r := New()
+	for _, key := range keys[:500] {
+		r.Set(key, key+"!")
+	}
+	sort.Strings(keys)
+	var want []KV
+	for _, key := range keys {
+		if v, ok := r.Get(key); ok {
+			want = append(want, KV{Key: key, Value: v})
+		}
+	}
+	if got := r.MGet(keys); !reflect.DeepEqual(got, want) {
+		t.Errorf("MGet and Get disagree")
+	}
+}
+
+func TestMSet(t *testing.T) {
+	for _, opts := range [][]Option{nil, {MaxKeys(4)}, {InternValues(), TrigramIndex(0.1)}} {
+		kvs := []KV{{"help", "1"}, {"hello", "2"}, {"he", "3"}, {"help", "4"}, {"world", "5"}, {"", "6"}, {"hé", "7"}}
+		r, s := New(opts...), New(opts...)
+		var ops []Op
+		r.OnChange(func(op Op) { ops = append(ops, op) })
+		r.MSet(kvs)
+		var wantOps []Op
+		s.OnChange(func(op Op) { wantOps = append(wantOps, op) })
+		for _, kv := range kvs {
+			s.Set(kv.Key, kv.Value)
+		}
+		if got, want := r.ToMap(), s.ToMap(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Got %v after MSet, want %v as with Set", got, want)
+		}
+		if !reflect.DeepEqual(ops, wantOps) {
+			t.Errorf("Got ops %v after MSet, want %v as with Set", ops, wantOps)
+		}
+		if err := r.ValidateInvariants(); err != nil {
+			t.Error(err)
+		}
+	}
+}
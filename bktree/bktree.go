@@ -0,0 +1,196 @@
+// Package bktree implements levtrie.Index with a BK-tree, which arranges keys
+// by their Levenshtein distance from each other and uses the triangle
+// inequality to skip most of them during a search. A BK-tree does less work
+// than a Trie for searches with a large edit distance relative to the length
+// of the query, but it can't search by prefix.
+//
+// Importing this package registers the Tree as "bktree" with
+// levtrie.RegisterIndex.
+package bktree
+
+import (
+	"sort"
+
+	"github.com/aaw/levtrie"
+)
+
+func init() {
+	levtrie.RegisterIndex("bktree", func() levtrie.Index { return New() })
+}
+
+// node is a key in the Tree. Each child of a node is at a distinct
+// Levenshtein distance from the node's key.
+type node struct {
+	key     string
+	value   string
+	deleted bool
+	child   map[int]*node
+}
+
+// Tree is a BK-tree. Deleted keys stay in the Tree as tombstones to keep its
+// shape intact until they outnumber the remaining keys, at which point the
+// Tree is rebuilt. Don't create directly, use bktree.New() instead.
+type Tree struct {
+	root    *node
+	size    int // The number of keys in the Tree, not counting tombstones.
+	deleted int // The number of tombstones in the Tree.
+}
+
+var _ levtrie.Index = (*Tree)(nil)
+
+// New returns a new, empty Tree.
+func New() *Tree {
+	return &Tree{}
+}
+
+// Len returns the number of keys in the Tree.
+func (t *Tree) Len() int {
+	return t.size
+}
+
+// find returns the node for key, including tombstones, or nil if there isn't
+// one.
+func (t *Tree) find(key string) *node {
+	n := t.root
+	for n != nil {
+		d := levtrie.Distance(key, n.key)
+		if d == 0 {
+			return n
+		}
+		n = n.child[d]
+	}
+	return nil
+}
+
+// Get returns the value associated with key and true, or the empty string and
+// false if there's no such key in the Tree.
+func (t *Tree) Get(key string) (string, bool) {
+	if n := t.find(key); n != nil && !n.deleted {
+		return n.value, true
+	}
+	return "", false
+}
+
+// Set associates key with val in the Tree, replacing any previous value.
+func (t *Tree) Set(key string, val string) {
+	if t.root == nil {
+		t.root = &node{key: key, value: val}
+		t.size++
+		return
+	}
+	n := t.root
+	for {
+		d := levtrie.Distance(key, n.key)
+		if d == 0 {
+			if n.deleted {
+				n.deleted = false
+				t.deleted--
+				t.size++
+			}
+			n.value = val
+			return
+		}
+		next, ok := n.child[d]
+		if !ok {
+			if n.child == nil {
+				n.child = make(map[int]*node)
+			}
+			n.child[d] = &node{key: key, value: val}
+			t.size++
+			return
+		}
+		n = next
+	}
+}
+
+// Delete removes key and its value from the Tree.
+func (t *Tree) Delete(key string) {
+	n := t.find(key)
+	if n == nil || n.deleted {
+		return
+	}
+	n.deleted = true
+	n.value = ""
+	t.size--
+	t.deleted++
+	if t.deleted > t.size {
+		t.rebuild()
+	}
+}
+
+// rebuild replaces the Tree with a new Tree containing only its live keys.
+func (t *Tree) rebuild() {
+	var kvs []levtrie.KV
+	t.walk(func(n *node) {
+		kvs = append(kvs, levtrie.KV{Key: n.key, Value: n.value})
+	})
+	*t = Tree{}
+	for _, kv := range kvs {
+		t.Set(kv.Key, kv.Value)
+	}
+}
+
+// walk calls f on every live node in the Tree.
+func (t *Tree) walk(f func(n *node)) {
+	if t.root == nil {
+		return
+	}
+	stack := []*node{t.root}
+	for len(stack) > 0 {
+		var n *node
+		n, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		if !n.deleted {
+			f(n)
+		}
+		for _, c := range n.child {
+			stack = append(stack, c)
+		}
+	}
+}
+
+// Suggest returns up to n KVs with keys that are within edit distance d of
+// key, ordered by edit distance and then by key. SuggestOptions restrict the
+// results the same way they do for levtrie.Trie.Suggest, except that keys
+// that are only within d of the query because of free edits allowed by
+// levtrie.AffixTolerance aren't found, since the Tree is organized by
+// Levenshtein distance.
+func (t *Tree) Suggest(key string, d int8, n int, opts ...levtrie.SuggestOption) []levtrie.KV {
+	if t.root == nil || d < 0 || n <= 0 {
+		return nil
+	}
+	type match struct {
+		n    *node
+		dist int
+	}
+	var found []match
+	stack := []*node{t.root}
+	for len(stack) > 0 {
+		var x *node
+		x, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		dist := levtrie.Distance(key, x.key)
+		if dist <= int(d) && !x.deleted && (len(opts) == 0 || levtrie.Within(key, x.key, d, opts...)) {
+			found = append(found, match{n: x, dist: dist})
+		}
+		// By the triangle inequality, keys within d of the query are in
+		// subtrees at distance dist - d through dist + d from x.
+		for cd, c := range x.child {
+			if cd >= dist-int(d) && cd <= dist+int(d) {
+				stack = append(stack, c)
+			}
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].dist != found[j].dist {
+			return found[i].dist < found[j].dist
+		}
+		return found[i].n.key < found[j].n.key
+	})
+	if len(found) > n {
+		found = found[:n]
+	}
+	results := make([]levtrie.KV, len(found))
+	for i, m := range found {
+		results[i] = levtrie.KV{Key: m.n.key, Value: m.n.value}
+	}
+	return results
+}
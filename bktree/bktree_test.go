@@ -0,0 +1,105 @@
+package bktree
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/aaw/levtrie"
+)
+
+func randWord(r *rand.Rand) string {
+	alphabet := []rune("abcdé")
+	rs := make([]rune, 1+r.Intn(7))
+	for i := range rs {
+		rs[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return string(rs)
+}
+
+func TestGetSetDelete(t *testing.T) {
+	tree := New()
+	tree.Set("hello", "1")
+	tree.Set("help", "2")
+	tree.Set("hello", "3")
+	if got, ok := tree.Get("hello"); !ok || got != "3" {
+		t.Errorf("Got (%v, %v), want (3, true)", got, ok)
+	}
+	if tree.Len() != 2 {
+		t.Errorf("Got Len() = %v, want 2", tree.Len())
+	}
+	tree.Delete("hello")
+	tree.Delete("hello")
+	if _, ok := tree.Get("hello"); ok {
+		t.Errorf("Got deleted key hello")
+	}
+	if got, ok := tree.Get("help"); !ok || got != "2" {
+		t.Errorf("Got (%v, %v), want (2, true)", got, ok)
+	}
+	if tree.Len() != 1 {
+		t.Errorf("Got Len() = %v, want 1", tree.Len())
+	}
+	tree.Set("hello", "4")
+	if got, ok := tree.Get("hello"); !ok || got != "4" {
+		t.Errorf("Got (%v, %v), want (4, true)", got, ok)
+	}
+}
+
+func TestSuggestMatchesTrie(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	tree, trie := New(), levtrie.New()
+	for i := 0; i < 2000; i++ {
+		w := randWord(r)
+		tree.Set(w, w)
+		trie.Set(w, w)
+	}
+	for i := 0; i < 1000; i++ {
+		w := randWord(r)
+		tree.Delete(w)
+		trie.Delete(w)
+	}
+	if tree.Len() != trie.Len() {
+		t.Fatalf("Got Len() = %v, want %v", tree.Len(), trie.Len())
+	}
+	for i := 0; i < 50; i++ {
+		q, d := randWord(r), int8(r.Intn(4))
+		got := tree.Suggest(q, d, trie.Len())
+		want := trie.Suggest(q, d, trie.Len())
+		if len(got) != len(want) {
+			t.Fatalf("Suggest(%q, %v): got %v, want %v", q, d, got, want)
+		}
+		for j, kv := range got {
+			if j > 0 && levtrie.Distance(q, got[j-1].Key) > levtrie.Distance(q, kv.Key) {
+				t.Errorf("Suggest(%q, %v) isn't ordered by distance: %v", q, d, got)
+			}
+			if v, ok := trie.Get(kv.Key); !ok || v != kv.Value {
+				t.Errorf("Suggest(%q, %v) returned %v, which isn't in the Trie", q, d, kv)
+			}
+		}
+	}
+}
+
+func TestSuggestOptions(t *testing.T) {
+	tree := New()
+	for _, w := range []string{"int", "intl", "intern", "internet", "tern"} {
+		tree.Set(w, "")
+	}
+	got := tree.Suggest("intern", 4, 10, levtrie.DeletionsOnly())
+	want := []levtrie.KV{{Key: "intern"}, {Key: "tern"}, {Key: "int"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	if got := tree.Suggest("intern", 4, 2); len(got) != 2 || got[0].Key != "intern" {
+		t.Errorf("Got %v, want intern and one other key", got)
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	x, err := levtrie.NewIndex("bktree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := x.(*Tree); !ok {
+		t.Errorf("Got %T, want *Tree", x)
+	}
+}
@@ -0,0 +1,119 @@
+package levtrie
+
+import (
+	"hash/maphash"
+	"math"
+)
+
+// bloom is a Bloom filter of the keys in a Trie. Keys are never removed from
+// the filter, so it's rebuilt from the keys in the Trie once more keys have
+// been added to it than it was sized for.
+type bloom struct {
+	bits     []uint64
+	k        int     // The number of hash functions.
+	capacity int     // The number of keys the filter was sized for.
+	fpRate   float64 // The target false positive rate at capacity.
+	added    int     // The number of keys added since the filter was built.
+	seed     maphash.Seed
+}
+
+// newBloom returns a Bloom filter sized for capacity keys with a false
+// positive rate of fpRate.
+func newBloom(capacity int, fpRate float64) *bloom {
+	if capacity < 1 {
+		capacity = 1
+	}
+	// The optimal number of bits is -n ln p / (ln 2)^2 and the optimal
+	// number of hash functions is (m / n) ln 2.
+	m := int(math.Ceil(-float64(capacity) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := int(math.Round(float64(m) / float64(capacity) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &bloom{
+		bits:     make([]uint64, (m+63)/64),
+		k:        k,
+		capacity: capacity,
+		fpRate:   fpRate,
+		seed:     maphash.MakeSeed(),
+	}
+}
+
+// hashes returns two hashes of key, which are combined to simulate k hash
+// functions.
+func (b *bloom) hashes(key string) (uint64, uint64) {
+	h := maphash.String(b.seed, key)
+	return h, h>>32 | h<<32 | 1
+}
+
+func (b *bloom) add(key string) {
+	h1, h2 := b.hashes(key)
+	m := uint64(len(b.bits) * 64)
+	for i := 0; i < b.k; i++ {
+		bit := (h1 + uint64(i)*h2) % m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+	b.added++
+}
+
+// mayContain returns false only if key was never added to the filter.
+func (b *bloom) mayContain(key string) bool {
+	h1, h2 := b.hashes(key)
+	m := uint64(len(b.bits) * 64)
+	for i := 0; i < b.k; i++ {
+		bit := (h1 + uint64(i)*h2) % m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// mayContain returns false if key is definitely not in the Trie, without
+// walking the Trie if the Trie has a Bloom filter or a NegativeCache.
+func (t *Trie) mayContain(key string) bool {
+	if t.absent != nil && t.absent.has(key) {
+		return false
+	}
+	return t.bloom == nil || t.bloom.mayContain(key)
+}
+
+// missed records that key was looked up and found missing.
+func (t *Trie) missed(key string) {
+	if t.absent != nil {
+		t.absent.add(key)
+	}
+}
+
+// addToBloom adds a new key to the Bloom filter of the Trie, if there is one,
+// rebuilding the filter if it's full.
+func (t *Trie) addToBloom(key string) {
+	if t.bloom == nil {
+		return
+	}
+	if t.bloom.added < t.bloom.capacity {
+		t.bloom.add(key)
+		return
+	}
+	capacity := t.bloom.capacity
+	if 2*t.size > capacity {
+		capacity = 2 * t.size
+	}
+	t.bloom = newBloom(capacity, t.bloom.fpRate)
+	stack := []*node{t.tree()}
+	for len(stack) > 0 {
+		var x *node
+		x, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		if x.data != nil {
+			t.bloom.add(x.data.key)
+		}
+		for _, child := range x.child {
+			stack = append(stack, child)
+		}
+	}
+	// The new key may not be attached to the Trie yet.
+	t.bloom.add(key)
+}
@@ -0,0 +1,69 @@
+package levtrie
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBloomNoFalseNegatives(t *testing.T) {
+	b := newBloom(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		b.add(fmt.Sprint(i))
+	}
+	for i := 0; i < 1000; i++ {
+		if !b.mayContain(fmt.Sprint(i)) {
+			t.Fatalf("Filter doesn't contain %d", i)
+		}
+	}
+}
+
+func TestBloomFalsePositiveRate(t *testing.T) {
+	b := newBloom(10000, 0.01)
+	for i := 0; i < 10000; i++ {
+		b.add(fmt.Sprint(i))
+	}
+	fp := 0
+	for i := 10000; i < 110000; i++ {
+		if b.mayContain(fmt.Sprint(i)) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / 100000; rate > 0.02 {
+		t.Errorf("Got false positive rate %v, want about 0.01", rate)
+	}
+}
+
+func TestBloomFilterGet(t *testing.T) {
+	r := New(BloomFilter(2, 0.01))
+	for i := 0; i < 100; i++ {
+		r.Set(fmt.Sprint(i), fmt.Sprint(i*i))
+	}
+	if r.bloom.capacity < 100 {
+		t.Errorf("Got capacity %v after growing, want at least 100", r.bloom.capacity)
+	}
+	for i := 0; i < 100; i++ {
+		expectGet(t, r, fmt.Sprint(i), fmt.Sprint(i*i))
+	}
+	for i := 100; i < 200; i++ {
+		if v, ok := r.Get(fmt.Sprint(i)); ok {
+			t.Errorf("Got %v for %d, want nothing", v, i)
+		}
+	}
+	r.Delete("7")
+	if _, ok := r.Get("7"); ok {
+		t.Errorf("Got a value for deleted key 7")
+	}
+	r.Set("7", "49")
+	expectGet(t, r, "7", "49")
+}
+
+func TestBloomFilterMaxKeys(t *testing.T) {
+	r := New(BloomFilter(4, 0.01), MaxKeys(4))
+	for i := 0; i < 20; i++ {
+		r.Set(fmt.Sprint(i), "x")
+		expectGet(t, r, fmt.Sprint(i), "x")
+	}
+	if r.Len() != 4 {
+		t.Errorf("Got %v keys, want 4", r.Len())
+	}
+}
@@ -0,0 +1,96 @@
+package levtrie
+
+// BucketCap makes a search take at most k keys from each edit distance, then
+// fill any results left with the closest matches it passed over, so close
+// matches don't crowd out farther ones. It implies Ordered and only applies to
+// methods that take a limit n. A k of 0 or less turns it off.
+func BucketCap(k int) SuggestOption {
+	return func(cfg *searchConfig) {
+		cfg.bucketCap = k
+		if k > 0 {
+			cfg.ordered = true
+		}
+	}
+}
+
+// bucketed is a match found by collectBuckets and its distance.
+type bucketed struct {
+	e    *entry
+	dist int8
+}
+
+// collectBuckets is collectEntries for BucketCap. Matches arrive in order of
+// distance, so each one either fits within the cap of its distance and is
+// taken, or is set aside in case the results have to be filled. At most limit
+// matches are set aside, since no more can be needed, and the search ends
+// once the matches taken make up limit results.
+func collectBuckets(limit int, cfg *searchConfig, find func(visit func(*entry) bool)) []*entry {
+	var taken, over []bucketed
+	n := 0                       // The number of results in taken.
+	counts := make(map[int8]int) // The number of matches taken at each distance.
+	take := func(e *entry, dist int8) bool {
+		if counts[dist] < cfg.bucketCap {
+			counts[dist]++
+			taken = append(taken, bucketed{e, dist})
+			n += kvCount(e, cfg.valuesPerKey)
+		} else if len(over) < limit {
+			over = append(over, bucketed{e, dist})
+		}
+		return n < limit
+	}
+	var found []*entry        // Every match, when they have to be chosen from.
+	var dists map[*entry]int8 // The distance of each match in found.
+	if cfg.collectsAll() {
+		dists = make(map[*entry]int8)
+	}
+	find(func(e *entry) bool {
+		if cfg.excluded(e) {
+			return true
+		}
+		if cfg.collectsAll() {
+			found = append(found, e)
+			dists[e] = cfg.dist
+			return !cfg.stops(e)
+		}
+		return take(e, cfg.dist) && !cfg.stops(e)
+	})
+	if cfg.collectsAll() {
+		for _, e := range cfg.choose(found) {
+			if !take(e, dists[e]) {
+				break
+			}
+		}
+	}
+	// Merge the matches taken with enough of the ones set aside, keeping
+	// the order of distance. Both lists are already in that order, and a
+	// match set aside at some distance was found after every match taken
+	// at that distance.
+	var results []*entry
+	m, fill := 0, limit-n // The number of results so far and left to fill.
+	for i, j := 0, 0; m < limit && (i < len(taken) || j < len(over)); {
+		if j < len(over) && fill > 0 && (i == len(taken) || over[j].dist < taken[i].dist) {
+			results = append(results, over[j].e)
+			m += kvCount(over[j].e, cfg.valuesPerKey)
+			fill -= kvCount(over[j].e, cfg.valuesPerKey)
+			j++
+		} else if i < len(taken) {
+			results = append(results, taken[i].e)
+			m += kvCount(taken[i].e, cfg.valuesPerKey)
+			i++
+		} else {
+			break
+		}
+	}
+	return results
+}
+
+// kvCount returns the number of results appendKVs adds for e.
+func kvCount(e *entry, valuesPerKey int) int {
+	if len(e.values) == 0 {
+		return 1
+	}
+	if valuesPerKey > 0 && valuesPerKey < len(e.values) {
+		return valuesPerKey
+	}
+	return len(e.values)
+}
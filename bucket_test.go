@@ -0,0 +1,109 @@
+package levtrie
+
+import (
+	"reflect"
+	"testing"
+)
+
+// naiveBucketCap returns the keys of the results of a search with BucketCap(k)
+// and limit n, given every match of the search in Ordered order.
+func naiveBucketCap(matches []string, dists []int, k, n int) []string {
+	counts := make(map[int]int)
+	taken := make([]bool, len(matches))
+	total := 0
+	for i := range matches {
+		if counts[dists[i]] < k && total < n {
+			counts[dists[i]]++
+			taken[i] = true
+			total++
+		}
+	}
+	for i := range matches {
+		if !taken[i] && total < n {
+			taken[i] = true
+			total++
+		}
+	}
+	var keys []string
+	for i, key := range matches {
+		if taken[i] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func TestBucketCap(t *testing.T) {
+	r := New()
+	for _, key := range []string{"cat", "bat", "cot", "cut", "hat", "mat", "cast", "coat", "at", "scat", "dog"} {
+		r.Set(key, "")
+	}
+	got := resultKeys(r.Suggest("cat", 1, 6, BucketCap(3)))
+	// "cat" is the only key at distance 0, so the results are filled
+	// with the keys at distance 1 that the cap passed over.
+	want := []string{"cat", "at", "bat", "cast", "coat", "cot"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Suggest with BucketCap(3) = %v, want %v", got, want)
+	}
+	got = resultKeys(r.Suggest("cat", 2, 4, BucketCap(2)))
+	want = []string{"cat", "at", "bat", "cast"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Suggest with BucketCap(2) = %v, want %v", got, want)
+	}
+	if got := resultKeys(r.Suggest("cat", 1, 3, BucketCap(0))); len(got) != 3 {
+		t.Errorf("Suggest with BucketCap(0) returned %v results, want 3", len(got))
+	}
+}
+
+func TestBucketCapMatchesNaive(t *testing.T) {
+	data := generateEdits(5, 300)
+	r := New()
+	for _, key := range data {
+		r.Set(key, "")
+	}
+	f := r.Freeze()
+	for i, key := range data[:40] {
+		d, n, k := int8(1+i%3), 1+i%9, 1+i%4
+		var matches []string
+		var dists []int
+		r.SuggestFunc(key, d, func(kv KV, dist int8) bool {
+			matches = append(matches, kv.Key)
+			dists = append(dists, int(dist))
+			return true
+		}, Ordered())
+		want := naiveBucketCap(matches, dists, k, n)
+		if got := resultKeys(r.Suggest(key, d, n, BucketCap(k))); !reflect.DeepEqual(got, want) {
+			t.Errorf("Suggest(%q, %v, %v, BucketCap(%v)) = %v, want %v", key, d, n, k, got, want)
+		}
+		if got := resultKeys(f.Suggest(key, d, n, BucketCap(k))); !reflect.DeepEqual(got, want) {
+			t.Errorf("FrozenTrie.Suggest(%q, %v, %v, BucketCap(%v)) = %v, want %v", key, d, n, k, got, want)
+		}
+	}
+}
+
+func TestBucketCapTrigramIndex(t *testing.T) {
+	keys := []string{"cat", "bat", "hat", "mat", "rat", "cart", "cast", "coat", "chat", "scat", "dog", "cs"}
+	r, x := New(), New(TrigramIndex(0.1))
+	for _, key := range keys {
+		r.Set(key, "")
+		x.Set(key, "")
+	}
+	want := []string{"cat", "bat", "cart", "cs"}
+	if got := resultKeys(x.Suggest("cat", 2, 4, BucketCap(1))); !reflect.DeepEqual(got, want) {
+		t.Errorf("Suggest with TrigramIndex and BucketCap(1) = %v, want %v", got, want)
+	}
+	for _, key := range keys {
+		if got, want := resultKeys(x.Suggest(key, 2, 5, BucketCap(2))), resultKeys(r.Suggest(key, 2, 5, BucketCap(2))); !reflect.DeepEqual(got, want) {
+			t.Errorf("Suggest(%q) with TrigramIndex = %v, without = %v", key, got, want)
+		}
+	}
+}
+
+// resultKeys returns the keys of kvs in order.
+func resultKeys(kvs []KV) []string {
+	var keys []string
+	for _, kv := range kvs {
+		keys = append(keys, kv.Key)
+	}
+	return keys
+}
@@ -0,0 +1,161 @@
+package levtrie
+
+import (
+	"time"
+)
+
+// BytesTrie is a Trie whose values are byte slices instead of strings, for
+// attaching serialized payloads to keys without converting them to strings.
+// Byte slices are stored and returned without copying, so callers shouldn't
+// modify a slice after passing it to Set or after it's returned from Get or
+// one of the Suggest methods. Don't create directly, use levtrie.NewBytes()
+// instead.
+type BytesTrie struct {
+	t *Trie
+}
+
+// BytesKV is a key-value pair stored in a BytesTrie.
+type BytesKV struct {
+	Key   string
+	Value []byte
+}
+
+// NewBytes returns a new BytesTrie configured with the given options.
+func NewBytes(opts ...Option) *BytesTrie {
+	return &BytesTrie{t: New(opts...)}
+}
+
+// Len returns the number of keys in the BytesTrie.
+func (b *BytesTrie) Len() int {
+	return b.t.Len()
+}
+
+// Get returns the value stored in the BytesTrie at the given key. The second
+// value returned is true exactly when the key exists in the BytesTrie.
+func (b *BytesTrie) Get(key string) ([]byte, bool) {
+	key, ok := b.t.preprocess(key)
+	if !ok {
+		return nil, false
+	}
+	if e := b.t.lookup(key); e != nil {
+		return e.payload, true
+	}
+	return nil, false
+}
+
+// Set associates key with val in the BytesTrie.
+func (b *BytesTrie) Set(key string, val []byte) {
+	b.set(key, val, 0)
+}
+
+// SetWithTTL associates key with val in the BytesTrie like Set, but the key
+// expires after the duration ttl has passed. See Trie.SetWithTTL.
+func (b *BytesTrie) SetWithTTL(key string, val []byte, ttl time.Duration) {
+	b.set(key, val, clock().Add(ttl).UnixNano())
+}
+
+// set associates key with val until expires, in Unix nanoseconds, or forever
+// if expires is 0, and reports an OpSet to the OnChange hooks.
+func (b *BytesTrie) set(key string, val []byte, expires int64) {
+	e := b.t.upsert(key)
+	e.payload, e.expires = val, expires
+	op := Op{Kind: OpSet, Key: e.key, Expires: e.expiration()}
+	if len(b.t.hooks) > 0 {
+		// Only copy the payload into a string when someone will see it.
+		op.Value = string(val)
+	}
+	b.t.notify(op)
+}
+
+// Delete removes the key from the BytesTrie.
+func (b *BytesTrie) Delete(key string) {
+	b.t.Delete(key)
+}
+
+// RemoveExpired removes all keys whose TTL has passed from the BytesTrie and
+// returns the number of keys removed.
+func (b *BytesTrie) RemoveExpired() int {
+	return b.t.RemoveExpired()
+}
+
+// OnChange registers f to be called after each change to the BytesTrie and
+// returns a function that unregisters it. The Value of an OpSet is the
+// payload converted to a string. See Trie.OnChange.
+func (b *BytesTrie) OnChange(f func(Op)) (remove func()) {
+	return b.t.OnChange(f)
+}
+
+// Suggest returns up to n BytesKVs with keys that are within edit distance d
+// of the input key. See Trie.Suggest.
+func (b *BytesTrie) Suggest(key string, d int8, n int, opts ...SuggestOption) []BytesKV {
+	key, ok := b.t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	runes := extractRunes(key)
+	return suggestBytes(doNotExpandSuffixes, b.t.tree(), runes, b.t.distance(len(runes), d), n, b.t.config(key, opts))
+}
+
+// SuggestSuffixes returns up to n BytesKVs, all of whose keys have a prefix
+// that is within edit distance d of the input key. See Trie.SuggestSuffixes.
+func (b *BytesTrie) SuggestSuffixes(key string, d int8, n int, opts ...SuggestOption) []BytesKV {
+	key, ok := b.t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	runes := extractRunes(key)
+	return suggestBytes(expandSuffixes, b.t.tree(), runes, b.t.distance(len(runes), d), n, b.t.config(key, opts))
+}
+
+// SuggestAfterExactPrefix returns up to n BytesKVs that share an exact prefix
+// of length p with the input key and are within edit distance d of the input
+// key. See Trie.SuggestAfterExactPrefix.
+func (b *BytesTrie) SuggestAfterExactPrefix(key string, p int, d int8, n int, opts ...SuggestOption) []BytesKV {
+	key, ok := b.t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	runes := extractRunes(key)
+	curr := descend(b.t.tree(), runes[:p])
+	if curr == nil {
+		return nil
+	}
+	cfg := b.t.config(key, opts)
+	cfg.after(runes[:p])
+	return suggestBytes(doNotExpandSuffixes, curr, runes[p:], b.t.distance(len(runes), d), n, cfg)
+}
+
+// SuggestSuffixesAfterExactPrefix returns up to n BytesKVs, all of whose keys
+// have a prefix that is within edit distance d of the input key and share an
+// exact prefix of at least length p with the input key. See
+// Trie.SuggestSuffixesAfterExactPrefix.
+func (b *BytesTrie) SuggestSuffixesAfterExactPrefix(key string, p int, d int8, n int, opts ...SuggestOption) []BytesKV {
+	key, ok := b.t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	runes := extractRunes(key)
+	curr := descend(b.t.tree(), runes[:p])
+	if curr == nil {
+		return nil
+	}
+	cfg := b.t.config(key, opts)
+	cfg.after(runes[:p])
+	return suggestBytes(expandSuffixes, curr, runes[p:], b.t.distance(len(runes), d), n, cfg)
+}
+
+// suggestBytes collects up to limit BytesKVs from the entries found by a
+// search, applying the same options as Trie.Suggest.
+func suggestBytes(process processAcceptingNode, root *node, runes []rune, d int8, limit int, cfg *searchConfig) []BytesKV {
+	var results []BytesKV
+	find := search
+	if cfg.ordered {
+		find = searchOrdered
+	}
+	for _, e := range collectEntries(limit, cfg, func(visit func(*entry) bool) {
+		find(process, root, runes, d, cfg, visit)
+	}) {
+		results = append(results, BytesKV{Key: e.key, Value: e.payload})
+	}
+	return results
+}
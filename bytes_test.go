@@ -0,0 +1,125 @@
+package levtrie
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func byteskeystr(x []BytesKV) string {
+	z := []string{}
+	for _, y := range x {
+		z = append(z, y.Key+"="+string(y.Value))
+	}
+	sort.Strings(z)
+	return strings.Join(z, " ")
+}
+
+func TestBytesTrieSetGetDelete(t *testing.T) {
+	r := NewBytes()
+	payload := []byte{0x08, 0x96, 0x01}
+	r.Set("foo", payload)
+	r.Set("bar", nil)
+	if got, ok := r.Get("foo"); !ok || !bytes.Equal(got, payload) {
+		t.Errorf("Got %v, %v, want %v, true", got, ok, payload)
+	}
+	if got, ok := r.Get("foo"); ok && &got[0] != &payload[0] {
+		t.Errorf("Get returned a copy of the stored slice")
+	}
+	if got, ok := r.Get("bar"); !ok || got != nil {
+		t.Errorf("Got %v, %v, want nil, true", got, ok)
+	}
+	if _, ok := r.Get("fo"); ok {
+		t.Errorf("Got ok, want !ok")
+	}
+	if got := r.Len(); got != 2 {
+		t.Errorf("Got Len() = %v, want 2", got)
+	}
+	r.Delete("foo")
+	if _, ok := r.Get("foo"); ok {
+		t.Errorf("Got ok, want !ok")
+	}
+}
+
+func TestBytesTrieSuggest(t *testing.T) {
+	r := NewBytes()
+	for _, key := range []string{"fob", "foo", "food", "fool", "for", "goo"} {
+		r.Set(key, []byte(strings.ToUpper(key)))
+	}
+	got := byteskeystr(r.Suggest("foo", 1, 10))
+	want := "fob=FOB foo=FOO food=FOOD fool=FOOL for=FOR goo=GOO"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	got = byteskeystr(r.SuggestSuffixes("foo", 0, 10))
+	want = "foo=FOO food=FOOD fool=FOOL"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	got = byteskeystr(r.SuggestAfterExactPrefix("foo", 1, 1, 10))
+	want = "fob=FOB foo=FOO food=FOOD fool=FOOL for=FOR"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	got = byteskeystr(r.SuggestSuffixesAfterExactPrefix("gox", 2, 1, 10))
+	want = "goo=GOO"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	if got := len(r.Suggest("foo", 1, 2)); got != 2 {
+		t.Errorf("Got %v results, want 2", got)
+	}
+}
+
+func TestBytesTrieExcludeQuery(t *testing.T) {
+	b := NewBytes()
+	b.Set("form", []byte("1"))
+	b.Set("fork", []byte("2"))
+	if got := b.Suggest("form", 1, 1, ExcludeQuery()); len(got) != 1 || got[0].Key != "fork" {
+		t.Errorf("Got %v, want fork", got)
+	}
+}
+
+func TestBytesTrieOnChange(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock = func() time.Time { return now }
+	defer func() { clock = time.Now }()
+	b := NewBytes()
+	var ops []Op
+	b.OnChange(func(op Op) { ops = append(ops, op) })
+	b.Set("a", []byte("1"))
+	b.SetWithTTL("b", []byte("2"), time.Second)
+	b.Delete("a")
+	now = now.Add(time.Minute)
+	b.RemoveExpired()
+	want := []Op{
+		{Kind: OpSet, Key: "a", Value: "1", Seq: 1},
+		{Kind: OpSet, Key: "b", Value: "2", Expires: time.Unix(1001, 0), Seq: 2},
+		{Kind: OpDelete, Key: "a", Seq: 3},
+		{Kind: OpDelete, Key: "b", Seq: 4},
+	}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("Got ops %+v, want %+v", ops, want)
+	}
+}
+
+func TestBytesTrieCollectOptions(t *testing.T) {
+	b := NewBytes()
+	for _, key := range []string{"foo", "Foo", "fob", "food", "fool", "fox", "fa"} {
+		b.Set(key, []byte(key))
+	}
+	got := b.Suggest("foo", 1, 10, Ordered(), DedupeBy(strings.ToLower))
+	if len(got) != 5 || got[0].Key != "foo" {
+		t.Errorf("Got %v, want foo first and Foo folded away", got)
+	}
+	got = b.Suggest("foo", 2, 3, BucketCap(1))
+	if len(got) != 3 || got[0].Key != "foo" || got[2].Key != "fa" {
+		t.Errorf("Got %v, want foo, a key at distance 1, and fa", got)
+	}
+	if got := b.Suggest("foo", 1, 3, SampleByWeight(nil)); len(got) != 3 {
+		t.Errorf("Got %v results, want 3", len(got))
+	}
+}
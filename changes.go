@@ -0,0 +1,86 @@
+package levtrie
+
+import "time"
+
+// OpKind is the kind of change described by an Op.
+type OpKind int
+
+const (
+	// OpSet is a call to Set or SetWithTTL.
+	OpSet OpKind = iota + 1
+	// OpAdd is a call to Add that added a new value.
+	OpAdd
+	// OpRemove is a call to Remove that removed a value.
+	OpRemove
+	// OpIncr is a call to Incr or IncrBy.
+	OpIncr
+	// OpDelete is the removal of a key from the Trie by Delete,
+	// RemoveExpired or eviction.
+	OpDelete
+	// OpClear is a call to Clear. It has no Key.
+	OpClear
+	// OpRename is a call to Rename that moved Key to NewKey.
+	OpRename
+)
+
+// Op describes a change made to a Trie. Only the fields relevant to the kind of
+// change are set.
+type Op struct {
+	Kind   OpKind
+	Key    string
+	NewKey string // The key that Key was renamed to.
+	Value  string // The value set, added or removed.
+	Delta  int64  // The amount a count was incremented by.
+	// Expires is the expiration time set by SetWithTTL, or the zero time
+	// if the key doesn't expire.
+	Expires time.Time
+	// Seq numbers the changes to a Trie from 1 in the order they happen,
+	// so that a follower can tell which changes it has already applied.
+	// See ApplyChanges.
+	Seq uint64
+}
+
+// hook is a function registered with OnChange.
+type hook struct {
+	f func(Op)
+}
+
+// OnChange registers f to be called after each change to the Trie, in the
+// order the changes happen, and returns a function that unregisters it. f is
+// called synchronously by the method that made the change, so it should be
+// quick and it must not modify the Trie. Keys that expire aren't reported
+// until they're removed by RemoveExpired or eviction. OnChange must not be
+// called concurrently with other methods that modify the Trie.
+func (t *Trie) OnChange(f func(Op)) (remove func()) {
+	h := &hook{f: f}
+	t.hooks = append(t.hooks, h)
+	return func() {
+		for i, x := range t.hooks {
+			if x == h {
+				// Copy rather than splice in place so that a hook
+				// can remove itself while hooks are being called.
+				hooks := make([]*hook, 0, len(t.hooks)-1)
+				hooks = append(hooks, t.hooks[:i]...)
+				t.hooks = append(hooks, t.hooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// notify numbers op and calls each hook registered with OnChange with it.
+func (t *Trie) notify(op Op) {
+	t.seq++
+	op.Seq = t.seq
+	for _, h := range t.hooks {
+		h.f(op)
+	}
+}
+
+// expiration returns the expiration time of e for an Op.
+func (e *entry) expiration() time.Time {
+	if e.expires == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, e.expires)
+}
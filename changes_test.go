@@ -0,0 +1,86 @@
+package levtrie
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOnChange(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock = func() time.Time { return now }
+	defer func() { clock = time.Now }()
+	r := New()
+	var ops []Op
+	remove := r.OnChange(func(op Op) { ops = append(ops, op) })
+	r.Set("a", "1")
+	r.SetWithTTL("b", "2", time.Second)
+	r.Add("a", "3")
+	r.Add("a", "3")
+	r.Remove("a", "1")
+	r.Remove("a", "missing")
+	r.IncrBy("c", 5)
+	r.Delete("c")
+	r.Delete("missing")
+	r.Remove("a", "3")
+	r.Rename("b", "e")
+	now = now.Add(time.Minute)
+	r.RemoveExpired()
+	r.Clear()
+	want := []Op{
+		{Kind: OpSet, Key: "a", Value: "1"},
+		{Kind: OpSet, Key: "b", Value: "2", Expires: time.Unix(1001, 0)},
+		{Kind: OpAdd, Key: "a", Value: "3"},
+		{Kind: OpRemove, Key: "a", Value: "1"},
+		{Kind: OpIncr, Key: "c", Delta: 5},
+		{Kind: OpDelete, Key: "c"},
+		{Kind: OpRemove, Key: "a", Value: "3"},
+		{Kind: OpRename, Key: "b", NewKey: "e"},
+		{Kind: OpDelete, Key: "e"},
+		{Kind: OpClear},
+	}
+	for i := range want {
+		want[i].Seq = uint64(i + 1)
+	}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("Got ops %+v, want %+v", ops, want)
+	}
+	remove()
+	r.Set("d", "4")
+	if len(ops) != len(want) {
+		t.Errorf("Got an op after removing the hook: %+v", ops[len(want):])
+	}
+}
+
+func TestOnChangeEviction(t *testing.T) {
+	r := New(MaxKeys(1))
+	var ops []Op
+	r.OnChange(func(op Op) { ops = append(ops, op) })
+	r.Set("a", "1")
+	r.Set("b", "2")
+	want := []Op{
+		{Kind: OpSet, Key: "a", Value: "1"},
+		{Kind: OpDelete, Key: "a"},
+		{Kind: OpSet, Key: "b", Value: "2"},
+	}
+	for i := range want {
+		want[i].Seq = uint64(i + 1)
+	}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("Got ops %+v, want %+v", ops, want)
+	}
+}
+
+func TestOnChangeRemoveDuringNotify(t *testing.T) {
+	r := New()
+	calls := 0
+	var remove func()
+	remove = r.OnChange(func(Op) { calls++; remove() })
+	other := 0
+	r.OnChange(func(Op) { other++ })
+	r.Set("a", "1")
+	r.Set("b", "2")
+	if calls != 1 || other != 2 {
+		t.Errorf("Got %v and %v calls, want 1 and 2", calls, other)
+	}
+}
@@ -0,0 +1,70 @@
+package levtrie
+
+import "sort"
+
+// Cluster is a group of keys that are all near one of them, see Trie.Cluster.
+type Cluster struct {
+	Representative string
+	Members        []string // Every key in the cluster in sorted order, including the Representative.
+}
+
+// Cluster groups the keys in the Trie into clusters, each made of a
+// representative key and the keys within edit distance d of it, which is the
+// usual first step in deduplicating names like "Jon Smith" and "John Smith".
+// The heaviest key that isn't in a cluster yet, by count and then in sorted
+// order, becomes the representative of a new cluster that takes every key
+// within distance d of it that isn't in a cluster yet, so each key ends up
+// with the heaviest representative near it that comes first. Every key is in
+// exactly one cluster and the clusters are returned in the order their
+// representatives were chosen. A key with nothing near it is a cluster of its
+// own. It takes one search for each cluster instead of one for each key. If d
+// is negative, it's chosen for each representative as in Suggest.
+//
+// Of the SuggestOptions, the ones that change which keys match apply:
+// DeletionsOnly, InsertionsOnly, EditCosts, AffixTolerance and Filter.
+// WithTags and Constrain restrict the clustering to the keys they match.
+func (t *Trie) Cluster(d int8, opts ...SuggestOption) []Cluster {
+	var es []*entry
+	all := t.config("", opts)
+	all.excludeQuery = false
+	expandSuffixes(t.tree(), func(e *entry) bool {
+		if !all.excluded(e) {
+			es = append(es, e)
+		}
+		return true
+	})
+	sort.Slice(es, func(i, j int) bool {
+		if wi, wj := all.weight(es[i]), all.weight(es[j]); wi != wj {
+			return wi > wj
+		}
+		return es[i].key < es[j].key
+	})
+	clustered := make(map[*entry]bool)
+	var clusters []Cluster
+	for _, e := range es {
+		if clustered[e] {
+			continue
+		}
+		clustered[e] = true
+		c := Cluster{Representative: e.key, Members: []string{e.key}}
+		runes, cfg := extractRunes(e.key), t.config(e.key, opts)
+		search(doNotExpandSuffixes, t.tree(), runes, t.distance(len(runes), d), cfg, func(m *entry) bool {
+			if !clustered[m] && !all.excluded(m) {
+				clustered[m] = true
+				c.Members = append(c.Members, m.key)
+			}
+			return true
+		})
+		sort.Strings(c.Members)
+		clusters = append(clusters, c)
+	}
+	return clusters
+}
+
+// Cluster groups the keys in the SyncTrie into clusters of keys within edit
+// distance d of a representative. See Trie.Cluster.
+func (s *SyncTrie) Cluster(d int8, opts ...SuggestOption) []Cluster {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Cluster(d, opts...)
+}
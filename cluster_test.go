@@ -0,0 +1,60 @@
+package levtrie
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestCluster(t *testing.T) {
+	r := New()
+	for key, count := range map[string]int64{"john smith": 5, "jon smith": 1, "john smyth": 2, "jane doe": 0, "jayne dow": 3, "zed": 0} {
+		r.IncrBy(key, count)
+	}
+	want := []Cluster{
+		{Representative: "john smith", Members: []string{"john smith", "john smyth", "jon smith"}},
+		{Representative: "jayne dow", Members: []string{"jayne dow"}},
+		{Representative: "jane doe", Members: []string{"jane doe"}},
+		{Representative: "zed", Members: []string{"zed"}},
+	}
+	if got := r.Cluster(1); !reflect.DeepEqual(got, want) {
+		t.Errorf("Cluster(1) = %v, want %v", got, want)
+	}
+	want[1].Members = []string{"jane doe", "jayne dow"}
+	want = append(want[:2], want[3])
+	if got := r.Cluster(2); !reflect.DeepEqual(got, want) {
+		t.Errorf("Cluster(2) = %v, want %v", got, want)
+	}
+	if got := New().Cluster(1); len(got) != 0 {
+		t.Errorf("Cluster(1) on an empty Trie = %v, want nothing", got)
+	}
+}
+
+func TestClusterCoversKeys(t *testing.T) {
+	rand.Seed(0)
+	r := New()
+	for _, key := range generateEdits(5, 300) {
+		r.IncrBy(key, rand.Int63n(10))
+	}
+	clusters := r.Cluster(1)
+	seen := make(map[string]bool)
+	for i, c := range clusters {
+		for _, key := range c.Members {
+			if seen[key] {
+				t.Errorf("%v is in more than one cluster", key)
+			}
+			seen[key] = true
+			if Distance(c.Representative, key) > 1 {
+				t.Errorf("%v is in the cluster of %v", key, c.Representative)
+			}
+		}
+		for _, prev := range clusters[:i] {
+			if Distance(prev.Representative, c.Representative) <= 1 {
+				t.Errorf("Representatives %v and %v are within distance 1", prev.Representative, c.Representative)
+			}
+		}
+	}
+	if len(seen) != r.Len() {
+		t.Errorf("Clusters have %v keys, want %v", len(seen), r.Len())
+	}
+}
@@ -0,0 +1,90 @@
+// levcomplete serves fuzzy word completions to editor plugins over stdin and
+// stdout.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aaw/levtrie"
+	"github.com/aaw/levtrie/complete"
+)
+
+var usage = `
+levcomplete loads a vocabulary and serves fuzzy completions of words in it,
+reading one JSON request per line from stdin and writing one JSON response per
+line to stdout. See the documentation of the complete package for the format
+of requests and responses.
+
+Each line of the vocabulary file contains a word, optionally followed by
+whitespace and a value that's returned as the detail of the completion.
+
+Flags:
+`
+
+// loadVocabulary reads a vocabulary in the format described in the usage
+// message into a Trie.
+func loadVocabulary(r io.Reader) (*levtrie.Trie, error) {
+	t := levtrie.New()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		word, detail := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			word, detail = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		t.Set(word, detail)
+	}
+	return t, scanner.Err()
+}
+
+// run runs levcomplete with the command-line arguments args and returns the
+// exit status.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("levcomplete", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	vocabFile := fs.String("vocabulary", "/usr/share/dict/words",
+		"A file containing the words to complete, one per line.")
+	max := fs.Int("max", 20, "The default max number of completions.")
+	exact := fs.Int("exact", 1,
+		"The number of runes at the beginning of a prefix that must match exactly.")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+	file, err := os.Open(*vocabFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "levcomplete: %v\n", err)
+		return 2
+	}
+	t, err := loadVocabulary(file)
+	file.Close()
+	if err != nil {
+		fmt.Fprintf(stderr, "levcomplete: %v: %v\n", *vocabFile, err)
+		return 2
+	}
+	if err := complete.Serve(stdin, stdout, t, complete.Options{Max: *max, ExactPrefix: *exact}); err != nil {
+		fmt.Fprintf(stderr, "levcomplete: %v\n", err)
+		return 2
+	}
+	return 0
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	vocab := filepath.Join(t.TempDir(), "vocab")
+	if err := os.WriteFile(vocab, []byte("println fmt.Println\nprintf fmt.Printf\nsprintf\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	in := `{"id":"a","prefix":"prnt","distance":1}` + "\n"
+	if status := run([]string{"-vocabulary", vocab}, strings.NewReader(in), &stdout, &stderr); status != 0 {
+		t.Fatalf("Got status %v: %v", status, stderr.String())
+	}
+	want := `{"id":"a","isIncomplete":false,"items":[` +
+		`{"label":"printf","detail":"fmt.Printf","sortText":"00000","filterText":"prnt"},` +
+		`{"label":"println","detail":"fmt.Println","sortText":"00001","filterText":"prnt"}]}` + "\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	if status := run([]string{"-vocabulary", filepath.Join(t.TempDir(), "missing")}, nil, &stdout, &stderr); status != 2 {
+		t.Errorf("Got status %v for a missing vocabulary, want 2", status)
+	}
+}
@@ -0,0 +1,169 @@
+// levgrep prints the lines of its input that are within a given edit distance
+// of a pattern.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aaw/levtrie"
+)
+
+var usage = `
+levgrep prints each line of a file, or of stdin if no file is given, that is
+within a given edit distance of pattern. Matching lines are printed in the
+order they appear in the input.
+
+Usage: levgrep [flags] pattern [file]
+
+Flags:
+`
+
+// config specifies the search run on the input.
+type config struct {
+	pattern     string
+	dist        int8
+	exactPrefix int
+	prefix      bool
+	ignoreCase  bool
+	max         int
+	lineNumbers bool
+	count       bool
+}
+
+// match is a line of input that matched the pattern.
+type match struct {
+	num  int
+	line string
+}
+
+// load reads each line of r into a Trie, associating each line with its line
+// number. It returns the Trie along with the lines read.
+func load(r io.Reader, ignoreCase bool) (*levtrie.Trie, []string, error) {
+	t := levtrie.New()
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		lines = append(lines, line)
+		if ignoreCase {
+			line = strings.ToLower(line)
+		}
+		t.Add(line, strconv.Itoa(len(lines)))
+	}
+	return t, lines, scanner.Err()
+}
+
+// grep returns the lines of r that match the search described by cfg.
+func grep(r io.Reader, cfg *config) ([]match, error) {
+	t, lines, err := load(r, cfg.ignoreCase)
+	if err != nil {
+		return nil, err
+	}
+	pattern := cfg.pattern
+	if cfg.ignoreCase {
+		pattern = strings.ToLower(pattern)
+	}
+	p := cfg.exactPrefix
+	if rc := len([]rune(pattern)); p > rc {
+		p = rc
+	}
+	limit := cfg.max
+	if limit <= 0 {
+		limit = math.MaxInt32
+	}
+	var kvs []levtrie.KV
+	if cfg.prefix {
+		kvs = t.SuggestSuffixesAfterExactPrefix(pattern, p, cfg.dist, limit)
+	} else {
+		kvs = t.SuggestAfterExactPrefix(pattern, p, cfg.dist, limit)
+	}
+	matches := make([]match, 0, len(kvs))
+	for _, kv := range kvs {
+		num, _ := strconv.Atoi(kv.Value)
+		matches = append(matches, match{num: num, line: lines[num-1]})
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].num < matches[j].num })
+	return matches, nil
+}
+
+// run runs levgrep with the command-line arguments args and returns the exit
+// status: 0 if a line matched, 1 if no lines matched and 2 on errors.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("levgrep", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	cfg := &config{}
+	dist := fs.Int("d", 1, "The edit distance to search within.")
+	fs.IntVar(&cfg.exactPrefix, "p", 0,
+		"The number of runes at the beginning of pattern that must match exactly.")
+	fs.BoolVar(&cfg.prefix, "prefix", false,
+		"Match lines that have a prefix within the edit distance of pattern.")
+	fs.BoolVar(&cfg.ignoreCase, "i", false, "Ignore case when matching.")
+	fs.IntVar(&cfg.max, "m", 0, "Stop after this many matching lines, if positive.")
+	fs.BoolVar(&cfg.lineNumbers, "n", false, "Prefix each line with its line number.")
+	fs.BoolVar(&cfg.count, "c", false, "Only print the number of matching lines.")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return 2
+	}
+	if *dist < 0 || *dist > math.MaxInt8 {
+		fmt.Fprintf(stderr, "levgrep: -d must be between 0 and %v\n", math.MaxInt8)
+		return 2
+	}
+	if cfg.exactPrefix < 0 {
+		fmt.Fprintln(stderr, "levgrep: -p can't be negative")
+		return 2
+	}
+	cfg.dist = int8(*dist)
+	cfg.pattern = fs.Arg(0)
+	in := stdin
+	if fs.NArg() == 2 && fs.Arg(1) != "-" {
+		file, err := os.Open(fs.Arg(1))
+		if err != nil {
+			fmt.Fprintf(stderr, "levgrep: %v\n", err)
+			return 2
+		}
+		defer file.Close()
+		in = file
+	}
+	matches, err := grep(in, cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "levgrep: %v\n", err)
+		return 2
+	}
+	w := bufio.NewWriter(stdout)
+	defer w.Flush()
+	if cfg.count {
+		fmt.Fprintln(w, len(matches))
+	} else {
+		for _, m := range matches {
+			if cfg.lineNumbers {
+				fmt.Fprintf(w, "%d:", m.num)
+			}
+			fmt.Fprintln(w, m.line)
+		}
+	}
+	if len(matches) == 0 {
+		return 1
+	}
+	return 0
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const input = `kitten
+sitting
+Mitten
+kitchen
+kitten
+mittens
+`
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		args   []string
+		status int
+		want   string
+	}{
+		{[]string{"-d", "0", "kitten"}, 0, "kitten\nkitten\n"},
+		{[]string{"-d", "1", "mitten"}, 0, "kitten\nMitten\nkitten\nmittens\n"},
+		{[]string{"-i", "-d", "0", "MITTEN"}, 0, "Mitten\n"},
+		{[]string{"-n", "-d", "2", "-p", "3", "kitten"}, 0, "1:kitten\n4:kitchen\n5:kitten\n"},
+		{[]string{"-prefix", "-d", "0", "mit"}, 0, "mittens\n"},
+		{[]string{"-c", "-d", "1", "mitten"}, 0, "4\n"},
+		{[]string{"-m", "1", "kitten"}, 0, "kitten\n"},
+		{[]string{"-d", "0", "dog"}, 1, ""},
+		{[]string{"-d", "-1", "dog"}, 2, ""},
+		{[]string{}, 2, ""},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		status := run(test.args, strings.NewReader(input), &stdout, &stderr)
+		if status != test.status {
+			t.Errorf("%v: got status %v, want %v (stderr: %v)", test.args, status, test.status, stderr.String())
+		}
+		if got := stdout.String(); got != test.want {
+			t.Errorf("%v: got output %q, want %q", test.args, got, test.want)
+		}
+	}
+}
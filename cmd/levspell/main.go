@@ -0,0 +1,168 @@
+// levspell checks the spelling of text read from stdin against a dictionary.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aaw/levtrie"
+	"github.com/aaw/levtrie/ispell"
+)
+
+var usage = `
+levspell reads text from stdin and prints each word that isn't in the
+dictionary, along with suggested corrections, as
+
+  line:column word: correction1, correction2, ...
+
+Corrections are ranked by edit distance and then by weight. Each line of the
+dictionary file contains a word, optionally followed by whitespace and a
+positive integer weight, usually the frequency of the word in some corpus.
+Words without a weight have weight 1.
+
+With -a, levspell speaks the pipe protocol of ispell -a instead, so that it
+can be used by tools that integrate with ispell or aspell.
+
+Flags:
+`
+
+// loadDictionary reads a dictionary in the format described in the usage
+// message into a Trie, storing the weight of each word as its count. Words are
+// lowercased.
+func loadDictionary(r io.Reader) (*levtrie.Trie, error) {
+	t := levtrie.New()
+	scanner := bufio.NewScanner(r)
+	num := 0
+	for scanner.Scan() {
+		num++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		weight := int64(1)
+		if len(fields) > 1 {
+			w, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil || w < 1 {
+				return nil, fmt.Errorf("line %d: invalid weight %q", num, fields[1])
+			}
+			weight = w
+		}
+		t.IncrBy(strings.ToLower(fields[0]), weight)
+	}
+	return t, scanner.Err()
+}
+
+// check prints each misspelled word in the text read from r to w along with
+// its corrections. It returns the number of misspelled words.
+func check(sp ispell.Speller, r io.Reader, w io.Writer) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	misspelled := 0
+	for num := 1; scanner.Scan(); num++ {
+		for _, tok := range ispell.Tokenize(scanner.Text()) {
+			corrections := sp.Correct(tok.Word)
+			if corrections == nil {
+				continue
+			}
+			misspelled++
+			fmt.Fprintf(w, "%d:%d %s: %s\n", num, tok.Offset+1, tok.Word, strings.Join(corrections, ", "))
+		}
+	}
+	return misspelled, scanner.Err()
+}
+
+// parseCosts parses a comma-separated list of insertion, deletion and
+// substitution costs.
+func parseCosts(s string) ([]levtrie.SuggestOption, error) {
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("-costs must have 3 comma-separated values, got %q", s)
+	}
+	var cs [3]int8
+	for i, p := range parts {
+		x, err := strconv.ParseInt(strings.TrimSpace(p), 10, 8)
+		if err != nil || x < 1 {
+			return nil, fmt.Errorf("-costs: invalid cost %q", p)
+		}
+		cs[i] = int8(x)
+	}
+	return []levtrie.SuggestOption{levtrie.EditCosts(cs[0], cs[1], cs[2])}, nil
+}
+
+// run runs levspell with the command-line arguments args and returns the exit
+// status: 0 if all words are spelled correctly, 1 if some aren't and 2 on
+// errors.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("levspell", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	dictFile := fs.String("dictionary", "/usr/share/dict/words",
+		"A file containing correctly spelled words, one per line.")
+	dist := fs.Int("d", 2, "The max edit distance, or total edit cost, of a correction.")
+	n := fs.Int("n", 5, "The max number of corrections printed for each word.")
+	costStr := fs.String("costs", "",
+		"Comma-separated costs of insertions, deletions and substitutions, like 1,2,2.")
+	pipe := fs.Bool("a", false, "Speak the ispell -a pipe protocol instead.")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+	if *dist < 0 || *dist > math.MaxInt8 {
+		fmt.Fprintf(stderr, "levspell: -d must be between 0 and %v\n", math.MaxInt8)
+		return 2
+	}
+	costs, err := parseCosts(*costStr)
+	if err != nil {
+		fmt.Fprintf(stderr, "levspell: %v\n", err)
+		return 2
+	}
+	file, err := os.Open(*dictFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "levspell: %v\n", err)
+		return 2
+	}
+	defer file.Close()
+	dict, err := loadDictionary(file)
+	if err != nil {
+		fmt.Fprintf(stderr, "levspell: %v: %v\n", *dictFile, err)
+		return 2
+	}
+	sp := &ispell.TrieSpeller{Trie: dict, Distance: int8(*dist), Max: *n, Options: costs}
+	if *pipe {
+		if err := ispell.Serve(stdin, stdout, sp); err != nil {
+			fmt.Fprintf(stderr, "levspell: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	// Write each line as soon as it's checked so that levspell can be used
+	// interactively.
+	misspelled, err := check(sp, stdin, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "levspell: %v\n", err)
+		return 2
+	}
+	if misspelled > 0 {
+		return 1
+	}
+	return 0
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
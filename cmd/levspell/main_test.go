@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const dictionary = `the 100
+then 20
+ten 5
+tea
+hello 50
+help 10
+world 30
+word 40
+don't
+`
+
+func writeDictionary(t *testing.T) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "dict")
+	if err := os.WriteFile(name, []byte(dictionary), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestRun(t *testing.T) {
+	dict := writeDictionary(t)
+	tests := []struct {
+		args   []string
+		input  string
+		status int
+		want   string
+	}{
+		{nil, "Hello world, don't worry.\n", 1, "1:20 worry: word, world\n"},
+		{nil, "the end\nteh wrd\n", 1,
+			"1:5 end: ten\n2:1 teh: ten, tea, the, then\n2:5 wrd: word, world\n"},
+		{[]string{"-n", "1", "-d", "1"}, "helo\n", 1, "1:1 helo: hello\n"},
+		{[]string{"-costs", "1,1,3", "-d", "2"}, "wrd\n", 1, "1:1 wrd: word, world\n"},
+		{nil, "hello\n", 0, ""},
+		{[]string{"-a", "-n", "2"}, "teh hello\n", 0,
+			"@(#) International Ispell Version 3.1.20 (but really levtrie)\n& teh 2 0: ten, tea\n*\n\n"},
+		{[]string{"-costs", "1,2"}, "", 2, ""},
+		{[]string{"-d", "-1"}, "", 2, ""},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"-dictionary", dict}, test.args...)
+		status := run(args, strings.NewReader(test.input), &stdout, &stderr)
+		if status != test.status {
+			t.Errorf("%v: got status %v, want %v (stderr: %v)", test.args, status, test.status, stderr.String())
+		}
+		if got := stdout.String(); got != test.want {
+			t.Errorf("%v: got output %q, want %q", test.args, got, test.want)
+		}
+	}
+}
+
+func TestLoadDictionaryErrors(t *testing.T) {
+	if _, err := loadDictionary(strings.NewReader("word x\n")); err == nil {
+		t.Errorf("Expected an error for an invalid weight")
+	}
+	if _, err := loadDictionary(strings.NewReader("word 0\n")); err == nil {
+		t.Errorf("Expected an error for a zero weight")
+	}
+}
@@ -0,0 +1,87 @@
+package levtrie
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Values stored in a Trie that compresses values start with one of these
+// headers to say whether the rest of the value is compressed.
+const (
+	rawValue byte = iota
+	compressedValue
+)
+
+// codec compresses and decompresses values using DEFLATE.
+type codec struct {
+	minLen  int
+	dict    []byte
+	writers sync.Pool // Holds *flate.Writers created with dict.
+	readers sync.Pool // Holds io.ReadClosers created with dict.
+}
+
+func newCodec(minLen int, dict []byte) *codec {
+	c := &codec{minLen: minLen, dict: append([]byte(nil), dict...)}
+	c.writers.New = func() interface{} {
+		w, _ := flate.NewWriterDict(nil, flate.BestCompression, c.dict)
+		return w
+	}
+	c.readers.New = func() interface{} {
+		return flate.NewReaderDict(nil, c.dict)
+	}
+	return c
+}
+
+// encode returns the representation of v stored in the Trie.
+func (t *Trie) encode(v string) string {
+	if t.codec == nil || v == "" {
+		return v
+	}
+	if len(v) >= t.codec.minLen {
+		if z, ok := t.codec.compress(v); ok {
+			return z
+		}
+	}
+	return string([]byte{rawValue}) + v
+}
+
+// decode returns the value represented by s, which was returned by encode.
+func (t *Trie) decode(s string) string {
+	if t.codec == nil || len(s) == 0 {
+		return s
+	}
+	if s[0] == compressedValue {
+		return t.codec.decompress(s[1:])
+	}
+	return s[1:]
+}
+
+// compress returns the compressed representation of v with a header. The
+// second value returned is false if compression doesn't make v any shorter.
+func (c *codec) compress(v string) (string, bool) {
+	var buf bytes.Buffer
+	buf.WriteByte(compressedValue)
+	w := c.writers.Get().(*flate.Writer)
+	defer c.writers.Put(w)
+	w.Reset(&buf)
+	io.WriteString(w, v)
+	w.Close()
+	if buf.Len() > len(v) {
+		return "", false
+	}
+	return buf.String(), true
+}
+
+// decompress returns the value compressed in z. The codec only decompresses
+// values that it compressed, so errors can't happen.
+func (c *codec) decompress(z string) string {
+	r := c.readers.Get().(io.ReadCloser)
+	defer c.readers.Put(r)
+	r.(flate.Resetter).Reset(strings.NewReader(z), c.dict)
+	var buf strings.Builder
+	io.Copy(&buf, r)
+	return buf.String()
+}
@@ -0,0 +1,72 @@
+package levtrie
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func jsonBlob(i int) string {
+	return fmt.Sprintf(`{"id":%d,"description":"%s","tags":["alpha","beta","gamma"]}`, i, strings.Repeat("lorem ipsum ", 10))
+}
+
+func TestCompressValues(t *testing.T) {
+	for _, dict := range [][]byte{nil, []byte(`{"id":,"description":"","tags":["alpha","beta","gamma"]}`)} {
+		r := New(CompressValues(16, dict))
+		keys := []string{"apple", "apply", "ample", "maple"}
+		for i, k := range keys {
+			r.Set(k, jsonBlob(i))
+		}
+		r.Set("short", "tiny")
+		r.Set("empty", "")
+		for i, k := range keys {
+			expectFound(t, r, k, jsonBlob(i))
+			e := r.find(k).data
+			if len(e.values[0]) >= len(jsonBlob(i)) {
+				t.Errorf("Value for %v wasn't compressed: %v bytes", k, len(e.values[0]))
+			}
+		}
+		expectFound(t, r, "short", "tiny")
+		expectFound(t, r, "empty", "")
+		got := r.Suggest("appl", 1, 10)
+		sort.Slice(got, func(i, j int) bool { return got[i].Key < got[j].Key })
+		want := []KV{{"apple", jsonBlob(0)}, {"apply", jsonBlob(1)}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Suggest with compressed values: got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCompressValuesIncompressible(t *testing.T) {
+	r := New(CompressValues(0, nil))
+	v := "qzx8!"
+	r.Set("k", v)
+	if e := r.find("k").data; e.values[0] != "\x00"+v {
+		t.Errorf("Incompressible value stored as %q, want it stored raw", e.values[0])
+	}
+	expectFound(t, r, "k", v)
+}
+
+func TestCompressValuesMultipleValues(t *testing.T) {
+	r := New(CompressValues(8, nil), InternValues())
+	r.Add("k", jsonBlob(1))
+	r.Add("k", jsonBlob(2))
+	r.Add("k", jsonBlob(1))
+	r.Add("j", jsonBlob(1))
+	if got, want := r.Values("k"), []string{jsonBlob(1), jsonBlob(2)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Values(k) = %v, want %v", got, want)
+	}
+	r.Remove("k", jsonBlob(1))
+	if got, want := r.Values("k"), []string{jsonBlob(2)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("After Remove, Values(k) = %v, want %v", got, want)
+	}
+	if st := r.Stats(); st.InternedValues != 2 {
+		t.Errorf("Got %v interned values, want 2", st.InternedValues)
+	}
+	got := r.Suggest("k", 0, 10)
+	if want := []KV{{"k", jsonBlob(2)}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Suggest(k) = %v, want %v", got, want)
+	}
+}
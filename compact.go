@@ -0,0 +1,57 @@
+package levtrie
+
+// minAutoCompact is the fewest deletions that trigger a compaction of a Trie
+// with the AutoCompact option, so that small tries aren't compacted over and
+// over.
+const minAutoCompact = 1024
+
+// AutoCompact makes a Trie call Compact by itself whenever more keys have been
+// deleted from it since the last compaction than it currently holds, and at
+// least 1024 of them. The cost of each compaction is proportional to the size
+// of the Trie, so it's spread over the deletions that led to it, but the
+// deletion that triggers it takes that long all at once.
+func AutoCompact() Option {
+	return func(t *Trie) {
+		t.autoCompact = true
+	}
+}
+
+// Compact rebuilds the map of children of every node in the Trie, and in the
+// trees kept by ReverseIndex and InfixIndex, at the size it needs now. Go maps
+// never shrink, so a node that once had many children keeps the memory for
+// all of them after most are deleted. Compacting a Trie after heavy churn,
+// like a bulk delete or a long run of inserts and deletes in a server,
+// returns that memory to the garbage collector. It walks the entire Trie and
+// doesn't change its contents.
+func (t *Trie) Compact() {
+	compactTree(t.ensureRoot())
+	if t.rev != nil {
+		compactTree(t.rev)
+	}
+	if t.infix != nil {
+		compactTree(t.infix.root)
+	}
+	t.removed = 0
+}
+
+// compactTree rebuilds the map of children of every node under root.
+func compactTree(root *node) {
+	for stack := []*node{root}; len(stack) > 0; {
+		var x *node
+		x, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		child := make(map[rune]*node, len(x.child))
+		for r, c := range x.child {
+			child[r] = c
+			stack = append(stack, c)
+		}
+		x.child = child
+	}
+}
+
+// compactAfterDelete records a deletion and compacts the Trie if it has the
+// AutoCompact option and enough keys have been deleted.
+func (t *Trie) compactAfterDelete() {
+	if t.removed++; t.autoCompact && t.removed >= minAutoCompact && t.removed > t.size {
+		t.Compact()
+	}
+}
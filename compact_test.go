@@ -0,0 +1,52 @@
+package levtrie
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+	r := New(ReverseIndex(), InfixIndex())
+	for i := 0; i < 2000; i++ {
+		r.Set(fmt.Sprintf("k%v", i), "v")
+	}
+	for i := 0; i < 2000; i++ {
+		if i%100 != 0 {
+			r.Delete(fmt.Sprintf("k%v", i))
+		}
+	}
+	before := r.Stats()
+	r.Compact()
+	if err := r.ValidateInvariants(); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Stats(); got != before {
+		t.Errorf("Stats = %+v after Compact, want %+v", got, before)
+	}
+	expectFound(t, r, "k1900", "v")
+	expectNotGet(t, r, "k1901")
+	if got, want := len(r.SuggestEndsWith("00", 0, 100)), 19; got != want {
+		t.Errorf("SuggestEndsWith found %v keys, want %v", got, want)
+	}
+	if got, want := len(r.SuggestInfix("190", 0, 10)), 1; got != want {
+		t.Errorf("SuggestInfix found %v keys, want %v", got, want)
+	}
+}
+
+func TestAutoCompact(t *testing.T) {
+	r := New(AutoCompact())
+	for i := 0; i < 3000; i++ {
+		r.Set(fmt.Sprintf("k%v", i), "v")
+	}
+	for i := 0; i < 2000; i++ {
+		r.Delete(fmt.Sprintf("k%v", i))
+		r.Delete("missing")
+	}
+	// The 1501st deletion leaves 1499 keys, which triggers a compaction.
+	if got, want := r.removed, 499; got != want {
+		t.Errorf("Got %v deletions since the last compaction, want %v", got, want)
+	}
+	if err := r.ValidateInvariants(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,218 @@
+// Package complete serves fuzzy word completions from a levtrie.Trie over a
+// line-oriented JSON protocol, for editor plugins that talk to a long-running
+// process.
+//
+// Each line read by Serve is a Request and each line written is a Response to
+// one Request, in the same order. Completions are shaped like the items of an
+// LSP CompletionList, so that plugins for editors that speak LSP can pass them
+// on with little translation. For example, the request
+//
+//	{"id": 1, "prefix": "helo", "max": 2}
+//
+// might get the response
+//
+//	{"id":1,"isIncomplete":true,"items":[{"label":"hello","sortText":"00000","filterText":"helo"},{"label":"help","sortText":"00001","filterText":"helo"}]}
+package complete
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/aaw/levtrie"
+)
+
+// Request asks for completions of a prefix.
+type Request struct {
+	// ID is copied to the Response so that clients can match responses
+	// to requests.
+	ID json.RawMessage `json:"id,omitempty"`
+	// Prefix is the partial word typed so far.
+	Prefix string `json:"prefix"`
+	// Max is the max number of completions returned. If it's not positive,
+	// Options.Max is used.
+	Max int `json:"max,omitempty"`
+	// Distance, if not nil, is the max edit distance between Prefix and a
+	// prefix of each completion. If it's nil, Options.Distance is used.
+	Distance *int `json:"distance,omitempty"`
+}
+
+// Response holds the completions for a Request.
+type Response struct {
+	ID json.RawMessage `json:"id,omitempty"`
+	// IsIncomplete is true if there may be more completions than Items.
+	IsIncomplete bool   `json:"isIncomplete"`
+	Items        []Item `json:"items"`
+	// Error describes why the request failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// Item is a single completion, named after the fields of an LSP
+// CompletionItem.
+type Item struct {
+	Label string `json:"label"`
+	// Detail is the value associated with the word in the Trie, if any.
+	Detail string `json:"detail,omitempty"`
+	// SortText orders the items from best to worst.
+	SortText string `json:"sortText"`
+	// FilterText is the prefix of the request, so that clients that filter
+	// items by what's been typed don't drop fuzzy completions.
+	FilterText string `json:"filterText"`
+}
+
+// Options configures Serve.
+type Options struct {
+	// Max is the default max number of completions. If it's not positive,
+	// 20 is used.
+	Max int
+	// Distance returns the default max edit distance for a prefix. If it's
+	// nil, the default is 0 for prefixes of up to 3 runes, 1 for prefixes of
+	// up to 6 runes and 2 for longer prefixes.
+	Distance func(prefix string) int8
+	// ExactPrefix is the number of runes at the beginning of each prefix
+	// that must match a completion exactly, which keeps searches fast and
+	// completions relevant.
+	ExactPrefix int
+	// MaxDistance caps the distance of a request. If it's not positive, 3
+	// is used.
+	MaxDistance int
+	// MaxItems caps the max number of completions of a request. If it's
+	// not positive, 1000 is used.
+	MaxItems int
+}
+
+func (o *Options) max() int {
+	if o.Max <= 0 {
+		return 20
+	}
+	return o.Max
+}
+
+func (o *Options) distance(prefix string) int8 {
+	if o.Distance != nil {
+		return o.Distance(prefix)
+	}
+	switch n := utf8.RuneCountInString(prefix); {
+	case n <= 3:
+		return 0
+	case n <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (o *Options) maxItems() int {
+	if o.MaxItems <= 0 {
+		return 1000
+	}
+	return o.MaxItems
+}
+
+func (o *Options) maxDistance() int {
+	if o.MaxDistance <= 0 {
+		return 3
+	}
+	return o.MaxDistance
+}
+
+// Complete returns the completions for req from t. Completions are ranked by
+// edit distance, then by count (see levtrie.Trie.Count) and then
+// alphabetically.
+func Complete(t *levtrie.Trie, req *Request, opts Options) *Response {
+	resp := &Response{ID: req.ID, Items: []Item{}}
+	max := req.Max
+	if max <= 0 {
+		max = opts.max()
+	}
+	if max > opts.maxItems() {
+		max = opts.maxItems()
+	}
+	d := opts.distance(req.Prefix)
+	if req.Distance != nil {
+		if *req.Distance < 0 || *req.Distance > opts.maxDistance() {
+			resp.Error = fmt.Sprintf("distance must be between 0 and %v", opts.maxDistance())
+			return resp
+		}
+		d = int8(*req.Distance)
+	}
+	if int(d) > opts.maxDistance() {
+		d = int8(opts.maxDistance())
+	}
+	p := opts.ExactPrefix
+	if n := utf8.RuneCountInString(req.Prefix); p > n {
+		p = n
+	}
+	// Fetch more completions than needed so that ranking can promote
+	// frequent words found late in the search.
+	kvs := t.SuggestSuffixesAfterExactPrefix(req.Prefix, p, d, 5*max+1)
+	type cand struct {
+		kv    levtrie.KV
+		dist  int
+		count int64
+	}
+	cands := make([]cand, 0, len(kvs))
+	seen := make(map[string]bool)
+	for _, kv := range kvs {
+		if !seen[kv.Key] {
+			seen[kv.Key] = true
+			cands = append(cands, cand{kv, levtrie.PrefixDistance(req.Prefix, kv.Key), t.Count(kv.Key)})
+		}
+	}
+	sort.Slice(cands, func(i, j int) bool {
+		a, b := cands[i], cands[j]
+		if a.dist != b.dist {
+			return a.dist < b.dist
+		}
+		if a.count != b.count {
+			return a.count > b.count
+		}
+		return a.kv.Key < b.kv.Key
+	})
+	resp.IsIncomplete = len(kvs) > 5*max || len(cands) > max
+	if len(cands) > max {
+		cands = cands[:max]
+	}
+	for i, c := range cands {
+		resp.Items = append(resp.Items, Item{
+			Label:      c.kv.Key,
+			Detail:     c.kv.Value,
+			SortText:   fmt.Sprintf("%05d", i),
+			FilterText: req.Prefix,
+		})
+	}
+	return resp
+}
+
+// Serve reads Requests from r, one JSON object per line, and writes a
+// Response for each one to w until r is exhausted. Each Response is flushed
+// as soon as it's written. A line that isn't a valid Request gets a Response
+// with an error.
+func Serve(r io.Reader, w io.Writer, t *levtrie.Trie, opts Options) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var req Request
+		var resp *Response
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp = &Response{ID: req.ID, Items: []Item{}, Error: err.Error()}
+		} else {
+			resp = Complete(t, &req, opts)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,100 @@
+package complete
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aaw/levtrie"
+)
+
+func newTrie() *levtrie.Trie {
+	t := levtrie.New()
+	for word, count := range map[string]int64{"hello": 5, "help": 50, "helium": 1, "hero": 1, "world": 1} {
+		t.IncrBy(word, count)
+	}
+	return t
+}
+
+func labels(resp *Response) []string {
+	var ls []string
+	for _, item := range resp.Items {
+		ls = append(ls, item.Label)
+	}
+	return ls
+}
+
+func TestComplete(t *testing.T) {
+	tr := newTrie()
+	one, three := 1, 3
+	tests := []struct {
+		req  Request
+		opts Options
+		want []string
+	}{
+		// Prefixes of up to 3 runes are matched exactly by default.
+		{Request{Prefix: "hel"}, Options{}, []string{"help", "hello", "helium"}},
+		{Request{Prefix: "hel", Max: 1}, Options{}, []string{"help"}},
+		// Every key has a prefix 1 edit away from helo.
+		{Request{Prefix: "helo"}, Options{}, []string{"help", "hello", "helium", "hero"}},
+		{Request{Prefix: "wrold", Distance: &one}, Options{}, nil},
+		{Request{Prefix: "wrld", Distance: &one}, Options{}, []string{"world"}},
+		{Request{Prefix: "wrld", Distance: &one}, Options{ExactPrefix: 2}, nil},
+	}
+	for _, test := range tests {
+		resp := Complete(tr, &test.req, test.opts)
+		if resp.Error != "" {
+			t.Errorf("%+v: got error %v", test.req, resp.Error)
+		}
+		if got := labels(resp); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%+v: got %v, want %v", test.req, got, test.want)
+		}
+	}
+	if resp := Complete(tr, &Request{Prefix: "hel", Distance: &three}, Options{MaxDistance: 2}); resp.Error == "" {
+		t.Errorf("Expected an error for a distance over the max")
+	}
+	if resp := Complete(tr, &Request{Prefix: "hel", Max: 2}, Options{}); !resp.IsIncomplete {
+		t.Errorf("Expected an incomplete response")
+	}
+	// A huge max is capped by MaxItems instead of overflowing.
+	if resp := Complete(tr, &Request{Prefix: "hel", Max: int(^uint(0) >> 1)}, Options{}); len(resp.Items) != 3 || resp.Error != "" {
+		t.Errorf("Got %v for a huge max, want 3 items", labels(resp))
+	}
+	if resp := Complete(tr, &Request{Prefix: "hel", Max: 10}, Options{MaxItems: 2}); len(resp.Items) != 2 || !resp.IsIncomplete {
+		t.Errorf("Got %v for a max over MaxItems, want 2 items and an incomplete response", labels(resp))
+	}
+}
+
+func TestServe(t *testing.T) {
+	in := strings.Join([]string{`{"id":1,"prefix":"hel","max":1}`, ``, `not json`, `{"id":[2],"prefix":"wor"}`}, "\n")
+	var out bytes.Buffer
+	if err := Serve(strings.NewReader(in), &out, newTrie(), Options{}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Got %v responses, want 3: %v", len(lines), out.String())
+	}
+	var resps []Response
+	for _, line := range lines {
+		var resp Response
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatal(err)
+		}
+		resps = append(resps, resp)
+	}
+	if string(resps[0].ID) != "1" || !reflect.DeepEqual(labels(&resps[0]), []string{"help"}) {
+		t.Errorf("Got %+v for the first request", resps[0])
+	}
+	if resps[1].Error == "" {
+		t.Errorf("Got %+v for invalid JSON, want an error", resps[1])
+	}
+	if string(resps[2].ID) != "[2]" || !reflect.DeepEqual(labels(&resps[2]), []string{"world"}) {
+		t.Errorf("Got %+v for the last request", resps[2])
+	}
+	if got := resps[0].Items[0]; got.FilterText != "hel" || got.SortText != "00000" {
+		t.Errorf("Got item %+v", got)
+	}
+}
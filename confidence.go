@@ -0,0 +1,72 @@
+package levtrie
+
+// ScoredKV is a KV returned by SuggestWithConfidence along with its edit
+// distance from the query and a confidence score.
+type ScoredKV struct {
+	Key        string
+	Value      string
+	Distance   int     // The edit distance, or cost, from the query to Key.
+	Confidence float64 // How likely Key is to be what was meant, in [0, 1].
+}
+
+// SuggestWithConfidence is like Suggest, but ranks the results by distance,
+// then by weight (see ByWeight), and scores each of them with a confidence in
+// [0, 1] that a UI can use to decide whether to correct a query automatically
+// or to merely point out that it might be wrong. The confidence of a result
+// at distance x with weight w (see Trie.IncrBy) is
+//
+//	(w+1)/(x+1) / sum((wi+1)/(xi+1)) / (x+1)
+//
+// where the sum is over every result returned. The first factor is the share
+// of the result among the results, so a result with close competitors gets a
+// low confidence, and the second factor discounts results that take more
+// edits to reach. An exact match with no competitors has confidence 1. Since
+// the confidence is relative to the results returned, n affects it: a larger
+// n includes more competitors.
+func (t *Trie) SuggestWithConfidence(key string, d int8, n int, opts ...SuggestOption) []ScoredKV {
+	key, ok := t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	var results []ScoredKV
+	var dists []int
+	var weights []int64
+	for x, kvs := range t.suggestByDistance(key, d, n, append(opts, ByWeight())) {
+		for _, kv := range kvs {
+			results = append(results, ScoredKV{Key: kv.Key, Value: kv.Value, Distance: x})
+			dists = append(dists, x)
+			weights = append(weights, t.weight(kv.Key))
+		}
+	}
+	for i, c := range confidences(dists, weights) {
+		results[i].Confidence = c
+	}
+	return results
+}
+
+// weight returns the count of key without counting it as a use of the key.
+func (t *Trie) weight(key string) int64 {
+	if n := t.find(key); n != nil && n.data.live() {
+		return t.count(n.data)
+	}
+	return 0
+}
+
+// confidences returns the confidence of each result with the given distance
+// and weight, as described in SuggestWithConfidence.
+func confidences(dists []int, weights []int64) []float64 {
+	scores := make([]float64, len(dists))
+	total := 0.0
+	for i := range scores {
+		w := float64(weights[i])
+		if w < 0 {
+			w = 0
+		}
+		scores[i] = (w + 1) / float64(dists[i]+1)
+		total += scores[i]
+	}
+	for i := range scores {
+		scores[i] = scores[i] / total / float64(dists[i]+1)
+	}
+	return scores
+}
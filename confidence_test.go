@@ -0,0 +1,41 @@
+package levtrie
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSuggestWithConfidence(t *testing.T) {
+	r := New()
+	for word, count := range map[string]int64{"the": 99, "then": 20, "ten": 5, "tea": 1, "apple": 3} {
+		r.IncrBy(word, count)
+	}
+	close := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	got := r.SuggestWithConfidence("apple", 2, 10)
+	if len(got) != 1 || got[0].Key != "apple" || got[0].Distance != 0 || !close(got[0].Confidence, 1) {
+		t.Errorf("Got %+v, want apple with confidence 1", got)
+	}
+	got = r.SuggestWithConfidence("aple", 2, 10)
+	if len(got) != 1 || !close(got[0].Confidence, 0.5) {
+		t.Errorf("Got %+v, want apple with confidence 0.5", got)
+	}
+	got = r.SuggestWithConfidence("thn", 1, 10)
+	var keys []string
+	total := 0.0
+	for i, s := range got {
+		keys = append(keys, s.Key)
+		if s.Distance != 1 || s.Confidence <= 0 || (i > 0 && s.Confidence > got[i-1].Confidence) {
+			t.Errorf("Result %v is %+v, want distance 1 and decreasing confidence", i, s)
+		}
+		total += s.Confidence
+	}
+	if got, want := ukeystr(r.Suggest("thn", 1, 10, ByWeight())), "the then ten"; got != want || len(keys) != 3 {
+		t.Errorf("Got %v, want %v", keys, want)
+	}
+	if !close(total, 0.5) {
+		t.Errorf("Confidences at distance 1 sum to %v, want 0.5", total)
+	}
+	if got := r.SuggestWithConfidence("zzzzz", 1, 10); len(got) != 0 {
+		t.Errorf("Got %+v, want nothing", got)
+	}
+}
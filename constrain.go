@@ -0,0 +1,96 @@
+package levtrie
+
+// Acceptor is a deterministic automaton over runes, like a DFA compiled from
+// a pattern, that restricts a search to the keys it accepts (see Constrain).
+// Its states are ints of its own choosing. An Acceptor must not change while
+// a search uses it, so one Acceptor can be shared by concurrent searches.
+type Acceptor interface {
+	// Start returns the state before any runes are read.
+	Start() int
+	// Step returns the state after reading r in state s, and false if
+	// no string that starts with the runes read so far followed by r is
+	// accepted. Returning false lets a search skip every key below.
+	Step(s int, r rune) (int, bool)
+	// Accepts returns true if the runes read to reach s are accepted.
+	Accepts(s int) bool
+}
+
+// Constrain restricts a search to the keys that a accepts, like keys that
+// match the pattern "[a-z]+-[0-9]+". The search runs a alongside the
+// Levenshtein automaton as it walks the Trie, so it skips the keys below any
+// prefix that a rules out instead of finding them and filtering them out
+// afterwards. Searches that don't walk keys from their first rune, like
+// SuggestEndsWith and SuggestInfix, check each match with a instead. Keys
+// are checked before the number of results is limited.
+func Constrain(a Acceptor) SuggestOption {
+	return func(cfg *searchConfig) {
+		cfg.acceptor = a
+	}
+}
+
+// after advances the state of the acceptor that a search starts from past
+// prefix, the runes of an exact prefix that the search doesn't walk.
+func (cfg *searchConfig) after(prefix []rune) {
+	if !cfg.prune {
+		return
+	}
+	for _, r := range prefix {
+		var ok bool
+		if cfg.from, ok = cfg.acceptor.Step(cfg.from, r); !ok {
+			cfg.rejected = true
+			return
+		}
+	}
+}
+
+// accepts returns true if the acceptor of cfg, if it has one, accepts key.
+func (cfg *searchConfig) accepts(key string) bool {
+	if cfg.acceptor == nil {
+		return true
+	}
+	s := cfg.acceptor.Start()
+	for _, r := range key {
+		var ok bool
+		if s, ok = cfg.acceptor.Step(s, r); !ok {
+			return false
+		}
+	}
+	return cfg.acceptor.Accepts(s)
+}
+
+// constrained is the product of an automaton and an Acceptor, which keeps the
+// state of the Acceptor in state.ext. A transition that the Acceptor rules
+// out leads back to the same state with a distance over budget, so the search
+// drops it.
+// Whether the Acceptor accepts a key is checked when the key is found, see
+// searchConfig.excluded.
+type constrained struct {
+	a    automaton
+	acc  Acceptor
+	from int // The state of acc at the start.
+	dead int // A distance over the budget of a.
+}
+
+func (c constrained) start() state {
+	s := c.a.start()
+	s.ext = c.from
+	return s
+}
+
+func (c constrained) accepts(s state) bool {
+	return c.a.accepts(s)
+}
+
+func (c constrained) distance(s state) int {
+	return c.a.distance(s)
+}
+
+func (c constrained) transition(s state, r rune) (state, int) {
+	ext, ok := c.acc.Step(s.ext, r)
+	if !ok {
+		return s, c.dead
+	}
+	ns, min := c.a.transition(s, r)
+	ns.ext = ext
+	return ns, min
+}
@@ -0,0 +1,120 @@
+package levtrie
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"testing"
+)
+
+// codeAcceptor accepts the strings that match ^[a-z]+-[0-9]+$ and counts the
+// runes it reads.
+type codeAcceptor struct {
+	steps *int
+}
+
+func (a codeAcceptor) Start() int { return 0 }
+
+func (a codeAcceptor) Step(s int, r rune) (int, bool) {
+	*a.steps++
+	switch {
+	case 'a' <= r && r <= 'z' && s <= 1:
+		return 1, true
+	case r == '-' && s == 1:
+		return 2, true
+	case '0' <= r && r <= '9' && s >= 2:
+		return 3, true
+	}
+	return 0, false
+}
+
+func (a codeAcceptor) Accepts(s int) bool { return s == 3 }
+
+var codePattern = regexp.MustCompile(`^[a-z]+-[0-9]+$`)
+
+func matchingCodes(kvs []KV) []KV {
+	var results []KV
+	for _, kv := range kvs {
+		if codePattern.MatchString(kv.Key) {
+			results = append(results, kv)
+		}
+	}
+	return results
+}
+
+func randomCode(rng *rand.Rand) string {
+	const alphabet = "ab-01"
+	b := make([]byte, 2+rng.Intn(5))
+	for i := range b {
+		b[i] = alphabet[rng.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+func TestConstrain(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	r := New()
+	for i := 0; i < 500; i++ {
+		key := randomCode(rng)
+		r.Set(key, key)
+	}
+	f := r.Freeze()
+	g, err := f.Dense()
+	if err != nil {
+		t.Fatalf("Dense() = %v", err)
+	}
+	var steps int
+	only := Constrain(codeAcceptor{&steps})
+	for i := 0; i < 50; i++ {
+		query := randomCode(rng)
+		for _, d := range []int8{0, 1, 2} {
+			for _, opts := range [][]SuggestOption{nil, {Ordered()}} {
+				name := fmt.Sprintf("(%q, %v, ordered: %v)", query, d, opts != nil)
+				constrained := append([]SuggestOption{only}, opts...)
+				want := matchingCodes(r.Suggest(query, d, 1000, opts...))
+				if got := r.Suggest(query, d, 1000, constrained...); keystr(got) != keystr(want) {
+					t.Errorf("Suggest%v = %v, want %v", name, keystr(got), keystr(want))
+				}
+				if got := f.Suggest(query, d, 1000, constrained...); keystr(got) != keystr(want) {
+					t.Errorf("FrozenTrie.Suggest%v = %v, want %v", name, keystr(got), keystr(want))
+				}
+				if got := g.Suggest(query, d, 1000, constrained...); keystr(got) != keystr(want) {
+					t.Errorf("DenseTrie.Suggest%v = %v, want %v", name, keystr(got), keystr(want))
+				}
+				want = matchingCodes(r.SuggestSuffixes(query, d, 1000, opts...))
+				if got := r.SuggestSuffixes(query, d, 1000, constrained...); keystr(got) != keystr(want) {
+					t.Errorf("SuggestSuffixes%v = %v, want %v", name, keystr(got), keystr(want))
+				}
+				want = matchingCodes(r.SuggestAfterExactPrefix(query, 1, d, 1000, opts...))
+				if got := r.SuggestAfterExactPrefix(query, 1, d, 1000, constrained...); keystr(got) != keystr(want) {
+					t.Errorf("SuggestAfterExactPrefix%v = %v, want %v", name, keystr(got), keystr(want))
+				}
+				want = matchingCodes(r.SuggestEndsWith(query, d, 1000, opts...))
+				if got := r.SuggestEndsWith(query, d, 1000, constrained...); keystr(got) != keystr(want) {
+					t.Errorf("SuggestEndsWith%v = %v, want %v", name, keystr(got), keystr(want))
+				}
+			}
+			if got, want := Compile(query, d, only).Matches(query), Within(query, query, d) && codePattern.MatchString(query); got != want {
+				t.Errorf("Compile(%q, %v).Matches(%q) = %v, want %v", query, d, query, got, want)
+			}
+		}
+	}
+}
+
+func TestConstrainPrunes(t *testing.T) {
+	r := New()
+	for i := 0; i < 1000; i++ {
+		r.Set(fmt.Sprintf("0abc-%d", i), "")
+	}
+	r.Set("abc-1", "")
+	var steps int
+	got := r.SuggestSuffixes("abc", 1, 10, Constrain(codeAcceptor{&steps}))
+	if keystr(got) != keystr([]KV{{Key: "abc-1"}}) {
+		t.Errorf("SuggestSuffixes(\"abc\") = %v, want [abc-1]", keystr(got))
+	}
+	// "0abc" is within distance 1 of "abc", but the acceptor rules out the
+	// 1000 keys below it as soon as it reads the "0".
+	if steps > 50 {
+		t.Errorf("Got %v steps, want at most 50", steps)
+	}
+}
@@ -0,0 +1,40 @@
+// Package corpus embeds a sample list of about 5,000 lowercase English words,
+// one per line, so that benchmarks, tests and examples don't depend on a
+// system word list like /usr/share/dict/words, which doesn't exist on every
+// platform. The words were collected from English prose, so they include
+// common words and some technical vocabulary.
+//
+// To load the words into a Trie, use
+//
+//	t, err := levtrie.ReadWordsFS(corpus.FS, corpus.File)
+//
+// This package doesn't import levtrie so that levtrie's own tests can use it.
+package corpus
+
+import (
+	"embed"
+	"io"
+	"strings"
+)
+
+// File is the name of the word list in FS.
+const File = "words.txt"
+
+// FS holds the word list in a file named File.
+//
+//go:embed words.txt
+var FS embed.FS
+
+//go:embed words.txt
+var words string
+
+// Words returns the words in the word list, in sorted order. The slice is
+// newly allocated on each call, so callers can modify it.
+func Words() []string {
+	return strings.Fields(words)
+}
+
+// Open returns a reader of the word list, one word per line.
+func Open() io.Reader {
+	return strings.NewReader(words)
+}
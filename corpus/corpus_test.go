@@ -0,0 +1,50 @@
+package corpus
+
+import (
+	"bufio"
+	"io/fs"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	ws := Words()
+	if len(ws) < 1000 {
+		t.Fatalf("Got %v words, want at least 1000", len(ws))
+	}
+	if !sort.StringsAreSorted(ws) {
+		t.Errorf("Words aren't sorted")
+	}
+	for i, w := range ws {
+		if w != strings.ToLower(w) {
+			t.Errorf("Word %q isn't lowercase", w)
+		}
+		if i > 0 && ws[i-1] == w {
+			t.Errorf("Word %q is repeated", w)
+		}
+	}
+	ws[0] = "modified"
+	if Words()[0] == "modified" {
+		t.Errorf("Modifying the result of Words changed the word list")
+	}
+}
+
+func TestFS(t *testing.T) {
+	b, err := fs.ReadFile(FS, File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(strings.Fields(string(b))), len(Words()); got != want {
+		t.Errorf("Got %v words in %v, want %v", got, File, want)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	n := 0
+	for scanner := bufio.NewScanner(Open()); scanner.Scan(); n++ {
+	}
+	if want := len(Words()); n != want {
+		t.Errorf("Got %v lines, want %v", n, want)
+	}
+}
@@ -0,0 +1,314 @@
+package levtrie
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CorrectOptions configures CorrectText.
+type CorrectOptions struct {
+	// Distance is the edit distance to search within for corrections of
+	// each word. If it's negative, the distance is chosen by the length of
+	// the word, see AdaptiveDistance.
+	Distance int8
+	// Max is the max number of candidates for each word. If Max isn't
+	// positive, 5 is used.
+	Max int
+	// Fold maps each word to the form it's looked up in the Trie with when
+	// it isn't in the Trie as it's written. If it's nil, strings.ToLower is
+	// used, so that "The" at the beginning of a sentence is spelled
+	// correctly if "the" is in the Trie.
+	Fold func(word string) string
+	// SuggestOptions are passed to each search for candidates.
+	SuggestOptions []SuggestOption
+	// Rerank, if not nil, is called with the words that come before each
+	// misspelled word in the text, in order, and the candidates found for
+	// it, and returns the candidates in the order they should be offered.
+	// This lets a language model use the context of a word to choose
+	// between candidates that are equally close to it, like "piece" and
+	// "peace" after "a". Misspelled words are passed in previous as their
+	// first candidate, if they have one. Rerank can drop candidates or add
+	// its own, and it must not keep a reference to previous.
+	Rerank func(previous []string, candidates []string) []string
+	// RestoreCase makes the candidates for a capitalized word capitalized
+	// and the candidates for a word in all caps all caps, so that "Teh" is
+	// corrected to "The" and "TEH" to "THE" instead of to "the". Candidates
+	// that are the same after restoring their case are only returned once.
+	RestoreCase bool
+	// Skip lists the classes of tokens that are never corrected, like
+	// Numbers or URLs. A token is a run of text between whitespace without
+	// the punctuation around it, and every word in a token that's in one of
+	// the classes is skipped, as if it wasn't in the text at all.
+	Skip []TokenClass
+}
+
+// TokenClass recognizes a class of tokens that shouldn't be corrected, see
+// CorrectOptions.Skip. Any predicate on tokens can be used as a TokenClass.
+type TokenClass func(token string) bool
+
+// Built-in TokenClasses.
+var (
+	// Numbers matches tokens with a digit in them, like "42", "3.14",
+	// "1990s" and "mp3".
+	Numbers TokenClass = func(token string) bool {
+		return strings.IndexFunc(token, unicode.IsDigit) >= 0
+	}
+	// URLs matches tokens with a scheme, like "https://example.com", and
+	// tokens that start with "www.".
+	URLs TokenClass = func(token string) bool {
+		return strings.Contains(token, "://") || strings.HasPrefix(strings.ToLower(token), "www.")
+	}
+	// Emails matches tokens that look like email addresses, with an "@"
+	// followed by a domain with a dot in it.
+	Emails TokenClass = func(token string) bool {
+		at := strings.LastIndexByte(token, '@')
+		return at > 0 && strings.Contains(token[at+1:], ".")
+	}
+	// Hashtags matches tokens that start with "#" or "@", like "#golang"
+	// and "@aaw".
+	Hashtags TokenClass = func(token string) bool {
+		return strings.HasPrefix(token, "#") || strings.HasPrefix(token, "@")
+	}
+	// Identifiers matches tokens that look like code, like "snake_case",
+	// "camelCase", "fmt.Println" and "main()".
+	Identifiers TokenClass = func(token string) bool {
+		if strings.ContainsAny(token, "_()") {
+			return true
+		}
+		var prev rune
+		for _, r := range token {
+			if unicode.IsLower(prev) && (unicode.IsUpper(r) || r == '.') {
+				return true
+			}
+			prev = r
+		}
+		return false
+	}
+)
+
+func (o *CorrectOptions) max() int {
+	if o.Max <= 0 {
+		return 5
+	}
+	return o.Max
+}
+
+func (o *CorrectOptions) fold(word string) string {
+	if o.Fold != nil {
+		return o.Fold(word)
+	}
+	return strings.ToLower(word)
+}
+
+// Correction describes a word in a text that isn't in the Trie, along with
+// the keys in the Trie that it might have been meant to be.
+type Correction struct {
+	Word       string   // The word as it appears in the text.
+	Start      int      // The byte offset of the word in the text.
+	End        int      // The byte offset just past the end of the word.
+	Candidates []string // Keys close to the word, best first. May be empty.
+	// Confidence holds the confidence in each of the Candidates, see
+	// SuggestWithConfidence.
+	Confidence []float64
+}
+
+// CorrectText splits text into words and returns a Correction for each word
+// that isn't in the Trie, in the order the words appear in the text. A word is
+// a run of letters and combining marks, possibly with apostrophes between
+// them like "don't", so punctuation, digits and whitespace separate words and
+// are never corrected, unless the Trie's Analyzer has its own Tokenizer. A
+// word is spelled correctly if it's in the Trie as it's written or after
+// folding it with opts.Fold. Candidates are found by searching for the folded
+// word and are ranked by edit distance, then by weight (see Trie.IncrBy),
+// then by key, unless opts.Rerank ranks them. The confidence in each
+// candidate is computed from its Levenshtein distance to the folded word and
+// its weight, as in SuggestWithConfidence.
+func (t *Trie) CorrectText(text string, opts CorrectOptions) []Correction {
+	var corrections []Correction
+	var previous []string
+	sopts := append([]SuggestOption{ByWeight(), ValuesPerKey(1)}, opts.SuggestOptions...)
+	skipped := opts.skipped(text)
+	for _, tok := range t.tokens(text) {
+		for len(skipped) > 0 && skipped[0].end <= tok.start {
+			skipped = skipped[1:]
+		}
+		if len(skipped) > 0 && skipped[0].start <= tok.start {
+			continue
+		}
+		folded := opts.fold(tok.word)
+		if t.Has(tok.word) || t.Has(folded) {
+			previous = append(previous, tok.word)
+			continue
+		}
+		c := Correction{Word: tok.word, Start: tok.start, End: tok.end, Candidates: []string{}}
+		for _, kv := range t.Suggest(folded, opts.Distance, opts.max(), sopts...) {
+			c.Candidates = append(c.Candidates, kv.Key)
+		}
+		if opts.Rerank != nil {
+			if c.Candidates = opts.Rerank(previous, c.Candidates); c.Candidates == nil {
+				c.Candidates = []string{}
+			}
+		}
+		dists, weights := make([]int, len(c.Candidates)), make([]int64, len(c.Candidates))
+		for i, cand := range c.Candidates {
+			dists[i], weights[i] = Distance(folded, cand), t.weight(cand)
+		}
+		c.Confidence = confidences(dists, weights)
+		if opts.RestoreCase {
+			c.Candidates, c.Confidence = restoreCase(tok.word, c.Candidates, c.Confidence)
+		}
+		if len(c.Candidates) > 0 {
+			previous = append(previous, c.Candidates[0])
+		} else {
+			previous = append(previous, tok.word)
+		}
+		corrections = append(corrections, c)
+	}
+	return corrections
+}
+
+// skipped returns the tokens of text in the classes listed in o.Skip, in order.
+func (o *CorrectOptions) skipped(text string) []token {
+	if len(o.Skip) == 0 {
+		return nil
+	}
+	var tokens []token
+	for start := 0; start < len(text); {
+		end := strings.IndexFunc(text[start:], unicode.IsSpace)
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += start
+		}
+		trimmed := strings.TrimLeftFunc(text[start:end], isEdgePunct)
+		tok := token{start: end - len(trimmed)}
+		tok.word = strings.TrimRightFunc(trimmed, isEdgePunct)
+		tok.end = tok.start + len(tok.word)
+		for _, class := range o.Skip {
+			if tok.word != "" && class(tok.word) {
+				tokens = append(tokens, tok)
+				break
+			}
+		}
+		_, size := utf8.DecodeRuneInString(text[end:])
+		start = end + size
+	}
+	return tokens
+}
+
+// isEdgePunct returns true for the punctuation that's trimmed from the ends of
+// tokens before they're matched against TokenClasses.
+func isEdgePunct(r rune) bool {
+	return strings.ContainsRune(`"'()[]{}<>.,;:!?`, r)
+}
+
+// token is a word in a text along with its byte offsets.
+type token struct {
+	word       string
+	start, end int
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r)
+}
+
+// tokenize splits text into words as described in CorrectText.
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		switch {
+		case isWordRune(r):
+			if start < 0 {
+				start = i
+			}
+		case start >= 0 && r == '\'':
+			// An apostrophe only continues a word if a letter follows.
+			if next, _ := utf8.DecodeRuneInString(text[i+1:]); isWordRune(next) {
+				continue
+			}
+			fallthrough
+		case start >= 0:
+			tokens = append(tokens, token{word: text[start:i], start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{word: text[start:], start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// didYouMeanMargin is how many times heavier the best correction found by
+// DidYouMean has to be than any other candidate at the same distance.
+const didYouMeanMargin = 2
+
+// DidYouMean returns the single best correction for query along with true,
+// or false if there's no correction it's confident about. It never returns
+// query itself. Corrections within edit distance 1 are preferred over
+// corrections within distance 2, and among corrections at the same distance,
+// the one with the largest weight (see Trie.IncrBy) wins, but only if it's
+// more than twice as heavy as the runner-up. If query is in the Trie, the
+// correction also has to be more than twice as heavy as query, so a rare but
+// correctly spelled word is only corrected to a much more common one.
+func (t *Trie) DidYouMean(query string) (string, bool) {
+	for d := int8(1); d <= 2; d++ {
+		kvs := t.Suggest(query, d, 2, ByWeight(), ExcludeQuery(), ValuesPerKey(1))
+		if len(kvs) == 0 {
+			continue
+		}
+		best := t.Count(kvs[0].Key)
+		if len(kvs) > 1 && best <= didYouMeanMargin*t.Count(kvs[1].Key) {
+			return "", false
+		}
+		if t.Has(query) && best <= didYouMeanMargin*t.Count(query) {
+			return "", false
+		}
+		return kvs[0].Key, true
+	}
+	return "", false
+}
+
+// restoreCase applies the case of word to each of the candidates as described
+// in CorrectOptions.RestoreCase, dropping the candidates, and their
+// confidences, that become duplicates of earlier ones.
+func restoreCase(word string, candidates []string, confidence []float64) ([]string, []float64) {
+	var apply func(string) string
+	first, size := utf8.DecodeRuneInString(word)
+	switch rest := word[size:]; {
+	case !unicode.IsUpper(first):
+		return candidates, confidence
+	case rest != "" && strings.ToUpper(rest) == rest && strings.ToLower(rest) != rest:
+		apply = strings.ToUpper
+	default:
+		apply = func(s string) string {
+			r, size := utf8.DecodeRuneInString(s)
+			return string(unicode.ToTitle(r)) + s[size:]
+		}
+	}
+	seen := make(map[string]bool)
+	cs, conf := candidates[:0], confidence[:0]
+	for i, c := range candidates {
+		if c = apply(c); !seen[c] {
+			seen[c] = true
+			cs, conf = append(cs, c), append(conf, confidence[i])
+		}
+	}
+	return cs, conf
+}
+
+// CorrectText finds the words of text that aren't keys of the SyncTrie and
+// suggests corrections for them. See Trie.CorrectText.
+func (s *SyncTrie) CorrectText(text string, opts CorrectOptions) []Correction {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.CorrectText(text, opts)
+}
+
+// DidYouMean returns a confident correction of query. See Trie.DidYouMean.
+func (s *SyncTrie) DidYouMean(query string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.DidYouMean(query)
+}
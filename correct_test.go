@@ -0,0 +1,170 @@
+package levtrie
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	text := "Don't  stop, héllo-world 'quoted' 42x it's'"
+	var got []string
+	for _, tok := range tokenize(text) {
+		if text[tok.start:tok.end] != tok.word {
+			t.Errorf("Token %q has offsets %v:%v", tok.word, tok.start, tok.end)
+		}
+		got = append(got, tok.word)
+	}
+	if want := []string{"Don't", "stop", "héllo", "world", "quoted", "x", "it's"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestCorrectText(t *testing.T) {
+	r := New()
+	for word, count := range map[string]int64{"the": 100, "then": 20, "ten": 5, "tea": 1, "cat": 10, "sat": 3, "on": 50, "mat": 2, "Paris": 1} {
+		r.IncrBy(word, count)
+	}
+	got := r.CorrectText("The caat sat on teh mat in Paris, paris.", CorrectOptions{Distance: 1, Max: 3})
+	want := []Correction{
+		{Word: "caat", Start: 4, End: 8, Candidates: []string{"cat"}, Confidence: []float64{0.5}},
+		{Word: "teh", Start: 16, End: 19, Candidates: []string{"ten", "tea"}, Confidence: []float64{0.375, 0.125}},
+		{Word: "in", Start: 24, End: 26, Candidates: []string{"on"}, Confidence: []float64{0.5}},
+		{Word: "paris", Start: 34, End: 39, Candidates: []string{"Paris"}, Confidence: []float64{0.5}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %+v, want %+v", got, want)
+	}
+	got = r.CorrectText("TEH", CorrectOptions{Distance: 2, Max: 2, Fold: strings.ToLower})
+	if len(got) != 1 || !reflect.DeepEqual(got[0].Candidates, []string{"ten", "tea"}) {
+		t.Errorf("Got %+v, want candidates ten and tea", got)
+	}
+	if got := r.CorrectText("", CorrectOptions{}); len(got) != 0 {
+		t.Errorf("Got %+v for no text, want nothing", got)
+	}
+}
+
+func TestCorrectTextRerank(t *testing.T) {
+	r := New()
+	for word, count := range map[string]int64{"a": 100, "piece": 5, "peace": 10, "of": 100, "cake": 3, "lake": 8} {
+		r.IncrBy(word, count)
+	}
+	bigrams := map[[2]string]int{{"a", "piece"}: 10, {"a", "peace"}: 1, {"piece", "of"}: 5, {"of", "cake"}: 3}
+	var calls [][]string
+	rerank := func(previous []string, candidates []string) []string {
+		calls = append(calls, append([]string(nil), previous...))
+		prev := previous[len(previous)-1]
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return bigrams[[2]string{prev, candidates[i]}] > bigrams[[2]string{prev, candidates[j]}]
+		})
+		return candidates
+	}
+	got := r.CorrectText("a peice of cakke", CorrectOptions{Distance: 2, Max: 2, Rerank: rerank})
+	if len(got) != 2 || got[0].Candidates[0] != "piece" || got[1].Candidates[0] != "cake" {
+		t.Errorf("Got %+v, want piece and cake first", got)
+	}
+	if want := [][]string{{"a"}, {"a", "piece", "of"}}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("Rerank was called with %q, want %q", calls, want)
+	}
+	got = r.CorrectText("a peice", CorrectOptions{Distance: 2, Rerank: func([]string, []string) []string { return nil }})
+	if len(got) != 1 || got[0].Candidates == nil || len(got[0].Candidates) != 0 {
+		t.Errorf("Got %+v, want no candidates", got)
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	r := New()
+	for word, count := range map[string]int64{"the": 100, "then": 20, "ten": 5, "tea": 4, "form": 20, "from": 30, "banana": 0, "bandana": 0, "cabana": 0, "apple": 7} {
+		r.IncrBy(word, count)
+	}
+	for _, test := range []struct {
+		query string
+		want  string
+		ok    bool
+	}{
+		{"thee", "the", true},
+		{"teh", "", false},
+		{"aple", "apple", true},
+		{"apple", "", false},
+		{"aplpe", "apple", true},
+		{"form", "", false},
+		{"frm", "", false},
+		{"banan", "banana", true},
+		{"bananna", "banana", true},
+		{"xyzzy", "", false},
+	} {
+		if got, ok := r.DidYouMean(test.query); got != test.want || ok != test.ok {
+			t.Errorf("DidYouMean(%q) = (%q, %v), want (%q, %v)", test.query, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestCorrectTextRestoreCase(t *testing.T) {
+	r := New()
+	for word, count := range map[string]int64{"the": 100, "tea": 5, "paris": 2, "Paris": 1, "élan": 1} {
+		r.IncrBy(word, count)
+	}
+	var got []string
+	for _, c := range r.CorrectText("Teh TEH teh Pariss PARISS Élann I", CorrectOptions{Distance: 1, RestoreCase: true}) {
+		got = append(got, strings.Join(c.Candidates, ","))
+		if len(c.Confidence) != len(c.Candidates) {
+			t.Errorf("Got %v confidences for %v candidates", len(c.Confidence), len(c.Candidates))
+		}
+	}
+	if want := []string{"Tea", "TEA", "tea", "Paris", "PARIS", "Élan", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	for _, test := range []struct{ word, want string }{{"Teh", "The"}, {"TEH", "THE"}, {"teh", "the"}, {"T", "The"}, {"TEh", "The"}} {
+		if got, _ := restoreCase(test.word, []string{"the"}, []float64{1}); got[0] != test.want {
+			t.Errorf("restoreCase(%q, the) = %v, want %v", test.word, got[0], test.want)
+		}
+	}
+}
+
+func TestTokenClasses(t *testing.T) {
+	for _, test := range []struct {
+		class TokenClass
+		yes   []string
+		no    []string
+	}{
+		{Numbers, []string{"42", "3.14", "1990s", "mp3"}, []string{"forty", "x"}},
+		{URLs, []string{"https://example.com/a", "www.example.com", "WWW.golang.org"}, []string{"example.com", "http"}},
+		{Emails, []string{"someone@example.com"}, []string{"@example.com", "someone@localhost"}},
+		{Hashtags, []string{"#golang", "@aaw"}, []string{"go#lang"}},
+		{Identifiers, []string{"snake_case", "camelCase", "fmt.Println", "main()", "os.Open"}, []string{"Title", "ALLCAPS", "plain"}},
+	} {
+		for _, s := range test.yes {
+			if !test.class(s) {
+				t.Errorf("%q wasn't matched", s)
+			}
+		}
+		for _, s := range test.no {
+			if test.class(s) {
+				t.Errorf("%q was matched", s)
+			}
+		}
+	}
+}
+
+func TestCorrectTextSkip(t *testing.T) {
+	r := New()
+	for _, word := range []string{"see", "the", "docs", "at", "or", "mail", "me", "about", "go"} {
+		r.Set(word, "")
+	}
+	text := "See teh docs at (https://exampel.com/dcos), or mail me@exampel.com about #golnag and fmt.Printn in 2024x yesss."
+	var got []string
+	for _, c := range r.CorrectText(text, CorrectOptions{Distance: 1, Skip: []TokenClass{Numbers, URLs, Emails, Hashtags, Identifiers}}) {
+		got = append(got, c.Word)
+	}
+	if want := []string{"teh", "and", "in", "yesss"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	got = nil
+	for _, c := range r.CorrectText(text, CorrectOptions{Distance: 1}) {
+		got = append(got, c.Word)
+	}
+	if len(got) <= 4 {
+		t.Errorf("Got %q without Skip, want the skipped words too", got)
+	}
+}
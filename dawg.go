@@ -0,0 +1,346 @@
+package levtrie
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// DAWG is a read-only set of keys stored as a directed acyclic word graph: a
+// trie in which identical subtrees are stored only once, so that keys with
+// common suffixes, like "walking" and "talking", share the nodes for them as
+// well as the nodes for their common prefixes. A DAWG of a natural language
+// dictionary usually has several times fewer nodes than a Trie of it. Keys
+// aren't stored at the nodes, so a DAWG can't associate values or counts with
+// its keys; every KV it returns has an empty value. It's safe for concurrent
+// use by multiple goroutines. Don't create directly, use a DAWGBuilder or
+// ReadDAWG instead.
+type DAWG struct {
+	root  *dawgNode
+	size  int
+	nodes int
+	// Holds the options of a Trie that apply to searches. It has no nodes.
+	t *Trie
+}
+
+// dawgNode is a node of a DAWG.
+type dawgNode struct {
+	id    int        // A unique id, assigned when the node is registered.
+	final bool       // True if the path to the node is a key.
+	edges []dawgEdge // Sorted by rune.
+}
+
+type dawgEdge struct {
+	r  rune
+	to *dawgNode
+}
+
+// DAWGBuilder builds a DAWG from keys added in sorted order, using Daciuk's
+// algorithm for incremental minimization. Once a key is added, the nodes for
+// the part of the previous key that it doesn't share can't change anymore, so
+// they're merged right away with any identical nodes already built. Only the
+// nodes for the last key added are ever unmerged, so the memory used while
+// building is close to the memory used by the finished DAWG, and far less
+// than a Trie of the same keys.
+type DAWGBuilder struct {
+	root      *dawgNode
+	prev      string
+	size      int
+	unchecked []dawgEdgeFrom // The unmerged edges on the path to prev.
+	register  map[string]*dawgNode
+	t         *Trie // Configured with the options of the DAWG. It has no nodes.
+}
+
+// dawgEdgeFrom is the last edge of parent, which leads to child.
+type dawgEdgeFrom struct {
+	parent, child *dawgNode
+}
+
+// NewDAWGBuilder returns a DAWGBuilder for a DAWG whose searches use the given
+// options, like AdaptiveDistance and PreprocessQueries. Options that only
+// apply to the storage of a Trie have no effect.
+func NewDAWGBuilder(opts ...Option) *DAWGBuilder {
+	return &DAWGBuilder{root: &dawgNode{}, register: make(map[string]*dawgNode), t: New(opts...)}
+}
+
+// Add adds key to the DAWG being built. Keys must be added in increasing
+// order, as strings are compared; Add returns an error for a key less than the
+// previous one and ignores a key equal to it.
+func (b *DAWGBuilder) Add(key string) error {
+	if b.size > 0 && key <= b.prev {
+		if key == b.prev {
+			return nil
+		}
+		return fmt.Errorf("levtrie: DAWG key %q added after %q", key, b.prev)
+	}
+	// Find the number of runes key shares with the previous key.
+	common, i := 0, 0
+	for i < len(key) && i < len(b.prev) {
+		r, w := utf8.DecodeRuneInString(key[i:])
+		if p, _ := utf8.DecodeRuneInString(b.prev[i:]); p != r {
+			break
+		}
+		common, i = common+1, i+w
+	}
+	b.minimize(common)
+	n := b.root
+	if len(b.unchecked) > 0 {
+		n = b.unchecked[len(b.unchecked)-1].child
+	}
+	for _, r := range key[i:] {
+		child := &dawgNode{}
+		n.edges = append(n.edges, dawgEdge{r: r, to: child})
+		b.unchecked = append(b.unchecked, dawgEdgeFrom{parent: n, child: child})
+		n = child
+	}
+	n.final = true
+	b.prev = key
+	b.size++
+	return nil
+}
+
+// minimize merges the unchecked nodes deeper than down with identical
+// registered nodes, or registers them if there are none, from the bottom up.
+func (b *DAWGBuilder) minimize(down int) {
+	for i := len(b.unchecked) - 1; i >= down; i-- {
+		u := b.unchecked[i]
+		sig := u.child.signature()
+		if existing, ok := b.register[sig]; ok {
+			u.parent.edges[len(u.parent.edges)-1].to = existing
+		} else {
+			u.child.id = len(b.register) + 1
+			b.register[sig] = u.child
+		}
+	}
+	b.unchecked = b.unchecked[:down]
+}
+
+// signature returns a string that's the same for two nodes exactly when they
+// have the same finality and edges to the same registered nodes.
+func (n *dawgNode) signature() string {
+	buf := make([]byte, 1, 1+len(n.edges)*2*binary.MaxVarintLen64)
+	if n.final {
+		buf[0] = 1
+	}
+	for _, e := range n.edges {
+		buf = binary.AppendUvarint(buf, uint64(e.r))
+		buf = binary.AppendUvarint(buf, uint64(e.to.id))
+	}
+	return string(buf)
+}
+
+// Finish returns the DAWG of the keys added so far. The DAWGBuilder can't be
+// used afterward.
+func (b *DAWGBuilder) Finish() *DAWG {
+	b.minimize(0)
+	g := &DAWG{root: b.root, size: b.size, nodes: len(b.register) + 1, t: b.t}
+	b.root, b.register = nil, nil
+	return g
+}
+
+// ReadDAWG returns a DAWG configured with the given options that has a key for
+// each line read from r, which must be in sorted order. Lines are trimmed
+// and empty lines are skipped as in ReadWords.
+func ReadDAWG(r io.Reader, opts ...Option) (_ *DAWG, err error) {
+	b := NewDAWGBuilder(opts...)
+	l, r := startRead(b.t.progress, r)
+	defer func() { l.finish(err) }()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if word := strings.TrimSpace(scanner.Text()); word != "" {
+			if err := b.Add(word); err != nil {
+				return nil, err
+			}
+			l.add(1, 0)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return b.Finish(), nil
+}
+
+// Len returns the number of keys in the DAWG.
+func (g *DAWG) Len() int {
+	return g.size
+}
+
+// child returns the node reached from n by r, or nil if there's none.
+func (n *dawgNode) child(r rune) *dawgNode {
+	i := sort.Search(len(n.edges), func(i int) bool { return n.edges[i].r >= r })
+	if i == len(n.edges) || n.edges[i].r != r {
+		return nil
+	}
+	return n.edges[i].to
+}
+
+// Has returns true exactly when key is in the DAWG.
+func (g *DAWG) Has(key string) bool {
+	key, ok := g.t.preprocess(key)
+	if !ok {
+		return false
+	}
+	n := g.root
+	for _, r := range key {
+		if n = n.child(r); n == nil {
+			return false
+		}
+	}
+	return n.final
+}
+
+// Suggest returns up to n KVs with keys within edit distance d of key. See
+// Trie.Suggest.
+func (g *DAWG) Suggest(key string, d int8, n int, opts ...SuggestOption) []KV {
+	return g.suggest(false, key, d, n, opts)
+}
+
+// SuggestSuffixes returns up to n KVs whose keys have a prefix within edit
+// distance d of key. See Trie.SuggestSuffixes.
+func (g *DAWG) SuggestSuffixes(key string, d int8, n int, opts ...SuggestOption) []KV {
+	return g.suggest(true, key, d, n, opts)
+}
+
+func (g *DAWG) suggest(expand bool, key string, d int8, limit int, opts []SuggestOption) []KV {
+	key, ok := g.t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	runes, cfg := extractRunes(key), g.t.config(key, opts)
+	d = g.t.distance(len(runes), d)
+	return g.t.collect(limit, cfg, func(visit func(*entry) bool) {
+		g.search(expand, runes, d, cfg, visit)
+	})
+}
+
+// dawgPath is a path from the root of a DAWG, stored as a linked list of runes
+// from the end of the path, so that frames can share their common prefixes.
+type dawgPath struct {
+	r    rune
+	prev *dawgPath
+}
+
+func (p *dawgPath) String() string {
+	var rs []rune
+	for ; p != nil; p = p.prev {
+		rs = append(rs, p.r)
+	}
+	for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+		rs[i], rs[j] = rs[j], rs[i]
+	}
+	return string(rs)
+}
+
+// dawgFrame is a frame of a search of a DAWG, see frame.
+type dawgFrame struct {
+	n    *dawgNode
+	s    state
+	path *dawgPath
+}
+
+// search is search and, with the Ordered option, searchOrdered for a DAWG.
+// Since many paths can lead to the same node, entries for keys are made as
+// they're found, and with Ordered they're recorded by key.
+func (g *DAWG) search(expand bool, runes []rune, d int8, cfg *searchConfig, visit func(*entry) bool) {
+	a := newAutomaton(runes, d, cfg)
+	stacks := make([][]dawgFrame, int(d)+1)
+	stacks[0] = []dawgFrame{{n: g.root, s: a.start()}}
+	var best map[string]int8
+	var entries map[string]*entry
+	var found [][]*entry
+	if cfg.ordered {
+		best, entries, found = make(map[string]int8), make(map[string]*entry), make([][]*entry, int(d)+1)
+	}
+	newEntry := func(key string) *entry {
+		if e, ok := entries[key]; ok {
+			return e
+		}
+		e := &entry{key: key, values: emptyValue}
+		if entries != nil {
+			entries[key] = e
+		}
+		return e
+	}
+	for i := range stacks {
+		for len(stacks[i]) > 0 {
+			var f dawgFrame
+			f, stacks[i] = stacks[i][len(stacks[i])-1], stacks[i][:len(stacks[i])-1]
+			if x := a.distance(f.s); x <= int(d) {
+				dist := int8(x)
+				record := func(key string) bool {
+					e := newEntry(key)
+					if !cfg.keep(e, dist) {
+						return true
+					}
+					if !cfg.ordered {
+						cfg.dist = dist
+						return visit(e)
+					}
+					if b, ok := best[key]; !ok || dist < b {
+						best[key] = dist
+						found[dist] = append(found[dist], e)
+					}
+					return true
+				}
+				if !g.process(expand, f.n, f.path, record) {
+					return
+				}
+				if expand && !cfg.ordered {
+					continue
+				}
+			}
+			for _, e := range f.n.edges {
+				if ns, min := a.transition(f.s, e.r); min <= int(d) {
+					stacks[min] = append(stacks[min], dawgFrame{n: e.to, s: ns, path: &dawgPath{r: e.r, prev: f.path}})
+				}
+			}
+		}
+		if !cfg.ordered {
+			continue
+		}
+		es := found[i][:0]
+		for _, e := range found[i] {
+			if best[e.key] == int8(i) {
+				best[e.key] = -1
+				es = append(es, e)
+			}
+		}
+		sort.Slice(es, func(a, b int) bool { return cfg.before(es[a], es[b]) })
+		cfg.dist = int8(i)
+		for _, e := range es {
+			if !visit(e) {
+				return
+			}
+		}
+	}
+}
+
+// process passes the key at n, whose path is path, and every key below n if
+// expand is true, to visit until visit returns false, and returns false if
+// visit did.
+func (g *DAWG) process(expand bool, n *dawgNode, path *dawgPath, visit func(key string) bool) bool {
+	if !expand {
+		return !n.final || visit(path.String())
+	}
+	type item struct {
+		n    *dawgNode
+		path *dawgPath
+	}
+	for stack := []item{{n, path}}; len(stack) > 0; {
+		var x item
+		x, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		if x.n.final && !visit(x.path.String()) {
+			return false
+		}
+		for i := len(x.n.edges) - 1; i >= 0; i-- {
+			e := x.n.edges[i]
+			stack = append(stack, item{e.to, &dawgPath{r: e.r, prev: x.path}})
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package levtrie
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aaw/levtrie/corpus"
+)
+
+func TestDAWG(t *testing.T) {
+	words := append([]string(nil), corpus.Words()...)
+	sort.Strings(words)
+	b := NewDAWGBuilder()
+	r := New()
+	for _, w := range words {
+		if err := b.Add(w); err != nil {
+			t.Fatal(err)
+		}
+		r.Set(w, "")
+	}
+	g := b.Finish()
+	if g.Len() != r.Len() {
+		t.Errorf("Got %v keys, want %v", g.Len(), r.Len())
+	}
+	if nodes := r.Stats().Nodes; g.nodes >= nodes {
+		t.Errorf("Got %v nodes, want fewer than the %v of a Trie", g.nodes, nodes)
+	}
+	for _, w := range words[:100] {
+		if !g.Has(w) || g.Has(w+"\x00") {
+			t.Errorf("Has(%v) is wrong", w)
+		}
+		q := w[:len(w)/2+1]
+		for _, opts := range [][]SuggestOption{nil, {Ordered()}, {ExcludeQuery()}} {
+			if got, want := keystr(g.Suggest(w, 1, 1000, opts...)), keystr(r.Suggest(w, 1, 1000, opts...)); got != want {
+				t.Errorf("Suggest(%v) = %v, want %v", w, got, want)
+			}
+			if got, want := keystr(g.SuggestSuffixes(q, 1, 1000, opts...)), keystr(r.SuggestSuffixes(q, 1, 1000, opts...)); got != want {
+				t.Errorf("SuggestSuffixes(%v) = %v, want %v", q, got, want)
+			}
+		}
+		if got, want := ukeystr(g.SuggestSuffixes(q, 1, 5, Ordered())), ukeystr(r.SuggestSuffixes(q, 1, 5, Ordered())); got != want {
+			t.Errorf("Ordered SuggestSuffixes(%v) = %v, want %v", q, got, want)
+		}
+	}
+}
+
+func TestDAWGSharesSuffixes(t *testing.T) {
+	g, err := ReadDAWG(strings.NewReader("talk\ntalked\ntalking\nwalk\nwalked\nwalking\nwalking\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The root, which leads to "alk" by "t" and "w", with "ed" and "ing"
+	// after it.
+	if g.Len() != 6 || g.nodes != 9 {
+		t.Errorf("Got %v keys and %v nodes, want 6 and 9", g.Len(), g.nodes)
+	}
+	if got, want := keystr(g.Suggest("talkng", 1, 10)), "talking"; got != want {
+		t.Errorf("Suggest = %v, want %v", got, want)
+	}
+	if _, err := ReadDAWG(strings.NewReader("b\na\n")); err == nil {
+		t.Error("Got no error for keys out of order")
+	}
+}
@@ -0,0 +1,85 @@
+package levtrie
+
+import (
+	"math"
+	"time"
+)
+
+// DecayWeights makes the count of every key, as Count and weighted searches
+// read it, halve each time halfLife passes after IncrBy last changed it, so
+// keys that are popular now outrank keys that were popular long ago. The
+// HalfLife of Maintenance is ignored with it. A halfLife of 0 or less turns
+// decay off.
+func DecayWeights(halfLife time.Duration) Option {
+	return func(t *Trie) {
+		t.decay = nil
+		if halfLife > 0 {
+			t.decay = &decay{halfLife: float64(halfLife), epoch: clock().UnixNano()}
+		}
+	}
+}
+
+// decay decays the counts of a Trie, see DecayWeights.
+type decay struct {
+	halfLife float64 // In nanoseconds.
+	// When the decay started, which counts that were never incremented
+	// decay from.
+	epoch int64
+}
+
+// weight returns the count of e decayed to now, in Unix nanoseconds, or its
+// count if d is nil.
+func (d *decay) weight(e *entry, now int64) float64 {
+	if d == nil || e.count == 0 {
+		return float64(e.count)
+	}
+	since := e.boosted
+	if since == 0 {
+		since = d.epoch
+	}
+	if now <= since {
+		return float64(e.count)
+	}
+	return float64(e.count) * math.Exp2(-float64(now-since)/d.halfLife)
+}
+
+// now returns the current time in Unix nanoseconds if d isn't nil, and 0
+// otherwise, so the clock is only read when counts decay.
+func (d *decay) now() int64 {
+	if d == nil {
+		return 0
+	}
+	return clock().UnixNano()
+}
+
+// count returns the count of e decayed to now and rounded to an integer, or
+// its count if d is nil.
+func (d *decay) count(e *entry, now int64) int64 {
+	if d == nil {
+		return e.count
+	}
+	return int64(math.Round(d.weight(e, now)))
+}
+
+// rebase replaces the count of e with its decayed count and records that it
+// was last changed now, ahead of a change to it.
+func (d *decay) rebase(e *entry) {
+	now := d.now()
+	e.count, e.boosted = d.count(e, now), now
+}
+
+// count returns the count of e as Count returns it.
+func (t *Trie) count(e *entry) int64 {
+	return t.decay.count(e, t.decay.now())
+}
+
+// weight returns the weight of e in the search, see DecayWeights.
+func (cfg *searchConfig) weight(e *entry) float64 {
+	return cfg.decay.weight(e, cfg.now)
+}
+
+// count returns the weight of e in the search rounded to an integer, as
+// Count would return it.
+func (cfg *searchConfig) count(e *entry) int64 {
+	return cfg.decay.count(e, cfg.now)
+}
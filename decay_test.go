@@ -0,0 +1,118 @@
+package levtrie
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecayWeights(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock = func() time.Time { return now }
+	defer func() { clock = time.Now }()
+	r := New(DecayWeights(time.Hour))
+	r.IncrBy("cat", 80)
+	if got := r.Count("cat"); got != 80 {
+		t.Errorf("Count(cat) = %v, want 80", got)
+	}
+	now = now.Add(time.Hour)
+	if got := r.Count("cat"); got != 40 {
+		t.Errorf("Count(cat) after a half-life = %v, want 40", got)
+	}
+	// The increment is added to the decayed count.
+	if got := r.IncrBy("cat", 10); got != 50 {
+		t.Errorf("IncrBy(cat, 10) = %v, want 50", got)
+	}
+	now = now.Add(2 * time.Hour)
+	if got := r.Count("cat"); got != 13 {
+		t.Errorf("Count(cat) after two more half-lives = %v, want 13", got)
+	}
+	if got := r.Count("dog"); got != 0 {
+		t.Errorf("Count(dog) = %v, want 0", got)
+	}
+}
+
+func TestDecayWeightsTrending(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock = func() time.Time { return now }
+	defer func() { clock = time.Now }()
+	r := New(DecayWeights(time.Hour))
+	r.IncrBy("cat", 100)
+	now = now.Add(5 * time.Hour)
+	r.IncrBy("cot", 20)
+	// cat decayed to about 3, so the trending cot comes first.
+	want := []KV{{"cot", ""}, {"cat", ""}}
+	if got := r.Suggest("cet", 1, 2, ByWeight()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Suggest with ByWeight = %v, want %v", got, want)
+	}
+	var weights []int64
+	r.Suggest("cet", 1, 3, ByWeight(), StopWhen(func(kv KV, dist int8, weight int64) bool {
+		weights = append(weights, weight)
+		return false
+	}))
+	if !reflect.DeepEqual(weights, []int64{20, 3}) {
+		t.Errorf("StopWhen saw weights %v, want [20 3]", weights)
+	}
+	// Without decay, cat is still heavier.
+	u := New()
+	u.IncrBy("cat", 100)
+	u.IncrBy("cot", 20)
+	if got := u.Suggest("cet", 1, 1, ByWeight()); got[0].Key != "cat" {
+		t.Errorf("Suggest with ByWeight and no decay = %v, want cat", got)
+	}
+}
+
+func TestDecayWeightsFrozen(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock = func() time.Time { return now }
+	defer func() { clock = time.Now }()
+	r := New(DecayWeights(time.Hour))
+	r.IncrBy("cat", 64)
+	f := r.Freeze()
+	now = now.Add(time.Hour)
+	if got := f.Count("cat"); got != 32 {
+		t.Errorf("FrozenTrie.Count(cat) = %v, want 32", got)
+	}
+	if got := f.Thaw().Count("cat"); got != 32 {
+		t.Errorf("Thaw().Count(cat) = %v, want 32", got)
+	}
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	now = now.Add(time.Hour)
+	g, err := ReadFrozenTrie(&buf, DecayWeights(time.Hour))
+	if err != nil {
+		t.Fatalf("ReadFrozenTrie: %v", err)
+	}
+	// The count was written as of the first hour and decays from the read.
+	if got := g.Count("cat"); got != 32 {
+		t.Errorf("Count(cat) after reading = %v, want 32", got)
+	}
+	now = now.Add(time.Hour)
+	if got := g.Count("cat"); got != 16 {
+		t.Errorf("Count(cat) an hour after reading = %v, want 16", got)
+	}
+}
+
+func ExampleDecayWeights() {
+	// Fix the clock so that the output doesn't depend on when it runs.
+	now := time.Unix(1000, 0)
+	clock = func() time.Time { return now }
+	defer func() { clock = time.Now }()
+	t := New(DecayWeights(time.Hour))
+	t.IncrBy("cat", 100)
+	now = now.Add(2 * time.Hour)
+	t.IncrBy("cot", 40)
+	// cat decayed to 25, so cot weighs more.
+	fmt.Println(t.Count("cat"), t.Count("cot"))
+	fmt.Println(t.Suggest("cet", 1, 2, ByWeight()))
+	// IncrBy adds to the decayed count.
+	fmt.Println(t.IncrBy("cat", 5))
+	// Output:
+	// 25 40
+	// [{cot } {cat }]
+	// 30
+}
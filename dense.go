@@ -0,0 +1,368 @@
+package levtrie
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// maxDenseRunes is the most distinct runes the keys of a DenseTrie can hold.
+const maxDenseRunes = 16
+
+// DenseTrie is a read-only copy of a Trie whose keys are spelled with an
+// alphabet of at most 16 runes, like DNA ("ACGT"), decimal digits or hex.
+// Each node keeps an array of children indexed by the position of their rune
+// in the alphabet, so finding a child is a single index instead of a map
+// lookup or a binary search. Searches also take advantage of the small
+// alphabet: the Levenshtein automaton for a query is turned into a DFA over
+// the alphabet as it's searched, so that each distinct automaton state is
+// computed once however many nodes of the DenseTrie reach it. Like a
+// FrozenTrie, a DenseTrie is safe for concurrent use by multiple goroutines
+// without any locking. Don't create directly, use Trie.Dense() or
+// FrozenTrie.Dense() instead.
+type DenseTrie struct {
+	alphabet []rune // The runes of the keys, sorted.
+	nodes    []denseNode
+	entries  []entry
+	// Holds the read options and tags of the Trie, see FrozenTrie.
+	t *Trie
+}
+
+// denseNode is a node of a DenseTrie. The root is always at index 0, so a
+// child index of 0 means there's no child.
+type denseNode struct {
+	child [maxDenseRunes]int32 // The child reached by each rune of the alphabet.
+	entry int32                // The index of the node's entry, or -1 if it has none.
+	tags  uint64               // The union of the tags of the entries below, see SetTags.
+}
+
+// Dense returns a DenseTrie holding the live keys of the Trie, along with
+// their values, counts, expiration times and tags, or an error if the keys
+// use more than 16 distinct runes. See Freeze for the options that carry over.
+func (t *Trie) Dense() (*DenseTrie, error) {
+	return t.Freeze().Dense()
+}
+
+// Dense returns a DenseTrie holding the keys of the FrozenTrie, or an error
+// if they use more than 16 distinct runes. The DenseTrie shares the entries
+// of the FrozenTrie.
+func (f *FrozenTrie) Dense() (*DenseTrie, error) {
+	seen := make(map[rune]bool)
+	for i := range f.nodes {
+		if int32(i) != f.root {
+			seen[f.nodes[i].label] = true
+		}
+	}
+	if len(seen) > maxDenseRunes {
+		return nil, fmt.Errorf("levtrie: keys use %v distinct runes, a DenseTrie holds at most %v", len(seen), maxDenseRunes)
+	}
+	g := &DenseTrie{entries: f.entries, t: f.t}
+	for r := range seen {
+		g.alphabet = append(g.alphabet, r)
+	}
+	sort.Slice(g.alphabet, func(i, j int) bool { return g.alphabet[i] < g.alphabet[j] })
+	// Lay out the nodes in breadth-first order, so the root is at index 0.
+	g.nodes = make([]denseNode, 0, len(f.nodes))
+	from := make([]int32, 0, len(f.nodes)) // The frozen node of each dense node.
+	g.nodes, from = append(g.nodes, denseNode{}), append(from, f.root)
+	for i := 0; i < len(g.nodes); i++ {
+		x := &f.nodes[from[i]]
+		g.nodes[i].entry, g.nodes[i].tags = x.entry, x.tags
+		for c := x.first; c < x.first+x.count; c++ {
+			g.nodes[i].child[g.symbol(f.nodes[c].label)] = int32(len(g.nodes))
+			g.nodes, from = append(g.nodes, denseNode{}), append(from, c)
+		}
+	}
+	return g, nil
+}
+
+// symbol returns the position of r in the alphabet of g, or -1 if it's not in
+// it.
+func (g *DenseTrie) symbol(r rune) int {
+	for i, x := range g.alphabet {
+		if x == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// Alphabet returns the distinct runes of the keys in the DenseTrie, sorted.
+func (g *DenseTrie) Alphabet() string {
+	return string(g.alphabet)
+}
+
+// Len returns the number of keys in the DenseTrie, including keys that have
+// expired since it was made.
+func (g *DenseTrie) Len() int {
+	return g.t.size
+}
+
+// descend returns the index of the node reached by following the runes of key
+// from the node at index n, or -1 if there's no such node.
+func (g *DenseTrie) descend(n int32, key string) int32 {
+	for i, w := 0, 0; i < len(key); i += w {
+		var r rune
+		r, w = utf8.DecodeRuneInString(key[i:])
+		s := g.symbol(r)
+		if s < 0 || g.nodes[n].child[s] == 0 {
+			return -1
+		}
+		n = g.nodes[n].child[s]
+	}
+	return n
+}
+
+// lookup returns the live entry for key after preprocessing it, or nil if
+// there's no such entry or the query was vetoed.
+func (g *DenseTrie) lookup(key string) *entry {
+	key, ok := g.t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	n := g.descend(0, key)
+	if n < 0 || g.nodes[n].entry < 0 {
+		return nil
+	}
+	if e := &g.entries[g.nodes[n].entry]; e.live() {
+		return e
+	}
+	return nil
+}
+
+// Get returns the value stored at key and true, or the empty string and false
+// if key isn't in the DenseTrie. See Trie.Get.
+func (g *DenseTrie) Get(key string) (string, bool) {
+	if e := g.lookup(key); e != nil {
+		return g.t.output(e.key, e.value()), true
+	}
+	return "", false
+}
+
+// Values returns all values associated with key, or nil if key isn't in the
+// DenseTrie. See Trie.Values.
+func (g *DenseTrie) Values(key string) []string {
+	if e := g.lookup(key); e != nil {
+		vals := make([]string, len(e.values))
+		for i, v := range e.values {
+			vals[i] = g.t.output(e.key, v)
+		}
+		return vals
+	}
+	return nil
+}
+
+// Has returns true exactly when key is in the DenseTrie.
+func (g *DenseTrie) Has(key string) bool {
+	return g.lookup(key) != nil
+}
+
+// Count returns the count associated with key when the DenseTrie was made, or
+// 0 if key isn't in the DenseTrie.
+func (g *DenseTrie) Count(key string) int64 {
+	if e := g.lookup(key); e != nil {
+		return e.count
+	}
+	return 0
+}
+
+// Suggest returns up to n KVs with keys within edit distance d of key. See
+// Trie.Suggest.
+func (g *DenseTrie) Suggest(key string, d int8, n int, opts ...SuggestOption) []KV {
+	return g.suggest(false, key, 0, d, n, opts)
+}
+
+// SuggestSuffixes returns up to n KVs whose keys have a prefix within edit
+// distance d of key. See Trie.SuggestSuffixes.
+func (g *DenseTrie) SuggestSuffixes(key string, d int8, n int, opts ...SuggestOption) []KV {
+	return g.suggest(true, key, 0, d, n, opts)
+}
+
+// SuggestAfterExactPrefix returns up to n KVs that share an exact prefix of
+// length p with key and are within edit distance d of it. See
+// Trie.SuggestAfterExactPrefix.
+func (g *DenseTrie) SuggestAfterExactPrefix(key string, p int, d int8, n int, opts ...SuggestOption) []KV {
+	return g.suggest(false, key, p, d, n, opts)
+}
+
+// SuggestSuffixesAfterExactPrefix returns up to n KVs whose keys have a prefix
+// within edit distance d of key and share an exact prefix of length p with
+// it. See Trie.SuggestSuffixesAfterExactPrefix.
+func (g *DenseTrie) SuggestSuffixesAfterExactPrefix(key string, p int, d int8, n int, opts ...SuggestOption) []KV {
+	return g.suggest(true, key, p, d, n, opts)
+}
+
+// suggest runs a search for key after an exact prefix of p runes, expanding
+// the suffixes of each match if expand is true.
+func (g *DenseTrie) suggest(expand bool, key string, p int, d int8, limit int, opts []SuggestOption) []KV {
+	key, ok := g.t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	runes, cfg := extractRunes(key), g.t.config(key, opts)
+	d = g.t.distance(len(runes), d)
+	root := g.descend(0, string(runes[:p]))
+	if root < 0 {
+		return nil
+	}
+	cfg.after(runes[:p])
+	return g.t.collect(limit, cfg, func(visit func(*entry) bool) {
+		g.search(expand, root, runes[p:], d, cfg, visit)
+	})
+}
+
+// denseFrame is a frame of a search of a DenseTrie: a node and the state of
+// the DFA that reached it.
+type denseFrame struct {
+	n int32
+	s int32
+}
+
+// search is FrozenTrie.search for a DenseTrie.
+func (g *DenseTrie) search(expand bool, root int32, runes []rune, d int8, cfg *searchConfig, visit func(*entry) bool) {
+	a := newDenseDFA(newAutomaton(runes, d, cfg), g.alphabet)
+	stacks := make([][]denseFrame, int(d)+1)
+	stacks[0] = []denseFrame{{n: root, s: 0}}
+	var best map[*entry]int8
+	var found [][]*entry
+	if cfg.ordered {
+		best, found = make(map[*entry]int8), make([][]*entry, int(d)+1)
+	}
+	for i := range stacks {
+		for len(stacks[i]) > 0 {
+			var fr denseFrame
+			fr, stacks[i] = stacks[i][len(stacks[i])-1], stacks[i][:len(stacks[i])-1]
+			if x := a.dists[fr.s]; x <= int(d) {
+				dist := int8(x)
+				record := func(e *entry) bool {
+					if !cfg.keep(e, dist) {
+						return true
+					}
+					if !cfg.ordered {
+						cfg.dist = dist
+						return visit(e)
+					}
+					if b, ok := best[e]; !ok || dist < b {
+						best[e] = dist
+						found[dist] = append(found[dist], e)
+					}
+					return true
+				}
+				if !g.process(expand, fr.n, record) {
+					return
+				}
+				if expand && !cfg.ordered {
+					continue
+				}
+			}
+			for sym, c := range g.nodes[fr.n].child[:len(g.alphabet)] {
+				if c == 0 || cfg.prunedTags(g.nodes[c].tags) {
+					continue
+				}
+				if ns, min := a.transition(fr.s, sym); min <= int(d) {
+					stacks[min] = append(stacks[min], denseFrame{n: c, s: ns})
+				}
+			}
+		}
+		if !cfg.ordered {
+			continue
+		}
+		es := found[i][:0]
+		for _, e := range found[i] {
+			if best[e] == int8(i) {
+				best[e] = -1
+				es = append(es, e)
+			}
+		}
+		sort.Slice(es, func(a, b int) bool { return cfg.before(es[a], es[b]) })
+		cfg.dist = int8(i)
+		for _, e := range es {
+			if !visit(e) {
+				return
+			}
+		}
+	}
+}
+
+// process passes the live entry of the node at index n, and every live entry
+// below it if expand is true, to visit until visit returns false, and returns
+// false if visit did.
+func (g *DenseTrie) process(expand bool, n int32, visit func(*entry) bool) bool {
+	for stack := []int32{n}; len(stack) > 0; {
+		var c int32
+		c, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		x := &g.nodes[c]
+		if x.entry >= 0 && g.entries[x.entry].live() && !visit(&g.entries[x.entry]) {
+			return false
+		}
+		if !expand {
+			return true
+		}
+		for i := len(g.alphabet) - 1; i >= 0; i-- {
+			if x.child[i] != 0 {
+				stack = append(stack, x.child[i])
+			}
+		}
+	}
+	return true
+}
+
+// denseDFA is an automaton determinized lazily over the alphabet of a
+// DenseTrie. Each distinct state of the automaton gets a number, and the
+// transitions from it are computed the first time they're taken, so nodes
+// that reach the same state share the work of every transition below them.
+// State 0 is the start state.
+type denseDFA struct {
+	a        automaton
+	alphabet []rune
+	states   []state
+	dists    []int                  // The distance of each state, see automaton.
+	next     [][maxDenseRunes]int32 // The state after each rune, plus 1, or 0 if unknown.
+	mins     [][maxDenseRunes]int32 // The minimum distance after each rune.
+	ids      map[string]int32       // The number of each state, by stateKey.
+	buf      []byte                 // Scratch space for stateKey.
+}
+
+func newDenseDFA(a automaton, alphabet []rune) *denseDFA {
+	m := &denseDFA{a: a, alphabet: alphabet, ids: make(map[string]int32)}
+	m.add(a.start())
+	return m
+}
+
+// stateKey encodes s in m.buf, so that equal states have equal keys.
+func (m *denseDFA) stateKey(s state) []byte {
+	m.buf = binary.AppendVarint(m.buf[:0], int64(s.offset))
+	m.buf = binary.AppendVarint(m.buf, int64(s.ext))
+	for _, x := range s.arr {
+		m.buf = binary.AppendVarint(m.buf, int64(x))
+	}
+	return m.buf
+}
+
+// add returns the number of s, numbering it if it's new.
+func (m *denseDFA) add(s state) int32 {
+	key := m.stateKey(s)
+	if id, ok := m.ids[string(key)]; ok {
+		return id
+	}
+	id := int32(len(m.states))
+	m.ids[string(key)] = id
+	m.states = append(m.states, s)
+	m.dists = append(m.dists, m.a.distance(s))
+	m.next = append(m.next, [maxDenseRunes]int32{})
+	m.mins = append(m.mins, [maxDenseRunes]int32{})
+	return id
+}
+
+// transition returns the state reached from state id by the rune at position
+// sym of the alphabet, along with its minimum distance.
+func (m *denseDFA) transition(id int32, sym int) (int32, int) {
+	if next := m.next[id][sym]; next != 0 {
+		return next - 1, int(m.mins[id][sym])
+	}
+	ns, min := m.a.transition(m.states[id], m.alphabet[sym])
+	next := m.add(ns)
+	m.next[id][sym], m.mins[id][sym] = next+1, int32(min)
+	return next, min
+}
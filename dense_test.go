@@ -0,0 +1,84 @@
+package levtrie
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// dnaKeys returns n random keys over the alphabet ACGT, up to length long.
+func dnaKeys(n int, length int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		b := make([]byte, 1+rand.Intn(length))
+		for j := range b {
+			b[j] = "ACGT"[rand.Intn(4)]
+		}
+		keys[i] = string(b)
+	}
+	return keys
+}
+
+func TestDenseMatchesTrie(t *testing.T) {
+	rand.Seed(0)
+	keys := dnaKeys(500, 12)
+	r := New()
+	for i, key := range keys {
+		r.Set(key, strings.ToLower(key))
+		r.IncrBy(key, int64(i%7))
+		if i%3 == 0 {
+			r.SetTags(key, "third")
+		}
+	}
+	g, err := r.Dense()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Len() != r.Len() || g.Alphabet() != "ACGT" || len(g.nodes) != r.Stats().Nodes {
+		t.Errorf("Got %v keys, alphabet %q and %v nodes, want %v, \"ACGT\" and %v", g.Len(), g.Alphabet(), len(g.nodes), r.Len(), r.Stats().Nodes)
+	}
+	for _, key := range append(keys[:50], "ACGTX", "") {
+		want, wantOK := r.Get(key)
+		if got, ok := g.Get(key); got != want || ok != wantOK || g.Count(key) != r.Count(key) || g.Has(key) != r.Has(key) {
+			t.Errorf("Got %v, %v, %v for %q, want %v, %v, %v", got, ok, g.Count(key), key, want, wantOK, r.Count(key))
+		}
+	}
+	optss := [][]SuggestOption{nil, {Ordered()}, {ByWeight()}, {WithTags("third")}, {EditCosts(1, 2, 1)}, {AffixTolerance(1, 1, 1)}}
+	for _, key := range append(dnaKeys(30, 12), "ACGUT", "NNNN") {
+		for _, opts := range optss {
+			for d := int8(0); d <= 3; d++ {
+				if got, want := keystr(g.Suggest(key, d, 1000, opts...)), keystr(r.Suggest(key, d, 1000, opts...)); got != want {
+					t.Errorf("Suggest(%v, %v) = %v, want %v", key, d, got, want)
+				}
+			}
+			if got, want := keystr(g.SuggestSuffixes(key, 1, 1000, opts...)), keystr(r.SuggestSuffixes(key, 1, 1000, opts...)); got != want {
+				t.Errorf("SuggestSuffixes(%v) = %v, want %v", key, got, want)
+			}
+			if got, want := keystr(g.SuggestAfterExactPrefix(key, 1, 2, 1000, opts...)), keystr(r.SuggestAfterExactPrefix(key, 1, 2, 1000, opts...)); got != want {
+				t.Errorf("SuggestAfterExactPrefix(%v) = %v, want %v", key, got, want)
+			}
+			if got, want := keystr(g.SuggestSuffixesAfterExactPrefix(key, 1, 1, 1000, opts...)), keystr(r.SuggestSuffixesAfterExactPrefix(key, 1, 1, 1000, opts...)); got != want {
+				t.Errorf("SuggestSuffixesAfterExactPrefix(%v) = %v, want %v", key, got, want)
+			}
+		}
+		if got, want := ukeystr(g.Suggest(key, 2, 10, ByWeight())), ukeystr(r.Suggest(key, 2, 10, ByWeight())); got != want {
+			t.Errorf("ByWeight Suggest(%v) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestDenseAlphabetTooLarge(t *testing.T) {
+	r := New()
+	r.Set("0123456789abcdef", "hex")
+	if _, err := r.Dense(); err != nil {
+		t.Errorf("Got %v for 16 runes, want no error", err)
+	}
+	r.Set("g", "not hex")
+	if _, err := r.Dense(); err == nil {
+		t.Error("Got no error for 17 runes")
+	}
+	var empty Trie
+	if g, err := empty.Dense(); err != nil || g.Len() != 0 || len(g.Suggest("A", 1, 10)) != 0 {
+		t.Errorf("Got %v for an empty Trie, want an empty DenseTrie", err)
+	}
+}
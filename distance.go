@@ -0,0 +1,94 @@
+package levtrie
+
+// Distance returns the Levenshtein distance between a and b: the minimum
+// number of single-rune insertions, deletions and substitutions needed to
+// turn a into b. This is the edit distance bounded by d in the Suggest
+// methods when no SuggestOptions are passed.
+func Distance(a, b string) int {
+	ra, rb := extractRunes(a), extractRunes(b)
+	// row[j] holds the distance between the runes of a read so far and the
+	// first j runes of b.
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		diag := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			next := diag
+			if ra[i-1] != rb[j-1] {
+				next = 1 + minInt(diag, minInt(row[j], row[j-1]))
+			}
+			diag, row[j] = row[j], next
+		}
+	}
+	return row[len(rb)]
+}
+
+// DamerauDistance returns the restricted Damerau-Levenshtein distance between
+// a and b, also called the optimal string alignment distance: the minimum
+// number of single-rune insertions, deletions and substitutions and swaps of
+// two adjacent runes needed to turn a into b, where no rune is edited again
+// after it's swapped. This is the edit distance bounded by d in the Suggest
+// methods with Transpositions.
+func DamerauDistance(a, b string) int {
+	ra, rb := extractRunes(a), extractRunes(b)
+	// prev, row and next hold the distances between the first i-1, i and
+	// i+1 runes of a and the first j runes of b.
+	prev, row, next := make([]int, len(rb)+1), make([]int, len(rb)+1), make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		next[0] = i
+		for j := 1; j <= len(rb); j++ {
+			next[j] = row[j-1]
+			if ra[i-1] != rb[j-1] {
+				next[j] = 1 + minInt(row[j-1], minInt(row[j], next[j-1]))
+				if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+					next[j] = minInt(next[j], prev[j-2]+1)
+				}
+			}
+		}
+		prev, row, next = row, next, prev
+	}
+	return row[len(rb)]
+}
+
+// PrefixDistance returns the smallest Levenshtein distance between a and a
+// prefix of b. This is the edit distance bounded by d in the SuggestSuffixes
+// methods when no SuggestOptions are passed.
+func PrefixDistance(a, b string) int {
+	ra, rb := extractRunes(a), extractRunes(b)
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		diag := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			next := diag
+			if ra[i-1] != rb[j-1] {
+				next = 1 + minInt(diag, minInt(row[j], row[j-1]))
+			}
+			diag, row[j] = row[j], next
+		}
+	}
+	best := row[0]
+	for _, x := range row {
+		best = minInt(best, x)
+	}
+	return best
+}
+
+// Within returns true exactly when key would be returned by a search for
+// query within edit distance d using the given SuggestOptions, for example by
+// Suggest(query, d, n, opts...) on a Trie containing key, regardless of n.
+// It's useful for checking candidates found by other means, like the
+// implementations of Index in other packages. To check many keys against the
+// same query, Compile an Automaton once instead.
+func Within(query, key string, d int8, opts ...SuggestOption) bool {
+	return Compile(query, d, opts...).Matches(key)
+}
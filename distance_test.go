@@ -0,0 +1,181 @@
+package levtrie
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"ἑйლ", "ἑლ", 1},
+		{"héllo", "hello", 1},
+	}
+	for _, test := range tests {
+		if got := Distance(test.a, test.b); got != test.want {
+			t.Errorf("Distance(%q, %q) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestDistanceFuzz(t *testing.T) {
+	alphabet := []rune{'a', 'b', 'ô', 'й'}
+	randWord := func() string {
+		rs := make([]rune, rand.Intn(7))
+		for i := range rs {
+			rs[i] = alphabet[rand.Intn(len(alphabet))]
+		}
+		return string(rs)
+	}
+	for i := 0; i < 1000; i++ {
+		a, b := randWord(), randWord()
+		if got, want := Distance(a, b), int(editDistance(a, b)); got != want {
+			t.Fatalf("Distance(%q, %q) = %v, want %v", a, b, got, want)
+		}
+	}
+}
+
+func TestDamerauDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"hte", "the", 1},
+		{"abcd", "acbd", 1},
+		{"kitten", "sitting", 3},
+		{"ca", "abc", 3},
+		{"ἑйლ", "йἑლ", 1},
+	}
+	for _, test := range tests {
+		if got := DamerauDistance(test.a, test.b); got != test.want {
+			t.Errorf("DamerauDistance(%q, %q) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestWithinTranspositionsFuzz(t *testing.T) {
+	haystack := generateEdits(5, 200)
+	for _, query := range haystack[:20] {
+		for _, key := range haystack {
+			for d := int8(0); d < 4; d++ {
+				want := DamerauDistance(query, key) <= int(d)
+				if got := Within(query, key, d, Transpositions(true)); got != want {
+					t.Fatalf("Within(%q, %q, %v, Transpositions(true)) = %v, want %v", query, key, d, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestPrefixDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "abc", 0},
+		{"abc", "", 3},
+		{"helo", "helicopter", 1},
+		{"toads", "toadstool", 0},
+		{"toads", "toast", 1},
+		{"xyz", "abc", 3},
+	}
+	for _, test := range tests {
+		if got := PrefixDistance(test.a, test.b); got != test.want {
+			t.Errorf("PrefixDistance(%q, %q) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestWithin(t *testing.T) {
+	tests := []struct {
+		query, key string
+		d          int8
+		opts       []SuggestOption
+		want       bool
+	}{
+		{"kitten", "sitting", 3, nil, true},
+		{"kitten", "sitting", 2, nil, false},
+		{"intern", "int", 3, []SuggestOption{DeletionsOnly()}, true},
+		{"int", "intern", 3, []SuggestOption{DeletionsOnly()}, false},
+		{"color", "colour", 1, []SuggestOption{EditCosts(1, 3, 2)}, true},
+		{"color", "colr", 2, []SuggestOption{EditCosts(1, 3, 2)}, false},
+		{"walk", "walking", 0, []SuggestOption{AffixTolerance(0, 3, 0)}, true},
+		{"abc", "abc", -1, nil, false},
+		{"hte", "the", 1, nil, false},
+		{"hte", "the", 1, []SuggestOption{Transpositions(true)}, true},
+		{"hte", "the", 1, []SuggestOption{Transpositions(true), Transpositions(false)}, false},
+		{"xab", "ba", 2, []SuggestOption{Transpositions(true)}, true},
+	}
+	for _, test := range tests {
+		if got := Within(test.query, test.key, test.d, test.opts...); got != test.want {
+			t.Errorf("Within(%q, %q, %v) = %v, want %v", test.query, test.key, test.d, got, test.want)
+		}
+	}
+}
+
+func TestWithinFuzz(t *testing.T) {
+	haystack := generateEdits(5, 200)
+	for _, query := range haystack[:20] {
+		for _, key := range haystack {
+			for d := int8(0); d < 4; d++ {
+				if got, want := Within(query, key, d), Distance(query, key) <= int(d); got != want {
+					t.Fatalf("Within(%q, %q, %v) = %v, want %v", query, key, d, got, want)
+				}
+			}
+		}
+	}
+}
+
+// longKey returns a random key of n runes.
+func longKey(n int) string {
+	alphabet := []rune{'A', 'ἑ', 'й', 'ლ', 'ô', 'Z', '1'}
+	rs := make([]rune, n)
+	for i := range rs {
+		rs[i] = alphabet[rand.Intn(len(alphabet))]
+	}
+	return string(rs)
+}
+
+func TestLongKeys(t *testing.T) {
+	rand.Seed(0)
+	query := longKey(300)
+	rs := []rune(query)
+	keys := []string{query, string(rs[:297]), query + "Z", "Z" + query, longKey(300), longKey(200), "A"}
+	for _, i := range []int{0, 150, 299} {
+		rs2 := append([]rune{}, rs...)
+		rs2[i] = 'x'
+		keys = append(keys, string(rs2))
+	}
+	r := New()
+	for _, k := range keys {
+		r.Set(k, k)
+	}
+	for _, d := range []int8{0, 1, 2, 31, 64, 127} {
+		var want []string
+		for _, k := range keys {
+			if Distance(query, k) <= int(d) {
+				want = append(want, k)
+			}
+			if got, want := Within(query, k, d), Distance(query, k) <= int(d); got != want {
+				t.Errorf("d=%v: Within = %v, want %v for a key of %v runes", d, got, want, len([]rune(k)))
+			}
+		}
+		sort.Strings(want)
+		if got := keystr(r.Suggest(query, d, len(keys))); got != strings.Join(want, " ") {
+			t.Errorf("d=%v: Suggest got %v keys, want %v", d, len(strings.Fields(got)), len(want))
+		}
+		if got := keystr(r.Suggest(query, d, len(keys), EditCosts(1, 1, 1))); got != strings.Join(want, " ") {
+			t.Errorf("d=%v: weighted Suggest got %v keys, want %v", d, len(strings.Fields(got)), len(want))
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package levtrie
+
+// SuggestFunc calls fn with each KV that Suggest would return for key and d,
+// along with the edit distance of its key, until fn returns false or there
+// are no more matches. Unlike Suggest, it doesn't build a slice of results,
+// so there's no n: fn decides when to stop, and a caller that only needs to
+// look at each match, like a server writing them to a response, allocates
+// nothing for them. As with Suggest, the order of the KVs is unspecified
+// unless the Ordered option is used. fn must not modify the Trie.
+func (t *Trie) SuggestFunc(key string, d int8, fn func(kv KV, dist int8) bool, opts ...SuggestOption) {
+	key, ok := t.preprocess(key)
+	if !ok {
+		return
+	}
+	runes, cfg := extractRunes(key), t.config(key, opts)
+	cfg.withDist = true
+	d = t.distance(len(runes), d)
+	find := search
+	if cfg.ordered {
+		find = searchOrdered
+	}
+	t.each(cfg, func(visit func(*entry) bool) {
+		find(doNotExpandSuffixes, t.tree(), runes, d, cfg, visit)
+	}, fn)
+}
+
+// each is collect without a limit: it passes a KV for each value of the
+// entries that find passes to visit, along with the distance in cfg.dist, to
+// fn until fn returns false, applying the options in cfg that apply to the
+// results of a search.
+func (t *Trie) each(cfg *searchConfig, find func(visit func(*entry) bool), fn func(KV, int8) bool) {
+	eachKV := func(e *entry, dist int8) bool {
+		if len(e.values) == 0 {
+			return fn(KV{Key: e.key, Value: t.output(e.key, "")}, dist)
+		}
+		for i, v := range e.values {
+			if cfg.valuesPerKey > 0 && i >= cfg.valuesPerKey {
+				break
+			}
+			if !fn(KV{Key: e.key, Value: t.output(e.key, v)}, dist) {
+				return false
+			}
+		}
+		return true
+	}
+	var found []*entry        // Every match, when they have to be chosen from.
+	var dists map[*entry]int8 // The distance of each match in found.
+	if cfg.collectsAll() {
+		dists = make(map[*entry]int8)
+	}
+	find(func(e *entry) bool {
+		if cfg.excluded(e) {
+			return true
+		}
+		if cfg.collectsAll() {
+			found = append(found, e)
+			dists[e] = cfg.dist
+			return !cfg.stops(e)
+		}
+		return eachKV(e, cfg.dist) && !cfg.stops(e)
+	})
+	if !cfg.collectsAll() {
+		return
+	}
+	for _, e := range cfg.choose(found) {
+		if !eachKV(e, dists[e]) {
+			return
+		}
+	}
+}
+
+// SuggestFunc calls fn with each KV within edit distance d of key. See
+// Trie.SuggestFunc.
+func (s *SyncTrie) SuggestFunc(key string, d int8, fn func(kv KV, dist int8) bool, opts ...SuggestOption) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.t.SuggestFunc(key, d, fn, opts...)
+}
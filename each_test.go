@@ -0,0 +1,37 @@
+package levtrie
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSuggestFunc(t *testing.T) {
+	rand.Seed(0)
+	r := New()
+	for _, key := range generateEdits(5, 500) {
+		r.Set(key, key)
+	}
+	for _, query := range generateEdits(5, 20) {
+		for _, opts := range [][]SuggestOption{nil, {Ordered()}, {AffixTolerance(0, 2, 1)}} {
+			var got []KV
+			r.SuggestFunc(query, 2, func(kv KV, dist int8) bool {
+				got = append(got, kv)
+				if opts == nil && int(dist) != Distance(query, kv.Key) {
+					t.Errorf("Got distance %v for %v, want %v", dist, kv.Key, Distance(query, kv.Key))
+				}
+				return true
+			}, opts...)
+			if want := r.Suggest(query, 2, 1000, opts...); keystr(got) != keystr(want) {
+				t.Errorf("SuggestFunc(%v) = %v, want %v", query, keystr(got), keystr(want))
+			}
+		}
+		var got []KV
+		r.SuggestFunc(query, 3, func(kv KV, dist int8) bool {
+			got = append(got, kv)
+			return len(got) < 3
+		}, Ordered())
+		if want := r.Suggest(query, 3, 3, Ordered()); ukeystr(got) != ukeystr(want) {
+			t.Errorf("Stopped SuggestFunc(%v) = %v, want %v", query, ukeystr(got), ukeystr(want))
+		}
+	}
+}
@@ -0,0 +1,259 @@
+// Package escompletion converts between a levtrie.Trie and the documents of
+// an Elasticsearch index with a completion field, so that the suggestions
+// curated for the Elasticsearch completion suggester can be loaded into a
+// Trie, and a Trie's keys can be indexed into Elasticsearch.
+//
+// A completion field holds one or more inputs, the strings suggestions are
+// found by, and a weight that ranks them. Each input becomes a key of the
+// Trie, the weight becomes its count (see levtrie.Trie.IncrBy), and the rest
+// of the document, its payload, becomes its value as JSON. For example, the
+// document
+//
+//	{"suggest": {"input": ["Nevermind", "Nirvana"], "weight": 34}, "year": 1991}
+//
+// adds the keys "Nevermind" and "Nirvana" with the value {"year":1991} and a
+// count of 34, and exporting them writes the same document back.
+package escompletion
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aaw/levtrie"
+)
+
+// Options configures Import and Export.
+type Options struct {
+	// Field is the name of the completion field. If it's empty, "suggest"
+	// is used.
+	Field string
+	// Index, if not empty, makes Export write an action line like
+	// {"index":{"_index":"songs"}} before each document, so that its
+	// output can be sent to the Elasticsearch bulk API as it is.
+	Index string
+}
+
+func (o Options) field() string {
+	if o.Field == "" {
+		return "suggest"
+	}
+	return o.Field
+}
+
+// completion is the value of a completion field in its object form. Payload
+// is a field of the completion suggester before Elasticsearch 5; if it's
+// there, it's used as the value of each input instead of the rest of the
+// document.
+type completion struct {
+	Input   inputs          `json:"input"`
+	Weight  int64           `json:"weight,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// inputs is the input of a completion, which is either a string or an array
+// of strings.
+type inputs []string
+
+func (in *inputs) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*in = inputs{s}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(in))
+}
+
+// completions parses a completion field, which is either a string, an array
+// of strings, a completion object or an array of completion objects.
+func completions(raw json.RawMessage) ([]completion, error) {
+	var in inputs
+	if err := json.Unmarshal(raw, &in); err == nil {
+		return []completion{{Input: in}}, nil
+	}
+	var c completion
+	if err := json.Unmarshal(raw, &c); err == nil {
+		return []completion{c}, nil
+	}
+	var cs []completion
+	if err := json.Unmarshal(raw, &cs); err != nil {
+		return nil, errors.New("completion field isn't a string, an array or an object with an input")
+	}
+	return cs, nil
+}
+
+// bulkActions are the actions of the lines of a bulk request that precede a
+// document, or stand alone for delete.
+var bulkActions = map[string]bool{"index": true, "create": true, "update": true, "delete": true}
+
+// Import reads documents from r and adds their completions to t, returning
+// the number of documents that had a completion field. r holds a sequence of
+// JSON documents, like a file with one document per line. A document can
+// also be a search hit, whose _source is used, or a JSON array of documents,
+// and the action lines of a bulk request are skipped, so the output of a
+// scroll or a bulk export can be imported without changes. Documents
+// without a completion field are skipped too.
+//
+// Each input passes through t.Analyze before it's added, so it's normalized
+// the way t normalizes queries, and inputs that t vetoes are skipped. The
+// value of each input is the document without its completion field, as a
+// JSON object, or the empty string if there's nothing else in it. If the only
+// other field is a string named "payload", the string is the value. An input
+// that appears in several documents gets each of their values, and the
+// largest of their weights as its count.
+func Import(t *levtrie.Trie, r io.Reader, opts Options) (int, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	imported := 0
+	for i := 1; ; i++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == io.EOF {
+			return imported, nil
+		} else if err != nil {
+			return imported, fmt.Errorf("escompletion: document %v: %v", i, err)
+		}
+		n, err := importValue(t, raw, opts.field())
+		imported += n
+		if err != nil {
+			return imported, fmt.Errorf("escompletion: document %v: %v", i, err)
+		}
+	}
+}
+
+// importValue imports the documents in raw, which is a document, a search
+// hit, a bulk action or an array of them.
+func importValue(t *levtrie.Trie, raw json.RawMessage, field string) (int, error) {
+	if raw = bytes.TrimSpace(raw); len(raw) > 0 && raw[0] == '[' {
+		var docs []json.RawMessage
+		if err := json.Unmarshal(raw, &docs); err != nil {
+			return 0, err
+		}
+		imported := 0
+		for _, doc := range docs {
+			n, err := importValue(t, doc, field)
+			imported += n
+			if err != nil {
+				return imported, err
+			}
+		}
+		return imported, nil
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return 0, err
+	}
+	if source, ok := doc["_source"]; ok {
+		doc = nil
+		if err := json.Unmarshal(source, &doc); err != nil {
+			return 0, err
+		}
+	} else if len(doc) == 1 {
+		for action, v := range doc {
+			if bytes.HasPrefix(bytes.TrimSpace(v), []byte("{")) && bulkActions[action] {
+				return 0, nil
+			}
+		}
+	}
+	value, ok := doc[field]
+	if !ok {
+		return 0, nil
+	}
+	cs, err := completions(value)
+	if err != nil {
+		return 0, err
+	}
+	delete(doc, field)
+	payload := ""
+	if s, ok := plainPayload(doc); ok {
+		payload = s
+	} else if len(doc) > 0 {
+		b, err := json.Marshal(doc)
+		if err != nil {
+			return 0, err
+		}
+		payload = string(b)
+	}
+	for _, c := range cs {
+		if c.Weight < 0 {
+			return 0, fmt.Errorf("negative weight %v", c.Weight)
+		}
+		value := payload
+		if len(c.Payload) > 0 {
+			value = string(c.Payload)
+		}
+		for _, input := range c.Input {
+			key, ok := t.Analyze(input)
+			if !ok {
+				continue
+			}
+			t.Add(key, value)
+			if count := t.Count(key); c.Weight > count {
+				t.IncrBy(key, c.Weight-count)
+			}
+		}
+	}
+	return 1, nil
+}
+
+// plainPayload returns the value of the payload field of doc and true if
+// that's the only field of doc and its value is a string, the way Export
+// writes values that aren't JSON objects.
+func plainPayload(doc map[string]json.RawMessage) (string, bool) {
+	var s string
+	if raw, ok := doc["payload"]; !ok || len(doc) != 1 || json.Unmarshal(raw, &s) != nil {
+		return "", false
+	}
+	return s, true
+}
+
+// Export writes a document for the keys of t to w, one per line, in the
+// format Import reads. Keys with the same value and count share a document,
+// whose completion field lists them as its inputs in sorted order and has
+// their count as its weight. A value that's a JSON object is used as the rest
+// of the document; any other value is kept in a field named "payload". A key
+// with more than one value is an input of a document for each.
+func Export(w io.Writer, t *levtrie.Trie, opts Options) error {
+	type group struct {
+		value  string
+		weight int64
+	}
+	var order []group
+	keys := make(map[group][]string)
+	it := t.Iterator()
+	for ok := it.Valid(); ok; ok = it.Next() {
+		for _, v := range it.Values() {
+			g := group{value: v, weight: t.Count(it.Key())}
+			if _, ok := keys[g]; !ok {
+				order = append(order, g)
+			}
+			keys[g] = append(keys[g], it.Key())
+		}
+	}
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	for _, g := range order {
+		doc := make(map[string]interface{})
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(g.value), &fields); err == nil && fields != nil {
+			for k, v := range fields {
+				doc[k] = v
+			}
+		} else if g.value != "" {
+			doc["payload"] = g.value
+		}
+		doc[opts.field()] = completion{Input: keys[g], Weight: g.weight}
+		if opts.Index != "" {
+			action := map[string]map[string]string{"index": {"_index": opts.Index}}
+			if err := enc.Encode(action); err != nil {
+				return err
+			}
+		}
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
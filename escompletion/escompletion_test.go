@@ -0,0 +1,101 @@
+package escompletion
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aaw/levtrie"
+)
+
+func TestImport(t *testing.T) {
+	docs := `{"index":{"_index":"songs","_id":"1"}}
+{"suggest": {"input": ["Nevermind", "Nirvana"], "weight": 34}, "year": 1991}
+{"suggest": "Bleach"}
+{"_id": "3", "_source": {"suggest": [{"input": "In Utero", "weight": 20}, {"input": ["Utero"], "weight": 2}]}}
+[{"suggest": ["Incesticide", "Nirvana"]}, {"title": "no completion"}]
+{"suggest": {"input": "Unplugged", "payload": {"id": 7}, "weight": 5}}
+{"delete":{"_index":"songs","_id":"9"}}
+`
+	r := levtrie.New()
+	n, err := Import(r, strings.NewReader(docs), Options{})
+	if err != nil || n != 5 {
+		t.Fatalf("Import() = %v, %v, want 5 documents", n, err)
+	}
+	for _, test := range []struct {
+		key    string
+		values []string
+		count  int64
+	}{
+		{"Nevermind", []string{`{"year":1991}`}, 34},
+		{"Nirvana", []string{`{"year":1991}`, ""}, 34},
+		{"Bleach", []string{""}, 0},
+		{"In Utero", []string{""}, 20},
+		{"Utero", []string{""}, 2},
+		{"Incesticide", []string{""}, 0},
+		{"Unplugged", []string{`{"id": 7}`}, 5},
+	} {
+		if got := r.Values(test.key); strings.Join(got, ",") != strings.Join(test.values, ",") || r.Count(test.key) != test.count {
+			t.Errorf("Got %q with count %v for %v, want %q with count %v", got, r.Count(test.key), test.key, test.values, test.count)
+		}
+	}
+	if r.Len() != 7 {
+		t.Errorf("Got %v keys, want 7", r.Len())
+	}
+
+	for _, bad := range []string{`{"suggest": 7}`, `{"suggest": {"input": "a", "weight": -1}}`, `{"suggest": "a"`, `[1]`} {
+		if _, err := Import(levtrie.New(), strings.NewReader(bad), Options{}); err == nil {
+			t.Errorf("Import(%v) succeeded", bad)
+		}
+	}
+}
+
+func TestImportAnalyzes(t *testing.T) {
+	r := levtrie.New(levtrie.UseAnalyzer("standard"), levtrie.PreprocessQueries(levtrie.RejectEmptyQuery))
+	docs := `{"name": {"input": ["  Nirvana ", " ", "NIRVANA"]}}`
+	if _, err := Import(r, strings.NewReader(docs), Options{Field: "name"}); err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() != 1 || !r.Has("nirvana") {
+		t.Errorf("Got %v keys, want nirvana", r.Len())
+	}
+}
+
+func TestExport(t *testing.T) {
+	docs := `{"suggest":{"input":["Nevermind","Nirvana"],"weight":34},"year":1991}
+{"suggest":{"input":["Bleach"]}}
+{"payload":"plain","suggest":{"input":["In Utero"],"weight":20}}
+`
+	r := levtrie.New()
+	if _, err := Import(r, strings.NewReader(docs), Options{}); err != nil {
+		t.Fatal(err)
+	}
+	r.Set("In Utero", "plain")
+	var b bytes.Buffer
+	if err := Export(&b, r, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"suggest":{"input":["Bleach"]}}
+{"payload":"plain","suggest":{"input":["In Utero"],"weight":20}}
+{"suggest":{"input":["Nevermind","Nirvana"],"weight":34},"year":1991}
+`
+	if b.String() != want {
+		t.Errorf("Export() wrote\n%v\nwant\n%v", b.String(), want)
+	}
+	b.Reset()
+	if err := Export(&b, r, Options{Field: "name", Index: "songs"}); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(b.String(), "\n"); len(lines) != 7 || lines[0] != `{"index":{"_index":"songs"}}` || !strings.Contains(lines[1], `"name":`) {
+		t.Errorf("Export() with an index wrote\n%v", b.String())
+	}
+	s := levtrie.New()
+	if _, err := Import(s, &b, Options{Field: "name"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"Bleach", "In Utero", "Nevermind", "Nirvana"} {
+		if got, want := strings.Join(s.Values(key), ","), strings.Join(r.Values(key), ","); got != want || s.Count(key) != r.Count(key) {
+			t.Errorf("Got %v with count %v for %v after a round trip, want %v with count %v", got, s.Count(key), key, want, r.Count(key))
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package levtrie_test
+
+import (
+	"fmt"
+
+	"github.com/aaw/levtrie"
+)
+
+// keys returns the keys of kvs in order.
+func keys(kvs []levtrie.KV) []string {
+	var ks []string
+	for _, kv := range kvs {
+		ks = append(ks, kv.Key)
+	}
+	return ks
+}
+
+func ExampleOrdered() {
+	t := levtrie.New()
+	for _, key := range []string{"cot", "dog", "cart", "bat", "cat", "at"} {
+		t.Set(key, "")
+	}
+	// The exact match comes first, then the keys at distance 1 by key.
+	fmt.Println(keys(t.Suggest("cat", 1, 10, levtrie.Ordered())))
+	// Output: [cat at bat cart cot]
+}
+
+func ExampleBucketCap() {
+	t := levtrie.New()
+	for _, key := range []string{"cat", "bat", "hat", "mat", "cart", "cast", "coat", "cs"} {
+		t.Set(key, "")
+	}
+	// At most 2 keys are taken at distance 1, which leaves room for "cs" at
+	// distance 2.
+	fmt.Println(keys(t.Suggest("cat", 2, 4, levtrie.BucketCap(2))))
+	// Output: [cat bat cart cs]
+}
+
+func ExampleTranspositions() {
+	t := levtrie.New()
+	for _, key := range []string{"the", "then", "hate"} {
+		t.Set(key, "")
+	}
+	// Swapping "ht" costs 2 edits without Transpositions and 1 with it.
+	fmt.Println(keys(t.Suggest("hte", 1, 10, levtrie.Ordered())))
+	fmt.Println(keys(t.Suggest("hte", 1, 10, levtrie.Ordered(), levtrie.Transpositions(true))))
+	// Output:
+	// [hate]
+	// [hate the]
+}
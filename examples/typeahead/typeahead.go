@@ -2,170 +2,64 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/aaw/levtrie"
+	"github.com/aaw/levtrie/corpus"
+	"github.com/aaw/levtrie/httpsuggest"
 	"log"
 	"net/http"
 	"os"
-	"strconv"
-	"strings"
+	"syscall"
 	"time"
 )
 
 var usage = `
 typeahead implements a simple spelling corrector served over HTTP.
 
-Example: /search?q=helo returns spelling corrections for "helo".
+Example: /search?q=helo returns spelling corrections for "helo". See the
+documentation of the httpsuggest package for the accepted query params.
 
-Accepted query params are;
- q: The string query. Default is the empty string.
- n: The max number of results. Default is 10.
- p: The length of the prefix of the query string to ignore for edit distance.
-    Default is 1/5 the length of the query string.
- d: The edit distance to search within. Default is 1/3 the length of the
-    non-ignored suffix of the query.
- e: If non-zero and fewer than the desired number of results are found with the
-    specified criteria, the results will be augmented with strings that have a
-    prefix that matches the query criteria. Default: 1
+The dictionary is reloaded without downtime when the server receives SIGHUP
+or a POST request to /admin/reload. The admin endpoint has no authentication,
+so it's served on its own port, bound to localhost, instead of alongside the
+public endpoints. Prometheus metrics are served at /metrics.
 
 Parameters:
 `
 
-var dictFile = flag.String("dictionary", "/usr/share/dict/words",
-	"A file containing correctly spelled words, one per line.")
+var dictFile = flag.String("dictionary", "corpus",
+	"A file containing correctly spelled words, one per line, or \"corpus\"\n"+
+		"for the sample word list embedded in the corpus package.")
 
 var port = flag.Int("port", 3000, "The port the server will listen on.")
 
-var logger *log.Logger
-
-// newSearchHandler loads the dictionary file at filename into a Trie and
-// returns the Trie wrapped in a searchHandler. The dictionary file should
-// contain a list of words, one per line.
-func newSearchHandler(filename string) searchHandler {
-	t := levtrie.New()
-	logger.Printf("Loading %v, this may take a few seconds...\n", filename)
-	start := time.Now()
-	file, err := os.Open(filename)
-	if err != nil {
-		panic(fmt.Sprintf("%v: %v", filename, err))
-	}
-	defer file.Close()
-	scanner := bufio.NewScanner(file)
-	scanner.Split(bufio.ScanLines)
-	count := 0
-	for scanner.Scan() {
-		word := strings.ToLower(scanner.Text())
-		t.Set(word, "")
-		count += 1
-	}
-	elapsed := time.Since(start)
-	logger.Printf("Loaded %v words from %v in time %v.\n",
-		count, filename, elapsed)
-	return searchHandler{t: t}
-}
-
-type searchHandler struct {
-	t *levtrie.Trie
-}
+var adminPort = flag.Int("admin_port", 3001,
+	"The port on localhost that /admin/reload is served on.")
 
-// uniq returns up to n strings in the input slice, omitting duplicates.
-func uniq(xs []string, n int) []string {
-	seen := make(map[string]bool)
-	j := 0
-	for i, x := range xs {
-		if !seen[x] {
-			seen[x] = true
-			xs[j] = xs[i]
-			j++
-			if j >= n {
-				return xs[:j]
-			}
-		}
-	}
-	return xs[:j]
-}
-
-// config specifies parameters for a Trie search
-type config struct {
-	query          string
-	limit          int
-	dist           int8
-	ignorePrefix   int
-	expandSuffixes bool
-}
+var logger *log.Logger
 
-// parseQuery parses query params into a config for searching a Trie. See usage
-// message defined at the top of this file for a list of accepted query params.
-func parseQuery(params map[string][]string) *config {
-	cfg := &config{}
-	if qp, ok := params["q"]; ok && len(qp) > 0 {
-		cfg.query = qp[0]
-	}
-	cfg.limit = 10
-	if qp, ok := params["n"]; ok && len(qp) > 0 {
-		if i, err := strconv.Atoi(qp[0]); err == nil {
-			cfg.limit = i
+// loadDictionary loads the dictionary file at filename into a Trie, logging
+// how long it takes. The dictionary file should contain a list of words, one
+// per line. If filename is "corpus", the embedded corpus is loaded instead.
+func loadDictionary(filename string) func() (*levtrie.Trie, error) {
+	load := httpsuggest.WordListFile(filename)
+	if filename == "corpus" {
+		load = func() (*levtrie.Trie, error) {
+			return levtrie.ReadWords(corpus.Open())
 		}
 	}
-	pset := false
-	if qp, ok := params["p"]; ok && len(qp) > 0 {
-		if i, err := strconv.Atoi(qp[0]); err == nil {
-			cfg.ignorePrefix = i
-			pset = true
-		}
-	}
-	if !pset {
-		cfg.ignorePrefix = len(cfg.query) / 5
-	}
-	cfg.dist = 1
-	dset := false
-	if qp, ok := params["d"]; ok && len(qp) > 0 {
-		if i, err := strconv.ParseInt(qp[0], 10, 8); err == nil {
-			cfg.dist = int8(i)
-			dset = true
-		}
-	}
-	if !dset {
-		raw_dist := (len(cfg.query) - cfg.ignorePrefix) / 3
-		if raw_dist > 255 {
-			raw_dist = 255
-		}
-		cfg.dist = int8(raw_dist)
-	}
-	cfg.expandSuffixes = true
-	if qp, ok := params["e"]; ok && len(qp) > 0 {
-		if i, err := strconv.Atoi(qp[0]); err == nil && i != 0 {
-			cfg.expandSuffixes = false
-		}
-	}
-	return cfg
-}
-
-func (s searchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	cfg := parseQuery(r.URL.Query())
-	results := []string{}
-	if cfg.query != "" {
+	return func() (*levtrie.Trie, error) {
+		logger.Printf("Loading %v, this may take a few seconds...\n", filename)
 		start := time.Now()
-		kvResults := s.t.SuggestAfterExactPrefix(
-			cfg.query, cfg.ignorePrefix, cfg.dist, cfg.limit)
-		if cfg.expandSuffixes && len(kvResults) < cfg.limit {
-			res := s.t.SuggestSuffixesAfterExactPrefix(
-				cfg.query, cfg.ignorePrefix, cfg.dist, cfg.limit)
-			kvResults = append(kvResults, res...)
+		t, err := load()
+		if err != nil {
+			return nil, err
 		}
-		elapsed := time.Since(start)
-		for _, kv := range kvResults {
-			results = append(results, kv.Key)
-		}
-		results = uniq(results, cfg.limit)
-		logger.Printf("Query %+v returned %v results in time %v\n",
-			cfg, len(results), elapsed)
+		logger.Printf("Loaded %v words from %v in time %v.\n",
+			t.Len(), filename, time.Since(start))
+		return t, nil
 	}
-	j, _ := json.Marshal(results)
-	fmt.Fprintf(w, string(j))
 }
 
 var indexText = `
@@ -212,7 +106,25 @@ func main() {
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(w, indexText)
 	})
-	http.Handle("/search", newSearchHandler(*dictFile))
+	dict, err := httpsuggest.NewDictionary(loadDictionary(*dictFile))
+	if err != nil {
+		logger.Fatalf("%v: %v", *dictFile, err)
+	}
+	dict.ReloadOnSignal(func(err error) {
+		logger.Printf("Reloading %v: %v\n", *dictFile, err)
+	}, syscall.SIGHUP)
+	metrics := httpsuggest.NewMetrics()
+	metrics.AddDictionary(*dictFile, func() int { return dict.Trie().Len() })
+	http.Handle("/search", dict.Handler(httpsuggest.Options{
+		Logger: logger, OnRequest: metrics.Observe}))
+	http.Handle("/metrics", metrics)
+	admin := http.NewServeMux()
+	admin.Handle("/admin/reload", dict.ReloadHandler())
+	go func() {
+		addr := fmt.Sprintf("localhost:%d", *adminPort)
+		logger.Printf("Serving admin endpoints on http://%v\n", addr)
+		logger.Fatal(http.ListenAndServe(addr, admin))
+	}()
 	logger.Printf("Serving on http://0.0.0.0:%d\n", *port)
 	http.ListenAndServe(fmt.Sprintf(":%d", *port), nil)
 }
@@ -0,0 +1,162 @@
+package levtrie
+
+import "sort"
+
+// Most searches in practice, like those made as a user types, use an edit
+// distance of 0 or 1. The general NFAs allocate a new state for every
+// transition, which dominates the cost of these searches, so they're answered
+// by the specialized automata below, whose states fit in state.offset alone.
+
+// exactOnly returns true if a search with cfg within distance d only matches
+// the query itself: d is 0 and no edit is free.
+func (cfg *searchConfig) exactOnly(d int8) bool {
+	return d == 0 && (cfg.affix.cost > 0 || cfg.affix.prefix == 0 && cfg.affix.suffix == 0)
+}
+
+// searchExact is search and searchOrdered for a search that only matches the
+// query itself. It just follows the runes of the query from root.
+func searchExact(process processAcceptingNode, root *node, runes []rune, cfg *searchConfig, visit func(*entry) bool) {
+	n := descend(root, runes)
+	if n == nil || cfg.pruned(n) {
+		return
+	}
+	cfg.dist = 0
+	if !cfg.ordered {
+		process(n, func(e *entry) bool { return !cfg.keep(e, 0) || visit(e) })
+		return
+	}
+	var es []*entry
+	process(n, func(e *entry) bool {
+		if cfg.keep(e, 0) {
+			es = append(es, e)
+		}
+		return true
+	})
+	sort.Slice(es, func(a, b int) bool { return cfg.before(es[a], es[b]) })
+	for _, e := range es {
+		if !visit(e) {
+			return
+		}
+	}
+}
+
+// exactAutomaton matches its runes within edit distance 0. The offset of a
+// state is the number of runes read, or -1 once a rune didn't match.
+type exactAutomaton struct {
+	rs []rune
+}
+
+func (a exactAutomaton) start() state {
+	return state{}
+}
+
+func (a exactAutomaton) accepts(s state) bool {
+	return s.offset == len(a.rs)
+}
+
+func (a exactAutomaton) distance(s state) int {
+	if s.offset == len(a.rs) {
+		return 0
+	}
+	return 1
+}
+
+func (a exactAutomaton) transition(s state, r rune) (state, int) {
+	if s.offset < 0 || s.offset >= len(a.rs) || a.rs[s.offset] != r {
+		return state{offset: -1}, 1
+	}
+	return state{offset: s.offset + 1}, 0
+}
+
+// The states of an oneEditAutomaton after reading c runes, as bits of a mask.
+const (
+	exactAt   = 1 << iota // 0 edits, c runes of the query matched.
+	editLess              // 1 edit, c-1 runes of the query matched.
+	editSame              // 1 edit, c runes of the query matched.
+	editAhead             // 1 edit, c+1 runes of the query matched.
+	editBits  = 4
+)
+
+// oneEditAutomaton matches its runes within edit distance 1 with all edits
+// allowed at unit cost. After c runes are read, the only NFA states that can
+// be active are the 4 listed above, so the offset of a state holds c in its
+// high bits and the mask of active states in its low editBits bits.
+type oneEditAutomaton struct {
+	rs []rune
+}
+
+// at returns true if the query has rune r at index i.
+func (a oneEditAutomaton) at(i int, r rune) bool {
+	return i >= 0 && i < len(a.rs) && a.rs[i] == r
+}
+
+// close adds the states reachable from mask after reading c runes by deleting
+// a rune of the query, and drops states past the end of the query.
+func (a oneEditAutomaton) close(c int, mask int) state {
+	if mask&exactAt != 0 && c < len(a.rs) {
+		mask |= editAhead
+	}
+	if c+1 > len(a.rs) {
+		mask &^= editAhead
+	}
+	if c > len(a.rs) {
+		mask &^= editSame
+	}
+	if c-1 > len(a.rs) {
+		mask &^= editLess
+	}
+	return state{offset: c<<editBits | mask}
+}
+
+func (a oneEditAutomaton) start() state {
+	return a.close(0, exactAt)
+}
+
+func (a oneEditAutomaton) accepts(s state) bool {
+	return a.distance(s) <= 1
+}
+
+func (a oneEditAutomaton) distance(s state) int {
+	c, mask := s.offset>>editBits, s.offset&(1<<editBits-1)
+	switch {
+	case mask&exactAt != 0 && c == len(a.rs):
+		return 0
+	case mask&editLess != 0 && c-1 == len(a.rs),
+		mask&editSame != 0 && c == len(a.rs),
+		mask&editAhead != 0 && c+1 == len(a.rs):
+		return 1
+	}
+	return 2
+}
+
+func (a oneEditAutomaton) transition(s state, r rune) (state, int) {
+	c, mask := s.offset>>editBits, s.offset&(1<<editBits-1)
+	next := 0
+	if mask&exactAt != 0 {
+		if a.at(c, r) {
+			next |= exactAt
+		}
+		// Insert r into the query, or substitute it for the next rune.
+		next |= editLess
+		if c < len(a.rs) {
+			next |= editSame
+		}
+	}
+	if mask&editLess != 0 && a.at(c-1, r) {
+		next |= editLess
+	}
+	if mask&editSame != 0 && a.at(c, r) {
+		next |= editSame
+	}
+	if mask&editAhead != 0 && a.at(c+1, r) {
+		next |= editAhead
+	}
+	ns := a.close(c+1, next)
+	switch m := ns.offset & (1<<editBits - 1); {
+	case m&exactAt != 0:
+		return ns, 0
+	case m != 0:
+		return ns, 1
+	}
+	return ns, 2
+}
@@ -0,0 +1,67 @@
+package levtrie
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// runAutomata returns the distances at which a and b accept key, and false if
+// they disagree on the minimum distance of a state along the way or on
+// whether key is accepted.
+func runAutomata(a, b automaton, key string) (int, int, bool) {
+	sa, sb := a.start(), b.start()
+	for _, r := range key {
+		var ma, mb int
+		sa, ma = a.transition(sa, r)
+		sb, mb = b.transition(sb, r)
+		if ma != mb {
+			return 0, 0, false
+		}
+	}
+	if a.accepts(sa) != b.accepts(sb) {
+		return 0, 0, false
+	}
+	return a.distance(sa), b.distance(sb), true
+}
+
+func TestFastAutomata(t *testing.T) {
+	rand.Seed(0)
+	keys := append(generateEdits(4, 300), "", "A")
+	for _, q := range keys[:60] {
+		rs := extractRunes(q)
+		for d, a := range map[int8]automaton{0: exactAutomaton{rs: rs}, 1: oneEditAutomaton{rs: rs}} {
+			for _, key := range keys {
+				da, db, ok := runAutomata(a, newNfa(rs, d, allEdits), key)
+				if !ok || da != db {
+					t.Fatalf("d=%v automaton for %q disagrees with the NFA on %q: %v vs %v", d, q, key, da, db)
+				}
+			}
+		}
+	}
+}
+
+func TestFastPathsMatchSearch(t *testing.T) {
+	rand.Seed(0)
+	keys := generateEdits(5, 500)
+	r := New()
+	for i, key := range keys {
+		r.Set(key, key)
+		r.IncrBy(key, int64(i%5))
+	}
+	for _, q := range keys[:40] {
+		prefix := string(extractRunes(q)[:len(extractRunes(q))/2])
+		for _, opts := range [][]SuggestOption{nil, {Ordered()}, {ByWeight()}} {
+			for d := int8(0); d <= 1; d++ {
+				// Unit edit costs given explicitly take the general
+				// weighted path.
+				slow := append([]SuggestOption{EditCosts(1, 1, 1), AffixTolerance(0, 0, 1)}, opts...)
+				if got, want := keystr(r.Suggest(q, d, 1000, opts...)), keystr(r.Suggest(q, d, 1000, slow...)); got != want {
+					t.Errorf("Suggest(%q, %v) = %v, want %v", q, d, got, want)
+				}
+				if got, want := keystr(r.SuggestSuffixes(prefix, d, 1000, opts...)), keystr(r.SuggestSuffixes(prefix, d, 1000, slow...)); got != want {
+					t.Errorf("SuggestSuffixes(%q, %v) = %v, want %v", prefix, d, got, want)
+				}
+			}
+		}
+	}
+}
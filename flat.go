@@ -0,0 +1,339 @@
+package levtrie
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// FlatTrie is a map from strings to strings with the same searches as a
+// Trie, stored without pointers: its nodes are structs of integers in a
+// single slice that refer to each other by index, and its values are stored
+// back to back in a single byte slice. The garbage collector doesn't scan
+// slices without pointers, so a FlatTrie with millions of nodes costs it
+// almost nothing, where a Trie costs it a map and several pointers per node,
+// and the slices can be written out and read back as they are. In exchange,
+// finding a child walks a list of siblings instead of looking it up in a
+// map, a FlatTrie holds a single value for each key, and it has none of the
+// indexes, counts, tags or hooks of a Trie. Its values can take up to 2 GiB
+// in total. It isn't safe for concurrent use if any goroutine writes to it.
+// Don't create directly, use NewFlat() instead.
+type FlatTrie struct {
+	nodes []flatNode // The root is at index 0.
+	free  int32      // The first node of a list of free nodes linked by next, or 0.
+	// The values of all keys, back to back, including values that have
+	// been replaced or deleted since values was last compacted.
+	values  []byte
+	garbage int // The number of bytes in values that aren't used anymore.
+	size    int
+	// Holds the options of the Trie that apply to searches, like
+	// AdaptiveDistance and PreprocessQueries. It has no nodes.
+	t *Trie
+}
+
+// flatNode is a node of a FlatTrie. Index 0 is the root, which can't be
+// anyone's child or sibling, so 0 means none in first and next.
+type flatNode struct {
+	label  rune
+	parent int32
+	first  int32 // The index of the first child. Children are sorted by label.
+	next   int32 // The index of the next sibling.
+	off    int32 // The offset of the node's value in values.
+	vlen   int32 // The length of the node's value, or -1 if it has no key.
+}
+
+var _ Index = (*FlatTrie)(nil)
+
+func init() {
+	RegisterIndex("flat", func() Index { return NewFlat() })
+}
+
+// NewFlat returns an empty FlatTrie whose searches use the given options.
+// Options that only apply to the storage of a Trie have no effect.
+func NewFlat(opts ...Option) *FlatTrie {
+	return &FlatTrie{nodes: []flatNode{{vlen: -1}}, t: New(opts...)}
+}
+
+// Len returns the number of keys in the FlatTrie.
+func (f *FlatTrie) Len() int {
+	return f.size
+}
+
+// child returns the index of the child of n reached by r and the index of the
+// sibling before it, or 0 if it's the first child. If there's no such child,
+// it returns 0 and the index of the sibling a new child for r would follow.
+func (f *FlatTrie) child(n int32, r rune) (int32, int32) {
+	var prev int32
+	for c := f.nodes[n].first; c != 0; prev, c = c, f.nodes[c].next {
+		if l := f.nodes[c].label; l == r {
+			return c, prev
+		} else if l > r {
+			break
+		}
+	}
+	return 0, prev
+}
+
+// find returns the index of the node for key, or 0 and false if there's no
+// such node.
+func (f *FlatTrie) find(key string) (int32, bool) {
+	var n int32
+	for _, r := range key {
+		if n, _ = f.child(n, r); n == 0 {
+			return 0, false
+		}
+	}
+	return n, true
+}
+
+// value returns the value of the node at index n.
+func (f *FlatTrie) value(n int32) string {
+	x := &f.nodes[n]
+	return string(f.values[x.off : x.off+x.vlen])
+}
+
+// Get returns the value associated with key and true, or the empty string and
+// false if key isn't in the FlatTrie.
+func (f *FlatTrie) Get(key string) (string, bool) {
+	key, ok := f.t.preprocess(key)
+	if !ok {
+		return "", false
+	}
+	if n, ok := f.find(key); ok && f.nodes[n].vlen >= 0 {
+		return f.value(n), true
+	}
+	return "", false
+}
+
+// Has returns true exactly when key is in the FlatTrie.
+func (f *FlatTrie) Has(key string) bool {
+	_, ok := f.Get(key)
+	return ok
+}
+
+// Set associates key with val, replacing any value previously associated with
+// key.
+func (f *FlatTrie) Set(key string, val string) {
+	var n int32
+	for _, r := range key {
+		c, prev := f.child(n, r)
+		if c == 0 {
+			c = f.alloc(flatNode{label: r, parent: n, vlen: -1})
+			if prev == 0 {
+				f.nodes[c].next, f.nodes[n].first = f.nodes[n].first, c
+			} else {
+				f.nodes[c].next, f.nodes[prev].next = f.nodes[prev].next, c
+			}
+		}
+		n = c
+	}
+	x := &f.nodes[n]
+	if x.vlen < 0 {
+		f.size++
+	} else {
+		f.garbage += int(x.vlen)
+	}
+	x.off, x.vlen = int32(len(f.values)), int32(len(val))
+	f.values = append(f.values, val...)
+	f.compact()
+}
+
+// alloc adds x to the nodes, reusing a free node if there is one, and returns
+// its index.
+func (f *FlatTrie) alloc(x flatNode) int32 {
+	if f.free == 0 {
+		f.nodes = append(f.nodes, x)
+		return int32(len(f.nodes) - 1)
+	}
+	n := f.free
+	f.free = f.nodes[n].next
+	f.nodes[n] = x
+	return n
+}
+
+// Delete removes key and its value from the FlatTrie. Nodes that no longer
+// lead to any key are freed for reuse.
+func (f *FlatTrie) Delete(key string) {
+	n, ok := f.find(key)
+	if !ok || f.nodes[n].vlen < 0 {
+		return
+	}
+	f.garbage += int(f.nodes[n].vlen)
+	f.nodes[n].vlen = -1
+	f.size--
+	for n != 0 && f.nodes[n].vlen < 0 && f.nodes[n].first == 0 {
+		parent := f.nodes[n].parent
+		if _, prev := f.child(parent, f.nodes[n].label); prev == 0 {
+			f.nodes[parent].first = f.nodes[n].next
+		} else {
+			f.nodes[prev].next = f.nodes[n].next
+		}
+		f.nodes[n] = flatNode{next: f.free, vlen: -1}
+		f.free = n
+		n = parent
+	}
+	f.compact()
+}
+
+// compact rewrites values without the bytes that aren't used anymore once
+// they make up more than half of it.
+func (f *FlatTrie) compact() {
+	if f.garbage <= len(f.values)/2 || f.garbage < 1024 {
+		return
+	}
+	values := make([]byte, 0, len(f.values)-f.garbage)
+	for i := range f.nodes {
+		if x := &f.nodes[i]; x.vlen >= 0 {
+			off := int32(len(values))
+			values = append(values, f.values[x.off:x.off+x.vlen]...)
+			x.off = off
+		}
+	}
+	f.values, f.garbage = values, 0
+}
+
+// key returns the key of the node at index n.
+func (f *FlatTrie) key(n int32) string {
+	var rs []rune
+	for ; n != 0; n = f.nodes[n].parent {
+		rs = append(rs, f.nodes[n].label)
+	}
+	b := make([]byte, 0, len(rs))
+	for i := len(rs) - 1; i >= 0; i-- {
+		b = utf8.AppendRune(b, rs[i])
+	}
+	return string(b)
+}
+
+// Suggest returns up to n KVs with keys within edit distance d of key. See
+// Trie.Suggest.
+func (f *FlatTrie) Suggest(key string, d int8, n int, opts ...SuggestOption) []KV {
+	return f.suggest(false, key, d, n, opts)
+}
+
+// SuggestSuffixes returns up to n KVs whose keys have a prefix within edit
+// distance d of key. See Trie.SuggestSuffixes.
+func (f *FlatTrie) SuggestSuffixes(key string, d int8, n int, opts ...SuggestOption) []KV {
+	return f.suggest(true, key, d, n, opts)
+}
+
+// suggest runs a search for key, expanding the suffixes of each match if
+// expand is true.
+func (f *FlatTrie) suggest(expand bool, key string, d int8, limit int, opts []SuggestOption) []KV {
+	key, ok := f.t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	runes, cfg := extractRunes(key), f.t.config(key, opts)
+	d = f.t.distance(len(runes), d)
+	return f.t.collect(limit, cfg, func(visit func(*entry) bool) {
+		f.search(expand, runes, d, cfg, visit)
+	})
+}
+
+// flatFrame is a frame of a search of a FlatTrie, see frame.
+type flatFrame struct {
+	n int32
+	s state
+}
+
+// search is FrozenTrie.search for a FlatTrie. The entries passed to visit are
+// made for the search, since a FlatTrie doesn't keep any.
+func (f *FlatTrie) search(expand bool, runes []rune, d int8, cfg *searchConfig, visit func(*entry) bool) {
+	a := newAutomaton(runes, d, cfg)
+	stacks := make([][]flatFrame, int(d)+1)
+	stacks[0] = []flatFrame{{s: a.start()}}
+	entries := make(map[int32]*entry)
+	entryAt := func(n int32) *entry {
+		e, ok := entries[n]
+		if !ok {
+			e = &entry{key: f.key(n), values: []string{f.value(n)}}
+			entries[n] = e
+		}
+		return e
+	}
+	var best map[*entry]int8
+	var found [][]*entry
+	if cfg.ordered {
+		best, found = make(map[*entry]int8), make([][]*entry, int(d)+1)
+	}
+	for i := range stacks {
+		for len(stacks[i]) > 0 {
+			var fr flatFrame
+			fr, stacks[i] = stacks[i][len(stacks[i])-1], stacks[i][:len(stacks[i])-1]
+			if x := a.distance(fr.s); x <= int(d) {
+				dist := int8(x)
+				record := func(n int32) bool {
+					e := entryAt(n)
+					if !cfg.keep(e, dist) {
+						return true
+					}
+					if !cfg.ordered {
+						cfg.dist = dist
+						return visit(e)
+					}
+					if b, ok := best[e]; !ok || dist < b {
+						best[e] = dist
+						found[dist] = append(found[dist], e)
+					}
+					return true
+				}
+				if !f.process(expand, fr.n, record) {
+					return
+				}
+				if expand && !cfg.ordered {
+					continue
+				}
+			}
+			if cfg.prunedTags(0) {
+				continue
+			}
+			for c := f.nodes[fr.n].first; c != 0; c = f.nodes[c].next {
+				if ns, min := a.transition(fr.s, f.nodes[c].label); min <= int(d) {
+					stacks[min] = append(stacks[min], flatFrame{n: c, s: ns})
+				}
+			}
+		}
+		if !cfg.ordered {
+			continue
+		}
+		es := found[i][:0]
+		for _, e := range found[i] {
+			if best[e] == int8(i) {
+				best[e] = -1
+				es = append(es, e)
+			}
+		}
+		sort.Slice(es, func(a, b int) bool { return cfg.before(es[a], es[b]) })
+		cfg.dist = int8(i)
+		for _, e := range es {
+			if !visit(e) {
+				return
+			}
+		}
+	}
+}
+
+// process passes the node at index n to visit if it has a key, and every node
+// with a key below it if expand is true, until visit returns false, and
+// returns false if visit did. Nodes are passed in sorted order of their keys.
+func (f *FlatTrie) process(expand bool, n int32, visit func(n int32) bool) bool {
+	if !expand {
+		return f.nodes[n].vlen < 0 || visit(n)
+	}
+	for stack := []int32{n}; len(stack) > 0; {
+		c := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if f.nodes[c].vlen >= 0 && !visit(c) {
+			return false
+		}
+		// Push the siblings of c and then its first child, so that
+		// children are visited before siblings that come after them.
+		if next := f.nodes[c].next; next != 0 && c != n {
+			stack = append(stack, next)
+		}
+		if first := f.nodes[c].first; first != 0 {
+			stack = append(stack, first)
+		}
+	}
+	return true
+}
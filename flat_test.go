@@ -0,0 +1,91 @@
+package levtrie
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestFlatTrieMatchesTrie(t *testing.T) {
+	rand.Seed(0)
+	keys := generateEdits(6, 500)
+	r, f := New(), NewFlat()
+	for i, key := range keys {
+		r.Set(key, key)
+		f.Set(key, key)
+		if i%3 == 0 {
+			// Replaced values become garbage until values is compacted.
+			r.Set(key, key+key)
+			f.Set(key, key+key)
+		}
+	}
+	for _, key := range keys[:200] {
+		r.Delete(key)
+		f.Delete(key)
+	}
+	r.Set("", "empty")
+	f.Set("", "empty")
+	if f.Len() != r.Len() {
+		t.Errorf("Got %v keys, want %v", f.Len(), r.Len())
+	}
+	for _, key := range keys {
+		gv, gok := f.Get(key)
+		wv, wok := r.Get(key)
+		if gv != wv || gok != wok || f.Has(key) != wok {
+			t.Errorf("Get(%v) = %v, %v, want %v, %v", key, gv, gok, wv, wok)
+		}
+	}
+	optss := [][]SuggestOption{nil, {Ordered()}, {ExcludeQuery()}, {EditCosts(1, 2, 1)}}
+	for _, key := range generateEdits(6, 30) {
+		for _, opts := range optss {
+			if got, want := kvstr(f.Suggest(key, 2, 1000, opts...)), kvstr(r.Suggest(key, 2, 1000, opts...)); got != want {
+				t.Errorf("Suggest(%v) = %v, want %v", key, got, want)
+			}
+			if got, want := kvstr(f.SuggestSuffixes(key[:3], 1, 1000, opts...)), kvstr(r.SuggestSuffixes(key[:3], 1, 1000, opts...)); got != want {
+				t.Errorf("SuggestSuffixes(%v) = %v, want %v", key[:3], got, want)
+			}
+		}
+		if got, want := ukeystr(f.Suggest(key, 2, 10, Ordered())), ukeystr(r.Suggest(key, 2, 10, Ordered())); got != want {
+			t.Errorf("Ordered Suggest(%v) = %v, want %v", key, got, want)
+		}
+	}
+	for _, key := range keys {
+		r.Delete(key)
+		f.Delete(key)
+	}
+	f.Delete("")
+	if f.Len() != 0 || len(f.Suggest("", 127, 10)) != 0 || f.nodes[0].first != 0 {
+		t.Errorf("Got %v keys after deleting them all, want 0", f.Len())
+	}
+	// Freed nodes are reused.
+	n := len(f.nodes)
+	for _, key := range keys[:100] {
+		f.Set(key, "")
+	}
+	if len(f.nodes) != n {
+		t.Errorf("Got %v nodes after adding keys back, want %v", len(f.nodes), n)
+	}
+}
+
+// kvstr returns the KVs in kvs in sorted order as a string.
+func kvstr(kvs []KV) string {
+	z := []string{}
+	for _, kv := range kvs {
+		z = append(z, kv.Key+"="+kv.Value)
+	}
+	sort.Strings(z)
+	return strings.Join(z, " ")
+}
+
+func TestNewIndexFlat(t *testing.T) {
+	x, err := NewIndex("flat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Set("hello", "1")
+	x.Set("help", "2")
+	if got, want := keystr(x.Suggest("helo", 1, 10)), "hello help"; got != want {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+}
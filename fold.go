@@ -0,0 +1,34 @@
+package levtrie
+
+// DedupeBy makes a search return at most one key for each distinct value of
+// fold(key), which is useful when several keys in the Trie only differ in
+// ways that fold erases, like "Polish" and "polish" under strings.ToLower.
+// Among the keys that fold to the same value, the one with the largest weight
+// (see Trie.IncrBy) is returned, or the first one found if there's a tie, in
+// the place where the first of them was found. Every match has to be found
+// before any can be dropped, so searches with DedupeBy take time proportional
+// to the number of matches rather than to n. DedupeBy applies to the searches
+// that walk the Trie; SuggestInfix and, without their indexes,
+// SuggestEndsWith ignore it.
+func DedupeBy(fold func(key string) string) SuggestOption {
+	return func(cfg *searchConfig) {
+		cfg.fold = fold
+	}
+}
+
+// dedupe returns the entries in es with at most one entry for each value of
+// fold(key) as described in DedupeBy.
+func dedupe(es []*entry, fold func(string) string, weight func(*entry) float64) []*entry {
+	rep := make(map[string]int) // The index in the result for each folded key.
+	var result []*entry
+	for _, e := range es {
+		f := fold(e.key)
+		if i, ok := rep[f]; !ok {
+			rep[f] = len(result)
+			result = append(result, e)
+		} else if weight(e) > weight(result[i]) {
+			result[i] = e
+		}
+	}
+	return result
+}
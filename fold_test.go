@@ -0,0 +1,28 @@
+package levtrie
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDedupeBy(t *testing.T) {
+	for _, opts := range [][]Option{nil, {TrigramIndex(0.1)}} {
+		r := New(opts...)
+		for key, count := range map[string]int64{"Polish": 5, "polish": 20, "POLISH": 1, "relish": 3, "Relish": 3} {
+			r.IncrBy(key, count)
+		}
+		got := r.Suggest("polish", 2, 10, DedupeBy(strings.ToLower), Ordered())
+		if len(got) != 2 || got[0].Key != "polish" || strings.ToLower(got[1].Key) != "relish" {
+			t.Errorf("Got %v, want polish and one relish", ukeystr(got))
+		}
+		if got := r.Suggest("polish", 2, 1, DedupeBy(strings.ToLower)); len(got) != 1 {
+			t.Errorf("Got %v, want one result", ukeystr(got))
+		}
+		if got, want := keystr(r.SuggestSuffixes("pol", 0, 10, DedupeBy(strings.ToLower))), "polish"; got != want {
+			t.Errorf("Got %v, want %v", got, want)
+		}
+		if got := r.Suggest("polish", 6, 10); len(got) != 5 {
+			t.Errorf("Got %v without DedupeBy, want all 5 keys", ukeystr(got))
+		}
+	}
+}
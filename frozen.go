@@ -0,0 +1,418 @@
+package levtrie
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// FrozenTrie is a read-only copy of a Trie laid out for fast searches. Its
+// nodes are stored in a single slice, with the children of each node next to
+// each other and sorted by rune, instead of in a map per node, so a FrozenTrie
+// takes less memory than the Trie it was made from and walking it touches
+// less of it. It suits dictionaries that are built once and then served for a
+// long time. A FrozenTrie can't be changed, so it's safe for concurrent use by
+// multiple goroutines without any locking. Don't create directly, use
+// Trie.Freeze() instead.
+type FrozenTrie struct {
+	nodes   []frozenNode
+	entries []entry
+	root    int32 // The index of the root in nodes, which is always the last.
+	// Holds the options of the Trie that apply to reads, like
+	// CompressValues, TransformValues, AdaptiveDistance and
+	// PreprocessQueries, and the tags of the keys. It has no nodes.
+	t *Trie
+}
+
+// frozenNode is a node of a FrozenTrie.
+type frozenNode struct {
+	label rune   // The rune on the edge from the node's parent.
+	entry int32  // The index of the node's entry, or -1 if it has none.
+	first int32  // The index of the node's first child.
+	count int32  // The number of children, which follow the first one.
+	tags  uint64 // The union of the tags of the entries below, see SetTags.
+}
+
+// Freeze returns a FrozenTrie holding the live keys of the Trie, along with
+// their values, counts, expiration times and tags. The FrozenTrie answers the
+// same reads as the Trie, using the same read options, but later changes to
+// the Trie don't affect it. Searches of a FrozenTrie always walk its nodes,
+// so options like TrigramIndex, ReverseIndex and InfixIndex don't carry over,
+// and reads don't count as uses of a key for MaxKeys. Freeze walks the
+// entire Trie.
+//
+// The nodes are laid out in the order they're finished by a walk of the Trie
+// in sorted order: the children of a node are written together after all of
+// their descendants, and the root is written last. That order can also be
+// produced from a stream of sorted keys without building a Trie first, which
+// is what a FrozenBuilder does.
+func (t *Trie) Freeze() *FrozenTrie {
+	f := &FrozenTrie{t: &Trie{
+		codec:         t.codec,
+		transform:     t.transform,
+		policy:        t.policy,
+		tagBits:       make(map[string]uint64, len(t.tagBits)),
+		preprocessors: append([]QueryProcessor(nil), t.preprocessors...),
+		tokenizer:     t.tokenizer,
+		decay:         t.decay,
+		damerau:       t.damerau,
+	}}
+	for tag, bit := range t.tagBits {
+		f.t.tagBits[tag] = bit
+	}
+	f.t.size = f.build(t.tree())
+	return f
+}
+
+// build adds the nodes under root to f, which must be empty, and returns the
+// number of entries added. Nodes that don't lead to any live entries are left
+// out.
+func (f *FrozenTrie) build(root *node) int {
+	type item struct {
+		n        *node
+		self     frozenNode
+		runes    []rune       // The runes of n's children, sorted.
+		children []frozenNode // The frozen children of n, so far.
+	}
+	open := func(n *node, label rune) item {
+		x := item{n: n, self: frozenNode{label: label, entry: -1}, runes: make([]rune, 0, len(n.child))}
+		for r := range n.child {
+			x.runes = append(x.runes, r)
+		}
+		sort.Slice(x.runes, func(i, j int) bool { return x.runes[i] < x.runes[j] })
+		return x
+	}
+	stack := []item{open(root, 0)}
+	for {
+		x := &stack[len(stack)-1]
+		if i := len(x.children); i < len(x.runes) {
+			// Descend into the next child. It's added to x.children when
+			// it's finished, or skipped if it has no live entries.
+			x.children = append(x.children, frozenNode{entry: -2})
+			stack = append(stack, open(x.n.child[x.runes[i]], x.runes[i]))
+			continue
+		}
+		// Every child of x is finished, so write them out and finish x.
+		kept := x.children[:0]
+		for _, c := range x.children {
+			if c.entry != -2 {
+				kept = append(kept, c)
+			}
+		}
+		x.self.first, x.self.count = int32(len(f.nodes)), int32(len(kept))
+		f.nodes = append(f.nodes, kept...)
+		if e := x.n.data; e.live() {
+			x.self.entry = int32(len(f.entries))
+			// The Trie can change its slice of values in place, so the
+			// FrozenTrie gets its own.
+			values := append([]string(nil), e.values...)
+			f.entries = append(f.entries, entry{key: e.key, values: values, count: e.count, payload: e.payload, expires: e.expires, tags: e.tags, boosted: e.boosted})
+		}
+		for _, c := range kept {
+			x.self.tags |= c.tags
+		}
+		if x.self.entry >= 0 {
+			x.self.tags |= f.entries[x.self.entry].tags
+		}
+		if len(stack) == 1 {
+			f.root = int32(len(f.nodes))
+			f.nodes = append(f.nodes, x.self)
+			return len(f.entries)
+		}
+		self := x.self
+		stack = stack[:len(stack)-1]
+		parent := &stack[len(stack)-1]
+		if self.entry >= 0 || self.count > 0 {
+			parent.children[len(parent.children)-1] = self
+		}
+	}
+}
+
+// Len returns the number of keys in the FrozenTrie, including keys that have
+// expired since it was frozen.
+func (f *FrozenTrie) Len() int {
+	return f.t.size
+}
+
+// child returns the index of the child of the node at index n reached by r,
+// or -1 if there's no such child.
+func (f *FrozenTrie) child(n int32, r rune) int32 {
+	x := &f.nodes[n]
+	children := f.nodes[x.first : x.first+x.count]
+	i := sort.Search(len(children), func(i int) bool { return children[i].label >= r })
+	if i == len(children) || children[i].label != r {
+		return -1
+	}
+	return x.first + int32(i)
+}
+
+// descend returns the index of the node reached by following the runes of key
+// from the node at index n, or -1 if there's no such node.
+func (f *FrozenTrie) descend(n int32, key string) int32 {
+	for i, w := 0, 0; i < len(key) && n >= 0; i += w {
+		var r rune
+		r, w = utf8.DecodeRuneInString(key[i:])
+		n = f.child(n, r)
+	}
+	return n
+}
+
+// lookup returns the live entry for key after preprocessing it, or nil if
+// there's no such entry or the query was vetoed.
+func (f *FrozenTrie) lookup(key string) *entry {
+	key, ok := f.t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	n := f.descend(f.root, key)
+	if n < 0 || f.nodes[n].entry < 0 {
+		return nil
+	}
+	if e := &f.entries[f.nodes[n].entry]; e.live() {
+		return e
+	}
+	return nil
+}
+
+// Get returns the value stored at key and true, or the empty string and false
+// if key isn't in the FrozenTrie. See Trie.Get.
+func (f *FrozenTrie) Get(key string) (string, bool) {
+	if e := f.lookup(key); e != nil {
+		return f.t.output(e.key, e.value()), true
+	}
+	return "", false
+}
+
+// Values returns all values associated with key, or nil if key isn't in the
+// FrozenTrie. See Trie.Values.
+func (f *FrozenTrie) Values(key string) []string {
+	if e := f.lookup(key); e != nil {
+		vals := make([]string, len(e.values))
+		for i, v := range e.values {
+			vals[i] = f.t.output(e.key, v)
+		}
+		return vals
+	}
+	return nil
+}
+
+// Has returns true exactly when key is in the FrozenTrie.
+func (f *FrozenTrie) Has(key string) bool {
+	return f.lookup(key) != nil
+}
+
+// Count returns the count associated with key when the Trie was frozen, or 0
+// if key isn't in the FrozenTrie. With DecayWeights, the count keeps decaying
+// after the Trie was frozen.
+func (f *FrozenTrie) Count(key string) int64 {
+	if e := f.lookup(key); e != nil {
+		return f.t.count(e)
+	}
+	return 0
+}
+
+// Suggest returns up to n KVs with keys within edit distance d of key. See
+// Trie.Suggest.
+func (f *FrozenTrie) Suggest(key string, d int8, n int, opts ...SuggestOption) []KV {
+	return f.suggest(false, key, 0, d, n, opts)
+}
+
+// SuggestSuffixes returns up to n KVs whose keys have a prefix within edit
+// distance d of key. See Trie.SuggestSuffixes.
+func (f *FrozenTrie) SuggestSuffixes(key string, d int8, n int, opts ...SuggestOption) []KV {
+	return f.suggest(true, key, 0, d, n, opts)
+}
+
+// SuggestAfterExactPrefix returns up to n KVs that share an exact prefix of
+// length p with key and are within edit distance d of it. See
+// Trie.SuggestAfterExactPrefix.
+func (f *FrozenTrie) SuggestAfterExactPrefix(key string, p int, d int8, n int, opts ...SuggestOption) []KV {
+	return f.suggest(false, key, p, d, n, opts)
+}
+
+// SuggestSuffixesAfterExactPrefix returns up to n KVs whose keys have a prefix
+// within edit distance d of key and share an exact prefix of length p with
+// it. See Trie.SuggestSuffixesAfterExactPrefix.
+func (f *FrozenTrie) SuggestSuffixesAfterExactPrefix(key string, p int, d int8, n int, opts ...SuggestOption) []KV {
+	return f.suggest(true, key, p, d, n, opts)
+}
+
+// suggest runs a search for key after an exact prefix of p runes, expanding
+// the suffixes of each match if expand is true.
+func (f *FrozenTrie) suggest(expand bool, key string, p int, d int8, limit int, opts []SuggestOption) []KV {
+	key, ok := f.t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	runes, cfg := extractRunes(key), f.t.config(key, opts)
+	d = f.t.distance(len(runes), d)
+	root := f.descend(f.root, string(runes[:p]))
+	if root < 0 {
+		return nil
+	}
+	cfg.after(runes[:p])
+	return f.t.collect(limit, cfg, func(visit func(*entry) bool) {
+		f.search(expand, root, runes[p:], d, cfg, visit)
+	})
+}
+
+// frozenFrame is a frame of a search of a FrozenTrie, see frame.
+type frozenFrame struct {
+	n int32
+	s state
+}
+
+// search is search and, with the Ordered option, searchOrdered for a
+// FrozenTrie. Matches are expanded to every entry below them if expand is
+// true, as expandSuffixes does, and only to their own entry otherwise.
+func (f *FrozenTrie) search(expand bool, root int32, runes []rune, d int8, cfg *searchConfig, visit func(*entry) bool) {
+	a := newAutomaton(runes, d, cfg)
+	stacks := make([][]frozenFrame, int(d)+1)
+	stacks[0] = []frozenFrame{{n: root, s: a.start()}}
+	var best map[*entry]int8
+	var found [][]*entry
+	if cfg.ordered {
+		best, found = make(map[*entry]int8), make([][]*entry, int(d)+1)
+	}
+	for i := range stacks {
+		for len(stacks[i]) > 0 {
+			var fr frozenFrame
+			fr, stacks[i] = stacks[i][len(stacks[i])-1], stacks[i][:len(stacks[i])-1]
+			if x := a.distance(fr.s); x <= int(d) {
+				dist := int8(x)
+				record := func(e *entry) bool {
+					if !cfg.keep(e, dist) {
+						return true
+					}
+					if !cfg.ordered {
+						cfg.dist = dist
+						return visit(e)
+					}
+					if b, ok := best[e]; !ok || dist < b {
+						best[e] = dist
+						found[dist] = append(found[dist], e)
+					}
+					return true
+				}
+				if !f.process(expand, fr.n, record) {
+					return
+				}
+				if expand && !cfg.ordered {
+					continue
+				}
+			}
+			x := &f.nodes[fr.n]
+			for c := x.first; c < x.first+x.count; c++ {
+				child := &f.nodes[c]
+				if cfg.prunedTags(child.tags) {
+					continue
+				}
+				if ns, min := a.transition(fr.s, child.label); min <= int(d) {
+					stacks[min] = append(stacks[min], frozenFrame{n: c, s: ns})
+				}
+			}
+		}
+		if !cfg.ordered {
+			continue
+		}
+		es := found[i][:0]
+		for _, e := range found[i] {
+			if best[e] == int8(i) {
+				best[e] = -1
+				es = append(es, e)
+			}
+		}
+		sort.Slice(es, func(a, b int) bool { return cfg.before(es[a], es[b]) })
+		cfg.dist = int8(i)
+		for _, e := range es {
+			if !visit(e) {
+				return
+			}
+		}
+	}
+}
+
+// process passes the live entry of the node at index n, and every live entry
+// below it if expand is true, to visit until visit returns false, and returns
+// false if visit did.
+func (f *FrozenTrie) process(expand bool, n int32, visit func(*entry) bool) bool {
+	if !expand {
+		x := &f.nodes[n]
+		if x.entry >= 0 && f.entries[x.entry].live() {
+			return visit(&f.entries[x.entry])
+		}
+		return true
+	}
+	for stack := []int32{n}; len(stack) > 0; {
+		var c int32
+		c, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		x := &f.nodes[c]
+		if x.entry >= 0 && f.entries[x.entry].live() && !visit(&f.entries[x.entry]) {
+			return false
+		}
+		for i := x.first + x.count - 1; i >= x.first; i-- {
+			stack = append(stack, i)
+		}
+	}
+	return true
+}
+
+// Thaw returns a new Trie holding the live keys of the FrozenTrie, along with
+// their values, counts, expiration times and tags, so that a dictionary that's
+// usually served frozen can be edited and frozen again without keeping a
+// mutable copy around in between. The Trie is configured with the options of
+// the Trie that was frozen that carry over to a FrozenTrie, followed by opts,
+// which can add options like MaxKeys or TrigramIndex that don't carry over.
+func (f *FrozenTrie) Thaw(opts ...Option) *Trie {
+	t := New(PreprocessQueries(f.t.preprocessors...), AdaptiveDistance(f.t.policy))
+	t.codec, t.transform, t.tokenizer, t.decay = f.t.codec, f.t.transform, f.t.tokenizer, f.t.decay
+	t.damerau = f.t.damerau
+	for tag, bit := range f.t.tagBits {
+		if t.tagBits == nil {
+			t.tagBits = make(map[string]uint64, len(f.t.tagBits))
+		}
+		t.tagBits[tag] = bit
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	// Keys are added in sorted order, so a cursor only follows the part of
+	// each key that differs from the previous one.
+	c := newCursor(t.ensureRoot())
+	for stack := []int32{f.root}; len(stack) > 0; {
+		var n int32
+		n, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		x := &f.nodes[n]
+		for i := x.first + x.count - 1; i >= x.first; i-- {
+			stack = append(stack, i)
+		}
+		if x.entry < 0 || !f.entries[x.entry].live() {
+			continue
+		}
+		t.restore(c, &f.entries[x.entry], f.t.decode)
+	}
+	return t
+}
+
+// restore adds the key of src to t with its values, passed through decode,
+// and its count, expiration time and tags, using c to find its node. Keys
+// restored with the same cursor must come in sorted order.
+func (t *Trie) restore(c *cursor, src *entry, decode func(string) string) {
+	e := t.upsertAt(c.seek(src.key, true), src.key)
+	if len(src.values) == 1 {
+		e.values = t.single(decode(src.values[0]))
+	} else if len(src.values) > 1 {
+		e.values = make([]string, len(src.values))
+		for i, v := range src.values {
+			e.values[i] = t.retain(t.encode(decode(v)))
+		}
+	}
+	e.count, e.payload, e.expires, e.boosted = src.count, src.payload, src.expires, src.boosted
+	if src.tags != 0 {
+		e.tags = src.tags
+		t.retag(src.key)
+	}
+	if t.maxKeys > 0 && t.size >= t.maxKeys {
+		// Eviction may have removed nodes on the path.
+		c.reset()
+	}
+}
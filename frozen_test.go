@@ -0,0 +1,131 @@
+package levtrie
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFreezeMatchesTrie(t *testing.T) {
+	rand.Seed(0)
+	keys := generateEdits(6, 300)
+	r := New()
+	for i, key := range keys {
+		r.Set(key, strings.ToLower(key))
+		r.IncrBy(key, int64(i%7))
+		if i%3 == 0 {
+			r.SetTags(key, "third")
+		}
+	}
+	f := r.Freeze()
+	if f.Len() != r.Len() || len(f.nodes) != r.Stats().Nodes {
+		t.Errorf("Got %v keys and %v nodes, want %v and %v", f.Len(), len(f.nodes), r.Len(), r.Stats().Nodes)
+	}
+	for _, key := range keys[:50] {
+		if got, _ := f.Get(key); got != strings.ToLower(key) || f.Count(key) != r.Count(key) || !f.Has(key) {
+			t.Errorf("Got %v, %v for %v, want the values from the Trie", got, f.Count(key), key)
+		}
+	}
+	optss := [][]SuggestOption{nil, {Ordered()}, {ByWeight()}, {WithTags("third")}, {EditCosts(1, 2, 1)}}
+	for _, key := range generateEdits(6, 30) {
+		for _, opts := range optss {
+			if got, want := keystr(f.Suggest(key, 2, 1000, opts...)), keystr(r.Suggest(key, 2, 1000, opts...)); got != want {
+				t.Errorf("Suggest(%v) = %v, want %v", key, got, want)
+			}
+			if got, want := keystr(f.SuggestSuffixes(key[:4], 1, 1000, opts...)), keystr(r.SuggestSuffixes(key[:4], 1, 1000, opts...)); got != want {
+				t.Errorf("SuggestSuffixes(%v) = %v, want %v", key[:4], got, want)
+			}
+			if got, want := keystr(f.SuggestAfterExactPrefix(key, 2, 2, 1000, opts...)), keystr(r.SuggestAfterExactPrefix(key, 2, 2, 1000, opts...)); got != want {
+				t.Errorf("SuggestAfterExactPrefix(%v) = %v, want %v", key, got, want)
+			}
+		}
+		// The order of Ordered results doesn't depend on the layout.
+		if got, want := ukeystr(f.Suggest(key, 2, 10, ByWeight())), ukeystr(r.Suggest(key, 2, 10, ByWeight())); got != want {
+			t.Errorf("ByWeight Suggest(%v) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	clock = func() time.Time { return now }
+	defer func() { clock = time.Now }()
+	r := New(PreprocessQueries(LowercaseQuery), CompressValues(4, nil))
+	r.Add("tea", "green tea")
+	r.Add("tea", "black tea")
+	r.Set("ten", "10")
+	r.SetWithTTL("to", "2", time.Minute)
+	r.SetWithTTL("toe", "gone", -time.Minute)
+	f := r.Freeze()
+	r.Delete("tea")
+	if got := strings.Join(f.Values("TEA"), ","); got != "green tea,black tea" {
+		t.Errorf("Values = %v, want the values at the time of Freeze", got)
+	}
+	if f.Has("toe") || f.Len() != 3 {
+		t.Errorf("Got %v keys, want 3 without the expired key", f.Len())
+	}
+	// Nodes that only led to expired keys are left out.
+	if got, want := len(f.nodes), 6; got != want {
+		t.Errorf("Got %v nodes, want %v", got, want)
+	}
+	if got, want := keystr(f.SuggestSuffixesAfterExactPrefix("Tx", 1, 1, 10, ValuesPerKey(1))), "tea ten to"; got != want {
+		t.Errorf("SuggestSuffixesAfterExactPrefix = %v, want %v", got, want)
+	}
+	now = now.Add(2 * time.Minute)
+	if got, want := keystr(f.Suggest("to", 2, 10, ValuesPerKey(1))), "tea ten"; got != want {
+		t.Errorf("Suggest = %v after to expired, want %v", got, want)
+	}
+	if f.SuggestAfterExactPrefix("xyz", 2, 1, 10) != nil {
+		t.Error("SuggestAfterExactPrefix found keys after a missing prefix")
+	}
+	if e := New().Freeze(); e.Len() != 0 || len(e.Suggest("a", 1, 10)) != 0 {
+		t.Error("Frozen empty Trie isn't empty")
+	}
+}
+
+func TestThaw(t *testing.T) {
+	rand.Seed(0)
+	keys := generateEdits(5, 200)
+	r := New(InternValues(), CompressValues(4, nil))
+	for i, key := range keys {
+		r.Add(key, "value "+key)
+		if i%2 == 0 {
+			r.Add(key, "other")
+		}
+		r.IncrBy(key, int64(i))
+		if i%5 == 0 {
+			r.SetTags(key, "fifth")
+		}
+	}
+	r.Incr("counted only")
+	thawed := r.Freeze().Thaw(TrigramIndex(0.3), MaxKeys(1000))
+	if err := thawed.ValidateInvariants(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := thawed.ToMap(), r.ToMap(); len(got) != len(want) {
+		t.Errorf("Thawed Trie has %v keys, want %v", len(got), len(want))
+	}
+	for _, key := range append(keys, "counted only") {
+		if got, want := strings.Join(thawed.Values(key), ","), strings.Join(r.Values(key), ","); got != want {
+			t.Errorf("Values(%v) = %v, want %v", key, got, want)
+		}
+		if thawed.Count(key) != r.Count(key) || strings.Join(thawed.Tags(key), ",") != strings.Join(r.Tags(key), ",") {
+			t.Errorf("Got a count of %v and tags %v for %v, want %v and %v", thawed.Count(key), thawed.Tags(key), key, r.Count(key), r.Tags(key))
+		}
+	}
+	if got, want := keystr(thawed.Suggest(keys[0], 2, 1000, WithTags("fifth"))), keystr(r.Suggest(keys[0], 2, 1000, WithTags("fifth"))); got != want {
+		t.Errorf("Suggest = %v, want %v", got, want)
+	}
+	// The thawed Trie can be changed without changing the FrozenTrie.
+	f := thawed.Freeze()
+	thawed.Remove(keys[0], "other")
+	thawed.Delete(keys[1])
+	if !f.Has(keys[1]) || len(f.Values(keys[0])) != 2 {
+		t.Error("Changing the thawed Trie changed the FrozenTrie")
+	}
+	small := f.Thaw(MaxKeys(10))
+	if err := small.ValidateInvariants(); err != nil || small.Len() != 10 {
+		t.Errorf("Got %v keys and error %v, want 10 keys", small.Len(), err)
+	}
+}
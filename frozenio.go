@@ -0,0 +1,368 @@
+package levtrie
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The file format of a FrozenTrie starts with frozenMagic and is followed by
+// records, each starting with one of the bytes below, in the order a
+// FrozenBuilder produces them. Node and entry records are numbered in the
+// order they appear, and nodes refer to their children and entries by those
+// numbers. Integers are varints as encoding/binary writes them and strings
+// are a length followed by their bytes.
+const (
+	frozenMagic = "levtrie frozen 1\n"
+	// A node: its label, entry+1, first child, number of children and
+	// tags.
+	frozenNodeRecord = 'n'
+	// An entry: its key, number of values, values, count, expiration time
+	// and tags. Values are stored as they're returned, without
+	// CompressValues.
+	frozenEntryRecord = 'e'
+	// A tag and its bit, see SetTags.
+	frozenTagRecord = 't'
+	// The last record: the number of the root node and the number of
+	// keys.
+	frozenEndRecord = 'z'
+)
+
+var errFrozenFormat = errors.New("levtrie: malformed FrozenTrie file")
+
+// FrozenBuilder writes the file format of a FrozenTrie, which ReadFrozenTrie
+// reads, from keys added in sorted order, without building a Trie or a
+// FrozenTrie first. A FrozenTrie lays out its nodes in the order a walk of
+// the keys in sorted order finishes them, so once a key is added, the nodes
+// for the part of the previous key that it doesn't share are finished and
+// written out right away. Only the nodes on the path to the last key added
+// are held in memory, so the memory a FrozenBuilder uses depends on the
+// length of the keys and the number of children of the nodes on that path,
+// not on the number of keys, and a file for far more keys than fit in memory
+// can be built from a sorted stream of them. Serving the file still takes
+// about its size in memory, since its records vary in length and can't be
+// mapped into memory and searched in place; see ReadFrozenTrie. Don't create
+// directly, use NewFrozenBuilder() instead.
+type FrozenBuilder struct {
+	w       *bufio.Writer
+	buf     []byte
+	prev    string
+	runes   []rune // The runes of prev.
+	started bool
+	path    []frozenItem // The unfinished nodes on the path to prev.
+	nodes   int          // The number of node records written.
+	entries int          // The number of entry records written.
+	err     error        // The first error returned by w.
+}
+
+// frozenItem is an unfinished node of a FrozenBuilder.
+type frozenItem struct {
+	self     frozenNode
+	e        *entry       // The node's entry, or nil if it has none.
+	children []frozenNode // The finished children of the node.
+}
+
+// NewFrozenBuilder returns a FrozenBuilder that writes to w.
+func NewFrozenBuilder(w io.Writer) *FrozenBuilder {
+	b := &FrozenBuilder{w: bufio.NewWriter(w)}
+	_, b.err = b.w.WriteString(frozenMagic)
+	b.path = []frozenItem{{self: frozenNode{entry: -1}}}
+	return b
+}
+
+// Add adds key with the given count and values, which are stored in order as
+// Add and Incr would store them in a Trie. Keys must be added in strictly
+// increasing order, and Add returns an error if key doesn't come after the
+// previous key, or if writing fails.
+func (b *FrozenBuilder) Add(key string, count int64, values ...string) error {
+	if b.err != nil {
+		return b.err
+	}
+	runes := extractRunes(key)
+	if b.started && key <= b.prev {
+		return fmt.Errorf("levtrie: frozen key %q added after %q", key, b.prev)
+	}
+	p := 0
+	for p < len(runes) && p < len(b.runes) && runes[p] == b.runes[p] {
+		p++
+	}
+	b.finish(p + 1)
+	for _, r := range runes[p:] {
+		b.path = append(b.path, frozenItem{self: frozenNode{label: r, entry: -1}})
+	}
+	b.path[len(b.path)-1].e = &entry{key: key, values: append([]string(nil), values...), count: count}
+	b.prev, b.runes, b.started = key, runes, true
+	return b.err
+}
+
+// finish finishes and writes the nodes on the path to the previous key below
+// depth, leaving depth nodes on the path.
+func (b *FrozenBuilder) finish(depth int) {
+	for len(b.path) > depth {
+		self := b.close(&b.path[len(b.path)-1])
+		b.path = b.path[:len(b.path)-1]
+		parent := &b.path[len(b.path)-1]
+		parent.children = append(parent.children, self)
+	}
+}
+
+// close writes out the children and entry of x and returns x's node.
+func (b *FrozenBuilder) close(x *frozenItem) frozenNode {
+	x.self.first, x.self.count = int32(b.nodes), int32(len(x.children))
+	for _, c := range x.children {
+		b.writeNode(c)
+		x.self.tags |= c.tags
+	}
+	if x.e != nil {
+		x.self.entry = int32(b.entries)
+		b.writeEntry(x.e, func(v string) string { return v })
+	}
+	return x.self
+}
+
+// Finish writes out the remaining nodes and the end of the file, and returns
+// the first error encountered while writing. The FrozenBuilder can't be used
+// after Finish.
+func (b *FrozenBuilder) Finish() error {
+	if b.err != nil {
+		return b.err
+	}
+	b.finish(1)
+	root := b.close(&b.path[0])
+	b.writeNode(root)
+	b.writeEnd(b.nodes-1, b.entries)
+	if b.err == nil {
+		b.err = b.w.Flush()
+	}
+	b.path = nil
+	return b.err
+}
+
+func (b *FrozenBuilder) writeNode(n frozenNode) {
+	b.buf = append(b.buf[:0], frozenNodeRecord)
+	b.buf = binary.AppendVarint(b.buf, int64(n.label))
+	b.buf = binary.AppendUvarint(b.buf, uint64(n.entry+1))
+	b.buf = binary.AppendUvarint(b.buf, uint64(n.first))
+	b.buf = binary.AppendUvarint(b.buf, uint64(n.count))
+	b.buf = binary.AppendUvarint(b.buf, n.tags)
+	b.write()
+	b.nodes++
+}
+
+// writeEntry writes e, with each of its values passed through decode.
+func (b *FrozenBuilder) writeEntry(e *entry, decode func(string) string) {
+	b.buf = append(b.buf[:0], frozenEntryRecord)
+	b.buf = appendString(b.buf, e.key)
+	b.buf = binary.AppendUvarint(b.buf, uint64(len(e.values)))
+	for _, v := range e.values {
+		b.buf = appendString(b.buf, decode(v))
+	}
+	b.buf = binary.AppendVarint(b.buf, e.count)
+	b.buf = binary.AppendVarint(b.buf, e.expires)
+	b.buf = binary.AppendUvarint(b.buf, e.tags)
+	b.write()
+	b.entries++
+}
+
+func (b *FrozenBuilder) writeTag(tag string, bit uint64) {
+	b.buf = append(b.buf[:0], frozenTagRecord)
+	b.buf = appendString(b.buf, tag)
+	b.buf = binary.AppendUvarint(b.buf, bit)
+	b.write()
+}
+
+func (b *FrozenBuilder) writeEnd(root, size int) {
+	b.buf = append(b.buf[:0], frozenEndRecord)
+	b.buf = binary.AppendUvarint(b.buf, uint64(root))
+	b.buf = binary.AppendUvarint(b.buf, uint64(size))
+	b.write()
+}
+
+func (b *FrozenBuilder) write() {
+	if b.err == nil {
+		_, b.err = b.w.Write(b.buf)
+	}
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// WriteTo writes the FrozenTrie to w in the format that ReadFrozenTrie reads,
+// including the counts, expiration times and tags of its keys, and returns
+// the number of bytes written. With DecayWeights, counts are written as
+// they've decayed to the time they're written.
+func (f *FrozenTrie) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	b := NewFrozenBuilder(cw)
+	for _, n := range f.nodes {
+		b.writeNode(n)
+	}
+	now := f.t.decay.now()
+	for i := range f.entries {
+		e := &f.entries[i]
+		if f.t.decay != nil {
+			// Write the count as of now, see DecayWeights.
+			decayed := *e
+			decayed.count = f.t.decay.count(e, now)
+			e = &decayed
+		}
+		b.writeEntry(e, f.t.decode)
+	}
+	for _, tag := range f.t.sortedTags() {
+		b.writeTag(tag, f.t.tagBits[tag])
+	}
+	b.writeEnd(int(f.root), f.t.size)
+	if b.err == nil {
+		b.err = b.w.Flush()
+	}
+	return cw.n, b.err
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ReadFrozenTrie reads a FrozenTrie written by a FrozenBuilder or by
+// FrozenTrie.WriteTo from r. Its reads use the given options, like
+// CompressValues, TransformValues, AdaptiveDistance and PreprocessQueries,
+// as if it had been frozen from a Trie with them. The whole FrozenTrie is
+// read into memory, where it takes about as much space as the file.
+func ReadFrozenTrie(r io.Reader, opts ...Option) (_ *FrozenTrie, err error) {
+	f := &FrozenTrie{t: &Trie{}}
+	for _, opt := range opts {
+		opt(f.t)
+	}
+	l, r := startRead(f.t.progress, r)
+	defer func() { l.finish(err) }()
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(frozenMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != frozenMagic {
+		return nil, errFrozenFormat
+	}
+	fr := frozenReader{r: br}
+	for {
+		kind, err := br.ReadByte()
+		if err != nil {
+			return nil, errFrozenFormat
+		}
+		switch kind {
+		case frozenNodeRecord:
+			n := frozenNode{label: rune(fr.varint())}
+			n.entry = int32(fr.uvarint()) - 1
+			n.first, n.count = int32(fr.uvarint()), int32(fr.uvarint())
+			n.tags = fr.uvarint()
+			f.nodes = append(f.nodes, n)
+		case frozenEntryRecord:
+			e := entry{key: fr.string()}
+			if k := fr.uvarint(); k > 0 && fr.err == nil {
+				// A corrupt count could be huge, so it's clamped as a
+				// uint64, before it can overflow an int.
+				c := k
+				if c > 1024 {
+					c = 1024
+				}
+				e.values = make([]string, 0, c)
+				for i := uint64(0); i < k && fr.err == nil; i++ {
+					e.values = append(e.values, f.t.encode(fr.string()))
+				}
+			}
+			e.count, e.expires, e.tags = fr.varint(), fr.varint(), fr.uvarint()
+			f.entries = append(f.entries, e)
+			l.add(1, 0)
+		case frozenTagRecord:
+			tag, bit := fr.string(), fr.uvarint()
+			if f.t.tagBits == nil {
+				f.t.tagBits = make(map[string]uint64)
+			}
+			f.t.tagBits[tag] = bit
+		case frozenEndRecord:
+			f.root, f.t.size = int32(fr.uvarint()), int(fr.uvarint())
+			if fr.err != nil || !f.valid() {
+				return nil, errFrozenFormat
+			}
+			return f, nil
+		default:
+			return nil, errFrozenFormat
+		}
+		if fr.err != nil {
+			return nil, errFrozenFormat
+		}
+	}
+}
+
+// valid returns true if the nodes of f refer to nodes and entries it has and
+// its root is its last node, so that searches of it stay in bounds.
+func (f *FrozenTrie) valid() bool {
+	if len(f.nodes) == 0 || int(f.root) != len(f.nodes)-1 {
+		return false
+	}
+	for i, n := range f.nodes {
+		// Children are written before their parent, so a walk of the
+		// nodes always ends.
+		if n.entry < -1 || int(n.entry) >= len(f.entries) || n.first < 0 || n.count < 0 ||
+			int64(n.first)+int64(n.count) > int64(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// frozenReader reads the fields of a record, keeping the first error.
+type frozenReader struct {
+	r   *bufio.Reader
+	buf []byte // Scratch space for bytes.
+	err error
+}
+
+func (fr *frozenReader) uvarint() uint64 {
+	if fr.err != nil {
+		return 0
+	}
+	var x uint64
+	x, fr.err = binary.ReadUvarint(fr.r)
+	return x
+}
+
+func (fr *frozenReader) varint() int64 {
+	if fr.err != nil {
+		return 0
+	}
+	var x int64
+	x, fr.err = binary.ReadVarint(fr.r)
+	return x
+}
+
+func (fr *frozenReader) string() string {
+	return string(fr.bytes())
+}
+
+// bytes reads a string into scratch space and returns it, so it's only valid
+// until the next call.
+func (fr *frozenReader) bytes() []byte {
+	n := fr.uvarint()
+	fr.buf = fr.buf[:0]
+	// Read in chunks, so a corrupt length doesn't allocate a huge buffer.
+	for n > 0 && fr.err == nil {
+		chunk := uint64(1 << 16)
+		if n < chunk {
+			chunk = n
+		}
+		start := len(fr.buf)
+		fr.buf = append(fr.buf, make([]byte, chunk)...)
+		_, fr.err = io.ReadFull(fr.r, fr.buf[start:])
+		n -= chunk
+	}
+	return fr.buf
+}
@@ -0,0 +1,145 @@
+package levtrie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestFrozenBuilder(t *testing.T) {
+	rand.Seed(0)
+	keys := generateEdits(6, 300)
+	sort.Strings(keys)
+	r := New()
+	var buf bytes.Buffer
+	b := NewFrozenBuilder(&buf)
+	for i, key := range keys {
+		if i > 0 && key == keys[i-1] {
+			continue
+		}
+		r.Add(key, key)
+		r.Add(key, key+"!")
+		r.IncrBy(key, int64(i%7))
+		if err := b.Add(key, int64(i%7), key, key+"!"); err != nil {
+			t.Fatalf("Add(%v) = %v", key, err)
+		}
+	}
+	if err := b.Finish(); err != nil {
+		t.Fatalf("Finish() = %v", err)
+	}
+	got, err := ReadFrozenTrie(&buf)
+	if err != nil {
+		t.Fatalf("ReadFrozenTrie() = %v", err)
+	}
+	want := r.Freeze()
+	if got.Len() != want.Len() || !reflect.DeepEqual(got.nodes, want.nodes) || !reflect.DeepEqual(got.entries, want.entries) {
+		t.Errorf("Built a FrozenTrie with %v keys and %v nodes, want the %v keys and %v nodes from Freeze", got.Len(), len(got.nodes), want.Len(), len(want.nodes))
+	}
+	for _, key := range generateEdits(6, 20) {
+		if g, w := keystr(got.Suggest(key, 2, 1000, ByWeight())), keystr(want.Suggest(key, 2, 1000, ByWeight())); g != w {
+			t.Errorf("Suggest(%v) = %v, want %v", key, g, w)
+		}
+	}
+}
+
+func TestFrozenBuilderErrors(t *testing.T) {
+	b := NewFrozenBuilder(&bytes.Buffer{})
+	if err := b.Add("b", 0); err != nil {
+		t.Errorf("Add(b) = %v", err)
+	}
+	for _, key := range []string{"a", "b"} {
+		if err := b.Add(key, 0); err == nil {
+			t.Errorf("Add(%v) after b succeeded", key)
+		}
+	}
+	var buf bytes.Buffer
+	if err := NewFrozenBuilder(&buf).Finish(); err != nil {
+		t.Errorf("Finish() on an empty FrozenBuilder = %v", err)
+	}
+	if f, err := ReadFrozenTrie(&buf); err != nil || f.Len() != 0 || len(f.Suggest("a", 1, 10)) != 0 {
+		t.Errorf("ReadFrozenTrie() of an empty FrozenTrie = %v, %v", f, err)
+	}
+	fail := errors.New("full")
+	b = NewFrozenBuilder(failingWriter{fail})
+	for i := 0; i < 10000 && b.Add(strings.Repeat("x", i), 0) == nil; i++ {
+	}
+	if err := b.Finish(); err != fail {
+		t.Errorf("Finish() with a failing writer = %v, want %v", err, fail)
+	}
+}
+
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write(p []byte) (int, error) { return 0, w.err }
+
+func TestFrozenWriteTo(t *testing.T) {
+	r := New(CompressValues(4, nil))
+	for _, key := range []string{"cat", "cot", "coat", "dog", ""} {
+		r.Set(key, strings.Repeat(key, 10))
+	}
+	r.Set("expired", "x")
+	r.SetTags("cat", "pet")
+	r.SetTags("dog", "pet", "loud")
+	f := r.Freeze()
+	var buf bytes.Buffer
+	n, err := f.WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatalf("WriteTo() = %v, %v, want %v bytes", n, err, buf.Len())
+	}
+	data := buf.Bytes()
+	got, err := ReadFrozenTrie(bytes.NewReader(data), CompressValues(4, nil))
+	if err != nil {
+		t.Fatalf("ReadFrozenTrie() = %v", err)
+	}
+	if !reflect.DeepEqual(got.nodes, f.nodes) || got.Len() != f.Len() || !reflect.DeepEqual(got.t.tagBits, f.t.tagBits) {
+		t.Errorf("ReadFrozenTrie() doesn't match the FrozenTrie written")
+	}
+	if v, _ := got.Get("coat"); v != strings.Repeat("coat", 10) {
+		t.Errorf("Get(coat) = %v, want %v", v, strings.Repeat("coat", 10))
+	}
+	if g, w := keystr(got.Suggest("cot", 1, 10, WithTags("pet"))), "cat"; g != w {
+		t.Errorf("Suggest(cot, pet) = %v, want %v", g, w)
+	}
+	// Every truncation and single-byte corruption is either rejected or
+	// reads as a FrozenTrie that can be searched.
+	for i := range data {
+		if _, err := ReadFrozenTrie(bytes.NewReader(data[:i])); err == nil {
+			t.Errorf("ReadFrozenTrie() of %v bytes succeeded", i)
+		}
+		bad := append([]byte(nil), data...)
+		bad[i] ^= 0x5a
+		if f, err := ReadFrozenTrie(bytes.NewReader(bad)); err == nil {
+			f.Suggest("cot", 2, 10)
+			f.SuggestSuffixes("", 3, 10)
+		}
+	}
+}
+
+func FuzzReadFrozenTrie(f *testing.F) {
+	r := New()
+	for _, key := range []string{"cat", "cot", "coat", "dog", ""} {
+		r.Set(key, key)
+	}
+	r.SetTags("cat", "pet")
+	var buf bytes.Buffer
+	r.Freeze().WriteTo(&buf)
+	f.Add(buf.Bytes())
+	// Lengths and counts too big for an int.
+	huge := binary.AppendUvarint(nil, 1<<63)
+	f.Add(append([]byte(frozenMagic+"e"), huge...))
+	f.Add(append([]byte(frozenMagic+"e\x00"), huge...))
+	f.Add(append([]byte(frozenMagic+"e\x00"), binary.AppendUvarint(nil, 1<<64-1)...))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Corrupt input is either rejected or reads as a FrozenTrie
+		// that can be searched, without panicking.
+		if g, err := ReadFrozenTrie(bytes.NewReader(data)); err == nil {
+			g.Suggest("cot", 2, 10)
+			g.SuggestSuffixes("", 3, 10)
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package levtrie
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+var (
+	_ gob.GobEncoder = (*Trie)(nil)
+	_ gob.GobDecoder = (*Trie)(nil)
+	_ gob.GobEncoder = (*SyncTrie)(nil)
+	_ gob.GobDecoder = (*SyncTrie)(nil)
+)
+
+// GobEncode implements gob.GobEncoder, so that a Trie, or a value that holds
+// one, can be written with encoding/gob and read back without adding its keys
+// one at a time. It encodes the live keys of the Trie along with their values,
+// counts, expiration times and tags, in the format that Save writes, so it
+// walks the entire Trie. Options aren't encoded, since some of them are
+// functions: a Trie decoded from the result has the options it was made with.
+func (t *Trie) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.Save(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder. It replaces the keys of the Trie with
+// the ones encoded by GobEncode, keeping the Trie's options, so a Trie made
+// with New and options like TrigramIndex or CompressValues can be decoded
+// into, as can the zero Trie that encoding/gob allocates for a *Trie field.
+// Like RemapKeys, it reports OpClear to the OnChange hooks of the Trie,
+// followed by the changes that add each key. If data is malformed, GobDecode
+// returns an error and leaves the Trie alone.
+func (t *Trie) GobDecode(data []byte) error {
+	// Check all of data before the Trie is changed.
+	nop := func(e *entry) {}
+	if err := readSaved(bytes.NewReader(data), func(string, uint64) {}, nop); err != nil {
+		return err
+	}
+	t.Clear()
+	if err := t.load(bytes.NewReader(data), nil); err != nil {
+		return err
+	}
+	if len(t.hooks) > 0 {
+		expandSuffixes(t.tree(), func(e *entry) bool {
+			t.replay(e)
+			return true
+		})
+	}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder. See Trie.GobEncode.
+func (s *SyncTrie) GobEncode() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.GobEncode()
+}
+
+// GobDecode implements gob.GobDecoder. See Trie.GobDecode. Decoding into the
+// zero SyncTrie that encoding/gob allocates for a *SyncTrie field gives it a
+// Trie without options.
+func (s *SyncTrie) GobDecode(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.t == nil {
+		s.t = New()
+	}
+	return s.t.GobDecode(data)
+}
@@ -0,0 +1,118 @@
+package levtrie
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGob(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	clock = func() time.Time { return now }
+	defer func() { clock = time.Now }()
+	r := New(CompressValues(4, nil))
+	keys := generateEdits(6, 200)
+	for i, key := range keys {
+		r.Set(key, strings.ToLower(key))
+		r.IncrBy(key, int64(i%5))
+		if i%4 == 0 {
+			r.SetTags(key, "fourth")
+		}
+	}
+	r.Add("tea", "green tea")
+	r.Add("tea", "black tea")
+	r.SetWithTTL("to", "2", time.Minute)
+	r.SetWithTTL("toe", "gone", -time.Minute)
+	// A Trie can be encoded on its own or as a field.
+	type dictionary struct {
+		Name string
+		Trie *Trie
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(dictionary{Name: "words", Trie: r}); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	var d dictionary
+	if err := gob.NewDecoder(&buf).Decode(&d); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	g := d.Trie
+	if d.Name != "words" || g.Len() != r.Len()-1 {
+		t.Fatalf("Decoded %q with %v keys, want words with %v", d.Name, g.Len(), r.Len()-1)
+	}
+	if !reflect.DeepEqual(g.ToMap(), r.ToMap()) {
+		t.Errorf("ToMap() = %v, want %v", g.ToMap(), r.ToMap())
+	}
+	if got := g.Values("tea"); !reflect.DeepEqual(got, []string{"green tea", "black tea"}) {
+		t.Errorf("Values(tea) = %v, want [green tea black tea]", got)
+	}
+	for _, key := range keys[:50] {
+		if g.Count(key) != r.Count(key) || !reflect.DeepEqual(g.Tags(key), r.Tags(key)) {
+			t.Errorf("Got count %v and tags %v for %v, want %v and %v", g.Count(key), g.Tags(key), key, r.Count(key), r.Tags(key))
+		}
+	}
+	if got, want := keystr(g.Suggest(keys[0], 2, 1000, WithTags("fourth"))), keystr(r.Suggest(keys[0], 2, 1000, WithTags("fourth"))); got != want {
+		t.Errorf("Suggest with WithTags = %v, want %v", got, want)
+	}
+	now = now.Add(2 * time.Minute)
+	if g.Has("to") {
+		t.Errorf("Has(to) = true after its TTL passed")
+	}
+	if err := g.ValidateInvariants(); err != nil {
+		t.Errorf("ValidateInvariants() = %v", err)
+	}
+}
+
+func TestGobDecodeKeepsOptions(t *testing.T) {
+	r := New()
+	r.Set("hello", "1")
+	r.Set("help", "2")
+	data, err := r.GobEncode()
+	if err != nil {
+		t.Fatalf("GobEncode: %v", err)
+	}
+	g := New(TrigramIndex(0.1), PreprocessQueries(LowercaseQuery))
+	g.Set("stale", "3")
+	var ops []Op
+	g.OnChange(func(op Op) { ops = append(ops, op) })
+	if err := g.GobDecode(data); err != nil {
+		t.Fatalf("GobDecode: %v", err)
+	}
+	if got := keystr(g.Suggest("HELO", 1, 10)); got != "hello help" {
+		t.Errorf("Suggest(HELO) = %v, want hello help", got)
+	}
+	if g.Has("stale") {
+		t.Errorf("Has(stale) = true, want the keys replaced")
+	}
+	if len(ops) != 3 || ops[0].Kind != OpClear {
+		t.Errorf("Got ops %v, want a clear and a set for each key", ops)
+	}
+	if err := g.ValidateInvariants(); err != nil {
+		t.Errorf("ValidateInvariants() = %v", err)
+	}
+	if err := g.GobDecode(data[:len(data)-1]); err == nil {
+		t.Errorf("GobDecode of truncated data succeeded")
+	}
+	if g.Len() != 2 {
+		t.Errorf("Got %v keys after a failed GobDecode, want 2", g.Len())
+	}
+}
+
+func TestGobSyncTrie(t *testing.T) {
+	s := NewSync()
+	s.Set("hello", "1")
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(s); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	var got *SyncTrie
+	if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if v, ok := got.Get("hello"); !ok || v != "1" {
+		t.Errorf("Get(hello) = (%v, %v), want (1, true)", v, ok)
+	}
+}
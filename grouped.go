@@ -0,0 +1,38 @@
+package levtrie
+
+// SuggestByDistance is like Suggest, but groups the results by their edit
+// distance from key, so that they can be shown in tiers like "exact", "close"
+// and "maybe" without computing each distance again. The result has d+1
+// elements and the element at index i holds the KVs whose keys are exactly
+// distance i from key, or whose edits cost exactly i with EditCosts. At most n
+// KVs are returned in total. If d is negative, the distance is chosen as in
+// Suggest and the result has an element for each distance up to the one
+// chosen.
+func (t *Trie) SuggestByDistance(key string, d int8, n int, opts ...SuggestOption) [][]KV {
+	key, ok := t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	return t.suggestByDistance(key, d, n, opts)
+}
+
+// suggestByDistance is SuggestByDistance for a key that's already been
+// preprocessed.
+func (t *Trie) suggestByDistance(key string, d int8, n int, opts []SuggestOption) [][]KV {
+	runes, cfg := extractRunes(key), t.config(key, opts)
+	d = t.distance(len(runes), d)
+	groups := make([][]KV, int(d)+1)
+	automata := make([]automaton, len(groups))
+	for _, kv := range t.suggestKey(key, d, n, opts) {
+		for i := range automata {
+			if automata[i] == nil {
+				automata[i] = newAutomaton(runes, int8(i), cfg)
+			}
+			if i == len(groups)-1 || matches(automata[i], int8(i), kv.Key) {
+				groups[i] = append(groups[i], kv)
+				break
+			}
+		}
+	}
+	return groups
+}
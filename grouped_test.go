@@ -0,0 +1,39 @@
+package levtrie
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSuggestByDistance(t *testing.T) {
+	r := New()
+	for _, key := range []string{"cat", "cot", "coat", "cart", "dog", "at", "chart"} {
+		r.Set(key, key)
+	}
+	groupstr := func(groups [][]KV) string {
+		var s []string
+		for _, g := range groups {
+			s = append(s, "["+keystr(g)+"]")
+		}
+		return strings.Join(s, " ")
+	}
+	for _, test := range []struct {
+		key  string
+		d    int8
+		n    int
+		opts []SuggestOption
+		want string
+	}{
+		{"cat", 0, 10, nil, "[cat]"},
+		{"cat", 1, 10, nil, "[cat] [at cart coat cot]"},
+		{"cat", 2, 10, nil, "[cat] [at cart coat cot] [chart]"},
+		{"cat", -1, 10, nil, "[cat] [at cart coat cot]"},
+		{"dgo", 2, 10, nil, "[] [] [dog]"},
+		{"cat", 3, 1, nil, "[cat] [] [] []"},
+		{"cat", 2, 10, []SuggestOption{EditCosts(1, 2, 2)}, "[cat] [cart coat] [at chart cot]"},
+	} {
+		if got := groupstr(r.SuggestByDistance(test.key, test.d, test.n, test.opts...)); got != test.want {
+			t.Errorf("SuggestByDistance(%q, %v, %v) = %v, want %v", test.key, test.d, test.n, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package levtrie
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+)
+
+// Hash returns a SHA-256 digest of the live keys in the Trie and their values,
+// in sorted order, so that replicas can check that they hold the same
+// dictionary by comparing 32 bytes. Two tries have the same Hash exactly when
+// they have the same keys with the same values in the same order, no matter
+// how they were built or what options they were created with: values are
+// hashed as they were set, before CompressValues or TransformValues, and
+// counts, expiration times and tags aren't hashed at all. Hash walks the
+// entire Trie.
+func (t *Trie) Hash() [32]byte {
+	h := sha256.New()
+	it := t.Iterator()
+	for ok := it.Valid(); ok; ok = it.Next() {
+		hashEntry(h, it.e, t.decode)
+	}
+	var sum [32]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// Hash returns the same digest as Trie.Hash for the live keys in the
+// FrozenTrie, so a FrozenTrie can be checked against the Trie it was frozen
+// from or against another replica.
+func (f *FrozenTrie) Hash() [32]byte {
+	h := sha256.New()
+	for stack := []int32{f.root}; len(stack) > 0; {
+		var n int32
+		n, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		x := &f.nodes[n]
+		if x.entry >= 0 && f.entries[x.entry].live() {
+			hashEntry(h, &f.entries[x.entry], f.t.decode)
+		}
+		for i := x.first + x.count - 1; i >= x.first; i-- {
+			stack = append(stack, i)
+		}
+	}
+	var sum [32]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// hashEntry writes the key and values of e to h. Every string is preceded by
+// its length and the values by their number, so that different entries, or
+// different sequences of entries, never write the same bytes.
+func hashEntry(h hash.Hash, e *entry, decode func(string) string) {
+	var buf [binary.MaxVarintLen64]byte
+	write := func(s string) {
+		h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(s)))])
+		h.Write([]byte(s))
+	}
+	write(e.key)
+	h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(e.values)))])
+	for _, v := range e.values {
+		write(decode(v))
+	}
+}
@@ -0,0 +1,51 @@
+package levtrie
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestHash(t *testing.T) {
+	rand.Seed(0)
+	keys := generateEdits(5, 300)
+	a := New()
+	b := New(CompressValues(2, nil), InternValues(), MaxKeys(1000))
+	for i, key := range keys {
+		a.Set(key, key)
+		b.Set(keys[len(keys)-1-i], keys[len(keys)-1-i])
+	}
+	b.Incr(keys[0])
+	b.SetTags(keys[1], "tag")
+	if a.Hash() != b.Hash() || a.Hash() != b.Freeze().Hash() {
+		t.Error("Tries with the same keys and values have different hashes")
+	}
+	if New().Hash() != New().Freeze().Hash() {
+		t.Error("Empty tries have different hashes")
+	}
+	before := a.Hash()
+	for _, change := range []func(r *Trie){
+		func(r *Trie) { r.Set(keys[0], "changed") },
+		func(r *Trie) { r.Add(keys[0], "another") },
+		func(r *Trie) { r.Delete(keys[0]) },
+		func(r *Trie) { r.Set("new key", keys[0]) },
+		func(r *Trie) { r.Rename(keys[0], keys[0]+"x") },
+		func(r *Trie) { r.SetWithTTL(keys[0], keys[0], -time.Second) },
+	} {
+		r := New()
+		for _, key := range keys {
+			r.Set(key, key)
+		}
+		change(r)
+		if r.Hash() == before {
+			t.Error("A change to the Trie didn't change its hash")
+		}
+	}
+	// Moving bytes between keys and values changes the hash.
+	x, y := New(), New()
+	x.Set("ab", "c")
+	y.Set("a", "bc")
+	if x.Hash() == y.Hash() {
+		t.Error("Different KVs with the same bytes have the same hash")
+	}
+}
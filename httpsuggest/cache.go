@@ -0,0 +1,105 @@
+package httpsuggest
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aaw/levtrie"
+)
+
+// source is a Backend being served, along with the number of times it has
+// changed. The hook that counts changes is registered once, when the handler
+// that serves the Backend is built or when a Dictionary swaps it in, since
+// OnChange can't be called on a Trie while other handlers are using it.
+type source struct {
+	b Backend
+	// gen is incremented by the hook registered with b whenever b changes.
+	// It's atomic so that the hook doesn't need a lock, since a SyncTrie
+	// calls hooks while it's locked.
+	gen    atomic.Uint64
+	remove func() // Unregisters the hook registered with b.
+}
+
+// watch returns a source for b.
+func watch(b Backend) *source {
+	s := &source{b: b}
+	s.remove = b.OnChange(func(levtrie.Op) { s.gen.Add(1) })
+	return s
+}
+
+// resultCache is an LRU cache of the results of queries on a Backend. All
+// cached results are invalidated whenever the Backend changes or is replaced
+// by another Backend.
+type resultCache struct {
+	mu    sync.Mutex
+	size  int
+	t     *source    // The source whose results are cached.
+	seen  uint64     // The value of t.gen when the cache was last cleared.
+	ll    *list.List // Holds *cached, most recently used first.
+	items map[string]*list.Element
+}
+
+type cached struct {
+	key     string
+	results []Result
+}
+
+func newResultCache(size int) *resultCache {
+	return &resultCache{size: size, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// use makes t the source whose results are cached and clears the cache if t
+// is new or has changed. c.mu must be held.
+func (c *resultCache) use(t *source) {
+	if t != c.t {
+		c.t, c.seen = t, t.gen.Load()
+		c.clear()
+	}
+	if gen := t.gen.Load(); gen != c.seen {
+		c.seen = gen
+		c.clear()
+	}
+}
+
+// clear removes all results from the cache. c.mu must be held.
+func (c *resultCache) clear() {
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+}
+
+// get returns the cached results of the query with the given key on t. If
+// there are none, it returns a token to pass to put along with the results.
+func (c *resultCache) get(t *source, key string) ([]Result, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.use(t)
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		return el.Value.(*cached).results, c.seen, true
+	}
+	return nil, c.seen, false
+}
+
+// put caches the results of the query with the given key on t, evicting the
+// least recently used results if the cache is full. The results aren't cached
+// if t has changed since get returned token, since they may be stale.
+func (c *resultCache) put(t *source, key string, results []Result, token uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.use(t)
+	if c.seen != token {
+		return
+	}
+	if el, ok := c.items[key]; ok {
+		el.Value.(*cached).results = results
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&cached{key: key, results: results})
+	if c.ll.Len() > c.size {
+		el := c.ll.Back()
+		c.ll.Remove(el)
+		delete(c.items, el.Value.(*cached).key)
+	}
+}
@@ -0,0 +1,90 @@
+package httpsuggest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/aaw/levtrie"
+)
+
+func TestHandlerCache(t *testing.T) {
+	tr := newTrie("hello", "help")
+	src := watch(tr)
+	h := newHandler(func() *source { return src }, Options{CacheSize: 2})
+	expectResults(t, h, "/search?q=helo&d=1&e=0", "hello", "help")
+	if _, _, ok := h.cache.get(src, cacheKey(&query{q: "helo", n: 10, d: 1})); !ok {
+		t.Errorf("Results weren't cached")
+	}
+	tr.Set("helot", "")
+	expectResults(t, h, "/search?q=helo&d=1&e=0", "hello", "help", "helot")
+	tr.Delete("help")
+	expectResults(t, h, "/search?q=helo&d=1&e=0", "hello", "helot")
+}
+
+func TestHandlerCacheConcurrent(t *testing.T) {
+	// Handlers with caches on the same Trie can be served concurrently,
+	// which go test -race checks.
+	tr := newTrie("hello", "help")
+	hs := []http.Handler{Handler(tr, Options{CacheSize: 2}), Handler(tr, Options{CacheSize: 2})}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(h http.Handler) {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", "/search?q=helo&d=1&e=0", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("Got status %v, want 200", rec.Code)
+			}
+		}(hs[i%2])
+	}
+	wg.Wait()
+}
+
+func TestResultCacheEviction(t *testing.T) {
+	tr := watch(levtrie.New())
+	c := newResultCache(2)
+	put := func(b *source, key string) {
+		_, token, _ := c.get(b, key)
+		c.put(b, key, []Result{{Key: key}}, token)
+	}
+	put(tr, "a")
+	put(tr, "b")
+	c.get(tr, "a")
+	put(tr, "c")
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, _, ok := c.get(tr, key); ok != want {
+			t.Errorf("get(%v): got %v, want %v", key, ok, want)
+		}
+	}
+	// Switching to another Backend clears the cache, and changes to the
+	// old Backend don't affect the cache anymore.
+	other := watch(levtrie.NewSync())
+	if _, _, ok := c.get(other, "a"); ok {
+		t.Errorf("Got results for a from another Backend")
+	}
+	put(other, "a")
+	tr.b.(*levtrie.Trie).Set("x", "")
+	if _, _, ok := c.get(other, "a"); !ok {
+		t.Errorf("Changing an old Backend invalidated the cache")
+	}
+	other.b.(*levtrie.SyncTrie).Set("x", "")
+	if _, _, ok := c.get(other, "a"); ok {
+		t.Errorf("Changing the Backend didn't invalidate the cache")
+	}
+}
+
+func TestResultCacheStalePut(t *testing.T) {
+	tr := levtrie.New()
+	src := watch(tr)
+	c := newResultCache(2)
+	_, token, _ := c.get(src, "a")
+	// The Trie changes while results are being computed.
+	tr.Set("a", "")
+	c.put(src, "a", []Result{}, token)
+	if _, _, ok := c.get(src, "a"); ok {
+		t.Errorf("Stale results were cached")
+	}
+}
@@ -0,0 +1,145 @@
+package httpsuggest
+
+import (
+	"bufio"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aaw/levtrie"
+)
+
+// Dictionary holds a Trie that can be reloaded while it's being served.
+// Reloading builds a new Trie in the background and atomically swaps it in
+// when it's complete, so requests in flight keep using the old Trie and no
+// request ever sees a partially loaded Trie.
+type Dictionary struct {
+	load   func() (*levtrie.Trie, error)
+	t      atomic.Pointer[source] // Holds the current *levtrie.Trie.
+	reload sync.Mutex             // Serializes reloads.
+}
+
+// NewDictionary returns a Dictionary holding the Trie returned by load. load
+// is called again each time the Dictionary is reloaded.
+func NewDictionary(load func() (*levtrie.Trie, error)) (*Dictionary, error) {
+	d := &Dictionary{load: load}
+	if err := d.Reload(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// WordListFile returns a function that loads the file at filename into a new
+// Trie, for use with NewDictionary. The file should contain a list of words,
+// one per line, which are lowercased and stored with empty values.
+func WordListFile(filename string, opts ...levtrie.Option) func() (*levtrie.Trie, error) {
+	return func() (*levtrie.Trie, error) {
+		file, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		t := levtrie.New(opts...)
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			t.Set(strings.ToLower(scanner.Text()), "")
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+}
+
+// Trie returns the current Trie held by the Dictionary.
+func (d *Dictionary) Trie() *levtrie.Trie {
+	return d.t.Load().b.(*levtrie.Trie)
+}
+
+// source returns the source of the current Trie.
+func (d *Dictionary) source() *source {
+	return d.t.Load()
+}
+
+// Reload loads a new Trie and swaps it in for the current one. If loading
+// fails, the current Trie is kept and the error is returned.
+func (d *Dictionary) Reload() error {
+	d.reload.Lock()
+	defer d.reload.Unlock()
+	t, err := d.load()
+	if err != nil {
+		return err
+	}
+	// The hook is registered before t is served, and removed from the old
+	// Trie once it's no longer served by new requests.
+	if old := d.t.Swap(watch(t)); old != nil {
+		old.remove()
+	}
+	return nil
+}
+
+// ReloadOnSignal reloads the Dictionary in the background each time the
+// process receives one of the signals passed, usually syscall.SIGHUP. Errors
+// are passed to onError if it's not nil. The returned function stops
+// reloading on signals.
+func (d *Dictionary) ReloadOnSignal(onError func(error), sigs ...os.Signal) (stop func()) {
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, sigs...)
+	go func() {
+		for {
+			select {
+			case <-c:
+				if err := d.Reload(); err != nil && onError != nil {
+					onError(err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(c)
+			close(done)
+		})
+	}
+}
+
+// Handler returns an http.Handler like the one returned by the package-level
+// Handler that serves suggestions from the current Trie of the Dictionary.
+func (d *Dictionary) Handler(opts Options) http.Handler {
+	return newHandler(d.source, opts)
+}
+
+// StreamHandler returns an http.Handler like the one returned by the
+// package-level StreamHandler that streams suggestions from the current Trie
+// of the Dictionary.
+func (d *Dictionary) StreamHandler(opts Options) http.Handler {
+	return newStreamHandler(d.source, opts)
+}
+
+// ReloadHandler returns an http.Handler for an admin endpoint that reloads the
+// Dictionary on POST requests. It responds with a JSON object whose "keys"
+// field holds the number of keys in the new Trie, or with a 500 response with
+// an "error" field if the reload fails.
+func (d *Dictionary) ReloadHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", "POST")
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if err := d.Reload(); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, struct {
+			Keys int `json:"keys"`
+		}{d.Trie().Len()})
+	})
+}
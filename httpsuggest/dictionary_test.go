@@ -0,0 +1,61 @@
+package httpsuggest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeWords(t *testing.T, name string, words string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(words), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDictionaryReload(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "words")
+	writeWords(t, name, "Hello\nhelp\n")
+	d, err := NewDictionary(WordListFile(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := d.Handler(Options{})
+	expectResults(t, h, "/search?q=hallo&d=1", "hello")
+
+	writeWords(t, name, "hallo\nhelp\n")
+	rec := httptest.NewRecorder()
+	d.ReloadHandler().ServeHTTP(rec, httptest.NewRequest("POST", "/reload", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Got status %v, want 200: %s", rec.Code, rec.Body)
+	}
+	var resp struct{ Keys int }
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Keys != 2 {
+		t.Errorf("Got body %s, want 2 keys", rec.Body)
+	}
+	expectResults(t, h, "/search?q=hallo&d=0", "hallo")
+
+	// A failed reload keeps the current Trie.
+	os.Remove(name)
+	rec = httptest.NewRecorder()
+	d.ReloadHandler().ServeHTTP(rec, httptest.NewRequest("POST", "/reload", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Got status %v, want 500", rec.Code)
+	}
+	expectResults(t, h, "/search?q=hallo&d=0", "hallo")
+
+	rec = httptest.NewRecorder()
+	d.ReloadHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/reload", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Got status %v, want 405", rec.Code)
+	}
+}
+
+func TestNewDictionaryError(t *testing.T) {
+	if _, err := NewDictionary(WordListFile(filepath.Join(t.TempDir(), "missing"))); err == nil {
+		t.Errorf("Expected an error loading a missing file")
+	}
+}
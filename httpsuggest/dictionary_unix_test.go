@@ -0,0 +1,31 @@
+//go:build unix
+
+package httpsuggest
+
+import (
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDictionaryReloadOnSignal(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "words")
+	writeWords(t, name, "hello\n")
+	d, err := NewDictionary(WordListFile(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := d.ReloadOnSignal(func(err error) { t.Error(err) }, syscall.SIGHUP)
+	defer stop()
+	writeWords(t, name, "hello\nhallo\n")
+	old := d.Trie()
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	deadline := time.Now().Add(5 * time.Second)
+	for d.Trie() == old && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := d.Trie().Len(); got != 2 {
+		t.Errorf("Got %v keys after SIGHUP, want 2", got)
+	}
+}
@@ -0,0 +1,135 @@
+package httpsuggest
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aaw/levtrie"
+)
+
+// EditOptions configures the handler returned by EditHandler.
+type EditOptions struct {
+	// Token is the secret that requests must present in an
+	// "Authorization: Bearer <Token>" header.
+	Token string
+	// Authorize, if not nil, is called instead of checking Token and
+	// returns true if r may modify the dictionary. If neither Token nor
+	// Authorize is set, all requests are rejected.
+	Authorize func(r *http.Request) bool
+	// Normalize, if not nil, is applied to each key before it's modified,
+	// to match how keys were normalized when they were loaded.
+	Normalize func(key string) string
+	// MaxKeyLength caps the number of runes in a key. If it's not positive,
+	// 256 is used.
+	MaxKeyLength int
+	// MaxValueLength caps the number of bytes in a value. If it's not
+	// positive, 65536 is used.
+	MaxValueLength int
+}
+
+func (o *EditOptions) maxKeyLength() int {
+	if o.MaxKeyLength <= 0 {
+		return 256
+	}
+	return o.MaxKeyLength
+}
+
+func (o *EditOptions) maxValueLength() int {
+	if o.MaxValueLength <= 0 {
+		return 65536
+	}
+	return o.MaxValueLength
+}
+
+// EditHandler returns an http.Handler that modifies s, so that a dictionary
+// served by Handler(s, ...) can be curated without a restart. The key to
+// modify is given by the key query param. A PUT request sets the value of the
+// key to the request body, or adds the body to the values of the key if the
+// add param is non-zero (see levtrie.Trie.Add). A DELETE request deletes the
+// key or, if the value param is present, removes that value from the key
+// (see levtrie.Trie.Remove). Successful requests get an empty 204 response.
+// Requests that aren't authorized by opts get a 401 response.
+func EditHandler(s *levtrie.SyncTrie, opts EditOptions) http.Handler {
+	return &editHandler{s: s, opts: opts}
+}
+
+type editHandler struct {
+	s    *levtrie.SyncTrie
+	opts EditOptions
+}
+
+// authorized returns true if r may modify the dictionary.
+func (h *editHandler) authorized(r *http.Request) bool {
+	if h.opts.Authorize != nil {
+		return h.opts.Authorize(r)
+	}
+	if h.opts.Token == "" {
+		return false
+	}
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	token := strings.TrimPrefix(auth, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.opts.Token)) == 1
+}
+
+func (h *editHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		w.Header().Set("Allow", "PUT, DELETE")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	params := r.URL.Query()
+	key := params.Get("key")
+	if h.opts.Normalize != nil {
+		key = h.opts.Normalize(key)
+	}
+	if key == "" {
+		writeError(w, http.StatusBadRequest, "key: missing")
+		return
+	}
+	if utf8.RuneCountInString(key) > h.opts.maxKeyLength() {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("key: longer than %v runes", h.opts.maxKeyLength()))
+		return
+	}
+	if r.Method == http.MethodDelete {
+		if vs, ok := params["value"]; ok && len(vs) > 0 {
+			h.s.Remove(key, vs[0])
+		} else {
+			h.s.Delete(key)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	add, err := intParam(params, "add", 0, 1)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	max := h.opts.maxValueLength()
+	body, err := io.ReadAll(io.LimitReader(r.Body, int64(max)+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(body) > max {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("value: longer than %v bytes", max))
+		return
+	}
+	if add != 0 {
+		h.s.Add(key, string(body))
+	} else {
+		h.s.Set(key, string(body))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,75 @@
+package httpsuggest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aaw/levtrie"
+)
+
+func edit(t *testing.T, h http.Handler, method, url, body, token string) int {
+	t.Helper()
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestEditHandler(t *testing.T) {
+	s := levtrie.NewSync()
+	s.Set("hello", "")
+	search := Handler(s, Options{CacheSize: 10})
+	h := EditHandler(s, EditOptions{Token: "secret", Normalize: strings.ToLower, MaxValueLength: 5})
+	expectResults(t, search, "/search?q=helo&d=1&e=0", "hello")
+	for _, test := range []struct {
+		method, url, body, token string
+		want                     int
+	}{
+		{"PUT", "/edit?key=Help", "v1", "secret", http.StatusNoContent},
+		{"PUT", "/edit?key=help&add=1", "v2", "secret", http.StatusNoContent},
+		{"PUT", "/edit?key=helm", "", "wrong", http.StatusUnauthorized},
+		{"PUT", "/edit?key=helm", "", "", http.StatusUnauthorized},
+		{"PUT", "/edit?key=helm", "toolong", "secret", http.StatusRequestEntityTooLarge},
+		{"PUT", "/edit", "", "secret", http.StatusBadRequest},
+		{"PUT", "/edit?key=helm&add=2", "", "secret", http.StatusBadRequest},
+		{"DELETE", "/edit?key=hello", "", "secret", http.StatusNoContent},
+		{"GET", "/edit?key=hello", "", "secret", http.StatusMethodNotAllowed},
+	} {
+		if got := edit(t, h, test.method, test.url, test.body, test.token); got != test.want {
+			t.Errorf("%v %v: got status %v, want %v", test.method, test.url, got, test.want)
+		}
+	}
+	if got, want := s.Values("help"), []string{"v1", "v2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Values(help) = %v, want %v", got, want)
+	}
+	// The cache of the search handler is invalidated by the edits.
+	expectResults(t, search, "/search?q=helo&d=1&e=0", "help")
+	edit(t, h, "DELETE", "/edit?key=help&value=v1", "", "secret")
+	if got, want := s.Values("help"), []string{"v2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Values(help) = %v, want %v", got, want)
+	}
+}
+
+func TestEditHandlerAuthorize(t *testing.T) {
+	s := levtrie.NewSync()
+	if got := edit(t, EditHandler(s, EditOptions{}), "PUT", "/edit?key=a", "", ""); got != http.StatusUnauthorized {
+		t.Errorf("Got status %v without any authorization configured, want 401", got)
+	}
+	h := EditHandler(s, EditOptions{Authorize: func(r *http.Request) bool { return r.Header.Get("X-Admin") == "yes" }})
+	req := httptest.NewRequest("PUT", "/edit?key=a", nil)
+	req.Header.Set("X-Admin", "yes")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("Got status %v, want 204", rec.Code)
+	}
+	if _, ok := s.Get("a"); !ok {
+		t.Errorf("Key wasn't set")
+	}
+}
@@ -0,0 +1,448 @@
+// Package httpsuggest serves suggestions from a levtrie.Trie over HTTP.
+//
+// The handler returned by Handler accepts GET requests with the following
+// query params:
+//
+//	q: The string query. Default is the empty string, which has no results.
+//	n: The max number of results. Default is Options.Limit.
+//	p: The length, in runes, of the prefix of the query that must match a key
+//	   exactly. Default is given by Options.IgnorePrefix.
+//	d: The edit distance to search within after the exact prefix. Default is
+//	   given by Options.Distance.
+//	e: If non-zero and fewer than n results are found within distance d of
+//	   the query, the results are augmented with keys that have a prefix
+//	   within distance d of the query. Default is 1 unless
+//	   Options.NoSuffixExpansion is set.
+//	verbose: If non-zero, each result is a Result object instead of a key.
+//	   Default is 0.
+//
+// The cost of a search grows quickly with d, so d, n and the length of q are
+// capped by Options.MaxDistance, Options.MaxLimit and Options.MaxQueryLength.
+// Requests can also be rate limited per client with Options.RateLimit.
+//
+// A successful response is a JSON array of up to n distinct keys or, if
+// verbose is set, of up to n Results for distinct keys. A request with an
+// invalid parameter or a parameter over its cap gets a 400 response with a
+// JSON object whose "error" field describes the problem, and a request from a
+// client that's over its rate limit gets a 429 response.
+//
+// A dictionary too large for one server can be split into shards, each served
+// by its own handler, and queried with Shards, which merges the results.
+package httpsuggest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aaw/levtrie"
+)
+
+// Options configures the handler returned by Handler. The zero value of
+// Options gives the defaults described in the package documentation.
+type Options struct {
+	// Limit is the max number of results returned if n isn't given. If
+	// Limit isn't positive, 10 is used.
+	Limit int
+	// IgnorePrefix returns the default exact prefix length for a query. If
+	// it's nil, the default is 1/5 of the number of runes in the query.
+	IgnorePrefix func(query string) int
+	// Distance returns the default edit distance for a query whose first p
+	// runes must match exactly. If it's nil, the default is 1/3 of the number
+	// of runes in the query after the exact prefix.
+	Distance func(query string, p int) int8
+	// NoSuffixExpansion changes the default of the e param to 0.
+	NoSuffixExpansion bool
+	// Normalize, if not nil, is applied to each query before it's parsed
+	// and searched for, to match how keys were normalized when they were
+	// added to the Trie. For example, strings.ToLower.
+	Normalize func(query string) string
+	// CacheSize, if positive, is the number of distinct queries whose
+	// results are kept in an LRU cache. Cached results are invalidated
+	// whenever the Trie is modified, through the hooks registered with
+	// levtrie.Trie.OnChange, or replaced by a reload. Keys that expire
+	// may be returned from the cache until the Trie is next modified.
+	CacheSize int
+	// MaxDistance caps the d param. Default edit distances are capped at
+	// MaxDistance and requests with a larger d are rejected. If MaxDistance
+	// isn't positive, 4 is used.
+	MaxDistance int
+	// MaxLimit caps the n param. If MaxLimit isn't positive, 1000 is used.
+	MaxLimit int
+	// MaxQueryLength caps the number of runes in the q param. If
+	// MaxQueryLength isn't positive, 100 is used.
+	MaxQueryLength int
+	// RateLimit, if positive, is the sustained number of requests per second
+	// allowed from each client. Clients can exceed it in bursts of up to
+	// RateBurst requests.
+	RateLimit float64
+	// RateBurst is the max number of requests a client can make at once
+	// when RateLimit is set. If RateBurst isn't positive, RateLimit rounded
+	// up is used.
+	RateBurst int
+	// ClientKey identifies the client that made a request for rate limiting.
+	// If it's nil, clients are identified by their IP address.
+	ClientKey func(r *http.Request) string
+	// Scorer, if not nil, ranks results. Up to Candidates results are
+	// found for each query and sorted by decreasing score before Filters
+	// are applied and the first n are returned. Otherwise, results are
+	// returned in the order they're found, which is roughly by increasing
+	// edit distance.
+	Scorer Scorer
+	// Filters are applied in order to the results of each query after
+	// they're ranked, and can remove or reorder results.
+	Filters []Filter
+	// Candidates is the number of results found for each query when Scorer
+	// or Filters are set. If Candidates is less than n, 5n is used.
+	Candidates int
+	// OnRequest, if not nil, is called after each request is handled, for
+	// instrumentation. See Metrics.
+	OnRequest func(RequestInfo)
+	// Logger, if not nil, logs each query along with the number of results
+	// and the time taken to find them.
+	Logger *log.Logger
+}
+
+func (o *Options) limit() int {
+	if o.Limit <= 0 {
+		return 10
+	}
+	return o.Limit
+}
+
+func (o *Options) maxDistance() int {
+	if o.MaxDistance <= 0 {
+		return 4
+	}
+	if o.MaxDistance > math.MaxInt8 {
+		return math.MaxInt8
+	}
+	return o.MaxDistance
+}
+
+func (o *Options) maxLimit() int {
+	if o.MaxLimit <= 0 {
+		return 1000
+	}
+	return o.MaxLimit
+}
+
+func (o *Options) maxQueryLength() int {
+	if o.MaxQueryLength <= 0 {
+		return 100
+	}
+	return o.MaxQueryLength
+}
+
+func (o *Options) ignorePrefix(q string) int {
+	if o.IgnorePrefix != nil {
+		return o.IgnorePrefix(q)
+	}
+	return utf8.RuneCountInString(q) / 5
+}
+
+func (o *Options) distance(q string, p int) int8 {
+	if o.Distance != nil {
+		return o.Distance(q, p)
+	}
+	d := (utf8.RuneCountInString(q) - p) / 3
+	if d > math.MaxInt8 {
+		d = math.MaxInt8
+	}
+	return int8(d)
+}
+
+// Sources of a Result.
+const (
+	// SourceMatch means the key is within the edit distance of the query.
+	SourceMatch = "match"
+	// SourcePrefix means a prefix of the key is within the edit distance of
+	// the query.
+	SourcePrefix = "prefix"
+)
+
+// Result is a suggestion returned by the handler when the verbose param is
+// set.
+type Result struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// Distance is the edit distance between the query and the key or, if
+	// Source is SourcePrefix, between the query and the closest prefix of
+	// the key.
+	Distance int `json:"distance"`
+	// Weight is the count associated with the key (see levtrie.Trie.Count).
+	Weight int64 `json:"weight"`
+	// Source says how the key matched the query: SourceMatch or
+	// SourcePrefix.
+	Source string `json:"source"`
+}
+
+// Backend is a dictionary that the handlers in this package can serve
+// suggestions from. It's implemented by *levtrie.Trie and *levtrie.SyncTrie.
+type Backend interface {
+	Len() int
+	Count(key string) int64
+	SuggestAfterExactPrefix(key string, p int, d int8, n int, opts ...levtrie.SuggestOption) []levtrie.KV
+	SuggestSuffixesAfterExactPrefix(key string, p int, d int8, n int, opts ...levtrie.SuggestOption) []levtrie.KV
+	OnChange(f func(levtrie.Op)) (remove func())
+}
+
+// Handler returns an http.Handler that serves suggestions from t. The handler
+// only reads from t, so if t is a *levtrie.Trie, it must not be modified while
+// the handler is in use. Use a *levtrie.SyncTrie to modify the dictionary
+// while it's being served, for example with EditHandler.
+func Handler(t Backend, opts Options) http.Handler {
+	s := watch(t)
+	return newHandler(func() *source { return s }, opts)
+}
+
+func newHandler(trie func() *source, opts Options) *handler {
+	h := &handler{trie: trie, opts: opts}
+	if opts.CacheSize > 0 {
+		h.cache = newResultCache(opts.CacheSize)
+	}
+	if opts.RateLimit > 0 {
+		h.limiter = newRateLimiter(opts.RateLimit, opts.RateBurst)
+	}
+	return h
+}
+
+type handler struct {
+	trie    func() *source // Returns the Backend to search.
+	opts    Options
+	cache   *resultCache // Nil unless opts.CacheSize is positive.
+	limiter *rateLimiter // Nil unless opts.RateLimit is positive.
+}
+
+// query holds the parsed parameters of a request.
+type query struct {
+	q       string
+	n       int
+	p       int
+	d       int8
+	expand  bool
+	verbose bool
+}
+
+// intParam parses the query param name as an integer in [0, max]. It returns
+// def if the param isn't present.
+func intParam(params map[string][]string, name string, def int, max int) (int, error) {
+	vs, ok := params[name]
+	if !ok || len(vs) == 0 {
+		return def, nil
+	}
+	i, err := strconv.Atoi(vs[0])
+	if err != nil {
+		return 0, fmt.Errorf("%v: %q isn't an integer", name, vs[0])
+	}
+	if i < 0 || i > max {
+		return 0, fmt.Errorf("%v: %v isn't between 0 and %v", name, i, max)
+	}
+	return i, nil
+}
+
+// parseQuery parses query params into a query. See the package documentation
+// for a list of accepted query params.
+func (h *handler) parseQuery(params map[string][]string) (*query, error) {
+	qr := &query{}
+	if vs, ok := params["q"]; ok && len(vs) > 0 {
+		qr.q = vs[0]
+	}
+	if h.opts.Normalize != nil {
+		qr.q = h.opts.Normalize(qr.q)
+	}
+	runes := utf8.RuneCountInString(qr.q)
+	if runes > h.opts.maxQueryLength() {
+		return nil, fmt.Errorf("q: longer than %v runes", h.opts.maxQueryLength())
+	}
+	var err error
+	defn := h.opts.limit()
+	if defn > h.opts.maxLimit() {
+		defn = h.opts.maxLimit()
+	}
+	if qr.n, err = intParam(params, "n", defn, h.opts.maxLimit()); err != nil {
+		return nil, err
+	}
+	defp := h.opts.ignorePrefix(qr.q)
+	if defp > runes {
+		defp = runes
+	}
+	if qr.p, err = intParam(params, "p", defp, runes); err != nil {
+		return nil, err
+	}
+	defd := int(h.opts.distance(qr.q, qr.p))
+	if defd < 0 {
+		defd = 0
+	} else if defd > h.opts.maxDistance() {
+		defd = h.opts.maxDistance()
+	}
+	d, err := intParam(params, "d", defd, h.opts.maxDistance())
+	if err != nil {
+		return nil, err
+	}
+	qr.d = int8(d)
+	defe := 1
+	if h.opts.NoSuffixExpansion {
+		defe = 0
+	}
+	e, err := intParam(params, "e", defe, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	qr.expand = e != 0
+	v, err := intParam(params, "verbose", 0, math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	qr.verbose = v != 0
+	return qr, nil
+}
+
+// suggest returns up to qr.n Results for distinct keys in t that match qr,
+// from the cache if possible.
+func (h *handler) suggest(t *source, qr *query) []Result {
+	if h.cache == nil {
+		return h.search(t.b, qr)
+	}
+	key := cacheKey(qr)
+	results, token, ok := h.cache.get(t, key)
+	if ok {
+		return results
+	}
+	results = h.search(t.b, qr)
+	h.cache.put(t, key, results, token)
+	return results
+}
+
+// search returns up to qr.n Results for distinct keys in t that match qr.
+// Only the Key field of each Result is set unless qr.verbose is set.
+func (h *handler) search(t Backend, qr *query) []Result {
+	results := []Result{}
+	if qr.q == "" || qr.n == 0 {
+		return results
+	}
+	want, ranked := qr.n, h.ranked()
+	if ranked {
+		want = h.opts.candidates(qr.n)
+	}
+	seen := make(map[string]bool)
+	collect := func(kvs []levtrie.KV, source string) {
+		for _, kv := range kvs {
+			if len(results) >= want {
+				return
+			}
+			if seen[kv.Key] {
+				continue
+			}
+			seen[kv.Key] = true
+			res := Result{Key: kv.Key}
+			if qr.verbose || ranked {
+				res.Value = kv.Value
+				res.Weight = t.Count(kv.Key)
+				res.Source = source
+				if source == SourcePrefix {
+					res.Distance = levtrie.PrefixDistance(qr.q, kv.Key)
+				} else {
+					res.Distance = levtrie.Distance(qr.q, kv.Key)
+				}
+			}
+			results = append(results, res)
+		}
+	}
+	collect(t.SuggestAfterExactPrefix(qr.q, qr.p, qr.d, want), SourceMatch)
+	if qr.expand && len(results) < want {
+		collect(t.SuggestSuffixesAfterExactPrefix(qr.q, qr.p, qr.d, want), SourcePrefix)
+	}
+	if ranked {
+		results = h.rank(qr, results)
+	}
+	return results
+}
+
+// RequestInfo describes a request handled by one of the handlers in this
+// package. It's passed to Options.OnRequest.
+type RequestInfo struct {
+	// Status is the HTTP status code of the response. The remaining fields
+	// are only set if Status is 200.
+	Status   int
+	Query    string
+	Distance int8 // The edit distance searched within.
+	Limit    int  // The max number of results requested.
+	Results  int  // The number of results returned.
+	// Duration is the time taken to find the results.
+	Duration time.Duration
+}
+
+// observe passes info to the OnRequest hook, if there is one.
+func (h *handler) observe(info RequestInfo) {
+	if h.opts.OnRequest != nil {
+		h.opts.OnRequest(info)
+	}
+}
+
+// answer returns the results of qr, logging and observing the query.
+func (h *handler) answer(qr *query, suggest func(*query) []Result) []Result {
+	start := time.Now()
+	results := suggest(qr)
+	elapsed := time.Since(start)
+	if h.opts.Logger != nil {
+		h.opts.Logger.Printf("Query %+v returned %v results in time %v\n",
+			*qr, len(results), elapsed)
+	}
+	h.observe(RequestInfo{
+		Status: http.StatusOK, Query: qr.q, Distance: qr.d, Limit: qr.n,
+		Results: len(results), Duration: elapsed,
+	})
+	return results
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		h.observe(RequestInfo{Status: http.StatusMethodNotAllowed})
+		return
+	}
+	if !h.allow(w, r) {
+		h.observe(RequestInfo{Status: http.StatusTooManyRequests})
+		return
+	}
+	qr, err := h.parseQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		h.observe(RequestInfo{Status: http.StatusBadRequest})
+		return
+	}
+	results := h.answer(qr, func(qr *query) []Result { return h.suggest(h.trie(), qr) })
+	writeJSON(w, http.StatusOK, render(qr, results))
+}
+
+// render returns the JSON representation of the results of qr: the results
+// themselves if qr.verbose is set and only their keys otherwise.
+func render(qr *query, results []Result) interface{} {
+	if qr.verbose {
+		return results
+	}
+	keys := make([]string, len(results))
+	for i, res := range results {
+		keys[i] = res.Key
+	}
+	return keys
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, struct {
+		Error string `json:"error"`
+	}{msg})
+}
@@ -0,0 +1,133 @@
+package httpsuggest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/aaw/levtrie"
+)
+
+func newTrie(words ...string) *levtrie.Trie {
+	t := levtrie.New()
+	for _, w := range words {
+		t.Set(w, "")
+	}
+	return t
+}
+
+func get(t *testing.T, h http.Handler, url string) (int, []byte) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("%v: got Content-Type %q, want application/json", url, ct)
+	}
+	return rec.Code, rec.Body.Bytes()
+}
+
+func expectResults(t *testing.T, h http.Handler, url string, want ...string) {
+	t.Helper()
+	code, body := get(t, h, url)
+	if code != http.StatusOK {
+		t.Fatalf("%v: got status %v, want 200: %s", url, code, body)
+	}
+	var got []string
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("%v: %v", url, err)
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	if want == nil {
+		want = []string{}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%v: got %v, want %v", url, got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	h := Handler(newTrie("hello", "help", "helot", "hellos", "yellow"), Options{})
+	expectResults(t, h, "/search?q=helo&d=1&e=0", "hello", "help", "helot")
+	expectResults(t, h, "/search?q=helo&d=1&e=0&p=4", "helot")
+	expectResults(t, h, "/search?q=hell&d=0", "hello", "hellos")
+	expectResults(t, h, "/search?q=helo&d=1&n=1&e=0&p=4", "helot")
+	expectResults(t, h, "/search?q=")
+	expectResults(t, h, "/search?q=xyz&n=0&d=3")
+}
+
+func TestHandlerDefaults(t *testing.T) {
+	tr := newTrie("hello", "help", "yellow")
+	// The default distance for "yelow" is 5/3 = 1 after a 1 rune prefix.
+	expectResults(t, Handler(tr, Options{}), "/search?q=yelow", "yellow")
+	expectResults(t, Handler(tr, Options{IgnorePrefix: func(string) int { return 0 }}), "/search?q=helo", "hello", "help")
+	expectResults(t, Handler(tr, Options{Distance: func(string, int) int8 { return 0 }}), "/search?q=helo")
+	expectResults(t, Handler(tr, Options{Limit: 1}), "/search?q=hello&d=0&e=1", "hello")
+	expectResults(t, Handler(tr, Options{NoSuffixExpansion: true}), "/search?q=hel&d=0")
+}
+
+func TestHandlerSuffixExpansion(t *testing.T) {
+	tr := newTrie("hello", "help", "helot", "yellow")
+	// A non-zero e expands suffixes and e=0 doesn't, whatever the default.
+	for _, opts := range []Options{{}, {NoSuffixExpansion: true}} {
+		h := Handler(tr, opts)
+		expectResults(t, h, "/search?q=hel&d=0&e=0")
+		expectResults(t, h, "/search?q=hel&d=0&e=1", "hello", "help", "helot")
+		expectResults(t, h, "/search?q=hel&d=0&e=2", "hello", "help", "helot")
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	h := Handler(newTrie("hello"), Options{})
+	for _, url := range []string{
+		"/search?q=helo&d=-1",
+		"/search?q=helo&d=300",
+		"/search?q=helo&d=x",
+		"/search?q=helo&n=-1",
+		"/search?q=helo&p=5",
+		"/search?q=helo&e=yes",
+	} {
+		code, body := get(t, h, url)
+		if code != http.StatusBadRequest {
+			t.Errorf("%v: got status %v, want 400", url, code)
+		}
+		var resp struct{ Error string }
+		if err := json.Unmarshal(body, &resp); err != nil || resp.Error == "" {
+			t.Errorf("%v: got body %s, want an error message", url, body)
+		}
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/search?q=helo", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: got status %v, want 405", rec.Code)
+	}
+}
+
+func TestHandlerVerbose(t *testing.T) {
+	tr := levtrie.New()
+	tr.Set("hello", "greeting")
+	tr.IncrBy("hello", 7)
+	tr.Set("helot", "serf")
+	tr.Set("helicopter", "aircraft")
+	h := Handler(tr, Options{})
+	code, body := get(t, h, "/search?q=helo&d=1&p=0&verbose=1")
+	if code != http.StatusOK {
+		t.Fatalf("Got status %v, want 200: %s", code, body)
+	}
+	var got []Result
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].Key < got[j].Key })
+	want := []Result{
+		{Key: "helicopter", Value: "aircraft", Distance: 1, Source: SourcePrefix},
+		{Key: "hello", Value: "greeting", Distance: 1, Weight: 7, Source: SourceMatch},
+		{Key: "helot", Value: "serf", Distance: 1, Source: SourceMatch},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package httpsuggest
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+)
+
+// Language is a dictionary served by the handler returned by LanguageHandler,
+// along with the Options used to serve it.
+type Language struct {
+	// Trie is the Backend that suggestions are served from, unless
+	// Dictionary is set.
+	Trie Backend
+	// Dictionary, if not nil, holds the Trie that suggestions are served
+	// from, so that the language can be reloaded independently of others.
+	Dictionary *Dictionary
+	// Options configures how queries for the language are parsed and
+	// searched for, for example how they're normalized and the default
+	// edit distance.
+	Options Options
+}
+
+// LanguageHandler returns an http.Handler that serves suggestions from one of
+// several dictionaries, each keyed by a language code like "en" or "de". The
+// language of a request is given by the lang query param or, if that's not
+// present, by the last element of the request's path if it's a key of langs,
+// so that both /search?lang=de&q=... and /search/de?q=... work. Requests that
+// don't specify a language are served from the language def, or get a 400
+// response if def is empty. Requests with a lang param that isn't a key of
+// langs get a 404 response. Otherwise, requests are handled as described in
+// the package documentation.
+func LanguageHandler(langs map[string]Language, def string) http.Handler {
+	lh := &languageHandler{handlers: make(map[string]*handler, len(langs)), def: def}
+	for code, lang := range langs {
+		var trie func() *source
+		if lang.Dictionary != nil {
+			trie = lang.Dictionary.source
+		} else {
+			s := watch(lang.Trie)
+			trie = func() *source { return s }
+		}
+		lh.handlers[code] = newHandler(trie, lang.Options)
+	}
+	return lh
+}
+
+type languageHandler struct {
+	handlers map[string]*handler
+	def      string
+}
+
+// language returns the language code requested by r.
+func (lh *languageHandler) language(r *http.Request) string {
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		return lang
+	}
+	if base := path.Base(r.URL.Path); base != "/" && base != "." {
+		if _, ok := lh.handlers[base]; ok {
+			return base
+		}
+	}
+	return lh.def
+}
+
+func (lh *languageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	lang := lh.language(r)
+	if lang == "" {
+		writeError(w, http.StatusBadRequest, "lang: no language given")
+		return
+	}
+	h, ok := lh.handlers[lang]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("lang: unknown language %q", lang))
+		return
+	}
+	h.ServeHTTP(w, r)
+}
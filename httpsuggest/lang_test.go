@@ -0,0 +1,38 @@
+package httpsuggest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aaw/levtrie"
+)
+
+func TestLanguageHandler(t *testing.T) {
+	de, err := NewDictionary(func() (*levtrie.Trie, error) { return newTrie("straße", "strand"), nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := LanguageHandler(map[string]Language{
+		"en": {Trie: newTrie("street", "strand", "stress"), Options: Options{Normalize: strings.ToLower}},
+		"de": {Dictionary: de, Options: Options{Distance: func(string, int) int8 { return 0 }}},
+	}, "en")
+	expectResults(t, h, "/search?q=STREET&d=0&e=0", "street")
+	expectResults(t, h, "/search?lang=en&q=stres&d=1&e=0", "stress")
+	expectResults(t, h, "/search/en?q=strand&d=0", "strand")
+	expectResults(t, h, "/search?lang=de&q=straße", "straße")
+	expectResults(t, h, "/search/de?q=strasse")
+	expectResults(t, h, "/search/de?q=STRAND&d=1")
+	for url, want := range map[string]int{
+		"/search?lang=fr&q=rue": http.StatusNotFound,
+		"/search/fr?q=rue":      http.StatusOK,
+	} {
+		if code, body := get(t, h, url); code != want {
+			t.Errorf("%v: got status %v, want %v: %s", url, code, want, body)
+		}
+	}
+	noDefault := LanguageHandler(map[string]Language{"en": {Trie: newTrie("street")}}, "")
+	if code, _ := get(t, noDefault, "/search?q=street"); code != http.StatusBadRequest {
+		t.Errorf("Got status %v without a language, want 400", code)
+	}
+}
@@ -0,0 +1,166 @@
+package httpsuggest
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Bucket upper bounds for the histograms exported by Metrics.
+var (
+	latencyBuckets = []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}
+	resultBuckets  = []float64{0, 1, 2, 5, 10, 20, 50, 100, 200, 500, 1000}
+)
+
+// histogram is a Prometheus histogram.
+type histogram struct {
+	bounds []float64
+	counts []uint64 // counts[i] is the number of observations <= bounds[i].
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(x float64) {
+	for i, b := range h.bounds {
+		if x <= b {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += x
+}
+
+// write writes the samples of the histogram in the Prometheus text format.
+// labels, if not empty, is a comma-separated list of labels for each sample.
+func (h *histogram) write(w *bufio.Writer, name string, labels string) {
+	sep := ""
+	if labels != "" {
+		sep = ","
+	}
+	for i, b := range h.bounds {
+		fmt.Fprintf(w, "%s_bucket{%s%sle=\"%s\"} %d\n", name, labels, sep, formatFloat(b), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{%s%sle=\"+Inf\"} %d\n", name, labels, sep, h.count)
+	if labels != "" {
+		labels = "{" + labels + "}"
+	}
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels, h.count)
+}
+
+// labelEscaper escapes a label value for the Prometheus text format, which
+// only escapes backslashes, double quotes and newlines.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(x float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+// Metrics collects metrics about suggestion requests and dictionaries and
+// exports them in the Prometheus text exposition format. Pass its Observe
+// method as Options.OnRequest to collect metrics about requests and serve it
+// on an endpoint like /metrics to export them. The metrics exported are:
+//
+//	levtrie_requests_total: A counter of requests by HTTP status code.
+//	levtrie_request_duration_seconds: A histogram of the time taken to find
+//	    results, by edit distance searched within.
+//	levtrie_results: A histogram of the number of results per request.
+//	levtrie_dictionary_keys: A gauge of the number of keys in each
+//	    dictionary added with AddDictionary.
+type Metrics struct {
+	mu       sync.Mutex
+	requests map[int]uint64
+	latency  map[int8]*histogram
+	results  *histogram
+	dicts    map[string]func() int
+}
+
+// NewMetrics returns a new Metrics with no observations.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests: make(map[int]uint64),
+		latency:  make(map[int8]*histogram),
+		results:  newHistogram(resultBuckets),
+		dicts:    make(map[string]func() int),
+	}
+}
+
+// Observe records a request. It's meant to be used as Options.OnRequest.
+func (m *Metrics) Observe(info RequestInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[info.Status]++
+	if info.Status != http.StatusOK {
+		return
+	}
+	h, ok := m.latency[info.Distance]
+	if !ok {
+		h = newHistogram(latencyBuckets)
+		m.latency[info.Distance] = h
+	}
+	h.observe(info.Duration.Seconds())
+	m.results.observe(float64(info.Results))
+}
+
+// AddDictionary exports the size of a dictionary with the given name, which is
+// found by calling size each time metrics are exported. For example, pass
+// func() int { return d.Trie().Len() } for a Dictionary d.
+func (m *Metrics) AddDictionary(name string, size func() int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dicts[name] = size
+}
+
+// ServeHTTP writes all metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(bw, "# HELP levtrie_requests_total Suggestion requests by HTTP status code.")
+	fmt.Fprintln(bw, "# TYPE levtrie_requests_total counter")
+	codes := make([]int, 0, len(m.requests))
+	for code := range m.requests {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		fmt.Fprintf(bw, "levtrie_requests_total{code=\"%d\"} %d\n", code, m.requests[code])
+	}
+
+	fmt.Fprintln(bw, "# HELP levtrie_request_duration_seconds Time taken to find suggestions by edit distance.")
+	fmt.Fprintln(bw, "# TYPE levtrie_request_duration_seconds histogram")
+	dists := make([]int, 0, len(m.latency))
+	for d := range m.latency {
+		dists = append(dists, int(d))
+	}
+	sort.Ints(dists)
+	for _, d := range dists {
+		m.latency[int8(d)].write(bw, "levtrie_request_duration_seconds", fmt.Sprintf("d=\"%d\"", d))
+	}
+
+	fmt.Fprintln(bw, "# HELP levtrie_results Number of suggestions returned per request.")
+	fmt.Fprintln(bw, "# TYPE levtrie_results histogram")
+	m.results.write(bw, "levtrie_results", "")
+
+	fmt.Fprintln(bw, "# HELP levtrie_dictionary_keys Number of keys in each dictionary.")
+	fmt.Fprintln(bw, "# TYPE levtrie_dictionary_keys gauge")
+	names := make([]string, 0, len(m.dicts))
+	for name := range m.dicts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(bw, "levtrie_dictionary_keys{dictionary=\"%s\"} %d\n", labelEscaper.Replace(name), m.dicts[name]())
+	}
+}
@@ -0,0 +1,43 @@
+package httpsuggest
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics()
+	tr := newTrie("hello", "help")
+	m.AddDictionary("en", tr.Len)
+	m.AddDictionary("café \"fr\"\n\\", tr.Len)
+	h := Handler(tr, Options{OnRequest: m.Observe})
+	get(t, h, "/search?q=helo&d=1&e=0")
+	get(t, h, "/search?q=hello&d=0&e=0")
+	get(t, h, "/search?q=hello&d=-1")
+	m.Observe(RequestInfo{Status: 200, Distance: 1, Results: 0, Duration: 2 * time.Second})
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`levtrie_requests_total{code="200"} 3`,
+		`levtrie_requests_total{code="400"} 1`,
+		`levtrie_request_duration_seconds_bucket{d="0",le="+Inf"} 1`,
+		`levtrie_request_duration_seconds_bucket{d="1",le="1"} 1`,
+		`levtrie_request_duration_seconds_bucket{d="1",le="+Inf"} 2`,
+		`levtrie_request_duration_seconds_count{d="1"} 2`,
+		`levtrie_results_bucket{le="0"} 1`,
+		`levtrie_results_bucket{le="1"} 2`,
+		`levtrie_results_bucket{le="2"} 3`,
+		`levtrie_results_sum 3`,
+		`levtrie_results_count 3`,
+		`levtrie_dictionary_keys{dictionary="en"} 2`,
+		`levtrie_dictionary_keys{dictionary="café \"fr\"\n\\"} 2`,
+		"# TYPE levtrie_results histogram",
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("Metrics don't contain %q:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package httpsuggest
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Scorer returns the score of a result for a query. Results with higher
+// scores are ranked first.
+type Scorer func(query string, r Result) float64
+
+// Filter transforms the ranked results of a query, for example by removing
+// results that shouldn't be shown or by promoting some of them.
+type Filter func(query string, results []Result) []Result
+
+// ScoreWeights configures the Scorer returned by LinearScorer.
+type ScoreWeights struct {
+	// Distance is subtracted from the score for each edit between the
+	// query and the key.
+	Distance float64
+	// Weight is multiplied by the log of 1 + the weight of the key (see
+	// Result.Weight) and added to the score.
+	Weight float64
+	// Recency is multiplied by a factor between 0 and 1 that decays with the
+	// time since the key was last used, as returned by LastUsed, and added
+	// to the score. The factor halves every HalfLife.
+	Recency  float64
+	LastUsed func(key string) time.Time
+	HalfLife time.Duration
+}
+
+// LinearScorer returns a Scorer that combines the edit distance, weight and
+// recency of a result linearly, as described by w.
+func LinearScorer(w ScoreWeights) Scorer {
+	return func(query string, r Result) float64 {
+		score := -w.Distance*float64(r.Distance) + w.Weight*math.Log1p(float64(r.Weight))
+		if w.Recency != 0 && w.LastUsed != nil && w.HalfLife > 0 {
+			if last := w.LastUsed(r.Key); !last.IsZero() {
+				age := time.Since(last)
+				if age < 0 {
+					age = 0
+				}
+				score += w.Recency * math.Exp2(-float64(age)/float64(w.HalfLife))
+			}
+		}
+		return score
+	}
+}
+
+// Keep returns a Filter that keeps only the results whose keys satisfy keep,
+// for example to drop offensive words.
+func Keep(keep func(key string) bool) Filter {
+	return func(query string, results []Result) []Result {
+		kept := results[:0]
+		for _, r := range results {
+			if keep(r.Key) {
+				kept = append(kept, r)
+			}
+		}
+		return kept
+	}
+}
+
+// ranked returns true if results are ranked by a Scorer or Filters.
+func (h *handler) ranked() bool {
+	return h.opts.Scorer != nil || len(h.opts.Filters) > 0
+}
+
+func (o *Options) candidates(n int) int {
+	if o.Candidates < n {
+		return 5 * n
+	}
+	return o.Candidates
+}
+
+// rank sorts results by score, applies filters and keeps the first qr.n.
+func (h *handler) rank(qr *query, results []Result) []Result {
+	if h.opts.Scorer != nil {
+		scores := make(map[string]float64, len(results))
+		for _, r := range results {
+			scores[r.Key] = h.opts.Scorer(qr.q, r)
+		}
+		sort.SliceStable(results, func(i, j int) bool {
+			return scores[results[i].Key] > scores[results[j].Key]
+		})
+	}
+	for _, f := range h.opts.Filters {
+		results = f(qr.q, results)
+	}
+	if len(results) > qr.n {
+		results = results[:qr.n]
+	}
+	return results
+}
@@ -0,0 +1,65 @@
+package httpsuggest
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aaw/levtrie"
+)
+
+func TestScorer(t *testing.T) {
+	tr := levtrie.New()
+	tr.IncrBy("hello", 1)
+	tr.IncrBy("help", 1000)
+	tr.IncrBy("helot", 10)
+	h := Handler(tr, Options{Scorer: LinearScorer(ScoreWeights{Distance: 1, Weight: 1})})
+	// Every key is 1 edit from helo, so the heaviest key comes first.
+	code, body := get(t, h, "/search?q=helo&d=1&e=0&n=2")
+	if want := `["help","helot"]`; code != 200 || strings.TrimSpace(string(body)) != want {
+		t.Errorf("Got %v %s, want %v", code, body, want)
+	}
+	// A much heavier distance weight ranks the exact match first.
+	h = Handler(tr, Options{Scorer: LinearScorer(ScoreWeights{Distance: 100, Weight: 1})})
+	code, body = get(t, h, "/search?q=hello&d=1&e=0&n=1")
+	if want := `["hello"]`; code != 200 || strings.TrimSpace(string(body)) != want {
+		t.Errorf("Got %v %s, want %v", code, body, want)
+	}
+}
+
+func TestLinearScorerRecency(t *testing.T) {
+	last := map[string]time.Time{"new": time.Now(), "old": time.Now().Add(-time.Hour)}
+	s := LinearScorer(ScoreWeights{
+		Recency:  1,
+		LastUsed: func(key string) time.Time { return last[key] },
+		HalfLife: time.Hour,
+	})
+	if got := s("q", Result{Key: "new"}); math.Abs(got-1) > 0.01 {
+		t.Errorf("Got score %v for a new key, want 1", got)
+	}
+	if got := s("q", Result{Key: "old"}); math.Abs(got-0.5) > 0.01 {
+		t.Errorf("Got score %v for an hour old key, want 0.5", got)
+	}
+	if got := s("q", Result{Key: "never"}); got != 0 {
+		t.Errorf("Got score %v for an unused key, want 0", got)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	tr := newTrie("hello", "help", "helot", "hel")
+	reverse := func(q string, rs []Result) []Result {
+		for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+			rs[i], rs[j] = rs[j], rs[i]
+		}
+		return rs
+	}
+	h := Handler(tr, Options{
+		Scorer:  func(q string, r Result) float64 { return float64(len(r.Key)) },
+		Filters: []Filter{Keep(func(k string) bool { return k != "helot" }), reverse},
+	})
+	code, body := get(t, h, "/search?q=helo&d=1&e=0&n=2")
+	if want := `["hel","help"]`; code != 200 || strings.TrimSpace(string(body)) != want {
+		t.Errorf("Got %v %s, want %v", code, body, want)
+	}
+}
@@ -0,0 +1,101 @@
+package httpsuggest
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxIdleClients is the number of clients tracked by a rateLimiter before it
+// forgets clients that haven't made a request recently.
+const maxIdleClients = 10000
+
+// rateLimiter limits the rate of requests from each client using a token
+// bucket per client.
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // Tokens added to each bucket per second.
+	burst   float64 // The capacity of each bucket.
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time // The time tokens was last updated.
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst <= 0 {
+		burst = int(math.Ceil(rate))
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// refill adds the tokens accumulated by b since it was last updated.
+func (l *rateLimiter) refill(b *bucket, now time.Time) {
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+}
+
+// allow takes a token from the bucket of the given client. If the bucket is
+// empty, it returns false along with the time until the next token arrives.
+func (l *rateLimiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	b, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= maxIdleClients {
+			l.forgetIdle(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	l.refill(b, now)
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// forgetIdle removes the buckets of clients whose buckets have refilled, which
+// behave the same as new buckets.
+func (l *rateLimiter) forgetIdle(now time.Time) {
+	for client, b := range l.buckets {
+		if l.refill(b, now); b.tokens >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// clientKey returns the key that identifies the client that made r.
+func (h *handler) clientKey(r *http.Request) string {
+	if h.opts.ClientKey != nil {
+		return h.opts.ClientKey(r)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// allow returns true if the client that made r is under its rate limit.
+// Otherwise, it writes a 429 response and returns false.
+func (h *handler) allow(w http.ResponseWriter, r *http.Request) bool {
+	if h.limiter == nil {
+		return true
+	}
+	ok, wait := h.limiter.allow(h.clientKey(r))
+	if !ok {
+		secs := int(math.Ceil(wait.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(secs))
+		writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+	}
+	return ok
+}
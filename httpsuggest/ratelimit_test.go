@@ -0,0 +1,63 @@
+package httpsuggest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(2, 3)
+	l.now = func() time.Time { return now }
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.allow("a"); !ok {
+			t.Fatalf("Request %v within the burst was denied", i)
+		}
+	}
+	ok, wait := l.allow("a")
+	if ok || wait != 500*time.Millisecond {
+		t.Errorf("Got (%v, %v) after the burst, want (false, 500ms)", ok, wait)
+	}
+	if ok, _ := l.allow("b"); !ok {
+		t.Errorf("Another client was denied")
+	}
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.allow("a"); !ok {
+		t.Errorf("Request after a refill was denied")
+	}
+	if ok, _ := l.allow("a"); ok {
+		t.Errorf("Request was allowed with an empty bucket")
+	}
+	now = now.Add(time.Hour)
+	l.forgetIdle(now)
+	if len(l.buckets) != 0 {
+		t.Errorf("Got %v buckets after all clients went idle, want 0", len(l.buckets))
+	}
+}
+
+func TestHandlerRateLimit(t *testing.T) {
+	h := Handler(newTrie("hello"), Options{RateLimit: 0.001, RateBurst: 2})
+	codes := []int{}
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/search?q=hello", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		h.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+		if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+			t.Errorf("Got a 429 response without Retry-After")
+		}
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Errorf("Got status codes %v, want [200 200 429]", codes)
+	}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/search?q=hello", nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Another client got status %v, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,141 @@
+package httpsuggest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Shards is a client for a dictionary split across several servers, each
+// serving one shard of it with Handler. Suggest sends a query to every shard
+// at once and merges their results into what a single server holding the
+// whole dictionary would return.
+type Shards struct {
+	// URLs are the URLs of the handlers of the shards, like
+	// "http://shard1:8080/suggest".
+	URLs []string
+	// Client sends the requests. If it's nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+// Suggest sends a request with the query params in params (see the package
+// documentation) to every shard and returns up to n merged Results, where n
+// is the n param or, if there's none, the most results returned by any
+// shard. The verbose param is always set, since merging needs the distance
+// and weight of each result. If some shards fail, Suggest returns the merged
+// results of the others along with an error describing the failures, so the
+// caller can choose between partial results and none.
+func (s *Shards) Suggest(ctx context.Context, params url.Values) ([]Result, error) {
+	q := url.Values{}
+	for k, vs := range params {
+		q[k] = vs
+	}
+	q.Set("verbose", "1")
+	n := -1
+	if v := params.Get("n"); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil || n < 0 {
+			return nil, fmt.Errorf("n: %q isn't a non-negative integer", v)
+		}
+	}
+	results := make([][]Result, len(s.URLs))
+	errs := make([]error, len(s.URLs))
+	var wg sync.WaitGroup
+	for i, u := range s.URLs {
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			results[i], errs[i] = s.get(ctx, u, q)
+		}(i, u)
+	}
+	wg.Wait()
+	if n < 0 {
+		for _, rs := range results {
+			if len(rs) > n {
+				n = len(rs)
+			}
+		}
+	}
+	return Merge(n, results...), errors.Join(errs...)
+}
+
+// get returns the Results of the handler at u for the query params q.
+func (s *Shards) get(ctx context.Context, u string, q url.Values) ([]Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return nil, fmt.Errorf("%v: %v %v", u, resp.Status, body.Error)
+	}
+	var results []Result
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("%v: %v", u, err)
+	}
+	return results, nil
+}
+
+// Merge merges the verbose Results returned by several shards for the same
+// query into up to n Results for distinct keys, ordered the way a single
+// handler orders them: matches before keys found by suffix expansion, which a
+// handler only adds once it runs out of matches, then by increasing distance,
+// decreasing weight and key. A key returned by more than one shard, as happens
+// when shards overlap while keys are moved between them, is kept once with
+// its best Source and Distance. Each shard must have been asked for n results,
+// since the first n results overall may all come from one shard.
+//
+// Results ranked by a Scorer on the shards are merged by the same order,
+// since their scores aren't returned.
+func Merge(n int, shards ...[]Result) []Result {
+	best := make(map[string]int)
+	merged := []Result{}
+	for _, rs := range shards {
+		for _, r := range rs {
+			i, ok := best[r.Key]
+			if !ok {
+				best[r.Key] = len(merged)
+				merged = append(merged, r)
+			} else if resultBefore(r, merged[i]) {
+				merged[i] = r
+			}
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return resultBefore(merged[i], merged[j]) })
+	if n >= 0 && len(merged) > n {
+		merged = merged[:n]
+	}
+	return merged
+}
+
+// resultBefore returns true if a comes before b in the order of Merge.
+func resultBefore(a, b Result) bool {
+	if (a.Source == SourcePrefix) != (b.Source == SourcePrefix) {
+		return b.Source == SourcePrefix
+	}
+	if a.Distance != b.Distance {
+		return a.Distance < b.Distance
+	}
+	if a.Weight != b.Weight {
+		return a.Weight > b.Weight
+	}
+	return a.Key < b.Key
+}
@@ -0,0 +1,65 @@
+package httpsuggest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestShards(t *testing.T) {
+	a := newTrie("hello", "help", "helmet")
+	a.IncrBy("help", 5)
+	b := newTrie("hello", "helot", "yellow")
+	var urls []string
+	for _, tr := range []Backend{a, b} {
+		s := httptest.NewServer(Handler(tr, Options{}))
+		defer s.Close()
+		urls = append(urls, s.URL)
+	}
+	shards := &Shards{URLs: urls}
+	results, err := shards.Suggest(context.Background(), url.Values{"q": {"helo"}, "d": {"1"}, "p": {"0"}, "n": {"4"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Key+":"+r.Source)
+	}
+	want := []string{"help:match", "hello:match", "helot:match", "helmet:prefix"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	// A failed shard still leaves the results of the others.
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	shards.URLs = append(shards.URLs, down.URL)
+	results, err = shards.Suggest(context.Background(), url.Values{"q": {"yelow"}, "d": {"1"}})
+	if err == nil || len(results) != 1 || results[0].Key != "yellow" {
+		t.Errorf("Got %v and error %v, want yellow and an error", results, err)
+	}
+	shards.URLs = urls
+	if _, err := shards.Suggest(context.Background(), url.Values{"q": {"x"}, "d": {"9"}}); err == nil {
+		t.Error("Suggest didn't return the error of an invalid request")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	got := Merge(3,
+		[]Result{{Key: "b", Distance: 1, Source: SourceMatch}, {Key: "c", Distance: 0, Source: SourcePrefix}},
+		[]Result{{Key: "c", Distance: 2, Source: SourceMatch}, {Key: "a", Distance: 1, Weight: 2, Source: SourceMatch}},
+		nil)
+	want := []Result{
+		{Key: "a", Distance: 1, Weight: 2, Source: SourceMatch},
+		{Key: "b", Distance: 1, Source: SourceMatch},
+		{Key: "c", Distance: 2, Source: SourceMatch},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge = %+v, want %+v", got, want)
+	}
+	if got := Merge(0, want); len(got) != 0 || got == nil {
+		t.Errorf("Merge(0) = %v, want no results", got)
+	}
+}
@@ -0,0 +1,203 @@
+package httpsuggest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// sessionCacheSize is the number of recent queries whose results are kept by
+// each streaming session.
+const sessionCacheSize = 32
+
+// StreamHandler returns an http.Handler that streams suggestions from t to
+// clients as they type, using server-sent events.
+//
+// A client opens a stream with a GET request that accepts text/event-stream.
+// The first event on the stream is a "session" event whose data is a session
+// id. The client then sends each version of the query as it's typed in a
+// request with a session param set to the session id and the query params
+// described in the package documentation, which gets an empty 202 response.
+// The results of each query are sent on the stream in a "suggestions" event
+// whose data is a JSON object with the query in its "q" field and the results
+// in its "results" field.
+//
+// Queries that are superseded by a newer query from the same session before
+// the server gets to them are skipped, and the results of recent queries are
+// reused when they're repeated, which is common as users backspace. Results
+// are reused within each session unless Options.CacheSize is set, in which
+// case they're reused from a cache shared by all sessions that's invalidated
+// whenever t changes. Like the handler returned by Handler, the handler only
+// reads from t.
+func StreamHandler(t Backend, opts Options) http.Handler {
+	s := watch(t)
+	return newStreamHandler(func() *source { return s }, opts)
+}
+
+func newStreamHandler(trie func() *source, opts Options) *streamHandler {
+	return &streamHandler{h: newHandler(trie, opts), sessions: make(map[string]*session)}
+}
+
+type streamHandler struct {
+	h        *handler
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// session is the state of a single stream.
+type session struct {
+	mu      sync.Mutex
+	pending *query        // The latest query that hasn't been answered.
+	notify  chan struct{} // Signaled when pending is set.
+	// Results of recent queries on the Trie t, keyed by cacheKey, and the
+	// order in which they were added.
+	t     *source
+	cache map[string][]Result
+	order []string
+}
+
+func newSession() *session {
+	return &session{notify: make(chan struct{}, 1), cache: make(map[string][]Result)}
+}
+
+// push replaces any pending query with qr.
+func (s *session) push(qr *query) {
+	s.mu.Lock()
+	s.pending = qr
+	s.mu.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pop returns the pending query, or nil if there isn't one.
+func (s *session) pop() *query {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	qr := s.pending
+	s.pending = nil
+	return qr
+}
+
+func cacheKey(qr *query) string {
+	return fmt.Sprintf("%+v", *qr)
+}
+
+// suggest returns the results of qr, reusing the results of a recent query in
+// the session if possible.
+func (s *session) suggest(h *handler, qr *query) []Result {
+	if h.cache != nil {
+		return h.suggest(h.trie(), qr)
+	}
+	if t := h.trie(); t != s.t {
+		// The Trie was replaced, so cached results may be stale.
+		s.t = t
+		s.cache = make(map[string][]Result)
+		s.order = nil
+	}
+	key := cacheKey(qr)
+	if results, ok := s.cache[key]; ok {
+		return results
+	}
+	results := h.suggest(s.t, qr)
+	if len(s.order) >= sessionCacheSize {
+		delete(s.cache, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.cache[key] = results
+	s.order = append(s.order, key)
+	return results
+}
+
+func newSessionID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+func (sh *streamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	if id := params.Get("session"); id != "" {
+		sh.query(w, r, id)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	sh.stream(w, r)
+}
+
+// query handles a request that sends a query to the session with the given
+// id.
+func (sh *streamHandler) query(w http.ResponseWriter, r *http.Request, id string) {
+	sh.mu.Lock()
+	s := sh.sessions[id]
+	sh.mu.Unlock()
+	if s == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("session %q not found", id))
+		return
+	}
+	if !sh.h.allow(w, r) {
+		sh.h.observe(RequestInfo{Status: http.StatusTooManyRequests})
+		return
+	}
+	qr, err := sh.h.parseQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		sh.h.observe(RequestInfo{Status: http.StatusBadRequest})
+		return
+	}
+	s.push(qr)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// stream handles a request that opens a stream, sending results to the
+// client until the client goes away.
+func (sh *streamHandler) stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+	id := newSessionID()
+	s := newSession()
+	sh.mu.Lock()
+	sh.sessions[id] = s
+	sh.mu.Unlock()
+	defer func() {
+		sh.mu.Lock()
+		delete(sh.sessions, id)
+		sh.mu.Unlock()
+	}()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "event: session\ndata: %s\n\n", id)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.notify:
+		}
+		qr := s.pop()
+		if qr == nil {
+			continue
+		}
+		results := sh.h.answer(qr, func(qr *query) []Result { return s.suggest(sh.h, qr) })
+		data, _ := json.Marshal(struct {
+			Q       string      `json:"q"`
+			Results interface{} `json:"results"`
+		}{qr.q, render(qr, results)})
+		fmt.Fprintf(w, "event: suggestions\ndata: %s\n\n", data)
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,112 @@
+package httpsuggest
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// readEvent reads the next server-sent event from r.
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var event, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Reading event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStreamHandler(t *testing.T) {
+	srv := httptest.NewServer(StreamHandler(newTrie("hello", "help", "helot", "world"), Options{}))
+	defer srv.Close()
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Got Content-Type %q, want text/event-stream", ct)
+	}
+	events := bufio.NewReader(resp.Body)
+	event, id := readEvent(t, events)
+	if event != "session" || id == "" {
+		t.Fatalf("Got event %q with data %q, want a session event", event, id)
+	}
+	for _, test := range []struct {
+		params string
+		want   []string
+	}{
+		{"q=hel&d=0&e=1", []string{"hello", "helot", "help"}},
+		{"q=helo&d=1&e=0", []string{"hello", "helot", "help"}},
+		{"q=hel&d=0&e=1", []string{"hello", "helot", "help"}},
+		{"q=wrld&d=1", []string{"world"}},
+	} {
+		qresp, err := http.Post(srv.URL+"?session="+id+"&"+test.params, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		qresp.Body.Close()
+		if qresp.StatusCode != http.StatusAccepted {
+			t.Fatalf("%v: got status %v, want 202", test.params, qresp.StatusCode)
+		}
+		event, data := readEvent(t, events)
+		if event != "suggestions" {
+			t.Fatalf("%v: got event %q, want suggestions", test.params, event)
+		}
+		var got struct {
+			Q       string
+			Results []string
+		}
+		if err := json.Unmarshal([]byte(data), &got); err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(got.Results)
+		if !reflect.DeepEqual(got.Results, test.want) {
+			t.Errorf("%v: got %v, want %v", test.params, got.Results, test.want)
+		}
+	}
+}
+
+func TestStreamHandlerErrors(t *testing.T) {
+	h := StreamHandler(newTrie("hello"), Options{})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/?session=nope&q=hello", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Unknown session: got status %v, want 404", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/?q=hello", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST without a session: got status %v, want 405", rec.Code)
+	}
+}
+
+func TestSessionCoalescesQueries(t *testing.T) {
+	s := newSession()
+	s.push(&query{q: "h"})
+	s.push(&query{q: "he"})
+	if qr := s.pop(); qr == nil || qr.q != "he" {
+		t.Errorf("Got %v, want the latest query", qr)
+	}
+	if qr := s.pop(); qr != nil {
+		t.Errorf("Got %v, want no pending query", qr)
+	}
+}
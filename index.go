@@ -0,0 +1,88 @@
+package levtrie
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Index is a map from strings to strings that supports searches for keys
+// within an edit distance of a query, the subset of the methods of a Trie
+// that other data structures for approximate string matching can implement
+// as well. Trie and SyncTrie implement Index, and the bktree and symspell
+// packages in this module provide alternatives with different tradeoffs.
+// Applications that only use an Index can choose an implementation with
+// configuration by creating it with NewIndex.
+type Index interface {
+	// Len returns the number of keys in the Index.
+	Len() int
+	// Get returns the value associated with key and true, or the empty
+	// string and false if there's no such key.
+	Get(key string) (string, bool)
+	// Set associates key with val, replacing any previous value.
+	Set(key string, val string)
+	// Delete removes key and its value from the Index.
+	Delete(key string)
+	// Suggest returns up to n KVs with keys that are within edit distance d
+	// of key, as described by Trie.Suggest.
+	Suggest(key string, d int8, n int, opts ...SuggestOption) []KV
+}
+
+var (
+	_ Index = (*Trie)(nil)
+	_ Index = (*SyncTrie)(nil)
+)
+
+var (
+	indexesMu sync.RWMutex
+	indexes   = make(map[string]func() Index)
+)
+
+func init() {
+	RegisterIndex("trie", func() Index { return New() })
+}
+
+// RegisterIndex makes an implementation of Index available to NewIndex under
+// the given name. Packages that implement Index register themselves when
+// they're imported, so importing one for its side effects, as in
+//
+//	import _ "github.com/aaw/levtrie/bktree"
+//
+// is enough to make it available. RegisterIndex panics if newIndex is nil or
+// if it's called twice with the same name.
+func RegisterIndex(name string, newIndex func() Index) {
+	indexesMu.Lock()
+	defer indexesMu.Unlock()
+	if newIndex == nil {
+		panic("levtrie: RegisterIndex called with a nil function")
+	}
+	if _, dup := indexes[name]; dup {
+		panic("levtrie: RegisterIndex called twice for " + name)
+	}
+	indexes[name] = newIndex
+}
+
+// NewIndex returns a new, empty Index of the implementation registered under
+// the given name. A Trie is registered as "trie".
+func NewIndex(name string) (Index, error) {
+	indexesMu.RLock()
+	newIndex, ok := indexes[name]
+	indexesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("levtrie: unknown index %q (forgotten import?)", name)
+	}
+	return newIndex(), nil
+}
+
+// Indexes returns the sorted names of the registered implementations of
+// Index.
+func Indexes() []string {
+	indexesMu.RLock()
+	defer indexesMu.RUnlock()
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,49 @@
+package levtrie
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewIndex(t *testing.T) {
+	x, err := NewIndex("trie")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := x.(*Trie); !ok {
+		t.Errorf("Got %T, want *Trie", x)
+	}
+	x.Set("hello", "1")
+	x.Set("help", "2")
+	if got, want := keystr(x.Suggest("helo", 1, 10)), "hello help"; got != want {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	if _, err := NewIndex("nonexistent"); err == nil {
+		t.Errorf("Got no error for an unknown index")
+	}
+}
+
+func TestRegisterIndex(t *testing.T) {
+	RegisterIndex("test-sync", func() Index { return NewSync() })
+	defer func() {
+		indexesMu.Lock()
+		delete(indexes, "test-sync")
+		indexesMu.Unlock()
+	}()
+	if got := strings.Join(Indexes(), " "); !strings.Contains(got, "test-sync") || !strings.Contains(got, "trie") {
+		t.Errorf("Got indexes %v, want test-sync and trie", got)
+	}
+	x, err := NewIndex("test-sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := x.(*SyncTrie); !ok {
+		t.Errorf("Got %T, want *SyncTrie", x)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Registering a name twice didn't panic")
+		}
+	}()
+	RegisterIndex("trie", func() Index { return New() })
+}
@@ -0,0 +1,141 @@
+package levtrie
+
+// infixIndex is a tree of every suffix of every key in a Trie. A fragment from
+// the middle of a key is a prefix of one of the key's suffixes, so searching
+// the tree for suffixes with a prefix near a fragment finds every key with a
+// substring near it. Each suffix is stored once, no matter how many keys end
+// with it, and the data of its node is a placeholder entry whose key is the
+// suffix.
+type infixIndex struct {
+	root   *node
+	owners map[string]map[string]bool // The keys that end with each suffix.
+}
+
+func newInfixIndex() *infixIndex {
+	return &infixIndex{root: &node{child: make(map[rune]*node)}, owners: make(map[string]map[string]bool)}
+}
+
+// suffixes calls f with each non-empty suffix of key, or with the empty string
+// if key is empty, so that the empty key can be found by short fragments.
+func suffixes(key string, f func(suffix string)) {
+	if key == "" {
+		f("")
+	}
+	for i := range key {
+		f(key[i:])
+	}
+}
+
+func (x *infixIndex) add(key string) {
+	suffixes(key, func(suffix string) {
+		if x.owners[suffix] == nil {
+			x.owners[suffix] = make(map[string]bool)
+			insertAt(x.root, suffix).data = &entry{key: suffix}
+		}
+		x.owners[suffix][key] = true
+	})
+}
+
+func (x *infixIndex) remove(key string) {
+	suffixes(key, func(suffix string) {
+		delete(x.owners[suffix], key)
+		if len(x.owners[suffix]) == 0 {
+			delete(x.owners, suffix)
+			unlink(x.root, suffix)
+		}
+	})
+}
+
+// SuggestInfix returns up to n KVs, all of whose keys contain a substring
+// that is within edit distance d of the input key, which makes it possible to
+// complete a word from a fragment in the middle of it. Example:
+// SuggestInfix("berg", 1, 10) would return up to 10 results which might
+// include "heisenberg", "iceberg" and "bergamot". With the InfixIndex option,
+// the search follows a tree of the suffixes of all keys. Without it, every key
+// in the Trie has to be checked.
+func (t *Trie) SuggestInfix(key string, d int8, n int, opts ...SuggestOption) []KV {
+	key, ok := t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	runes, cfg := extractRunes(key), t.config(key, opts)
+	d = t.distance(len(runes), d)
+	// Matches start in the middle of keys, so the Acceptor of Constrain
+	// checks each match instead of pruning.
+	cfg.prune = false
+	var results []KV
+	if n <= 0 {
+		return results
+	}
+	visit := func(e *entry) bool {
+		if cfg.excluded(e) {
+			return true
+		}
+		results = t.appendKVs(results, e, cfg.valuesPerKey)
+		return len(results) < n && !cfg.stops(e)
+	}
+	if t.infix != nil {
+		seen := make(map[string]bool)
+		// The infix index doesn't keep tags, so tags are only checked by
+		// visit.
+		icfg := *cfg
+		// The filter is applied to the keys of each suffix instead of the
+		// suffix itself. A key can match through several of its suffixes,
+		// so its distance is computed as a scan would compute it.
+		icfg.tags, icfg.tagMask, icfg.unknownTag, icfg.filter, icfg.stop = nil, 0, false, nil, nil
+		var a automaton
+		if cfg.needsDist() {
+			a = newAutomaton(runes, d, cfg)
+		}
+		search(expandSuffixes, t.infix.root, runes, d, &icfg, func(s *entry) bool {
+			for k := range t.infix.owners[s.key] {
+				if seen[k] {
+					continue
+				}
+				e := t.find(k).data
+				if !e.live() {
+					continue
+				}
+				if a != nil {
+					cfg.dist = int8(infixDistance(a, d, k))
+				}
+				if !cfg.keep(e, cfg.dist) {
+					continue
+				}
+				seen[k] = true
+				if !visit(e) {
+					return false
+				}
+			}
+			return true
+		})
+	} else {
+		a := newAutomaton(runes, d, cfg)
+		expandSuffixes(t.tree(), func(e *entry) bool {
+			if dist := infixDistance(a, d, e.key); dist <= int(d) && cfg.keep(e, int8(dist)) {
+				cfg.dist = int8(dist)
+				return visit(e)
+			}
+			return true
+		})
+	}
+	if len(results) > n {
+		results = results[:n]
+	}
+	return results
+}
+
+// infixDistance returns the smallest distance at which the automaton a, which
+// has edit distance d, accepts a substring of key, or more than d if it
+// doesn't accept any.
+func infixDistance(a automaton, d int8, key string) int {
+	best := prefixDistance(a, d, key)
+	for i := range key {
+		if i > 0 {
+			if dist := prefixDistance(a, d, key[i:]); dist < best {
+				best = dist
+			}
+		}
+	}
+	return best
+}
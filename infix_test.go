@@ -0,0 +1,102 @@
+package levtrie
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggestInfix(t *testing.T) {
+	data := []string{"heisenberg", "iceberg", "bergamot", "iceburg", "hamburger", "berm", "zoo", ""}
+	for _, opts := range [][]Option{nil, {InfixIndex()}} {
+		r := New(opts...)
+		for _, key := range data {
+			r.Set(key, key)
+		}
+		if got, want := keystr(r.SuggestInfix("berg", 0, 10)), "bergamot heisenberg iceberg"; got != want {
+			t.Errorf("Got %v, want %v", got, want)
+		}
+		if got, want := keystr(r.SuggestInfix("berg", 1, 10)), "bergamot berm hamburger heisenberg iceberg iceburg"; got != want {
+			t.Errorf("Got %v, want %v", got, want)
+		}
+		if got := r.SuggestInfix("berg", 1, 3); len(got) != 3 {
+			t.Errorf("Got %v, want 3 results", got)
+		}
+		if got, want := keystr(r.SuggestInfix("zo", 2, 10)), keystr(r.SuggestInfix("", 0, 10)); got != want {
+			t.Errorf("Got %v, want every key: %v", got, want)
+		}
+		r.Delete("iceberg")
+		r.Rename("bergamot", "gutenberg")
+		r.Set("heisenberg", "werner")
+		if got, want := keystr(r.SuggestInfix("berg", 0, 10)), "gutenberg heisenberg"; got != want {
+			t.Errorf("Got %v, want %v", got, want)
+		}
+		if err := r.ValidateInvariants(); err != nil {
+			t.Error(err)
+		}
+		r.Clear()
+		if got := r.SuggestInfix("berg", 1, 10); len(got) != 0 {
+			t.Errorf("Got %v after Clear, want nothing", got)
+		}
+	}
+}
+
+func TestSuggestInfixMatchesScan(t *testing.T) {
+	data := generateEdits(6, 500)
+	r, s := New(InfixIndex()), New()
+	for _, key := range data {
+		r.Set(key, "")
+		s.Set(key, "")
+	}
+	for i, key := range data[:20] {
+		frag, d := string(extractRunes(key)[i%3:]), int8(i%3)
+		if got, want := keystr(r.SuggestInfix(frag, d, len(data))), keystr(s.SuggestInfix(frag, d, len(data))); got != want {
+			t.Errorf("SuggestInfix(%q, %v) with the index = %v, without = %v", frag, d, got, want)
+		}
+	}
+	for _, key := range data[:250] {
+		r.Delete(key)
+	}
+	if err := r.ValidateInvariants(); err != nil {
+		t.Error(err)
+	}
+	if len(r.infix.owners) == 0 {
+		t.Errorf("The infix index is empty with %v keys left", r.Len())
+	}
+}
+
+func TestSuggestInfixFilterDistance(t *testing.T) {
+	data := []string{"iceberg", "heisenbrg", "bergamot", "hamburger", "zoo"}
+	var want map[string]int8
+	for _, opts := range [][]Option{nil, {InfixIndex()}} {
+		r := New(opts...)
+		for _, key := range data {
+			r.Set(key, key)
+		}
+		dists := make(map[string]int8)
+		r.SuggestInfix("berg", 1, 10, Filter(func(kv KV, d int8) bool {
+			dists[kv.Key] = d
+			return true
+		}))
+		if want == nil {
+			want = dists
+		} else if !reflect.DeepEqual(dists, want) {
+			t.Errorf("With InfixIndex, Filter saw %v, want %v", dists, want)
+		}
+		if got, want := keystr(r.SuggestInfix("berg", 1, 10, Filter(func(_ KV, d int8) bool { return d == 0 }))), "bergamot iceberg"; got != want {
+			t.Errorf("Exact matches with options %d = %v, want %v", len(opts), got, want)
+		}
+		var stops []int8
+		r.SuggestInfix("berg", 1, 10, StopWhen(func(kv KV, d int8, _ int64) bool {
+			if kv.Key == "heisenbrg" {
+				stops = append(stops, d)
+			}
+			return false
+		}))
+		if !reflect.DeepEqual(stops, []int8{1}) {
+			t.Errorf("StopWhen saw heisenbrg at %v, want [1]", stops)
+		}
+	}
+	if want["heisenbrg"] != 1 || want["iceberg"] != 0 {
+		t.Errorf("Filter saw %v, want heisenbrg at 1 and iceberg at 0", want)
+	}
+}
@@ -0,0 +1,45 @@
+package levtrie
+
+// internedValue is the canonical copy of a value in a Trie that interns
+// values, along with the number of times the value is stored in the Trie.
+type internedValue struct {
+	s    string
+	refs int
+}
+
+// retain returns the canonical copy of v if the Trie interns values, adding v
+// to the interned values if needed. Every call to retain must be balanced by a
+// call to release when the value is removed from the Trie. The empty string is
+// never interned, since there's nothing to save.
+func (t *Trie) retain(v string) string {
+	if t.interned == nil || v == "" {
+		return v
+	}
+	iv, ok := t.interned[v]
+	if !ok {
+		iv = &internedValue{s: v}
+		t.interned[v] = iv
+	}
+	iv.refs++
+	return iv.s
+}
+
+// release drops a reference to v if the Trie interns values, forgetting v once
+// it's no longer stored anywhere in the Trie.
+func (t *Trie) release(v string) {
+	if t.interned == nil || v == "" {
+		return
+	}
+	if iv, ok := t.interned[v]; ok {
+		if iv.refs--; iv.refs <= 0 {
+			delete(t.interned, v)
+		}
+	}
+}
+
+// releaseAll releases each value in vs.
+func (t *Trie) releaseAll(vs []string) {
+	for _, v := range vs {
+		t.release(v)
+	}
+}
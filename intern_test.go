@@ -0,0 +1,50 @@
+package levtrie
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestInternValues(t *testing.T) {
+	r := New(InternValues())
+	langs := []string{"en", "de", "en", "fr", "en", "de"}
+	for i, lang := range langs {
+		// Build each value separately so that they don't share memory.
+		r.Set(strings.Repeat("k", i+1), strings.Repeat(lang, 4))
+	}
+	first, _ := r.Get("k")
+	third, _ := r.Get("kkk")
+	if unsafe.StringData(first) != unsafe.StringData(third) {
+		t.Errorf("Values for k and kkk weren't interned")
+	}
+	st := r.Stats()
+	if st.InternedValues != 3 {
+		t.Errorf("Got %v interned values, want 3", st.InternedValues)
+	}
+	if st.InternedBytesSaved != 24 {
+		t.Errorf("Got %v bytes saved, want 24", st.InternedBytesSaved)
+	}
+	r.Delete("k")
+	r.Set("kk", "xx")
+	r.Add("kkkkkk", "xx")
+	r.Remove("kkkkkk", "dededede")
+	st = r.Stats()
+	if st.InternedValues != 3 {
+		t.Errorf("Got %v interned values, want 3", st.InternedValues)
+	}
+	if st.InternedBytesSaved != 10 {
+		t.Errorf("Got %v bytes saved, want 10", st.InternedBytesSaved)
+	}
+	expectFound(t, r, "kkk", "enenenen")
+	expectFound(t, r, "kkkkkk", "xx")
+}
+
+func TestWithoutInternValues(t *testing.T) {
+	r := New()
+	r.Set("a", "x")
+	r.Set("b", "x")
+	if st := r.Stats(); st.InternedValues != 0 || st.InternedBytesSaved != 0 {
+		t.Errorf("Got interning stats %+v for a Trie without interning", st)
+	}
+}
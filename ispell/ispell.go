@@ -0,0 +1,192 @@
+// Package ispell implements the pipe protocol spoken by ispell -a and aspell
+// -a, so that editors and other tools that integrate with ispell can check
+// spelling against a levtrie dictionary.
+//
+// Serve reads lines of text and responds to each one with a line for each
+// word in it, followed by an empty line. A word that's spelled correctly gets
+// a line containing "*", a misspelled word with corrections gets a line like
+//
+//	& word count offset: correction1, correction2, ...
+//
+// and a misspelled word without corrections gets a line like
+//
+//	# word offset
+//
+// where offset is the 0-based offset, in runes, of the word in the line. Lines
+// starting with one of the following characters are commands:
+//
+//	*word: Accept word for the rest of the session.
+//	@word: Accept word for the rest of the session.
+//	&word: Accept word, lowercased, for the rest of the session.
+//	^text: Check text, even if it starts with a command character.
+//	!: Enter terse mode, where correct words get no response line.
+//	%: Leave terse mode.
+//
+// All other commands (#, +, -, ~, $) are accepted and ignored.
+package ispell
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/aaw/levtrie"
+)
+
+// Banner is the first line written by Serve. Tools check that it starts with
+// "@(#)".
+const Banner = "@(#) International Ispell Version 3.1.20 (but really levtrie)"
+
+// Speller checks the spelling of words.
+type Speller interface {
+	// Correct returns nil if word is spelled correctly and otherwise a
+	// possibly empty list of corrections, best first.
+	Correct(word string) []string
+}
+
+// TrieSpeller is a Speller backed by a Trie whose keys are correctly spelled
+// words, lowercased. Corrections are ranked by edit distance and then by the
+// count of each word (see levtrie.Trie.Count), which is usually its frequency
+// in some corpus.
+type TrieSpeller struct {
+	Trie *levtrie.Trie
+	// Distance is the max edit distance, or total edit cost if Options
+	// include levtrie.EditCosts, of a correction.
+	Distance int8
+	// Max is the max number of corrections returned for a word.
+	Max int
+	// Options are passed to the Suggest calls that find corrections.
+	Options []levtrie.SuggestOption
+}
+
+// maxCandidates bounds the number of words fetched from the Trie for each
+// misspelled word before they're ranked.
+const maxCandidates = 1000
+
+// Correct implements Speller.
+func (s *TrieSpeller) Correct(word string) []string {
+	word = strings.ToLower(word)
+	if _, ok := s.Trie.Get(word); ok {
+		return nil
+	}
+	cands := s.Trie.Suggest(word, s.Distance, maxCandidates, s.Options...)
+	dists := make(map[string]int, len(cands))
+	counts := make(map[string]int64, len(cands))
+	for _, kv := range cands {
+		dists[kv.Key] = levtrie.Distance(word, kv.Key)
+		counts[kv.Key] = s.Trie.Count(kv.Key)
+	}
+	sort.Slice(cands, func(i, j int) bool {
+		a, b := cands[i].Key, cands[j].Key
+		if dists[a] != dists[b] {
+			return dists[a] < dists[b]
+		}
+		if counts[a] != counts[b] {
+			return counts[a] > counts[b]
+		}
+		return a < b
+	})
+	corrections := []string{}
+	seen := make(map[string]bool)
+	for _, kv := range cands {
+		if len(corrections) >= s.Max {
+			break
+		}
+		if !seen[kv.Key] {
+			seen[kv.Key] = true
+			corrections = append(corrections, kv.Key)
+		}
+	}
+	return corrections
+}
+
+// Token is a word in a line of text.
+type Token struct {
+	Word   string
+	Offset int // The 0-based offset, in runes, of the word in the line.
+}
+
+// Tokenize splits a line into words: maximal runs of letters and marks,
+// including apostrophes between letters.
+func Tokenize(line string) []Token {
+	var tokens []Token
+	rs := []rune(line)
+	isWordRune := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsMark(r) }
+	for i := 0; i < len(rs); {
+		if !isWordRune(rs[i]) {
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(rs) && (isWordRune(rs[j]) ||
+			rs[j] == '\'' && j+1 < len(rs) && isWordRune(rs[j+1])) {
+			j++
+		}
+		tokens = append(tokens, Token{Word: string(rs[i:j]), Offset: i})
+		i = j
+	}
+	return tokens
+}
+
+// Serve speaks the ispell -a protocol, reading lines from r and writing
+// responses to w until r is exhausted. Responses are flushed after each line
+// so that Serve can be used interactively over a pipe.
+func Serve(r io.Reader, w io.Writer, sp Speller) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, Banner)
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	accepted := make(map[string]bool)
+	terse := false
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			switch line[0] {
+			case '*', '@':
+				accepted[line[1:]] = true
+				continue
+			case '&':
+				accepted[strings.ToLower(line[1:])] = true
+				continue
+			case '!':
+				terse = true
+				continue
+			case '%':
+				terse = false
+				continue
+			case '#', '+', '-', '~', '$':
+				continue
+			case '^':
+				line = line[1:]
+			}
+		}
+		for _, tok := range Tokenize(line) {
+			var corrections []string
+			if !accepted[tok.Word] && !accepted[strings.ToLower(tok.Word)] {
+				corrections = sp.Correct(tok.Word)
+			}
+			switch {
+			case corrections == nil:
+				if !terse {
+					fmt.Fprintln(bw, "*")
+				}
+			case len(corrections) == 0:
+				fmt.Fprintf(bw, "# %s %d\n", tok.Word, tok.Offset)
+			default:
+				fmt.Fprintf(bw, "& %s %d %d: %s\n", tok.Word, len(corrections), tok.Offset,
+					strings.Join(corrections, ", "))
+			}
+		}
+		fmt.Fprintln(bw)
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
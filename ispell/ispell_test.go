@@ -0,0 +1,76 @@
+package ispell
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aaw/levtrie"
+)
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Don't  stop, héllo-world 'quoted' 42x")
+	want := []Token{{"Don't", 0}, {"stop", 7}, {"héllo", 13}, {"world", 19}, {"quoted", 26}, {"x", 36}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+}
+
+func newSpeller() *TrieSpeller {
+	tr := levtrie.New()
+	for word, count := range map[string]int64{"the": 100, "then": 20, "ten": 5, "tea": 1, "hello": 50} {
+		tr.IncrBy(word, count)
+	}
+	return &TrieSpeller{Trie: tr, Distance: 2, Max: 3}
+}
+
+func TestTrieSpeller(t *testing.T) {
+	sp := newSpeller()
+	if got := sp.Correct("The"); got != nil {
+		t.Errorf("Correct(The) = %v, want nil", got)
+	}
+	if got, want := sp.Correct("teh"), []string{"ten", "tea", "the"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Correct(teh) = %v, want %v", got, want)
+	}
+	if got := sp.Correct("xylophone"); got == nil || len(got) != 0 {
+		t.Errorf("Correct(xylophone) = %#v, want an empty list", got)
+	}
+}
+
+func TestServe(t *testing.T) {
+	input := strings.Join([]string{
+		"teh hello",
+		"xylophone",
+		"*teh",
+		"teh",
+		"!",
+		"hello wrld",
+		"%",
+		"^*hello",
+		"#",
+		"",
+	}, "\n")
+	var out bytes.Buffer
+	if err := Serve(strings.NewReader(input), &out, newSpeller()); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		Banner,
+		"& teh 3 0: ten, tea, the",
+		"*",
+		"",
+		"# xylophone 0",
+		"",
+		"*",
+		"",
+		"# wrld 6",
+		"",
+		"*",
+		"",
+		"",
+	}, "\n")
+	if got := out.String(); got != want {
+		t.Errorf("Got output:\n%q\nwant:\n%q", got, want)
+	}
+}
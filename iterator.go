@@ -0,0 +1,135 @@
+package levtrie
+
+import "sort"
+
+// Iterator visits the keys in a Trie in sorted order, starting from any key.
+// Keys that are prefixes of other keys come first, and runes are compared by
+// code point, which is the same order as comparing keys as strings. An
+// Iterator is invalidated by any change to the Trie; to use one with a
+// SyncTrie, call SyncTrie.View, or iterate over a SyncTrie.Snapshot while
+// writes continue. Don't create directly, use Trie.Iterator() instead.
+//
+// A typical loop over the keys of a Trie starting from "m" looks like:
+//
+//	it := t.Iterator()
+//	for ok := it.Seek("m"); ok; ok = it.Next() {
+//		fmt.Println(it.Key(), it.Value())
+//	}
+//
+// To resume a walk after a checkpoint at the key last, Seek(last) and skip
+// last if it's still there.
+type Iterator struct {
+	t     *Trie
+	stack []iterFrame // The path from the root to the current key.
+	e     *entry      // The current entry, or nil if there isn't one.
+}
+
+// iterFrame is a node on the path to the current key of an Iterator.
+type iterFrame struct {
+	n     *node
+	runes []rune // The runes of n's children, sorted.
+	next  int    // The index in runes of the next child to visit.
+	self  bool   // True once n's own key has been visited.
+}
+
+func newIterFrame(n *node) iterFrame {
+	runes := make([]rune, 0, len(n.child))
+	for r := range n.child {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	return iterFrame{n: n, runes: runes}
+}
+
+// Iterator returns an Iterator positioned at the first key in the Trie.
+func (t *Trie) Iterator() *Iterator {
+	it := &Iterator{t: t}
+	it.Seek("")
+	return it
+}
+
+// Seek positions the Iterator at the first key that's greater than or equal
+// to key, and returns true if there is such a key. Seeking to a prefix
+// positions the Iterator at the first key with that prefix, if there is one.
+func (it *Iterator) Seek(key string) bool {
+	it.stack = append(it.stack[:0], newIterFrame(it.t.tree()))
+	for _, r := range key {
+		f := &it.stack[len(it.stack)-1]
+		// The key at this node is a proper prefix of key, so it comes
+		// before key.
+		f.self = true
+		f.next = sort.Search(len(f.runes), func(i int) bool { return f.runes[i] >= r })
+		if f.next == len(f.runes) || f.runes[f.next] != r {
+			break
+		}
+		f.next++
+		it.stack = append(it.stack, newIterFrame(f.n.child[r]))
+	}
+	return it.advance()
+}
+
+// advance moves the Iterator to the next live key in pre-order, starting with
+// the key at the top of the stack if it hasn't been visited.
+func (it *Iterator) advance() bool {
+	for len(it.stack) > 0 {
+		f := &it.stack[len(it.stack)-1]
+		if !f.self {
+			f.self = true
+			if f.n.data.live() {
+				it.e = f.n.data
+				return true
+			}
+		}
+		if f.next < len(f.runes) {
+			child := f.n.child[f.runes[f.next]]
+			f.next++
+			it.stack = append(it.stack, newIterFrame(child))
+			continue
+		}
+		it.stack = it.stack[:len(it.stack)-1]
+	}
+	it.e = nil
+	return false
+}
+
+// Next moves the Iterator to the next key and returns true if there is one.
+func (it *Iterator) Next() bool {
+	if it.e == nil {
+		return false
+	}
+	return it.advance()
+}
+
+// Valid returns true if the Iterator is positioned at a key.
+func (it *Iterator) Valid() bool {
+	return it.e != nil
+}
+
+// Key returns the current key, or the empty string if the Iterator isn't
+// positioned at a key.
+func (it *Iterator) Key() string {
+	if it.e == nil {
+		return ""
+	}
+	return it.e.key
+}
+
+// Value returns the value of the current key, as Get would return it.
+func (it *Iterator) Value() string {
+	if it.e == nil {
+		return ""
+	}
+	return it.t.output(it.e.key, it.e.value())
+}
+
+// Values returns all values of the current key, as Values would return them.
+func (it *Iterator) Values() []string {
+	if it.e == nil {
+		return nil
+	}
+	vals := make([]string, len(it.e.values))
+	for i, v := range it.e.values {
+		vals[i] = it.t.output(it.e.key, v)
+	}
+	return vals
+}
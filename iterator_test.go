@@ -0,0 +1,104 @@
+package levtrie
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func collect(it *Iterator) []string {
+	var keys []string
+	for ; it.Valid(); it.Next() {
+		keys = append(keys, it.Key())
+	}
+	return keys
+}
+
+func TestIterator(t *testing.T) {
+	keys := generateEdits(4, 500)
+	sort.Strings(keys)
+	if keys[0] != "" {
+		keys = append(keys, "")
+	}
+	r := New()
+	for _, key := range keys {
+		r.Set(key, key+"!")
+	}
+	sort.Strings(keys)
+	if got := collect(r.Iterator()); !reflect.DeepEqual(got, keys) {
+		t.Errorf("Iterator visited %v keys out of order, want %v in order", len(got), len(keys))
+	}
+	for i := 0; i < 100; i++ {
+		target := keys[rand.Intn(len(keys))]
+		runes := extractRunes(target)
+		if len(runes) > 0 && i%2 == 0 {
+			// Seek to something that's usually not a key.
+			runes[len(runes)-1]++
+			target = string(runes)
+		}
+		it := r.Iterator()
+		it.Seek(target)
+		want := keys[sort.SearchStrings(keys, target):]
+		if got := collect(it); !reflect.DeepEqual(got, want) && !(len(got) == 0 && len(want) == 0) {
+			t.Errorf("After Seek(%q), got %v keys, want %v", target, len(got), len(want))
+		}
+	}
+}
+
+func TestIteratorValues(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock = func() time.Time { return now }
+	defer func() { clock = time.Now }()
+	r := New(CompressValues(0, nil))
+	r.Add("b", "1")
+	r.Add("b", "2")
+	r.SetWithTTL("c", "3", time.Second)
+	r.Set("d", "4")
+	now = now.Add(time.Minute)
+	it := r.Iterator()
+	if !it.Valid() || it.Key() != "b" || it.Value() != "1" || !reflect.DeepEqual(it.Values(), []string{"1", "2"}) {
+		t.Errorf("Got (%q, %q, %q), want (b, 1, [1 2])", it.Key(), it.Value(), it.Values())
+	}
+	if !it.Next() || it.Key() != "d" || it.Value() != "4" {
+		t.Errorf("Got (%q, %q), want (d, 4)", it.Key(), it.Value())
+	}
+	if it.Next() || it.Valid() || it.Key() != "" || it.Value() != "" || it.Values() != nil {
+		t.Errorf("Iterator is still valid after the last key")
+	}
+	if it.Next() {
+		t.Errorf("Next succeeded after the last key")
+	}
+	if !it.Seek("a") || it.Key() != "b" {
+		t.Errorf("Seek(a) moved to %q, want b", it.Key())
+	}
+	if it.Seek("e") {
+		t.Errorf("Seek(e) moved to %q, want nothing", it.Key())
+	}
+	if New().Iterator().Valid() {
+		t.Errorf("Iterator over an empty Trie is valid")
+	}
+}
+
+func TestIteratorResume(t *testing.T) {
+	r := New()
+	for _, key := range []string{"a", "ab", "abc", "b", "ba", "c"} {
+		r.Set(key, "")
+	}
+	var got []string
+	it := r.Iterator()
+	for ok := it.Seek(""); ok && len(got) < 3; ok = it.Next() {
+		got = append(got, it.Key())
+	}
+	last := got[len(got)-1]
+	it = r.Iterator()
+	for ok := it.Seek(last); ok; ok = it.Next() {
+		if it.Key() != last {
+			got = append(got, it.Key())
+		}
+	}
+	if want := []string{"a", "ab", "abc", "b", "ba", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,147 @@
+package levtrie
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Journal keeps the most recent changes made to a Trie, so that followers
+// that mirror the Trie can read the changes they haven't seen yet and apply
+// them with ApplyChanges. Register a Journal with OnChange:
+//
+//	j := levtrie.NewJournal(100000)
+//	leader.OnChange(j.Record)
+//	...
+//	ops, err := j.Since(follower.Applied(), 1000) // Sent to the follower.
+//	err = follower.ApplyChanges(ops)
+//
+// A Journal is safe for concurrent use by multiple goroutines, so followers
+// can read it while the Trie is changed.
+type Journal struct {
+	mu    sync.Mutex
+	ops   []Op // A ring buffer of the most recent changes.
+	start int  // The index in ops of the oldest change.
+	n     int  // The number of changes in ops.
+}
+
+// NewJournal returns a Journal that keeps up to the last capacity changes.
+func NewJournal(capacity int) *Journal {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Journal{ops: make([]Op, capacity)}
+}
+
+// Record adds op to the Journal, dropping the oldest change if the Journal is
+// full. It's meant to be registered with OnChange.
+func (j *Journal) Record(op Op) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.n < len(j.ops) {
+		j.ops[(j.start+j.n)%len(j.ops)] = op
+		j.n++
+		return
+	}
+	j.ops[j.start] = op
+	j.start = (j.start + 1) % len(j.ops)
+}
+
+// Last returns the Seq of the newest change in the Journal, or 0 if it's
+// empty.
+func (j *Journal) Last() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.n == 0 {
+		return 0
+	}
+	return j.ops[(j.start+j.n-1)%len(j.ops)].Seq
+}
+
+// Since returns up to n changes that follow the change numbered seq, oldest
+// first, or no changes if there are none yet. A follower resumes by passing
+// the Seq of the last change it applied, so reading the same changes twice
+// after a failure is harmless. Since returns an error if the Journal has
+// already dropped some of the changes that follow seq, in which case the
+// follower has to be rebuilt from a copy of the Trie, with SyncFrom for
+// example, and SetApplied.
+func (j *Journal) Since(seq uint64, n int) ([]Op, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.n == 0 {
+		return nil, nil
+	}
+	first := j.ops[j.start].Seq
+	if seq+1 < first {
+		return nil, fmt.Errorf("levtrie: journal starts at change %v, can't resume after %v", first, seq)
+	}
+	i := 0
+	if seq >= first {
+		i = int(seq - first + 1)
+	}
+	var ops []Op
+	for ; i < j.n && len(ops) < n; i++ {
+		ops = append(ops, j.ops[(j.start+i)%len(j.ops)])
+	}
+	return ops, nil
+}
+
+// ApplyChanges makes the changes described by ops to the Trie, in order,
+// so that a follower Trie can mirror a leader by applying the changes
+// reported by the leader's OnChange hooks, usually through a Journal. Changes
+// whose Seq is no more than Applied() were already applied and are skipped,
+// so ops can be delivered more than once. ApplyChanges returns an error,
+// after applying the changes before it, if a change is missing: if an Op has
+// a Seq more than one past the last one applied. An Op with a Seq of 0 is
+// always applied. The follower numbers the changes it makes and reports them
+// to its own OnChange hooks, so it can be followed in turn.
+func (t *Trie) ApplyChanges(ops []Op) error {
+	for _, op := range ops {
+		if op.Seq != 0 {
+			if op.Seq <= t.applied {
+				continue
+			}
+			if op.Seq > t.applied+1 {
+				return fmt.Errorf("levtrie: missing changes after %v, got %v", t.applied, op.Seq)
+			}
+		}
+		switch op.Kind {
+		case OpSet:
+			if op.Expires.IsZero() {
+				t.Set(op.Key, op.Value)
+			} else {
+				t.setUntil(op.Key, op.Value, op.Expires)
+			}
+		case OpAdd:
+			t.Add(op.Key, op.Value)
+		case OpRemove:
+			t.Remove(op.Key, op.Value)
+		case OpIncr:
+			t.IncrBy(op.Key, op.Delta)
+		case OpDelete:
+			t.Delete(op.Key)
+		case OpClear:
+			t.Clear()
+		case OpRename:
+			t.Rename(op.Key, op.NewKey)
+		default:
+			return fmt.Errorf("levtrie: unknown change kind %v", op.Kind)
+		}
+		if op.Seq != 0 {
+			t.applied = op.Seq
+		}
+	}
+	return nil
+}
+
+// Applied returns the Seq of the last change applied by ApplyChanges, or 0
+// if none have been.
+func (t *Trie) Applied() uint64 {
+	return t.applied
+}
+
+// SetApplied sets the Seq of the last change applied, so that ApplyChanges
+// resumes after the change numbered seq. It's for a follower that was copied
+// from the leader as of that change instead of built by ApplyChanges.
+func (t *Trie) SetApplied(seq uint64) {
+	t.applied = seq
+}
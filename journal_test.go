@@ -0,0 +1,78 @@
+package levtrie
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyChanges(t *testing.T) {
+	leader, follower := New(), New()
+	j := NewJournal(100)
+	leader.OnChange(j.Record)
+	leader.Set("tea", "green")
+	leader.Add("tea", "black")
+	leader.SetWithTTL("ten", "10", time.Hour)
+	leader.IncrBy("to", 3)
+	leader.Rename("ten", "tent")
+	leader.Remove("tea", "green")
+	ops, err := j.Since(follower.Applied(), 4)
+	if err != nil || len(ops) != 4 {
+		t.Fatalf("Since = %v, %v, want 4 changes", ops, err)
+	}
+	// Deliver the first batch twice, as after a lost acknowledgement.
+	for i := 0; i < 2; i++ {
+		if err := follower.ApplyChanges(ops); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ops, _ = j.Since(follower.Applied(), 100)
+	if err := follower.ApplyChanges(ops); err != nil {
+		t.Fatal(err)
+	}
+	if leader.Hash() != follower.Hash() || follower.Count("to") != 3 || follower.Applied() != j.Last() {
+		t.Errorf("Follower has %v, want %v", follower.ToMap(), leader.ToMap())
+	}
+	if got := follower.Values("tea"); len(got) != 1 || got[0] != "black" {
+		t.Errorf("Values(tea) = %v, want black", got)
+	}
+	if ops, err := j.Since(j.Last(), 10); ops != nil || err != nil {
+		t.Errorf("Since(Last()) = %v, %v, want nothing", ops, err)
+	}
+	leader.Delete("tea")
+	leader.Clear()
+	leader.Set("a", "1")
+	ops, _ = j.Since(follower.Applied(), 100)
+	if err := follower.ApplyChanges(ops[1:]); err == nil {
+		t.Error("ApplyChanges didn't fail on a missing change")
+	}
+	if err := follower.ApplyChanges(ops); err != nil || follower.Len() != 1 {
+		t.Errorf("Got %v keys and error %v, want 1 key", follower.Len(), err)
+	}
+}
+
+func TestJournalTruncated(t *testing.T) {
+	leader := New()
+	j := NewJournal(3)
+	leader.OnChange(j.Record)
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		leader.Set(key, key)
+	}
+	if _, err := j.Since(1, 10); err == nil {
+		t.Error("Since didn't fail after changes were dropped")
+	}
+	ops, err := j.Since(2, 10)
+	if err != nil || len(ops) != 3 || ops[0].Key != "c" {
+		t.Errorf("Since(2) = %v, %v, want changes to c, d and e", ops, err)
+	}
+	// A follower copied from the leader resumes from the copy.
+	follower := New()
+	if _, err := follower.SyncFrom(LocalReplica(leader)); err != nil {
+		t.Fatal(err)
+	}
+	follower.SetApplied(j.Last())
+	leader.Delete("a")
+	ops, _ = j.Since(follower.Applied(), 10)
+	if err := follower.ApplyChanges(ops); err != nil || follower.Hash() != leader.Hash() {
+		t.Errorf("Follower has %v and error %v, want %v", follower.ToMap(), err, leader.ToMap())
+	}
+}
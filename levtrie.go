@@ -48,13 +48,46 @@
 package levtrie
 
 import (
+	"time"
 	"unicode/utf8"
 )
 
 // Trie supports common map operations as well as lookups within a given edit
-// distance bound. Don't create directly, use levtrie.New() instead.
+// distance bound. The zero value is an empty Trie without any options, ready
+// to use, so a Trie can be embedded by value in another struct. Use
+// levtrie.New() to create a Trie with options.
 type Trie struct {
-	root *node
+	root    *node
+	size    int      // The number of keys in the Trie.
+	maxKeys int      // The maximum number of keys, or 0 for no maximum.
+	tick    uint64   // A logical clock for access times, see touch.
+	slots   []*entry // All entries when maxKeys > 0, see evict.
+	// Canonical copies of values when values are interned, see retain.
+	interned map[string]*internedValue
+	codec    *codec            // Compresses values, see encode.
+	hooks    []*hook           // Functions registered with OnChange.
+	bloom    *bloom            // Filters out lookups of missing keys, see BloomFilter.
+	grams    *trigramIndex     // Answers searches with large d, see TrigramIndex.
+	rev      *node             // The root of a tree of reversed keys, see ReverseIndex.
+	infix    *infixIndex       // Finds keys by fragments, see InfixIndex.
+	policy   DistancePolicy    // Chooses d when it's negative, see AdaptiveDistance.
+	tagBits  map[string]uint64 // The bit for each tag, see SetTags.
+	// Rewrite or veto queries, see PreprocessQueries.
+	preprocessors []QueryProcessor
+	tokenizer     Tokenizer    // Splits text for CorrectText, see UseAnalyzer.
+	autoCompact   bool         // Whether deletions trigger Compact, see AutoCompact.
+	removed       int          // The number of keys deleted since the last Compact.
+	maint         *maintenance // See Maintenance.
+	// Maps each value before it's returned, see TransformValues.
+	transform func(kv KV) string
+	seq       uint64          // The Seq of the last change, see Op.
+	applied   uint64          // The Seq of the last change applied, see ApplyChanges.
+	deletes   uint64          // The number of keys ever deleted, see Reader.
+	absent    *absentCache    // Keys recently found missing, see NegativeCache.
+	results   *suggestCache   // Results of recent searches, see SuggestCache.
+	progress  *progressConfig // Reports the progress of loads, see LoadProgress.
+	decay     *decay          // Decays counts, see DecayWeights.
+	damerau   bool            // Whether searches allow transpositions, see Damerau.
 }
 
 // KV is a key-value pair, the basic storage unit of the Trie.
@@ -66,37 +99,134 @@ type KV struct {
 // node is a Trie node.
 type node struct {
 	child map[rune]*node
-	data  *KV
+	data  *entry
+	tags  uint64 // The union of the tags of the entries below, see SetTags.
 }
 
-// New returns a new Trie.
-func New() *Trie {
-	return &Trie{root: &node{child: make(map[rune]*node)}}
+// entry holds the values stored in the Trie for a single key.
+type entry struct {
+	key     string
+	values  []string
+	count   int64
+	payload []byte // The value of the key in a BytesTrie.
+	expires int64  // Expiration time in Unix nanoseconds, or 0 for never.
+	access  uint64 // The Trie's tick at the last access, see touch.
+	slot    int    // The index of the entry in the Trie's slots.
+	tags    uint64 // A bit for each of the key's tags, see SetTags.
+	// When IncrBy last changed count in Unix nanoseconds, or 0 if it
+	// never has, see DecayWeights.
+	boosted int64
 }
 
-// Get returns the value stored in the Trie at the given key. If there is no
-// such key in the Trie, it returns the empty string. The second value returned
-// is true exactly when the key exists in the Trie.
-func (t *Trie) Get(key string) (string, bool) {
-	n := t.root
+// clock returns the current time. Tests can replace it to control expiration.
+var clock = time.Now
+
+// live returns true exactly when e is non-nil and hasn't expired. The clock is
+// only consulted for entries with an expiration time.
+func (e *entry) live() bool {
+	return e != nil && (e.expires == 0 || clock().UnixNano() < e.expires)
+}
+
+// value returns the first value stored in the entry.
+func (e *entry) value() string {
+	if len(e.values) == 0 {
+		return ""
+	}
+	return e.values[0]
+}
+
+// output returns the value v stored at key as it's returned to callers,
+// decoded and then transformed as TransformValues asks.
+func (t *Trie) output(key string, v string) string {
+	if v = t.decode(v); t.transform != nil {
+		return t.transform(KV{Key: key, Value: v})
+	}
+	return v
+}
+
+// appendKVs appends a KV to results for each of up to max values stored in
+// the entry e. If max is not positive, all values are appended. An entry with
+// no values, which is created by Incr, is appended as a KV with an empty value.
+func (t *Trie) appendKVs(results []KV, e *entry, max int) []KV {
+	if len(e.values) == 0 {
+		return append(results, KV{Key: e.key, Value: t.output(e.key, "")})
+	}
+	for i, v := range e.values {
+		if max > 0 && i >= max {
+			break
+		}
+		results = append(results, KV{Key: e.key, Value: t.output(e.key, v)})
+	}
+	return results
+}
+
+// New returns a new Trie configured with the given options.
+func New(opts ...Option) *Trie {
+	t := &Trie{root: &node{child: make(map[rune]*node)}}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Len returns the number of keys in the Trie, including expired keys that
+// haven't been removed yet.
+func (t *Trie) Len() int {
+	return t.size
+}
+
+// IsEmpty returns true exactly when Len returns 0. Deleting the last key from a
+// Trie releases all of its nodes other than the root, so an empty Trie holds
+// on to no memory for keys it used to contain.
+func (t *Trie) IsEmpty() bool {
+	return t.size == 0
+}
+
+// emptyRoot stands in for the root of a zero Trie in methods that only read
+// the Trie, so that they can run concurrently without creating the root. It's
+// never modified.
+var emptyRoot = &node{child: map[rune]*node{}}
+
+// tree returns the root of the Trie for reading.
+func (t *Trie) tree() *node {
+	if t.root == nil {
+		return emptyRoot
+	}
+	return t.root
+}
+
+// ensureRoot returns the root of the Trie for writing, creating it if the
+// Trie is the zero value.
+func (t *Trie) ensureRoot() *node {
+	if t.root == nil {
+		t.root = &node{child: make(map[rune]*node)}
+	}
+	return t.root
+}
+
+// find returns the node for the given key, or nil if there's no such node.
+func (t *Trie) find(key string) *node {
+	n := t.tree()
 	var ok bool
 	var r rune
 	for i, w := 0, 0; i < len(key); i += w {
 		r, w = utf8.DecodeRuneInString(key[i:])
 		if n, ok = n.child[r]; !ok {
-			return "", false
+			return nil
 		}
 	}
-	if n.data != nil {
-		return n.data.Value, true
-	}
-	return "", false
+	return n
 }
 
-// Set associates key with val in the Trie. A subsequent call to Get(key)
-// will return (val, true).
-func (t *Trie) Set(key string, val string) {
-	n := t.root
+// insert returns the node for the given key, creating it and any missing
+// nodes on the path to it.
+func (t *Trie) insert(key string) *node {
+	return insertAt(t.ensureRoot(), key)
+}
+
+// insertAt returns the node for the given key in the tree of nodes under n,
+// creating it and any missing nodes on the path to it.
+func insertAt(n *node, key string) *node {
 	var r rune
 	for i, w := 0, 0; i < len(key); i += w {
 		r, w = utf8.DecodeRuneInString(key[i:])
@@ -109,34 +239,414 @@ func (t *Trie) Set(key string, val string) {
 		}
 
 	}
-	n.data = &KV{Key: key, Value: val}
+	return n
+}
+
+// lookup returns the live entry for the given key, or nil if there's no such
+// entry.
+func (t *Trie) lookup(key string) *entry {
+	if !t.mayContain(key) {
+		return nil
+	}
+	if n := t.find(key); n != nil && n.data.live() {
+		t.touch(n.data)
+		return n.data
+	}
+	t.missed(key)
+	return nil
+}
+
+// upsert returns the live entry for the given key, creating it if it doesn't
+// exist and replacing it if it's expired. Creating an entry may evict another
+// key from the Trie if the Trie has a maximum number of keys.
+func (t *Trie) upsert(key string) *entry {
+	return t.upsertAt(t.insert(key), key)
+}
+
+// upsertAt is upsert for the node n that was returned by insert(key).
+func (t *Trie) upsertAt(n *node, key string) *entry {
+	if n.data.live() {
+		t.touch(n.data)
+		return n.data
+	}
+	e := &entry{key: key}
+	if n.data == nil {
+		t.size++
+		t.addSlot(e)
+		t.indexKey(e)
+	} else {
+		t.releaseAll(n.data.values)
+		t.replaceSlot(n.data, e)
+		t.reindexKey(e)
+	}
+	n.data = e
+	t.touch(e)
+	if t.maxKeys > 0 && t.size > t.maxKeys {
+		t.evict(e)
+	}
+	return e
+}
+
+// indexKey adds the key of e, which was just added to the Trie, to the
+// structures kept alongside the Trie by options like BloomFilter.
+func (t *Trie) indexKey(e *entry) {
+	t.addToBloom(e.key)
+	if t.absent != nil {
+		t.absent.remove(e.key)
+	}
+	if t.grams != nil {
+		t.grams.add(e.key)
+	}
+	if t.rev != nil {
+		insertAt(t.rev, reverse(e.key)).data = e
+	}
+	if t.infix != nil {
+		t.infix.add(e.key)
+	}
+}
+
+// reindexKey updates the structures kept alongside the Trie when the entry for
+// a key is replaced by e.
+func (t *Trie) reindexKey(e *entry) {
+	if t.absent != nil {
+		t.absent.remove(e.key)
+	}
+	if t.rev != nil {
+		insertAt(t.rev, reverse(e.key)).data = e
+	}
+}
+
+// unindexKey removes key, which was just removed from the Trie, from the
+// structures kept alongside the Trie. Bloom filters can't forget keys, so
+// they're rebuilt as they grow instead.
+func (t *Trie) unindexKey(key string) {
+	if t.grams != nil {
+		t.grams.remove(key)
+	}
+	if t.rev != nil {
+		unlink(t.rev, reverse(key))
+	}
+	if t.infix != nil {
+		t.infix.remove(key)
+	}
+}
+
+// Get returns the value stored in the Trie at the given key. If there is no
+// such key in the Trie, it returns the empty string. The second value returned
+// is true exactly when the key exists in the Trie. If more than one value is
+// associated with the key, Get returns the first one. Use Values to get all of
+// them.
+func (t *Trie) Get(key string) (string, bool) {
+	key, ok := t.preprocess(key)
+	if !ok {
+		return "", false
+	}
+	if e := t.lookup(key); e != nil {
+		return t.output(key, e.value()), true
+	}
+	return "", false
+}
+
+// Values returns all values associated with the given key in the order they
+// were added, or nil if there is no such key in the Trie.
+func (t *Trie) Values(key string) []string {
+	key, ok := t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	if e := t.lookup(key); e != nil {
+		vals := make([]string, len(e.values))
+		for i, v := range e.values {
+			vals[i] = t.output(key, v)
+		}
+		return vals
+	}
+	return nil
+}
+
+// emptyValue holds the values of every entry whose only value is empty. It's
+// never modified in place, so entries can share it.
+var emptyValue = []string{""}
+
+// single returns the values of an entry whose only value is val. Empty values,
+// which are common in tries used as sets of keys, are stored without any
+// allocation, encoding or interning.
+func (t *Trie) single(val string) []string {
+	if val == "" {
+		return emptyValue
+	}
+	return []string{t.retain(t.encode(val))}
+}
+
+// Has returns true exactly when key is in the Trie. Unlike Get, Has doesn't
+// look at the values associated with key, so it's the cheapest way to check
+// membership in a Trie used as a set of keys.
+func (t *Trie) Has(key string) bool {
+	key, ok := t.preprocess(key)
+	if !ok {
+		return false
+	}
+	return t.lookup(key) != nil
+}
+
+// Set associates key with val in the Trie, replacing any values previously
+// associated with key and removing any expiration time set by SetWithTTL. A
+// subsequent call to Get(key) will return (val, true).
+func (t *Trie) Set(key string, val string) {
+	t.set(t.upsert(key), val)
+}
+
+// set replaces the values of the entry e with val, like Set.
+func (t *Trie) set(e *entry, val string) {
+	t.releaseAll(e.values)
+	e.values = t.single(val)
+	e.expires = 0
+	t.notify(Op{Kind: OpSet, Key: e.key, Value: val})
+}
+
+// SetWithTTL associates key with val in the Trie like Set, but the key expires
+// after the duration ttl has passed. Expired keys are ignored by all methods
+// that read from the Trie and are replaced by subsequent writes to the same
+// key, but they're only removed from the Trie when RemoveExpired is called.
+// Add, Remove and Incr don't change the expiration time of a key.
+func (t *Trie) SetWithTTL(key string, val string, ttl time.Duration) {
+	t.setUntil(key, val, clock().Add(ttl))
+}
+
+// setUntil associates key with val like SetWithTTL, but the key expires at
+// the time expires.
+func (t *Trie) setUntil(key string, val string, expires time.Time) {
+	e := t.upsert(key)
+	t.releaseAll(e.values)
+	e.values = t.single(val)
+	e.expires = expires.UnixNano()
+	t.notify(Op{Kind: OpSet, Key: key, Value: val, Expires: e.expiration()})
+}
+
+// RemoveExpired removes all keys whose TTL has passed from the Trie and
+// returns the number of keys removed.
+func (t *Trie) RemoveExpired() int {
+	var expired []string
+	stack := []*node{t.tree()}
+	for len(stack) > 0 {
+		var x *node
+		x, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		if x.data != nil && !x.data.live() {
+			expired = append(expired, x.data.key)
+		}
+		for _, child := range x.child {
+			stack = append(stack, child)
+		}
+	}
+	for _, key := range expired {
+		if t.delete(key) {
+			t.notify(Op{Kind: OpDelete, Key: key})
+		}
+	}
+	return len(expired)
+}
+
+// Add associates val with key in the Trie in addition to any values already
+// associated with key, so that a single key can map to a set of values. Adding
+// a value that's already associated with key has no effect. Each of the Suggest
+// methods returns a separate KV for each value associated with a key.
+func (t *Trie) Add(key string, val string) {
+	e := t.upsert(key)
+	for _, v := range e.values {
+		if t.decode(v) == val {
+			return
+		}
+	}
+	e.values = append(e.values, t.retain(t.encode(val)))
+	t.notify(Op{Kind: OpAdd, Key: key, Value: val})
+}
+
+// Remove disassociates val from key in the Trie, leaving any other values
+// associated with key in place. If val is the only value associated with key,
+// Remove is equivalent to Delete(key).
+func (t *Trie) Remove(key string, val string) {
+	e := t.lookup(key)
+	if e == nil {
+		return
+	}
+	for i, v := range e.values {
+		if t.decode(v) != val {
+			continue
+		}
+		if len(e.values) == 1 {
+			t.delete(key)
+		} else {
+			t.release(v)
+			// Copy rather than splice in place, since Values may
+			// have handed out the old slice.
+			vals := make([]string, 0, len(e.values)-1)
+			vals = append(vals, e.values[:i]...)
+			e.values = append(vals, e.values[i+1:]...)
+		}
+		t.notify(Op{Kind: OpRemove, Key: key, Value: val})
+		return
+	}
+}
+
+// Incr increments the count associated with key in the Trie and returns the
+// new count. If key isn't in the Trie, it's added with an empty value and a
+// count of 1. Counts are stored separately from values, so Set and Add leave
+// the count of a key alone.
+func (t *Trie) Incr(key string) int64 {
+	return t.IncrBy(key, 1)
+}
+
+// IncrBy adds delta to the count associated with key in the Trie and returns
+// the new count. If key isn't in the Trie, it's added with an empty value and
+// a count of delta. With DecayWeights, delta is added to the decayed count.
+func (t *Trie) IncrBy(key string, delta int64) int64 {
+	e := t.upsert(key)
+	if t.decay != nil {
+		t.decay.rebase(e)
+	}
+	e.count += delta
+	t.notify(Op{Kind: OpIncr, Key: key, Delta: delta})
+	return e.count
+}
+
+// Count returns the count associated with key in the Trie, which is 0 if the
+// key has never been incremented or isn't in the Trie. With DecayWeights,
+// it's the count decayed to now.
+func (t *Trie) Count(key string) int64 {
+	key, ok := t.preprocess(key)
+	if !ok {
+		return 0
+	}
+	if e := t.lookup(key); e != nil {
+		return t.count(e)
+	}
+	return 0
 }
 
 // Delete removes the key from the Trie. A subsequent call to Get(key) will
 // return ("", false).
 func (t *Trie) Delete(key string) {
-	n := t.root
+	if t.delete(key) {
+		t.notify(Op{Kind: OpDelete, Key: key})
+	}
+}
+
+// CompareAndSwap sets the value of key to new, like Set, if key is in the Trie
+// and Get(key) would return old. It returns true exactly when it sets the
+// value. On a SyncTrie, this lets concurrent writers update a key based on its
+// current value without holding a lock between reading and writing it.
+func (t *Trie) CompareAndSwap(key string, old string, new string) bool {
+	e := t.lookup(key)
+	if e == nil || t.decode(e.value()) != old {
+		return false
+	}
+	t.set(e, new)
+	return true
+}
+
+// CompareAndDelete deletes key, like Delete, if key is in the Trie and Get(key)
+// would return old. It returns true exactly when it deletes key.
+func (t *Trie) CompareAndDelete(key string, old string) bool {
+	e := t.lookup(key)
+	if e == nil || t.decode(e.value()) != old {
+		return false
+	}
+	t.Delete(key)
+	return true
+}
+
+// Rename moves the values, count and expiration time of oldKey to newKey in a
+// single operation, replacing anything stored at newKey, and returns true. If
+// oldKey isn't in the Trie, Rename returns false and leaves the Trie alone.
+// On a SyncTrie, readers never see both keys or neither of them.
+func (t *Trie) Rename(oldKey string, newKey string) bool {
+	e := t.lookup(oldKey)
+	if e == nil {
+		return false
+	}
+	if oldKey == newKey {
+		return true
+	}
+	moved := &entry{key: newKey, values: e.values, count: e.count, payload: e.payload, expires: e.expires, tags: e.tags, boosted: e.boosted}
+	// delete releases the values of oldKey, so retain them for newKey first.
+	for _, v := range moved.values {
+		t.retain(v)
+	}
+	t.delete(oldKey)
+	t.delete(newKey)
+	n := t.insert(newKey)
+	n.data = moved
+	t.size++
+	t.addSlot(moved)
+	t.indexKey(moved)
+	t.retag(newKey)
+	t.touch(moved)
+	t.notify(Op{Kind: OpRename, Key: oldKey, NewKey: newKey})
+	return true
+}
+
+// Clear removes all keys from the Trie, leaving it configured with the same
+// options and change hooks. This lets a long-lived Trie be rebuilt in place,
+// without replacing it in every component that holds on to it.
+func (t *Trie) Clear() {
+	t.reset()
+	t.slots = nil
+	if t.interned != nil {
+		t.interned = make(map[string]*internedValue)
+	}
+	t.tagBits = nil
+	t.notify(Op{Kind: OpClear})
+}
+
+// delete removes the key from the Trie and returns true if it was there.
+func (t *Trie) delete(key string) bool {
+	e := unlink(t.tree(), key)
+	if e == nil {
+		return false
+	}
+	t.size--
+	t.deletes++
+	t.unindexKey(key)
+	t.retag(key)
+	t.releaseAll(e.values)
+	t.removeSlot(e)
+	t.compactAfterDelete()
+	return true
+}
+
+// unlink removes the entry for key from the tree of nodes under root and
+// returns it, or returns nil if there's no such entry. Nodes that no longer
+// lead to any entry are removed from the tree.
+func unlink(root *node, key string) *entry {
+	n := root
 	var ok bool
 	// If the path through the Trie that we're trying to delete ends in a
 	// leaf node, there will be a path of nodes starting from the last node
 	// with more than one child between the root and the leaf and ending at
 	// the leaf that should be cleaned up. We keep track of the root of that
 	// path here with cnode/crune and prune it after the deletion.
+	// Nodes that store a key can't be pruned either, so they also start a
+	// new path.
 	var cnode *node
 	var r, crune rune
 	for i, w := 0, 0; i < len(key); i += w {
 		r, w = utf8.DecodeRuneInString(key[i:])
-		if len(n.child) > 1 || cnode == nil {
+		if len(n.child) > 1 || n.data != nil || cnode == nil {
 			cnode, crune = n, r
 		}
 		if n, ok = n.child[r]; !ok {
-			return
+			return nil
 		}
 	}
+	e := n.data
+	if e == nil {
+		return nil
+	}
 	n.data = nil
-	if len(n.child) == 0 {
+	if len(n.child) == 0 && cnode != nil {
 		delete(cnode.child, crune)
 	}
+	return e
 }
 
 // state is a state in the simulation of a Levenshtein NFA. This state
@@ -144,96 +654,241 @@ func (t *Trie) Delete(key string) {
 // directly, use newState to create one instead.
 type state struct {
 	offset int
-	arr    []int8
+	arr    []int16
+	ext    int // The state of the Acceptor of a constrained automaton.
 }
 
 func newState(d int8, offset int) state {
-	arr := make([]int8, 2*d+1)
+	arr := make([]int16, 2*int(d)+1)
 	for i := range arr {
-		arr[i] = int8(d + 1)
+		arr[i] = int16(d) + 1
 	}
 	return state{offset: offset, arr: arr}
 }
 
-// nfa is a Levenshtein NFA.
+// edits is a set of edit operations allowed during a search.
+type edits uint8
+
+const (
+	insertions edits = 1 << iota
+	deletions
+	substitutions
+	// Swaps of two adjacent runes, which aren't part of allEdits. See
+	// Transpositions.
+	transpositions
+	allEdits = insertions | deletions | substitutions
+)
+
+// automaton is a simulation of a Levenshtein NFA.
+type automaton interface {
+	// start returns the start state of the NFA.
+	start() state
+	// accepts returns true exactly when the NFA state passed is accepting.
+	accepts(s state) bool
+	// distance returns the smallest edit distance at which the NFA state
+	// passed is accepting, or a distance larger than the NFA's if it isn't.
+	// Distances are ints, since the distance of the NFA can be as large as
+	// an int8 can hold and the distance of a state that isn't accepting is
+	// larger.
+	distance(s state) int
+	// transition computes the effect of a rune transition on a set of NFA
+	// states, returning the new set of states and their minimum edit
+	// distance.
+	transition(s state, r rune) (state, int)
+}
+
+// newAutomaton returns an automaton that matches the runes rs within edit
+// distance d using the edit operations and costs given in cfg. The unweighted
+// NFA is faster, so it's used whenever the search doesn't need weights.
+func newAutomaton(rs []rune, d int8, cfg *searchConfig) automaton {
+	a := newLevAutomaton(rs, d, cfg)
+	if cfg.prune {
+		return constrained{a: a, acc: cfg.acceptor, from: cfg.from, dead: int(d) + 1}
+	}
+	return a
+}
+
+// newLevAutomaton is newAutomaton without the Acceptor of Constrain.
+func newLevAutomaton(rs []rune, d int8, cfg *searchConfig) automaton {
+	if cfg.exactOnly(d) {
+		return exactAutomaton{rs: rs}
+	}
+	if cfg.costs != unitCosts || cfg.affix != (affixes{}) {
+		return newWnfa(rs, d, cfg.ops, cfg.costs, cfg.affix)
+	}
+	ops := cfg.ops
+	if ops&allEdits != allEdits {
+		// Transpositions only apply along with every other edit, see
+		// Transpositions.
+		ops &^= transpositions
+	}
+	if d == 1 && ops == allEdits {
+		return oneEditAutomaton{rs: rs}
+	}
+	return newNfa(rs, d, ops)
+}
+
+// nfa is a Levenshtein NFA. With transpositions, it's the NFA for the
+// restricted Damerau-Levenshtein distance, which has a second set of states
+// for transpositions that have read the first of their two runes. Those are
+// kept after the usual states in the arr of a state, see transition.
 type nfa struct {
 	rs   []rune // The word this NFA matches, split into runes.
 	d    int8   // The edit distance of the NFA.
-	jump []int8 // Scratch space used by the transition method.
+	ops  edits  // The edit operations represented by transitions.
+	jump []int  // Scratch space used by the transition method.
+}
+
+func newNfa(rs []rune, d int8, ops edits) *nfa {
+	return &nfa{rs: rs, d: d, ops: ops, jump: make([]int, 3*int(d)+2)}
+}
+
+// width returns the number of usual states in the arr of a state.
+func (n nfa) width() int {
+	return 2*int(n.d) + 1
 }
 
-func newNfa(rs []rune, d int8) *nfa {
-	return &nfa{rs: rs, d: d, jump: make([]int8, 3*int(d)+2)}
+// newState is newState with room for the states of transpositions.
+func (n nfa) newState(offset int) state {
+	s := newState(n.d, offset)
+	if n.ops&transpositions != 0 {
+		s.arr = append(s.arr, s.arr...)
+	}
+	return s
 }
 
 // start returns the start state of the nfa.
 func (n nfa) start() state {
-	initial := newState(n.d, int(-2*n.d))
-	initial.arr[2*n.d] = 0
+	initial := n.newState(-2 * int(n.d))
+	initial.arr[2*int(n.d)] = 0
 	return initial
 }
 
 // accepts returns true exactly when the NFA state passed is accepting.
 func (n nfa) accepts(s state) bool {
-	for i, x := range s.arr {
-		dist := int8(len(n.rs) - s.offset - i)
-		if dist <= n.d && dist >= x {
+	for i, x := range s.arr[:n.width()] {
+		dist := len(n.rs) - s.offset - i
+		if dist > int(n.d) || dist < int(x) {
+			continue
+		}
+		// Without deletions, the state has to be in the last column.
+		if dist == int(x) || n.ops&deletions != 0 {
 			return true
 		}
 	}
 	return false
 }
 
+// distance returns the smallest edit distance at which the NFA state passed
+// is accepting, or n.d + 1 if it isn't accepting.
+func (n nfa) distance(s state) int {
+	min := int(n.d) + 1
+	for i, x := range s.arr[:n.width()] {
+		dist := len(n.rs) - s.offset - i
+		if dist > int(n.d) || dist < int(x) {
+			continue
+		}
+		if (dist == int(x) || n.ops&deletions != 0) && dist < min {
+			min = dist
+		}
+	}
+	return min
+}
+
 // transition computes the effect of a rune transition on a set of NFA states.
 // Given a set of NFA states and a rune, it returns a new NFA state and the
 // minimum edit distance among those states. The minimum edit distance is used
 // to guide the Trie traversal in the direction of the matches with smallest
 // edit distance.
-func (n nfa) transition(s state, r rune) (state, int8) {
-	ns := newState(n.d, s.offset+1)
-	min := n.d + 1
+func (n nfa) transition(s state, r rune) (state, int) {
+	ns := n.newState(s.offset + 1)
+	w := n.width()
+	inactive := int(n.d) + 1
+	min := inactive
 	// Populate jump array, which lets us compute the horizontal transition
 	// contribution in constant time below. jump stores information about
 	// the position of r values within the string that's used by the next
 	// for loop to figure out where active horizontal r-transitions on a
-	// diagonal might occur.
-	for i, next := len(n.jump)-1, n.d+1; i >= 0; i, next = i-1, next+1 {
+	// diagonal might occur. Reaching a later r-transition on a diagonal
+	// takes a deletion for each column skipped, so without deletions only
+	// the r-transition in the current column is reachable.
+	for i, next := len(n.jump)-1, inactive; i >= 0; i, next = i-1, next+1 {
+		if n.ops&deletions == 0 {
+			next = inactive
+		}
 		x := s.offset + i
 		if x < len(n.rs) && x >= 0 && n.rs[x] == r {
 			next = 0
 		}
 		n.jump[i] = next
 	}
-	for j := range ns.arr {
-		val := n.d + 1
+	for j := 0; j < w; j++ {
+		val := inactive
 		// Compute horizontal transition contribution.
-		cr := s.arr[j] + n.jump[j+int(s.arr[j])]
+		cr := int(s.arr[j]) + n.jump[j+int(s.arr[j])]
 		if cr < val {
 			val = cr
 		}
 		// Compute diagonal transition contribution.
-		if j < len(s.arr)-1 && s.arr[j+1]+1 < val {
-			val = s.arr[j+1] + 1
+		if n.ops&substitutions != 0 && j < w-1 && int(s.arr[j+1])+1 < val {
+			val = int(s.arr[j+1]) + 1
 		}
 		// Compute vertical transition contribution.
-		if j < len(s.arr)-2 && s.arr[j+2]+1 < val {
-			val = s.arr[j+2] + 1
+		if n.ops&insertions != 0 && j < w-2 && int(s.arr[j+2])+1 < val {
+			val = int(s.arr[j+2]) + 1
 		}
-		if val < n.d+1 {
-			ns.arr[j] = val
+		if val < inactive {
+			ns.arr[j] = int16(val)
 		}
 		if val < min {
 			min = val
 		}
 	}
+	if n.ops&transpositions != 0 {
+		min = n.transpose(s, ns, r, min)
+	}
 	return ns, min
 }
 
+// transpose adds the effect of a rune transition on the states of
+// transpositions to ns, the result of the transition on the usual states, and
+// returns the new minimum edit distance. A transposition from the usual state
+// at position p with e edits starts when r is the rune at p+1, which leaves a
+// state with e+1 edits that's finished when the next rune is the one at p,
+// reaching position p+2 with e+1 edits. That's the diagonal one below the
+// one it started on, which is where its state is kept in the meantime.
+// Starting transpositions from the states that deletions lead to along a
+// diagonal isn't needed, since deleting up to the rune before the pair,
+// substituting it and then deleting the rune after the pair costs the same.
+func (n nfa) transpose(s state, ns state, r rune, min int) int {
+	w, inactive := n.width(), int(n.d)+1
+	for j := 0; j < w; j++ {
+		// Finish a transposition whose first rune was the last one read.
+		if e := int(s.arr[w+j]); e < inactive {
+			if x := s.offset + j + e - 1; x >= 0 && x < len(n.rs) && n.rs[x] == r && e < int(ns.arr[j]) {
+				ns.arr[j] = int16(e)
+			}
+		}
+		// Start a transposition.
+		if e := int(s.arr[j]); e < int(n.d) && j > 0 {
+			if p := s.offset + j + e; p >= 0 && p+1 < len(n.rs) && n.rs[p+1] == r && n.rs[p] != r && e+1 < int(ns.arr[w+j-1]) {
+				ns.arr[w+j-1] = int16(e + 1)
+			}
+		}
+	}
+	for _, x := range ns.arr {
+		if int(x) < min {
+			min = int(x)
+		}
+	}
+	return min
+}
+
 // frame is the complete state needed during a traversal of the Trie that's
 // informed by a Levenshtein NFA: a node from the Trie plus a set of states in
 // the NFA.
 type frame struct {
-	n node
+	n *node
 	s state
 }
 
@@ -251,64 +906,103 @@ func extractRunes(s string) []rune {
 
 // doNotExpandSuffixes is a strategy for searching a Trie that does not expand
 // a node to explore suffixes of matches.
-func doNotExpandSuffixes(n node, limit int) (results []KV, halt bool) {
+func doNotExpandSuffixes(n *node, visit func(*entry) bool) (halt bool, stop bool) {
 	halt = false // Continue exploring this node from the traversal
-	if n.data != nil {
-		results = append(results, *n.data)
+	if n.data.live() {
+		stop = !visit(n.data)
 	}
 	return
 }
 
 // expandSuffixes is a strategy for searching a Trie that adds all descendents
 // of a node to the result set.
-func expandSuffixes(n node, limit int) (results []KV, halt bool) {
+func expandSuffixes(n *node, visit func(*entry) bool) (halt bool, stop bool) {
 	halt = true // Stop exploring this node from the traversal
-	stack := []node{n}
+	stack := []*node{n}
 	for len(stack) > 0 {
-		var x node
+		var x *node
 		x, stack = stack[len(stack)-1], stack[:len(stack)-1]
-		if x.data != nil {
-			results = append(results, *x.data)
-			if len(results) >= limit {
-				break
-			}
+		if x.data.live() && !visit(x.data) {
+			return halt, true
 		}
 		for _, child := range x.child {
-			stack = append(stack, *child)
+			stack = append(stack, child)
 		}
 	}
 	return
 }
 
+// descend returns the node reached by following the runes rs from n, or nil
+// if there's no such node.
+func descend(n *node, rs []rune) *node {
+	var ok bool
+	for _, r := range rs {
+		if n, ok = n.child[r]; !ok {
+			return nil
+		}
+	}
+	return n
+}
+
 // Suggest returns up to n KVs with keys that are within edit distance d of the
 // input key. Example: Suggest("banana", 2, 10) would return up to 10 results
-// which might include keys like "bahama", "bananas", or "panama".
-func (t Trie) Suggest(key string, d int8, n int) []KV {
-	return suggest(doNotExpandSuffixes, *t.root, extractRunes(key), d, n)
+// which might include keys like "bahama", "bananas", or "panama". If d is
+// negative, here and in the other Suggest methods, the distance is chosen by
+// the length of key instead, see AdaptiveDistance.
+func (t *Trie) Suggest(key string, d int8, n int, opts ...SuggestOption) []KV {
+	key, ok := t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	return t.cached(suggestMatches, key, 0, d, n, opts, func() []KV {
+		return t.suggestKey(key, d, n, opts)
+	})
+}
+
+// suggestKey is Suggest for a key that's already been preprocessed.
+func (t *Trie) suggestKey(key string, d int8, n int, opts []SuggestOption) []KV {
+	runes, cfg := extractRunes(key), t.config(key, opts)
+	d = t.distance(len(runes), d)
+	if n > 0 && t.grams.covers(runes, d, cfg) {
+		return t.suggestByTrigrams(runes, d, n, cfg)
+	}
+	return t.suggest(doNotExpandSuffixes, t.tree(), runes, d, n, cfg)
 }
 
 // SuggestSuffixes returns up to n KVs, all of whose keys have a prefix that
 // is within edit distance d of the input key. Example:
 // SuggestSuffixes("eat", 1, 10) would return up to 10 results which might
 // include keys like "eaten", "eating", "beaten", and "meatball"
-func (t Trie) SuggestSuffixes(key string, d int8, n int) []KV {
-	return suggest(expandSuffixes, *t.root, extractRunes(key), d, n)
+func (t *Trie) SuggestSuffixes(key string, d int8, n int, opts ...SuggestOption) []KV {
+	key, ok := t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	return t.cached(suggestSuffixes, key, 0, d, n, opts, func() []KV {
+		runes := extractRunes(key)
+		return t.suggest(expandSuffixes, t.tree(), runes, t.distance(len(runes), d), n, t.config(key, opts))
+	})
 }
 
 // SuggestAfterExactPrefix returns up to n KVs that share an exact prefix of
 // length p with the input key and are within edit distance d of the input key.
 // Example: SuggestAfterExactPrefix("britney", 3, 2, 10) would return up to 10
 // results which might include "brine" and "briney" but not "jitney".
-func (t Trie) SuggestAfterExactPrefix(key string, p int, d int8, n int) []KV {
-	runes := extractRunes(key)
-	var ok bool
-	curr := t.root
-	for _, r := range runes[:p] {
-		if curr, ok = curr.child[r]; !ok {
+func (t *Trie) SuggestAfterExactPrefix(key string, p int, d int8, n int, opts ...SuggestOption) []KV {
+	key, ok := t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	return t.cached(suggestMatches, key, p, d, n, opts, func() []KV {
+		runes := extractRunes(key)
+		curr := descend(t.tree(), runes[:p])
+		if curr == nil {
 			return nil
 		}
-	}
-	return suggest(doNotExpandSuffixes, *curr, runes[p:], d, n)
+		cfg := t.config(key, opts)
+		cfg.after(runes[:p])
+		return t.suggest(doNotExpandSuffixes, curr, runes[p:], t.distance(len(runes), d), n, cfg)
+	})
 }
 
 // SuggestSuffixesAfterExactPrefix returns up to n KVs, all of whose keys have
@@ -316,24 +1010,96 @@ func (t Trie) SuggestAfterExactPrefix(key string, p int, d int8, n int) []KV {
 // prefix of at least length p with the input key. Example:
 // SuggestSuffixesAfterExactPrefix("toads", 1, 2, 10) would return up to 10
 // results which might include "toadstool" and "toast" but not "roads".
-func (t Trie) SuggestSuffixesAfterExactPrefix(key string, p int, d int8, n int) []KV {
-	runes := extractRunes(key)
-	var ok bool
-	curr := t.root
-	for _, r := range runes[:p] {
-		if curr, ok = curr.child[r]; !ok {
+func (t *Trie) SuggestSuffixesAfterExactPrefix(key string, p int, d int8, n int, opts ...SuggestOption) []KV {
+	key, ok := t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	return t.cached(suggestSuffixes, key, p, d, n, opts, func() []KV {
+		runes := extractRunes(key)
+		curr := descend(t.tree(), runes[:p])
+		if curr == nil {
 			return nil
 		}
+		cfg := t.config(key, opts)
+		cfg.after(runes[:p])
+		return t.suggest(expandSuffixes, curr, runes[p:], t.distance(len(runes), d), n, cfg)
+	})
+}
+
+// processAcceptingNode is a strategy for handling an accepting node during a
+// search. It passes entries found at or below the node to visit until visit
+// returns false and returns halt = true if the search shouldn't explore
+// the node's descendants and stop = true if visit returned false.
+type processAcceptingNode func(n *node, visit func(*entry) bool) (halt bool, stop bool)
+
+// suggest collects up to limit KVs from the entries found by a search.
+func (t *Trie) suggest(process processAcceptingNode, root *node, runes []rune, d int8, limit int, cfg *searchConfig) []KV {
+	find := search
+	if cfg.ordered {
+		find = searchOrdered
+	}
+	return t.collect(limit, cfg, func(visit func(*entry) bool) {
+		find(process, root, runes, d, cfg, visit)
+	})
+}
+
+// collect collects up to limit KVs from the entries that find passes to visit
+// until visit returns false, applying the options in cfg that apply to the
+// results of a search.
+func (t *Trie) collect(limit int, cfg *searchConfig, find func(visit func(*entry) bool)) []KV {
+	var results []KV
+	for _, e := range collectEntries(limit, cfg, find) {
+		results = t.appendKVs(results, e, cfg.valuesPerKey)
+	}
+	if len(results) > limit {
+		results = results[:limit]
 	}
-	return suggest(expandSuffixes, *curr, runes[p:], d, n)
+	return results
 }
 
-type processAcceptingNode func(n node, limit int) ([]KV, bool)
+// collectEntries is collect without the conversion to KVs: it returns the
+// entries that make up the first limit results, in order. The results of the
+// last entry can run past limit when it has more than one value.
+func collectEntries(limit int, cfg *searchConfig, find func(visit func(*entry) bool)) []*entry {
+	if limit <= 0 {
+		return nil
+	}
+	if cfg.bucketCap > 0 {
+		return collectBuckets(limit, cfg, find)
+	}
+	var es []*entry
+	n := 0             // The number of results in es.
+	var found []*entry // Every match, when they have to be chosen from.
+	find(func(e *entry) bool {
+		if cfg.excluded(e) {
+			return true
+		}
+		if cfg.collectsAll() {
+			found = append(found, e)
+			return !cfg.stops(e)
+		}
+		es = append(es, e)
+		n += kvCount(e, cfg.valuesPerKey)
+		return n < limit && !cfg.stops(e)
+	})
+	if cfg.collectsAll() {
+		for _, e := range cfg.choose(found) {
+			if n >= limit {
+				break
+			}
+			es = append(es, e)
+			n += kvCount(e, cfg.valuesPerKey)
+		}
+	}
+	return es
+}
 
-// suggest runs the traversal of the Trie, using frames consisting of a Trie
+// search runs the traversal of the Trie, using frames consisting of a Trie
 // state and a set of NFA nodes to store state. These frames are pushed on a
 // stack and explored using the strategy defined by the process parameter to
 // decide whether to halt or keep exploring suffixes after a match is found.
+// Each entry found is passed to visit until visit returns false.
 //
 // Each state in the NFA corresponds to an edit distance. The edit distance of a
 // state can't decrease when a transition occurs in the NFA and similarly,
@@ -345,22 +1111,32 @@ type processAcceptingNode func(n node, limit int) ([]KV, bool)
 // distance i. Once all frames have been popped and explored from stack[i], new
 // frames will only be pushed to stack[i+1] or greater so we never need to
 // backtrack through stack indexes.
-func suggest(process processAcceptingNode, root node, runes []rune, d int8, limit int) []KV {
-	n := newNfa(runes, d)
+func search(process processAcceptingNode, root *node, runes []rune, d int8, cfg *searchConfig, visit func(*entry) bool) {
+	if cfg.exactOnly(d) {
+		searchExact(process, root, runes, cfg, visit)
+		return
+	}
+	n := newAutomaton(runes, d, cfg)
 	start := n.start()
-	stacks := make([][]frame, d+1)
+	stacks := make([][]frame, int(d)+1)
 	stacks[0] = []frame{frame{n: root, s: start}}
-	var results []KV
 	for i := range stacks {
 		for len(stacks[i]) > 0 {
 			var f frame
 			// Pop the top frame from stacks[i]
 			f, stacks[i] = stacks[i][len(stacks[i])-1], stacks[i][:len(stacks[i])-1]
 			if n.accepts(f.s) {
-				rs, halt := process(f.n, limit-len(results))
-				results = append(results, rs...)
-				if len(results) >= limit {
-					return results[:limit]
+				v := visit
+				if cfg.needsDist() {
+					dist := int8(n.distance(f.s))
+					v = func(e *entry) bool {
+						cfg.dist = dist
+						return !cfg.keep(e, dist) || visit(e)
+					}
+				}
+				halt, stop := process(f.n, v)
+				if stop {
+					return
 				}
 				if halt {
 					continue
@@ -369,11 +1145,13 @@ func suggest(process processAcceptingNode, root node, runes []rune, d int8, limi
 			// Register each of the current Trie node's children
 			// for a traversal.
 			for r, node := range f.n.child {
-				if ns, min := n.transition(f.s, r); min < d+1 {
-					stacks[min] = append(stacks[min], frame{n: *node, s: ns})
+				if cfg.pruned(node) {
+					continue
+				}
+				if ns, min := n.transition(f.s, r); min <= int(d) {
+					stacks[min] = append(stacks[min], frame{n: node, s: ns})
 				}
 			}
 		}
 	}
-	return results
 }
@@ -1,12 +1,13 @@
 package levtrie
 
 import (
-	"bufio"
+	"bytes"
 	"fmt"
 	"math/rand"
-	"os"
-	"strings"
+	"runtime"
 	"testing"
+
+	"github.com/aaw/levtrie/corpus"
 )
 
 var data []string
@@ -46,18 +47,7 @@ func ensureWords() {
 	if len(words) > 0 {
 		return
 	}
-	filename := "/usr/share/dict/words"
-	file, err := os.Open(filename)
-	if err != nil {
-		panic(fmt.Sprintf("%v: %v", filename, err))
-	}
-	defer file.Close()
-	scanner := bufio.NewScanner(file)
-	scanner.Split(bufio.ScanLines)
-	for scanner.Scan() {
-		word := strings.ToLower(scanner.Text())
-		words = append(words, word)
-	}
+	words = corpus.Words()
 }
 
 func benchmarkSuggest(d int, b *testing.B) {
@@ -84,6 +74,10 @@ func benchmarkSuggestAfterExactPrefix(d int, p int, b *testing.B) {
 	}
 }
 
+func BenchmarkSuggestTopTenDistance0(b *testing.B) {
+	benchmarkSuggest(0, b)
+}
+
 func BenchmarkSuggestTopTenDistance1(b *testing.B) {
 	benchmarkSuggest(1, b)
 }
@@ -186,6 +180,44 @@ func BenchmarkLevtrieGet(b *testing.B) {
 	}
 }
 
+// typedPrefixes returns every prefix of every word in suggestData, in the
+// order they're seen when the words are typed.
+func typedPrefixes() []string {
+	var keys []string
+	for _, word := range suggestData {
+		for i := 1; i <= len(word); i++ {
+			keys = append(keys, word[:i])
+		}
+	}
+	return keys
+}
+
+func BenchmarkGetTyping(b *testing.B) {
+	ensureWords()
+	r := New()
+	for _, word := range words {
+		r.Set(word, word)
+	}
+	keys := typedPrefixes()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Get(keys[i%len(keys)])
+	}
+}
+
+func BenchmarkReaderGetTyping(b *testing.B) {
+	ensureWords()
+	r := New()
+	for _, word := range words {
+		r.Set(word, word)
+	}
+	keys, reader := typedPrefixes(), r.Reader()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reader.Get(keys[i%len(keys)])
+	}
+}
+
 func BenchmarkMapGet(b *testing.B) {
 	ensureData(b.N)
 	m := make(map[string]string)
@@ -221,3 +253,188 @@ func BenchmarkMapDelete(b *testing.B) {
 		delete(m, data[i])
 	}
 }
+
+func BenchmarkGetMiss(b *testing.B) {
+	for _, bl := range []bool{false, true} {
+		b.Run(fmt.Sprintf("bloom=%v", bl), func(b *testing.B) {
+			var opts []Option
+			if bl {
+				opts = append(opts, BloomFilter(10000, 0.01))
+			}
+			r := New(opts...)
+			for i := 0; i < 10000; i++ {
+				r.Set(fmt.Sprintf("key%d", i), "")
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r.Get(fmt.Sprintf("key%dx", i%10000))
+			}
+		})
+	}
+}
+
+func BenchmarkSetWords(b *testing.B) {
+	ensureWords()
+	for i := 0; i < b.N; i++ {
+		r := New()
+		for _, word := range words {
+			r.Set(word, word)
+		}
+	}
+}
+
+func BenchmarkFromKVsWords(b *testing.B) {
+	ensureWords()
+	kvs := make([]KV, len(words))
+	for i, word := range words {
+		kvs[i] = KV{Key: word, Value: word}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FromKVs(kvs)
+	}
+}
+
+func BenchmarkGetWords(b *testing.B) {
+	ensureWords()
+	r := New()
+	for _, word := range words {
+		r.Set(word, word)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, word := range words {
+			r.Get(word)
+		}
+	}
+}
+
+func BenchmarkMGetWords(b *testing.B) {
+	ensureWords()
+	r := New()
+	for _, word := range words {
+		r.Set(word, word)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.MGet(words)
+	}
+}
+
+// dnaSearcher is the search method shared by Trie, FrozenTrie and DenseTrie.
+type dnaSearcher interface {
+	Suggest(key string, d int8, n int, opts ...SuggestOption) []KV
+}
+
+func benchmarkSuggestDNA(b *testing.B, layout func(*Trie) dnaSearcher) {
+	rand.Seed(0)
+	r := New()
+	for _, key := range dnaKeys(100000, 20) {
+		r.Set(key, key)
+	}
+	s := layout(r)
+	queries := dnaKeys(100, 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Suggest(queries[i%len(queries)], 2, 10)
+	}
+}
+
+func BenchmarkSuggestDNATrie(b *testing.B) {
+	benchmarkSuggestDNA(b, func(t *Trie) dnaSearcher { return t })
+}
+
+func BenchmarkSuggestDNAFrozen(b *testing.B) {
+	benchmarkSuggestDNA(b, func(t *Trie) dnaSearcher { return t.Freeze() })
+}
+
+func BenchmarkSuggestDNADense(b *testing.B) {
+	benchmarkSuggestDNA(b, func(t *Trie) dnaSearcher {
+		g, err := t.Dense()
+		if err != nil {
+			b.Fatal(err)
+		}
+		return g
+	})
+}
+
+func BenchmarkSuggestFuncTopTenDistance2(b *testing.B) {
+	ensureWords()
+	r := New()
+	for _, word := range words {
+		r.Set(word, word)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		found := 0
+		r.SuggestFunc(suggestData[i%len(suggestData)], 2, func(kv KV, dist int8) bool {
+			found++
+			return found < 10
+		})
+	}
+}
+
+func BenchmarkNearestNeighborsWords(b *testing.B) {
+	ensureWords()
+	r := New()
+	for _, word := range words {
+		r.Set(word, word)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.NearestNeighbors(10, 1, func(e Edge) bool { return true })
+	}
+}
+
+func BenchmarkNearestNeighborsWordsNaive(b *testing.B) {
+	ensureWords()
+	r := New()
+	for _, word := range words {
+		r.Set(word, word)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		naiveNeighbors(r, 10, 1)
+	}
+}
+
+func benchmarkGC(b *testing.B, x Index) {
+	ensureWords()
+	for _, word := range words {
+		x.Set(word, word)
+	}
+	runtime.GC()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+	}
+	runtime.KeepAlive(x)
+}
+
+func BenchmarkGCWordsTrie(b *testing.B) {
+	benchmarkGC(b, New())
+}
+
+func BenchmarkGCWordsFlat(b *testing.B) {
+	benchmarkGC(b, NewFlat())
+}
+
+func BenchmarkLoad(b *testing.B) {
+	ensureWords()
+	r := New()
+	for _, word := range words {
+		r.Set(word, word)
+	}
+	var buf bytes.Buffer
+	if err := r.Save(&buf); err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(buf.Len()))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Load(bytes.NewReader(buf.Bytes())); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/aaw/levtrie/corpus"
 )
 
 func TestExtractRunes(t *testing.T) {
@@ -32,6 +35,13 @@ func expectGet(t *testing.T, r *Trie, key string, val string) {
 	}
 }
 
+func expectFound(t *testing.T, r *Trie, key string, val string) {
+	if actual, ok := r.Get(key); !ok || actual != val {
+		t.Errorf("Got val = '%v', ok = %v but want val == '%v', ok = true.",
+			actual, ok, val)
+	}
+}
+
 func expectNotGet(t *testing.T, r *Trie, key string) {
 	if actual, ok := r.Get(key); ok {
 		t.Errorf("Got val = %v, ok = %v but want !ok", actual, ok)
@@ -147,6 +157,435 @@ func TestDeletePathCleanup(t *testing.T) {
 	expectGet(t, r, "delta", "5")
 }
 
+func TestAddValues(t *testing.T) {
+	r := New()
+	r.Add("nyc", "New York City")
+	r.Add("nyc", "New York County")
+	r.Add("nyc", "New York City")
+	r.Add("ny", "New York")
+	expectGet(t, r, "nyc", "New York City")
+	got := strings.Join(r.Values("nyc"), ",")
+	want := "New York City,New York County"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	if vals := r.Values("n"); vals != nil {
+		t.Errorf("Got %v, want nil", vals)
+	}
+	r.Remove("nyc", "New York City")
+	r.Remove("nyc", "Nowhere")
+	expectGet(t, r, "nyc", "New York County")
+	r.Remove("nyc", "New York County")
+	expectNotGet(t, r, "nyc")
+	expectGet(t, r, "ny", "New York")
+	r.Add("nyc", "New York City")
+	r.Add("nyc", "New York County")
+	r.Set("nyc", "NYC")
+	got = strings.Join(r.Values("nyc"), ",")
+	want = "NYC"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+}
+
+func TestSuggestMultipleValues(t *testing.T) {
+	r := New()
+	r.Add("paris", "Paris, France")
+	r.Add("paris", "Paris, Texas")
+	r.Add("paris", "Paris, Ontario")
+	r.Add("parts", "parts")
+	kvstr := func(kvs []KV) string {
+		z := []string{}
+		for _, kv := range kvs {
+			z = append(z, kv.Key+"="+kv.Value)
+		}
+		sort.Strings(z)
+		return strings.Join(z, ";")
+	}
+	got := kvstr(r.Suggest("paris", 1, 10))
+	want := "paris=Paris, France;paris=Paris, Ontario;paris=Paris, Texas;parts=parts"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	got = kvstr(r.Suggest("paris", 1, 10, ValuesPerKey(1)))
+	want = "paris=Paris, France;parts=parts"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	got = kvstr(r.SuggestSuffixes("pa", 0, 10, ValuesPerKey(2)))
+	want = "paris=Paris, France;paris=Paris, Texas;parts=parts"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	if got := len(r.Suggest("paris", 0, 2)); got != 2 {
+		t.Errorf("Got %v results, want 2", got)
+	}
+}
+
+func TestIncrCount(t *testing.T) {
+	r := New()
+	for _, word := range strings.Fields("the cat and the hat and the bat") {
+		r.Incr(word)
+	}
+	counts := map[string]int64{"the": 3, "and": 2, "cat": 1, "hat": 1, "bat": 1, "th": 0, "rat": 0}
+	for key, want := range counts {
+		if got := r.Count(key); got != want {
+			t.Errorf("Got Count(%v) = %v, want %v", key, got, want)
+		}
+	}
+	expectGet(t, r, "the", "")
+	expectNotGet(t, r, "th")
+	r.Set("the", "article")
+	expectGet(t, r, "the", "article")
+	if got := r.IncrBy("the", 10); got != 13 {
+		t.Errorf("Got %v, want 13", got)
+	}
+	if got := r.IncrBy("rat", -2); got != -2 {
+		t.Errorf("Got %v, want -2", got)
+	}
+	got := keystr(r.Suggest("rat", 1, 10))
+	want := "bat cat hat rat"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	r.Delete("the")
+	if got := r.Count("the"); got != 0 {
+		t.Errorf("Got %v, want 0", got)
+	}
+}
+
+func TestSetWithTTL(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	clock = func() time.Time { return now }
+	defer func() { clock = time.Now }()
+	r := New()
+	r.SetWithTTL("recent", "1", time.Minute)
+	r.SetWithTTL("recant", "2", time.Hour)
+	r.Set("resent", "3")
+	expectGet(t, r, "recent", "1")
+	now = now.Add(2 * time.Minute)
+	expectNotGet(t, r, "recent")
+	expectGet(t, r, "recant", "2")
+	if got := r.Count("recent"); got != 0 {
+		t.Errorf("Got %v, want 0", got)
+	}
+	got := keystr(r.Suggest("recent", 1, 10))
+	want := "recant resent"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	got = keystr(r.SuggestSuffixes("re", 0, 10))
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	r.Add("recent", "4")
+	expectGet(t, r, "recent", "4")
+	if got := len(r.Values("recent")); got != 1 {
+		t.Errorf("Got %v values, want 1", got)
+	}
+	now = now.Add(time.Hour)
+	expectNotGet(t, r, "recant")
+	if got := r.RemoveExpired(); got != 1 {
+		t.Errorf("Got %v removed, want 1", got)
+	}
+	r.SetWithTTL("resent", "5", time.Second)
+	r.Set("resent", "6")
+	now = now.Add(time.Minute)
+	expectGet(t, r, "resent", "6")
+	if got := r.RemoveExpired(); got != 0 {
+		t.Errorf("Got %v removed, want 0", got)
+	}
+	got = keystr(r.SuggestSuffixes("", 0, 10))
+	want = "recent resent"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+}
+
+func TestDeleteKeepsKeysOnPath(t *testing.T) {
+	r := New()
+	r.Set("ab", "1")
+	r.Set("abc", "2")
+	r.Set("abcde", "3")
+	r.Delete("abcde")
+	expectFound(t, r, "ab", "1")
+	expectFound(t, r, "abc", "2")
+	r.Delete("abc")
+	expectFound(t, r, "ab", "1")
+	r.Delete("")
+	expectFound(t, r, "ab", "1")
+	if got := r.Len(); got != 1 {
+		t.Errorf("Got Len() = %v, want 1", got)
+	}
+}
+
+func TestLen(t *testing.T) {
+	r := New()
+	if got := r.Len(); got != 0 {
+		t.Errorf("Got Len() = %v, want 0", got)
+	}
+	r.Set("a", "1")
+	r.Set("a", "2")
+	r.Add("b", "1")
+	r.Add("b", "2")
+	r.Incr("c")
+	r.Set("", "empty")
+	if got := r.Len(); got != 4 {
+		t.Errorf("Got Len() = %v, want 4", got)
+	}
+	r.Delete("a")
+	r.Delete("a")
+	r.Delete("z")
+	r.Remove("b", "1")
+	if got := r.Len(); got != 3 {
+		t.Errorf("Got Len() = %v, want 3", got)
+	}
+	r.Remove("b", "2")
+	r.Delete("")
+	if got := r.Len(); got != 1 {
+		t.Errorf("Got Len() = %v, want 1", got)
+	}
+}
+
+func TestHas(t *testing.T) {
+	r := New()
+	r.Set("a", "")
+	r.Add("b", "1")
+	r.Incr("c")
+	for _, key := range []string{"a", "b", "c"} {
+		if !r.Has(key) {
+			t.Errorf("Has(%q) = false, want true", key)
+		}
+	}
+	for _, key := range []string{"", "d", "ab"} {
+		if r.Has(key) {
+			t.Errorf("Has(%q) = true, want false", key)
+		}
+	}
+	r.Delete("a")
+	if r.Has("a") {
+		t.Errorf("Has(\"a\") = true after deleting a")
+	}
+}
+
+func TestEmptyValues(t *testing.T) {
+	for _, opts := range [][]Option{nil, {InternValues(), CompressValues(0, nil)}} {
+		r := New(opts...)
+		r.Set("a", "")
+		r.Set("b", "")
+		r.Add("b", "x")
+		r.Add("b", "")
+		if got := r.Values("b"); len(got) != 2 || got[0] != "" || got[1] != "x" {
+			t.Errorf("Got values %q, want [\"\" x]", got)
+		}
+		r.Remove("b", "")
+		expectFound(t, r, "a", "")
+		expectFound(t, r, "b", "x")
+		if err := r.ValidateInvariants(); err != nil {
+			t.Error(err)
+		}
+		if allocs := testing.AllocsPerRun(100, func() { r.Set("a", "") }); allocs > 0 {
+			t.Errorf("Got %v allocations setting an empty value, want 0", allocs)
+		}
+	}
+}
+
+func TestTransformValues(t *testing.T) {
+	redact := func(kv KV) string {
+		if strings.HasPrefix(kv.Key, "secret") {
+			return "<redacted>"
+		}
+		return kv.Key + ":" + kv.Value
+	}
+	r := New(TransformValues(redact), CompressValues(4, nil))
+	r.Set("secret", "hunter2")
+	r.Set("public", "hello world")
+	r.Add("public", "again")
+	r.Incr("counted")
+	expectGet(t, r, "secret", "<redacted>")
+	expectGet(t, r, "public", "public:hello world")
+	if got, want := strings.Join(r.Values("public"), ","), "public:hello world,public:again"; got != want {
+		t.Errorf("Values = %v, want %v", got, want)
+	}
+	if got := r.Suggest("secrat", 1, 10); len(got) != 1 || got[0].Value != "<redacted>" {
+		t.Errorf("Suggest = %v, want the redacted value", got)
+	}
+	if got := r.Suggest("counted", 0, 10); len(got) != 1 || got[0].Value != "counted:" {
+		t.Errorf("Suggest = %v, want the transformed empty value", got)
+	}
+	if got := r.MGet([]string{"public"}); len(got) != 1 || got[0].Value != "public:hello world" {
+		t.Errorf("MGet = %v, want the transformed value", got)
+	}
+	if got := r.ToMap()["secret"]; got != "<redacted>" {
+		t.Errorf("ToMap has %v for secret, want the redacted value", got)
+	}
+	// Comparisons are against the stored values.
+	if !r.CompareAndSwap("secret", "hunter2", "swordfish") {
+		t.Error("CompareAndSwap against the stored value failed")
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	r := New()
+	if r.CompareAndSwap("a", "", "1") {
+		t.Errorf("CompareAndSwap succeeded for a missing key")
+	}
+	r.SetWithTTL("a", "1", time.Hour)
+	r.Add("a", "2")
+	if r.CompareAndSwap("a", "2", "3") {
+		t.Errorf("CompareAndSwap succeeded for the second value of a key")
+	}
+	if !r.CompareAndSwap("a", "1", "3") {
+		t.Errorf("CompareAndSwap failed for the value of a key")
+	}
+	if got := r.Values("a"); len(got) != 1 || got[0] != "3" {
+		t.Errorf("Got values %v, want [3]", got)
+	}
+	if r.find("a").data.expires != 0 {
+		t.Errorf("CompareAndSwap didn't clear the expiration time")
+	}
+}
+
+func TestCompareAndDelete(t *testing.T) {
+	r := New()
+	r.Set("a", "1")
+	r.Incr("b")
+	if r.CompareAndDelete("a", "2") || r.CompareAndDelete("c", "") {
+		t.Errorf("CompareAndDelete succeeded for a different value")
+	}
+	expectFound(t, r, "a", "1")
+	if !r.CompareAndDelete("a", "1") || !r.CompareAndDelete("b", "") {
+		t.Errorf("CompareAndDelete failed for the value of a key")
+	}
+	if r.Len() != 0 {
+		t.Errorf("Got Len() = %v, want 0", r.Len())
+	}
+}
+
+func TestRename(t *testing.T) {
+	for _, opts := range [][]Option{nil, {MaxKeys(10), InternValues(), BloomFilter(1, 0.01), TrigramIndex(0.1)}} {
+		r := New(opts...)
+		r.Add("help", "1")
+		r.Add("help", "2")
+		r.IncrBy("help", 5)
+		r.Set("hello", "3")
+		r.Set("helping", "4")
+		if r.Rename("missing", "help") {
+			t.Errorf("Rename succeeded for a missing key")
+		}
+		if !r.Rename("help", "hello") {
+			t.Errorf("Rename failed")
+		}
+		expectNotGet(t, r, "help")
+		if got := r.Values("hello"); len(got) != 2 || got[0] != "1" || got[1] != "2" {
+			t.Errorf("Got values %v, want [1 2]", got)
+		}
+		if got := r.Count("hello"); got != 5 {
+			t.Errorf("Got count %v, want 5", got)
+		}
+		if r.Len() != 2 {
+			t.Errorf("Got Len() = %v, want 2", r.Len())
+		}
+		if !r.Rename("helping", "he") || !r.Rename("he", "he") {
+			t.Errorf("Rename failed")
+		}
+		if got := keystr(r.Suggest("he", 1, 10)); got != "he" {
+			t.Errorf("Got %v, want he", got)
+		}
+		if err := r.ValidateInvariants(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRenameKeepsExpiration(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock = func() time.Time { return now }
+	defer func() { clock = time.Now }()
+	r := New()
+	r.SetWithTTL("a", "1", time.Second)
+	r.Rename("a", "b")
+	expectFound(t, r, "b", "1")
+	now = now.Add(time.Minute)
+	expectNotGet(t, r, "b")
+	if r.Rename("b", "c") {
+		t.Errorf("Rename succeeded for an expired key")
+	}
+}
+
+func TestClear(t *testing.T) {
+	r := New(MaxKeys(3), InternValues(), BloomFilter(2, 0.01), TrigramIndex(0.1))
+	r.Set("a", "1")
+	r.Set("ab", "1")
+	r.Set("abc", "2")
+	r.Clear()
+	if r.Len() != 0 {
+		t.Errorf("Got Len() = %v after Clear, want 0", r.Len())
+	}
+	expectNotGet(t, r, "a")
+	if got := r.Suggest("ab", 2, 10); len(got) != 0 {
+		t.Errorf("Got %v after Clear, want nothing", got)
+	}
+	if st := r.Stats(); st != (Stats{Nodes: 1}) {
+		t.Errorf("Got %+v after Clear, want an empty Trie", st)
+	}
+	for _, key := range []string{"w", "x", "y", "z"} {
+		r.Set(key, "3")
+	}
+	if r.Len() != 3 {
+		t.Errorf("Got Len() = %v, want 3 with MaxKeys(3)", r.Len())
+	}
+	if got := keystr(r.Suggest("q", 1, 10)); len(got) != 5 {
+		t.Errorf("Got %q, want 3 of w, x, y and z", got)
+	}
+	if err := r.ValidateInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMaxKeys(t *testing.T) {
+	r := New(MaxKeys(3))
+	r.Set("alpha", "1")
+	r.Set("beta", "2")
+	r.Set("gamma", "3")
+	r.Get("alpha")
+	r.Set("delta", "4")
+	expectFound(t, r, "alpha", "1")
+	expectNotGet(t, r, "beta")
+	expectFound(t, r, "gamma", "3")
+	expectFound(t, r, "delta", "4")
+	r.Incr("delta")
+	r.Set("epsilon", "5")
+	expectNotGet(t, r, "alpha")
+	if got := r.Len(); got != 3 {
+		t.Errorf("Got Len() = %v, want 3", got)
+	}
+	got := keystr(r.SuggestSuffixes("", 0, 10))
+	want := "delta epsilon gamma"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+}
+
+func TestMaxKeysApproximatesLRU(t *testing.T) {
+	rand.Seed(0)
+	r := New(MaxKeys(100))
+	hot := []string{}
+	for i := 0; i < 10; i++ {
+		hot = append(hot, randString(10))
+	}
+	for i := 0; i < 10000; i++ {
+		r.Set(hot[i%len(hot)], "hot")
+		r.Set(randString(8), "cold")
+		if got := r.Len(); got > 100 {
+			t.Fatalf("Got Len() = %v, want at most 100", got)
+		}
+	}
+	for _, key := range hot {
+		expectFound(t, r, key, "hot")
+	}
+}
+
 func TestSetAndGetCommonPrefix(t *testing.T) {
 	r := New()
 	r.Set("fooey", "bara")
@@ -616,3 +1055,343 @@ func TestSuggestFuzz(t *testing.T) {
 		}
 	}
 }
+
+func TestSuggestCorpus(t *testing.T) {
+	rand.Seed(0)
+	haystack := corpus.Words()
+	r := New()
+	for _, s := range haystack {
+		r.Set(s, s)
+	}
+	for i := 0; i < 20; i++ {
+		runes := extractRunes(haystack[rand.Intn(len(haystack))])
+		runes[rand.Intn(len(runes))] = alphabet[rand.Intn(len(alphabet))]
+		needle, dist := string(runes), int8(1+i%3)
+		results := keystr(r.Suggest(needle, dist, len(haystack)))
+		var want []KV
+		for _, s := range haystack {
+			if Distance(needle, s) <= int(dist) {
+				want = append(want, KV{Key: s})
+			}
+		}
+		if expected := keystr(want); results != expected {
+			t.Errorf("When asking for strings edit distance %v away from %v,"+
+				"got:\n%v\nbut want:\n%v", dist, needle, results, expected)
+		}
+	}
+}
+
+func TestSuggestDeletionsOnly(t *testing.T) {
+	data := []string{
+		"", "i", "in", "int", "intl", "intern", "internal", "international",
+		"internet", "nation", "tin", "lint",
+	}
+	r := New()
+	var got, want string
+	unlimited := len(data) + 1
+	for _, key := range data {
+		r.Set(key, key)
+	}
+	got = keystr(r.Suggest("intl", 1, unlimited, DeletionsOnly()))
+	want = "int intl"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	got = keystr(r.Suggest("international", 9, unlimited, DeletionsOnly()))
+	want = "intern internal international intl nation"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	got = keystr(r.SuggestSuffixes("lint", 1, unlimited, DeletionsOnly()))
+	want = "int intern internal international internet intl lint"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+}
+
+func TestSuggestTranspositions(t *testing.T) {
+	data := []string{"the", "then", "they", "he", "hate", "tea", "eth"}
+	unlimited := len(data) + 1
+	r := New()
+	for _, key := range data {
+		r.Set(key, key)
+	}
+	if got, want := keystr(r.Suggest("hte", 1, unlimited)), "hate he"; got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	if got, want := keystr(r.Suggest("hte", 1, unlimited, Transpositions(true))), "hate he the"; got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	if got, want := keystr(r.SuggestSuffixes("hte", 1, unlimited, Transpositions(true))), "hate he tea the then they"; got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	d := New(Damerau())
+	for _, key := range data {
+		d.Set(key, key)
+	}
+	if got, want := keystr(d.Suggest("hte", 1, unlimited)), "hate he the"; got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	if got, want := keystr(d.Suggest("hte", 1, unlimited, Transpositions(false))), "hate he"; got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	if got, want := keystr(d.Freeze().Suggest("hte", 1, unlimited)), "hate he the"; got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+}
+
+func TestSuggestTranspositionsFuzz(t *testing.T) {
+	alphabet := []rune{'a', 'b', 'c', 'ô'}
+	randWord := func() string {
+		rs := make([]rune, rand.Intn(7))
+		for i := range rs {
+			rs[i] = alphabet[rand.Intn(len(alphabet))]
+		}
+		return string(rs)
+	}
+	r := New(Damerau())
+	keys := make(map[string]bool)
+	for i := 0; i < 300; i++ {
+		key := randWord()
+		r.Set(key, "")
+		keys[key] = true
+	}
+	for i := 0; i < 50; i++ {
+		query, d := randWord(), int8(i%4)
+		var want []string
+		for key := range keys {
+			if DamerauDistance(query, key) <= int(d) {
+				want = append(want, key)
+			}
+		}
+		sort.Strings(want)
+		if got := keystr(r.Suggest(query, d, len(keys))); got != strings.Join(want, " ") {
+			t.Fatalf("Suggest(%q, %v) = '%v', want '%v'", query, d, got, strings.Join(want, " "))
+		}
+	}
+}
+
+func TestSuggestInsertionsOnly(t *testing.T) {
+	data := []string{
+		"", "i", "in", "int", "intel", "intl", "initial", "international",
+		"internet", "until", "lint",
+	}
+	r := New()
+	var got, want string
+	unlimited := len(data) + 1
+	for _, key := range data {
+		r.Set(key, key)
+	}
+	got = keystr(r.Suggest("intl", 1, unlimited, InsertionsOnly()))
+	want = "intel intl"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	got = keystr(r.Suggest("intl", 9, unlimited, InsertionsOnly()))
+	want = "initial intel international intl"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	got = keystr(r.SuggestAfterExactPrefix("intl", 1, 3, unlimited, InsertionsOnly()))
+	want = "initial intel intl"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+}
+
+// Returns true if s can be obtained by deleting runes from t.
+func isSubsequence(s string, t string) bool {
+	rs, rt := extractRunes(s), extractRunes(t)
+	i := 0
+	for _, r := range rt {
+		if i < len(rs) && rs[i] == r {
+			i++
+		}
+	}
+	return i == len(rs)
+}
+
+func TestSuggestRestrictedEditsFuzz(t *testing.T) {
+	rand.Seed(0)
+	r := New()
+	haystack := generateEdits(6, 3000)
+	for _, s := range haystack {
+		r.Set(s, s)
+	}
+	for dist := int8(0); dist < 5; dist++ {
+		needle := haystack[rand.Intn(len(haystack))]
+		var dels, ins []KV
+		for _, s := range haystack {
+			diff := len(extractRunes(needle)) - len(extractRunes(s))
+			if diff >= 0 && int8(diff) <= dist && isSubsequence(s, needle) {
+				dels = append(dels, KV{Key: s, Value: s})
+			}
+			if diff <= 0 && int8(-diff) <= dist && isSubsequence(needle, s) {
+				ins = append(ins, KV{Key: s, Value: s})
+			}
+		}
+		results := keystr(r.Suggest(needle, dist, len(haystack), DeletionsOnly()))
+		if expected := keystr(dels); results != expected {
+			t.Errorf("When asking for deletions of %v within %v, got:\n%v\n"+
+				"but want:\n%v", needle, dist, results, expected)
+		}
+		results = keystr(r.Suggest(needle, dist, len(haystack), InsertionsOnly()))
+		if expected := keystr(ins); results != expected {
+			t.Errorf("When asking for insertions into %v within %v, got:\n%v\n"+
+				"but want:\n%v", needle, dist, results, expected)
+		}
+	}
+}
+
+func TestSuggestExcludeQuery(t *testing.T) {
+	for _, opts := range [][]Option{nil, {TrigramIndex(0.1), ReverseIndex(), InfixIndex()}} {
+		r := New(opts...)
+		for _, key := range []string{"form", "from", "fork", "forms", "farm"} {
+			r.Set(key, key)
+		}
+		if got, want := keystr(r.Suggest("form", 1, 3, ExcludeQuery())), "farm fork forms"; got != want {
+			t.Errorf("Suggest = %v, want %v", got, want)
+		}
+		if got, want := keystr(r.Suggest("form", 1, 1, ExcludeQuery(), Ordered())), "farm"; got != want {
+			t.Errorf("Suggest with Ordered = %v, want %v", got, want)
+		}
+		if got, want := keystr(r.SuggestSuffixes("form", 0, 10, ExcludeQuery())), "forms"; got != want {
+			t.Errorf("SuggestSuffixes = %v, want %v", got, want)
+		}
+		if got, want := keystr(r.SuggestAfterExactPrefix("form", 2, 1, 10, ExcludeQuery())), "fork forms"; got != want {
+			t.Errorf("SuggestAfterExactPrefix = %v, want %v", got, want)
+		}
+		if got, want := keystr(r.SuggestEndsWith("orm", 0, 10, ExcludeQuery())), "form"; got != want {
+			t.Errorf("SuggestEndsWith = %v, want %v", got, want)
+		}
+		if got, want := keystr(r.SuggestInfix("form", 0, 10, ExcludeQuery())), "forms"; got != want {
+			t.Errorf("SuggestInfix = %v, want %v", got, want)
+		}
+		if got, want := keystr(r.Suggest("form", 1, 10)), "farm fork form forms"; got != want {
+			t.Errorf("Suggest without ExcludeQuery = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSuggestFilter(t *testing.T) {
+	for _, opts := range [][]Option{nil, {TrigramIndex(0.1), ReverseIndex(), InfixIndex()}} {
+		r := New(opts...)
+		for _, key := range []string{"cat", "cot", "coat", "cart", "at", "chat", "scat"} {
+			r.Set(key, strings.ToUpper(key))
+		}
+		type call struct {
+			key  string
+			dist int8
+		}
+		var calls []call
+		noA := func(kv KV, dist int8) bool {
+			if kv.Value != strings.ToUpper(kv.Key) {
+				t.Errorf("Filter got %v, want the value of the key", kv)
+			}
+			calls = append(calls, call{kv.Key, dist})
+			return !strings.HasPrefix(kv.Key, "c")
+		}
+		if got := r.Suggest("cat", 1, 2, Filter(noA)); len(got) != 2 || strings.HasPrefix(keystr(got), "c") || strings.Contains(keystr(got), " c") {
+			t.Errorf("Suggest = %v, want two keys that don't start with c", keystr(got))
+		}
+		for _, c := range calls {
+			if want := int8(Distance("cat", c.key)); c.dist != want {
+				t.Errorf("Filter got distance %v for %v, want %v", c.dist, c.key, want)
+			}
+		}
+		if got, want := keystr(r.Suggest("cat", 1, 10, Filter(noA), Ordered())), "at scat"; got != want {
+			t.Errorf("Ordered Suggest = %v, want %v", got, want)
+		}
+		calls = nil
+		if got, want := keystr(r.SuggestEndsWith("at", 0, 10, Filter(noA))), "at scat"; got != want {
+			t.Errorf("SuggestEndsWith = %v, want %v", got, want)
+		}
+		if got, want := keystr(r.SuggestInfix("ca", 0, 10, Filter(noA))), "scat"; got != want {
+			t.Errorf("SuggestInfix = %v, want %v", got, want)
+		}
+		for _, c := range calls {
+			if c.dist != 0 {
+				t.Errorf("Filter got distance %v for %v, want 0", c.dist, c.key)
+			}
+		}
+	}
+}
+
+func TestSuggestStopWhen(t *testing.T) {
+	for _, opts := range [][]Option{nil, {TrigramIndex(0.1), ReverseIndex(), InfixIndex()}} {
+		r := New(opts...)
+		for _, key := range []string{"cat", "cot", "coat", "cart", "at", "chat", "scat", "cut", "cast"} {
+			r.Set(key, key)
+		}
+		// Stop once 3 results within distance 1 are found.
+		close := 0
+		threeClose := func(kv KV, dist int8, weight int64) bool {
+			if want := int8(Distance("cat", kv.Key)); dist != want {
+				t.Errorf("StopWhen got distance %v for %v, want %v", dist, kv.Key, want)
+			}
+			if dist <= 1 {
+				close++
+			}
+			return close == 3
+		}
+		if got := r.Suggest("cat", 2, 10, StopWhen(threeClose)); len(got) < 3 || close != 3 {
+			t.Errorf("Suggest = %v with %v close keys, want a stop after 3 close keys", keystr(got), close)
+		}
+		close = 0
+		if got, want := keystr(r.Suggest("cat", 2, 10, StopWhen(threeClose), Ordered())), "at cart cat"; got != want {
+			t.Errorf("Ordered Suggest = %v, want %v", got, want)
+		}
+		// Stop when the cumulative weight exceeds 10.
+		r.IncrBy("cot", 8)
+		r.IncrBy("cat", 5)
+		total := int64(0)
+		heavy := func(kv KV, dist int8, weight int64) bool {
+			total += weight
+			return total > 10
+		}
+		if got, want := keystr(r.Suggest("cat", 1, 10, StopWhen(heavy), ByWeight())), "cat cot"; got != want {
+			t.Errorf("ByWeight Suggest = %v, want %v", got, want)
+		}
+		stopAt := func(n int) func(KV, int8, int64) bool {
+			return func(KV, int8, int64) bool { n--; return n == 0 }
+		}
+		if got := r.SuggestEndsWith("at", 0, 10, StopWhen(stopAt(2))); len(got) != 2 {
+			t.Errorf("SuggestEndsWith = %v, want 2 keys", keystr(got))
+		}
+		if got := r.SuggestInfix("a", 0, 10, StopWhen(stopAt(1))); len(got) != 1 {
+			t.Errorf("SuggestInfix = %v, want 1 key", keystr(got))
+		}
+	}
+}
+
+func TestZeroValue(t *testing.T) {
+	var r Trie
+	if _, ok := r.Get("a"); ok || r.Has("a") || r.Len() != 0 {
+		t.Error("Zero Trie isn't empty")
+	}
+	if got := r.Suggest("a", 1, 10); len(got) != 0 {
+		t.Errorf("Got %v, want nothing", got)
+	}
+	if got := r.SuggestSuffixesAfterExactPrefix("a", 1, 1, 10); len(got) != 0 {
+		t.Errorf("Got %v, want nothing", got)
+	}
+	if it := r.Iterator(); it.Valid() {
+		t.Error("Iterator over a zero Trie is positioned at a key")
+	}
+	r.Delete("a")
+	if err := r.ValidateInvariants(); err != nil {
+		t.Error(err)
+	}
+	var s struct {
+		name  string
+		words Trie
+	}
+	s.words.Set("banana", "1")
+	s.words.Set("bandana", "2")
+	if got, want := keystr(s.words.Suggest("banana", 1, 10)), "banana bandana"; got != want {
+		t.Errorf("Got '%v', want '%v'", got, want)
+	}
+	if err := s.words.ValidateInvariants(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,209 @@
+// Package levtrietest provides helpers for testing code that uses levtrie:
+// building tries from maps, checking the results of the Suggest methods,
+// generating random sets of words that are close to each other in edit
+// distance, and generating the typos of a word.
+package levtrietest
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aaw/levtrie"
+)
+
+// FromMap returns a new Trie configured with the given options that maps each
+// key in m to its value. It's levtrie.FromMap, repeated here so that tests can
+// find all of their helpers in one place.
+func FromMap(m map[string]string, opts ...levtrie.Option) *levtrie.Trie {
+	return levtrie.FromMap(m, opts...)
+}
+
+// Keys returns the keys of kvs, in order.
+func Keys(kvs []levtrie.KV) []string {
+	keys := make([]string, len(kvs))
+	for i, kv := range kvs {
+		keys[i] = kv.Key
+	}
+	return keys
+}
+
+// ExpectKeys reports an error unless the keys of got are exactly want, in any
+// order. Keys that appear more than once in got, like keys with more than one
+// value, have to appear the same number of times in want.
+func ExpectKeys(t testing.TB, got []levtrie.KV, want ...string) {
+	t.Helper()
+	g, w := Keys(got), append([]string(nil), want...)
+	sort.Strings(g)
+	sort.Strings(w)
+	if strings.Join(g, "\x00") != strings.Join(w, "\x00") || len(g) != len(w) {
+		t.Errorf("Got keys %q, want %q", g, w)
+	}
+}
+
+// ExpectOrder reports an error unless the keys of got are exactly want, in
+// the same order.
+func ExpectOrder(t testing.TB, got []levtrie.KV, want ...string) {
+	t.Helper()
+	g := Keys(got)
+	if strings.Join(g, "\x00") != strings.Join(want, "\x00") || len(g) != len(want) {
+		t.Errorf("Got keys %q, want %q", g, want)
+	}
+}
+
+// ExpectWithin reports an error for each key in got that isn't within edit
+// distance d of query. The Suggest methods of a Trie find keys at smaller edit
+// distances first but don't return them in strict order of edit distance, so
+// ExpectWithin doesn't check the order of got.
+func ExpectWithin(t testing.TB, query string, d int, got []levtrie.KV) {
+	t.Helper()
+	for _, kv := range got {
+		if dist := levtrie.Distance(query, kv.Key); dist > d {
+			t.Errorf("Got key %q at edit distance %v from %q, want at most %v", kv.Key, dist, query, d)
+		}
+	}
+}
+
+// Within returns the words that are within edit distance d of query, in the
+// order they appear in words. It checks every word, so it's a slow but simple
+// reference for the results of Suggest.
+func Within(words []string, query string, d int) []string {
+	var result []string
+	for _, w := range words {
+		if levtrie.Distance(query, w) <= d {
+			result = append(result, w)
+		}
+	}
+	return result
+}
+
+// Alphabet is the default alphabet used by GenerateEdits. It mixes runes that
+// are encoded in UTF-8 with one, two and three bytes.
+var Alphabet = []rune{'A', 'ἑ', 'й', 'ლ', 'ô', 'Z', '1'}
+
+// GenerateEdits returns n distinct words that are all close to each other in
+// edit distance. It starts with a random word of k runes from alphabet, or
+// from Alphabet if alphabet is empty, then repeatedly picks one of the words
+// generated so far and applies a random insertion, deletion or substitution
+// to it until there are n distinct words. The words generated depend only on
+// the arguments and the state of r. GenerateEdits doesn't return if alphabet
+// and k can't produce n distinct words.
+func GenerateEdits(r *rand.Rand, alphabet []rune, k int, n int) []string {
+	if len(alphabet) == 0 {
+		alphabet = Alphabet
+	}
+	seed := make([]rune, k)
+	for i := range seed {
+		seed[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	seen := map[string]bool{string(seed): true}
+	results := []string{string(seed)}
+	for len(results) < n {
+		runes := []rune(results[r.Intn(len(results))])
+		if len(runes) == 0 {
+			runes = []rune{alphabet[r.Intn(len(alphabet))]}
+		} else {
+			i, a := r.Intn(len(runes)), alphabet[r.Intn(len(alphabet))]
+			switch r.Intn(3) {
+			case 0: // Delete
+				runes = append(runes[:i], runes[i+1:]...)
+			case 1: // Insert
+				runes = append(runes[:i], append([]rune{a}, runes[i:]...)...)
+			case 2: // Substitute
+				runes[i] = a
+			}
+		}
+		if edited := string(runes); !seen[edited] {
+			seen[edited] = true
+			results = append(results, edited)
+		}
+	}
+	return results
+}
+
+// Neighborhood returns every distinct string within edit distance d of key
+// whose runes, other than the ones it shares with key, come from alphabet, or
+// from Alphabet if alphabet is empty, in sorted order. It includes key
+// itself. The number of strings grows like (len(key) * len(alphabet))^d, so
+// it's meant for short keys and small d, like building the full set of typos
+// a spelling corrector should handle. Neighborhood returns nil if d is
+// negative.
+func Neighborhood(key string, d int, alphabet []rune) []string {
+	if d < 0 {
+		return nil
+	}
+	if len(alphabet) == 0 {
+		alphabet = Alphabet
+	}
+	seen := map[string]bool{key: true}
+	frontier := []string{key}
+	for i := 0; i < d; i++ {
+		var next []string
+		for _, w := range frontier {
+			for _, e := range edits([]rune(w), alphabet) {
+				if !seen[e] {
+					seen[e] = true
+					next = append(next, e)
+				}
+			}
+		}
+		frontier = next
+	}
+	result := make([]string, 0, len(seen))
+	for w := range seen {
+		result = append(result, w)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// edits returns the strings one insertion, deletion or substitution of a rune
+// from alphabet away from runes, with repeats.
+func edits(runes []rune, alphabet []rune) []string {
+	var result []string
+	for i := 0; i <= len(runes); i++ {
+		for _, a := range alphabet {
+			result = append(result, string(runes[:i])+string(a)+string(runes[i:]))
+			if i < len(runes) && runes[i] != a {
+				result = append(result, string(runes[:i])+string(a)+string(runes[i+1:]))
+			}
+		}
+		if i < len(runes) {
+			result = append(result, string(runes[:i])+string(runes[i+1:]))
+		}
+	}
+	return result
+}
+
+// Typo returns a random string within edit distance d of key, made by applying
+// d random insertions, deletions or substitutions of runes from alphabet, or
+// from Alphabet if alphabet is empty, to key. Later edits can undo earlier
+// ones, so the string can be closer than d to key, but never farther; use
+// levtrie.Distance to keep only the strings at exactly distance d. Deletions
+// are skipped once the string is empty. The string returned depends only on
+// the arguments and the state of r, so a seeded r makes an evaluation corpus
+// reproducible.
+func Typo(r *rand.Rand, key string, d int, alphabet []rune) string {
+	if len(alphabet) == 0 {
+		alphabet = Alphabet
+	}
+	runes := []rune(key)
+	for i := 0; i < d; i++ {
+		a := alphabet[r.Intn(len(alphabet))]
+		if len(runes) == 0 {
+			runes = []rune{a}
+			continue
+		}
+		j := r.Intn(len(runes))
+		switch r.Intn(3) {
+		case 0: // Delete
+			runes = append(runes[:j], runes[j+1:]...)
+		case 1: // Insert
+			runes = append(runes[:j], append([]rune{a}, runes[j:]...)...)
+		case 2: // Substitute
+			runes[j] = a
+		}
+	}
+	return string(runes)
+}
@@ -0,0 +1,142 @@
+package levtrietest
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/aaw/levtrie"
+)
+
+// recorder is a testing.TB that records errors instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestFromMap(t *testing.T) {
+	r := FromMap(map[string]string{"hello": "1", "help": "2"}, levtrie.MaxKeys(10))
+	if got, ok := r.Get("help"); !ok || got != "2" {
+		t.Errorf("Got (%v, %v), want (2, true)", got, ok)
+	}
+	if r.Len() != 2 {
+		t.Errorf("Got %v keys, want 2", r.Len())
+	}
+}
+
+func TestExpectKeys(t *testing.T) {
+	kvs := []levtrie.KV{{Key: "b"}, {Key: "a"}, {Key: "b"}}
+	rec := &recorder{}
+	ExpectKeys(rec, kvs, "a", "b", "b")
+	if len(rec.errors) != 0 {
+		t.Errorf("Got errors %v, want none", rec.errors)
+	}
+	ExpectKeys(rec, kvs, "a", "b")
+	ExpectKeys(rec, kvs, "a", "b", "c")
+	if len(rec.errors) != 2 {
+		t.Errorf("Got errors %v, want 2", rec.errors)
+	}
+}
+
+func TestExpectOrder(t *testing.T) {
+	kvs := []levtrie.KV{{Key: "b"}, {Key: "a"}}
+	rec := &recorder{}
+	ExpectOrder(rec, kvs, "b", "a")
+	if len(rec.errors) != 0 {
+		t.Errorf("Got errors %v, want none", rec.errors)
+	}
+	ExpectOrder(rec, kvs, "a", "b")
+	if len(rec.errors) != 1 {
+		t.Errorf("Got errors %v, want 1", rec.errors)
+	}
+}
+
+func TestExpectWithin(t *testing.T) {
+	rec := &recorder{}
+	ExpectWithin(rec, "helo", 1, []levtrie.KV{{Key: "helo"}, {Key: "help"}})
+	if len(rec.errors) != 0 {
+		t.Errorf("Got errors %v, want none", rec.errors)
+	}
+	ExpectWithin(rec, "helo", 1, []levtrie.KV{{Key: "help"}, {Key: "world"}, {Key: "hello"}, {Key: "hi"}})
+	if len(rec.errors) != 2 {
+		t.Errorf("Got errors %v, want 2", rec.errors)
+	}
+}
+
+func TestGenerateEdits(t *testing.T) {
+	words := GenerateEdits(rand.New(rand.NewSource(1)), nil, 5, 500)
+	if len(words) != 500 {
+		t.Fatalf("Got %v words, want 500", len(words))
+	}
+	seen := make(map[string]bool)
+	for _, w := range words {
+		if seen[w] {
+			t.Errorf("Got %q twice", w)
+		}
+		seen[w] = true
+	}
+	again := GenerateEdits(rand.New(rand.NewSource(1)), nil, 5, 500)
+	if !reflect.DeepEqual(words, again) {
+		t.Errorf("GenerateEdits isn't deterministic")
+	}
+}
+
+func TestSuggestMatchesWithin(t *testing.T) {
+	words := GenerateEdits(rand.New(rand.NewSource(2)), []rune("abc"), 6, 300)
+	r := levtrie.New()
+	for _, w := range words {
+		r.Set(w, "")
+	}
+	for d := 0; d < 3; d++ {
+		got := r.Suggest(words[0], int8(d), len(words))
+		ExpectKeys(t, got, Within(words, words[0], d)...)
+		ExpectWithin(t, words[0], d, got)
+	}
+}
+
+func TestNeighborhood(t *testing.T) {
+	// Every string over "ab" of up to 5 runes, to check against.
+	all := []string{""}
+	for i := 0; i < len(all); i++ {
+		if len(all[i]) < 5 {
+			all = append(all, all[i]+"a", all[i]+"b")
+		}
+	}
+	for _, key := range []string{"", "a", "abb"} {
+		for d := 0; d <= 2; d++ {
+			got := Neighborhood(key, d, []rune("ab"))
+			want := Within(all, key, d)
+			sort.Strings(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Neighborhood(%q, %v) = %q, want %q", key, d, got, want)
+			}
+		}
+	}
+	if got := Neighborhood("a", -1, nil); got != nil {
+		t.Errorf("Got %q for a negative d, want nil", got)
+	}
+}
+
+func TestTypo(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for _, key := range []string{"", "kitten", "ლô1"} {
+		for d := 0; d <= 3; d++ {
+			typo := Typo(r, key, d, nil)
+			if dist := levtrie.Distance(key, typo); dist > d {
+				t.Errorf("Typo(%q, %v) = %q at distance %v", key, d, typo, dist)
+			}
+		}
+	}
+	a, b := Typo(rand.New(rand.NewSource(4)), "kitten", 2, nil), Typo(rand.New(rand.NewSource(4)), "kitten", 2, nil)
+	if a != b {
+		t.Errorf("Typo isn't deterministic: %q vs %q", a, b)
+	}
+}
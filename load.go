@@ -0,0 +1,47 @@
+package levtrie
+
+import (
+	"bufio"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// ReadWords returns a new Trie configured with the given options that has a
+// key for each line read from r, associated with the empty string. Leading and
+// trailing whitespace is trimmed from each line and empty lines are skipped.
+func ReadWords(r io.Reader, opts ...Option) (_ *Trie, err error) {
+	t := New(opts...)
+	l, r := startRead(t.progress, r)
+	defer func() { l.finish(err) }()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if word := strings.TrimSpace(scanner.Text()); word != "" {
+			t.Set(word, "")
+			l.add(1, 0)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ReadWordsFS returns a new Trie configured with the given options that holds
+// the words in the file with the given name in fsys, one per line, as
+// described in ReadWords. With an embed.FS, this lets a binary ship with its
+// dictionary:
+//
+//	//go:embed words.txt
+//	var words embed.FS
+//
+//	t, err := levtrie.ReadWordsFS(words, "words.txt")
+func ReadWordsFS(fsys fs.FS, name string, opts ...Option) (*Trie, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadWords(f, opts...)
+}
@@ -0,0 +1,43 @@
+package levtrie
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+//go:embed testdata/words.txt
+var testWords embed.FS
+
+func TestReadWords(t *testing.T) {
+	r, err := ReadWords(strings.NewReader("apple\nbanana\r\n\n  cherry  \nbanana"), MaxKeys(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() != 3 {
+		t.Errorf("Got %v keys, want 3", r.Len())
+	}
+	for _, word := range []string{"apple", "banana", "cherry"} {
+		expectFound(t, r, word, "")
+	}
+}
+
+func TestReadWordsFS(t *testing.T) {
+	r, err := ReadWordsFS(testWords, "testdata/words.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := keystr(r.Suggest("bananna", 1, 10)); got != "banana" {
+		t.Errorf("Got %v, want banana", got)
+	}
+	mfs := fstest.MapFS{"dict/words": {Data: []byte("kitten\nsitting\n")}}
+	if r, err = ReadWordsFS(mfs, "dict/words"); err != nil || r.Len() != 2 {
+		t.Errorf("Got %v keys and error %v, want 2 keys", r.Len(), err)
+	}
+	if _, err := ReadWordsFS(mfs, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Got error %v for a missing file, want fs.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,79 @@
+package levtrie
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// evictionSamples is the number of keys sampled when choosing a key to evict.
+// The sampled key that was used least recently is evicted, which approximates
+// LRU eviction without the bookkeeping of a linked list on every access.
+const evictionSamples = 5
+
+// touch records an access to e if the Trie has a maximum number of keys. The
+// access time is a logical clock that's updated atomically so that concurrent
+// readers can record accesses.
+func (t *Trie) touch(e *entry) {
+	if t.maxKeys > 0 {
+		atomic.StoreUint64(&e.access, atomic.AddUint64(&t.tick, 1))
+	}
+}
+
+// addSlot adds e to the slots used to sample eviction candidates.
+func (t *Trie) addSlot(e *entry) {
+	if t.maxKeys > 0 {
+		e.slot = len(t.slots)
+		t.slots = append(t.slots, e)
+	}
+}
+
+// replaceSlot puts e in the slot used by old.
+func (t *Trie) replaceSlot(old *entry, e *entry) {
+	if t.maxKeys > 0 {
+		e.slot = old.slot
+		t.slots[e.slot] = e
+	}
+}
+
+// removeSlot removes e from the slots used to sample eviction candidates by
+// moving the last slot into its place. The vacated slot is cleared so that the
+// slots don't keep removed entries alive.
+func (t *Trie) removeSlot(e *entry) {
+	if t.maxKeys > 0 {
+		last := t.slots[len(t.slots)-1]
+		last.slot = e.slot
+		t.slots[e.slot] = last
+		t.slots[len(t.slots)-1] = nil
+		t.slots = t.slots[:len(t.slots)-1]
+		if len(t.slots) == 0 {
+			t.slots = nil
+		}
+	}
+}
+
+// evict removes an approximately least recently used key other than the key
+// of keep from the Trie. Expired keys are evicted before any live keys.
+func (t *Trie) evict(keep *entry) {
+	var victim *entry
+	for i := 0; i < evictionSamples && i < len(t.slots)-1; i++ {
+		var e *entry
+		if len(t.slots) <= evictionSamples+1 {
+			e = t.slots[i]
+		} else {
+			e = t.slots[rand.Intn(len(t.slots))]
+		}
+		if e == keep {
+			e = t.slots[(e.slot+1)%len(t.slots)]
+		}
+		if !e.live() {
+			victim = e
+			break
+		}
+		if victim == nil || atomic.LoadUint64(&e.access) < atomic.LoadUint64(&victim.access) {
+			victim = e
+		}
+	}
+	if victim != nil {
+		t.Delete(victim.key)
+	}
+}
@@ -0,0 +1,167 @@
+package levtrie
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// MaintenanceOptions configures the maintenance of a Trie, see Maintenance.
+type MaintenanceOptions struct {
+	// Interval is the time between slices of maintenance run by a SyncTrie.
+	// If it isn't positive, one second is used.
+	Interval time.Duration
+	// Slice is the number of nodes each slice visits. If it isn't positive,
+	// 1000 is used.
+	Slice int
+	// HalfLife, if positive, makes the count of every key halve each time
+	// HalfLife passes, so that keys that were popular long ago don't
+	// outweigh keys that are popular now. Counts are decayed as each pass
+	// reaches them and are rounded up or down at random, in proportion to
+	// their fractional part, so that they're right on average. HalfLife
+	// is ignored by a Trie with DecayWeights, which decays counts as
+	// they're read instead.
+	HalfLife time.Duration
+}
+
+// Maintenance makes a SyncTrie run maintenance in the background, a small
+// slice at a time, until it's closed with SyncTrie.Close. Each slice holds the
+// write lock of the SyncTrie while it visits the next few nodes of the Trie in
+// sorted order, removing expired keys, rebuilding maps of children that may
+// have shrunk as Compact does and decaying counts as opts.HalfLife asks, so
+// that these costs are spread out instead of landing on the calls that happen
+// to trigger them. A plain Trie doesn't start any goroutines, but uses opts
+// when its Maintain method is called.
+func Maintenance(opts MaintenanceOptions) Option {
+	return func(t *Trie) {
+		if opts.Interval <= 0 {
+			opts.Interval = time.Second
+		}
+		if opts.Slice <= 0 {
+			opts.Slice = 1000
+		}
+		t.maint = &maintenance{opts: opts, background: true}
+	}
+}
+
+// maintenance tracks a pass of maintenance over a Trie that's run in slices.
+type maintenance struct {
+	opts       MaintenanceOptions
+	background bool      // True if a SyncTrie should run maintenance itself.
+	inPass     bool      // True if a pass has started and not finished.
+	next       string    // The path to the next node the pass visits.
+	compact    bool      // True if the pass rebuilds maps of children.
+	factor     float64   // The factor the pass multiplies counts by.
+	last       time.Time // When the last pass started.
+}
+
+// Maintain runs a slice of maintenance on the Trie as described in
+// Maintenance, using the options passed to Maintenance or the defaults if
+// there weren't any, and returns true if it finished a pass over the whole
+// Trie. Calling Maintain regularly, like between requests in a server,
+// keeps the Trie maintained without a background goroutine.
+func (t *Trie) Maintain() bool {
+	if t.maint == nil {
+		Maintenance(MaintenanceOptions{})(t)
+		t.maint.background = false
+	}
+	m := t.maint
+	if !m.inPass {
+		m.begin(t)
+	}
+	// Find the frames on the path to the next node, as Iterator.Seek does.
+	stack := []iterFrame{newIterFrame(t.tree())}
+	var path []rune
+	for _, r := range m.next {
+		f := &stack[len(stack)-1]
+		f.self = true
+		f.next = sort.Search(len(f.runes), func(i int) bool { return f.runes[i] >= r })
+		if f.next == len(f.runes) || f.runes[f.next] != r {
+			break
+		}
+		f.next++
+		path = append(path, r)
+		stack = append(stack, newIterFrame(f.n.child[r]))
+	}
+	var expired []string
+	visited := 0
+	for len(stack) > 0 {
+		f := &stack[len(stack)-1]
+		if !f.self {
+			if visited == m.opts.Slice {
+				m.next = string(path)
+				break
+			}
+			f.self = true
+			visited++
+			if e := m.visit(f.n); e != nil {
+				expired = append(expired, e.key)
+			}
+		}
+		if f.next < len(f.runes) {
+			r := f.runes[f.next]
+			f.next++
+			path = append(path, r)
+			stack = append(stack, newIterFrame(f.n.child[r]))
+			continue
+		}
+		stack = stack[:len(stack)-1]
+		if len(stack) > 0 {
+			path = path[:len(stack)-1]
+		}
+	}
+	if m.factor < 1 && visited > 0 && t.results != nil {
+		// Counts changed without a change being reported.
+		t.results.clear()
+	}
+	for _, key := range expired {
+		if t.delete(key) {
+			t.notify(Op{Kind: OpDelete, Key: key})
+		}
+	}
+	if len(stack) == 0 {
+		m.inPass, m.next = false, ""
+		return true
+	}
+	return false
+}
+
+// begin starts a new pass of maintenance over t.
+func (m *maintenance) begin(t *Trie) {
+	now := clock()
+	m.inPass, m.next = true, ""
+	m.compact, t.removed = t.removed > 0, 0
+	m.factor = 1
+	if m.opts.HalfLife > 0 && t.decay == nil && !m.last.IsZero() {
+		m.factor = math.Exp2(-float64(now.Sub(m.last)) / float64(m.opts.HalfLife))
+	}
+	m.last = now
+}
+
+// visit maintains the node n and returns its entry if it has expired.
+func (m *maintenance) visit(n *node) *entry {
+	if m.compact {
+		child := make(map[rune]*node, len(n.child))
+		for r, c := range n.child {
+			child[r] = c
+		}
+		n.child = child
+	}
+	e := n.data
+	if e == nil {
+		return nil
+	}
+	if !e.live() {
+		return e
+	}
+	if m.factor < 1 && e.count != 0 {
+		x := float64(e.count) * m.factor
+		whole, frac := math.Modf(x)
+		e.count = int64(whole)
+		if rand.Float64() < math.Abs(frac) {
+			e.count += int64(math.Copysign(1, x))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package levtrie
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestMaintain(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	clock = func() time.Time { return now }
+	defer func() { clock = time.Now }()
+	rand.Seed(0)
+	r := New(Maintenance(MaintenanceOptions{Slice: 7, HalfLife: time.Hour}))
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("k%02d", i)
+		if i%10 == 0 {
+			r.SetWithTTL(key, "v", time.Minute)
+		} else {
+			r.Set(key, "v")
+		}
+		r.IncrBy(key, 1000)
+	}
+	removed := 0
+	r.OnChange(func(op Op) {
+		if op.Kind == OpDelete {
+			removed++
+		}
+	})
+	// The first pass only sets the start time for decay.
+	slices := 1
+	for !r.Maintain() {
+		slices++
+	}
+	// The Trie has a root, a node for "k", 10 for "k0".."k9" and 100 for the
+	// keys, so a pass takes 112/7 = 16 slices.
+	if slices != 16 {
+		t.Errorf("A pass took %v slices, want 16", slices)
+	}
+	if removed != 0 || r.Count("k01") != 1000 {
+		t.Errorf("Got %v removals and a count of %v before anything expired or decayed", removed, r.Count("k01"))
+	}
+	now = now.Add(2 * time.Hour)
+	for !r.Maintain() {
+	}
+	if removed != 10 || r.Len() != 90 {
+		t.Errorf("Got %v removals and %v keys, want 10 and 90", removed, r.Len())
+	}
+	total := int64(0)
+	for i := 0; i < 100; i++ {
+		total += r.Count(fmt.Sprintf("k%02d", i))
+	}
+	if total < 90*240 || total > 90*260 {
+		t.Errorf("Got a total count of %v after two half lives, want about %v", total, 90*250)
+	}
+	if err := r.ValidateInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMaintainResumesAfterChanges(t *testing.T) {
+	r := New()
+	for i := 0; i < 50; i++ {
+		r.Set(fmt.Sprintf("%03d", i), "v")
+	}
+	r.Maintain()
+	// Deleting the keys around the point where the pass stopped doesn't
+	// stop the pass from reaching the rest of the keys.
+	for i := 0; i < 50; i++ {
+		r.Delete(fmt.Sprintf("%03d", i))
+	}
+	r.Set("zzz", "v")
+	for !r.Maintain() {
+	}
+	if err := r.ValidateInvariants(); err != nil {
+		t.Error(err)
+	}
+	expectFound(t, r, "zzz", "v")
+}
+
+func TestSyncTrieMaintenance(t *testing.T) {
+	s := NewSync(Maintenance(MaintenanceOptions{Interval: time.Millisecond}))
+	s.SetWithTTL("gone", "v", time.Nanosecond)
+	s.Set("kept", "v")
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Len() > 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	s.Close()
+	s.Close()
+	if got := s.Len(); got != 1 {
+		t.Errorf("Got %v keys after maintenance, want 1", got)
+	}
+	NewSync().Close()
+}
@@ -0,0 +1,101 @@
+package levtrie
+
+import "sort"
+
+// FromMap returns a new Trie configured with the given options that maps each
+// key in m to its value, built as described in FromKVs.
+func FromMap(m map[string]string, opts ...Option) *Trie {
+	kvs := make([]KV, 0, len(m))
+	for k, v := range m {
+		kvs = append(kvs, KV{Key: k, Value: v})
+	}
+	return FromKVs(kvs, opts...)
+}
+
+// FromKVs returns a new Trie configured with the given options that holds the
+// KVs in kvs, as if Set were called for each KV in order, so if a key appears
+// more than once, the last value wins. The KVs are sorted by key first, unless
+// they're already sorted, so that the Trie can be built in a single pass
+// without looking up any keys. kvs isn't modified.
+func FromKVs(kvs []KV, opts ...Option) *Trie {
+	t := New(opts...)
+	var l *loadProgress
+	if t.progress != nil {
+		var total int64
+		for _, kv := range kvs {
+			total += int64(len(kv.Key) + len(kv.Value))
+		}
+		l = startLoad(t.progress, total)
+	}
+	if t.maxKeys > 0 {
+		// Building a Trie in sorted order would decide which keys to
+		// evict differently than Set does.
+		for _, kv := range kvs {
+			t.Set(kv.Key, kv.Value)
+			l.add(1, int64(len(kv.Key)+len(kv.Value)))
+		}
+		l.finish(nil)
+		return t
+	}
+	sorted := kvs
+	less := func(i, j int) bool { return sorted[i].Key < sorted[j].Key }
+	if !sort.SliceIsSorted(sorted, less) {
+		sorted = append([]KV(nil), kvs...)
+		sort.SliceStable(sorted, less)
+	}
+	t.build(sorted, l)
+	l.finish(nil)
+	return t
+}
+
+// build adds the KVs in kvs, which must be sorted by key, to an empty Trie.
+// Keys that share a prefix are adjacent in sorted order, so following them
+// with a cursor only visits the nodes below each key's longest common prefix
+// with the previous key. Only the last of a run of KVs with the same key is
+// added. The progress of the build is recorded in l.
+func (t *Trie) build(kvs []KV, l *loadProgress) {
+	c := newCursor(t.ensureRoot())
+	for i, kv := range kvs {
+		l.add(1, int64(len(kv.Key)+len(kv.Value)))
+		if i+1 < len(kvs) && kvs[i+1].Key == kv.Key {
+			continue
+		}
+		e := &entry{key: kv.Key, values: t.single(kv.Value)}
+		c.seek(kv.Key, true).data = e
+		t.size++
+		t.indexKey(e)
+	}
+}
+
+// ToMap returns a map from each key in the Trie to its value, the value that
+// Get would return for the key. Keys associated with more than one value are
+// mapped to their first value, and keys that have expired are left out.
+func (t *Trie) ToMap() map[string]string {
+	m := make(map[string]string, t.size)
+	expandSuffixes(t.tree(), func(e *entry) bool {
+		m[e.key] = t.output(e.key, e.value())
+		return true
+	})
+	return m
+}
+
+// ToMapN is like ToMap, but the map returned holds at most n keys of the Trie,
+// in no particular order. The second value returned is true exactly when the
+// map holds every key in the Trie. This bounds the memory used to copy a Trie
+// whose size isn't known in advance.
+func (t *Trie) ToMapN(n int) (map[string]string, bool) {
+	if n < 0 {
+		n = 0
+	}
+	m := make(map[string]string, minInt(n, t.size))
+	complete := true
+	expandSuffixes(t.tree(), func(e *entry) bool {
+		if len(m) >= n {
+			complete = false
+			return false
+		}
+		m[e.key] = t.output(e.key, e.value())
+		return true
+	})
+	return m, complete
+}
@@ -0,0 +1,93 @@
+package levtrie
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestToMap(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock = func() time.Time { return now }
+	defer func() { clock = time.Now }()
+	r := New(CompressValues(0, nil))
+	r.Set("a", "1")
+	r.Add("ab", "2")
+	r.Add("ab", "3")
+	r.Incr("abc")
+	r.SetWithTTL("b", "4", time.Second)
+	r.Set("", "5")
+	now = now.Add(time.Minute)
+	want := map[string]string{"a": "1", "ab": "2", "abc": "", "": "5"}
+	if got := r.ToMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	if got, complete := r.ToMapN(4); !complete || !reflect.DeepEqual(got, want) {
+		t.Errorf("Got (%v, %v), want (%v, true)", got, complete, want)
+	}
+	got, complete := r.ToMapN(2)
+	if complete || len(got) != 2 {
+		t.Errorf("Got (%v, %v), want 2 keys and false", got, complete)
+	}
+	for k, v := range got {
+		if want[k] != v {
+			t.Errorf("Got %v for %q, want %v", v, k, want[k])
+		}
+	}
+	if got, complete := New().ToMapN(-1); complete != true || len(got) != 0 {
+		t.Errorf("Got (%v, %v) for an empty Trie, want (map[], true)", got, complete)
+	}
+}
+
+func TestFromKVs(t *testing.T) {
+	kvs := []KV{
+		{"help", "1"}, {"hello", "2"}, {"", "3"}, {"he", "4"}, {"help", "5"},
+		{"hélp", "6"}, {"world", "7"}, {"hel", "8"}, {"hello", "9"},
+	}
+	want := map[string]string{
+		"help": "5", "hello": "9", "": "3", "he": "4", "hélp": "6", "world": "7", "hel": "8",
+	}
+	for _, opts := range [][]Option{
+		nil,
+		{MaxKeys(100)},
+		{InternValues(), CompressValues(0, nil), BloomFilter(2, 0.01), TrigramIndex(0.1)},
+	} {
+		r := FromKVs(kvs, opts...)
+		if got := r.ToMap(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Got %v, want %v", got, want)
+		}
+		if r.Len() != len(want) {
+			t.Errorf("Got Len() = %v, want %v", r.Len(), len(want))
+		}
+		if err := r.ValidateInvariants(); err != nil {
+			t.Error(err)
+		}
+		if got := keystr(r.Suggest("helo", 1, 10)); got != "hel hello help" {
+			t.Errorf("Got %v, want hel hello help", got)
+		}
+	}
+	if kvs[0].Key != "help" {
+		t.Errorf("FromKVs modified its argument")
+	}
+}
+
+func TestFromMap(t *testing.T) {
+	m := make(map[string]string)
+	for _, key := range generateEdits(5, 1000) {
+		m[key] = key + key
+	}
+	r := FromMap(m)
+	if got := r.ToMap(); !reflect.DeepEqual(got, m) {
+		t.Errorf("ToMap(FromMap(m)) != m")
+	}
+	if err := r.ValidateInvariants(); err != nil {
+		t.Error(err)
+	}
+	s := New()
+	for k, v := range m {
+		s.Set(k, v)
+	}
+	if got, want := r.Stats(), s.Stats(); got != want {
+		t.Errorf("Got %+v from FromMap, want %+v as with Set", got, want)
+	}
+}
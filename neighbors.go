@@ -0,0 +1,210 @@
+package levtrie
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Edge is an edge of the nearest-neighbor graph of a Trie: To is one of the
+// keys nearest to From, at edit distance Distance.
+type Edge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Distance int8   `json:"distance"`
+}
+
+// EdgeFormat is a format for writing Edges, see WriteNeighborGraph.
+type EdgeFormat int
+
+const (
+	// EdgesCSV writes a header line "from,to,distance" followed by a
+	// line for each Edge, quoting keys as encoding/csv does.
+	EdgesCSV EdgeFormat = iota
+	// EdgesJSONL writes a JSON object like
+	// {"from":"cat","to":"cot","distance":1} on a line for each Edge.
+	EdgesJSONL
+)
+
+// NearestNeighbors calls fn with an Edge from each key in the Trie to each of
+// its k nearest keys within edit distance d, until fn returns false. The
+// nearest keys are the ones at the smallest distance and then the first in
+// sorted order, and a key is never its own neighbor. Keys are visited in
+// sorted order and the Edges from each key in order of distance.
+//
+// Instead of searching the Trie once for each key, it walks the Trie once,
+// keeping the set of nodes within distance d of the key at each node it
+// visits. The set at a child is built from the set at its parent, so keys
+// that share a prefix share the work of matching it. Distances are plain
+// Levenshtein distances; NearestNeighbors doesn't take SuggestOptions.
+func (t *Trie) NearestNeighbors(k int, d int8, fn func(e Edge) bool) {
+	if k <= 0 || d < 0 {
+		return
+	}
+	g := neighborGraph{d: int(d), k: k, fn: fn}
+	active := map[*node]int{t.tree(): 0}
+	g.close(active)
+	g.walk(t.tree(), active)
+}
+
+// neighborGraph holds the state of a NearestNeighbors walk.
+type neighborGraph struct {
+	d  int
+	k  int
+	fn func(e Edge) bool
+}
+
+// walk visits the keys at and below n, where active maps each node within
+// distance d of n's prefix to its distance, and returns false if fn did.
+func (g *neighborGraph) walk(n *node, active map[*node]int) bool {
+	if n.data.live() && !g.visit(n.data, active) {
+		return false
+	}
+	for _, r := range newIterFrame(n).runes {
+		if !g.walk(n.child[r], g.step(active, r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// visit calls fn with the Edges from e to its nearest neighbors among the
+// active nodes.
+func (g *neighborGraph) visit(e *entry, active map[*node]int) bool {
+	var near []*node
+	for n := range active {
+		if n.data.live() && n.data != e {
+			near = append(near, n)
+		}
+	}
+	sort.Slice(near, func(i, j int) bool {
+		if active[near[i]] != active[near[j]] {
+			return active[near[i]] < active[near[j]]
+		}
+		return near[i].data.key < near[j].data.key
+	})
+	if len(near) > g.k {
+		near = near[:g.k]
+	}
+	for _, n := range near {
+		if !g.fn(Edge{From: e.key, To: n.data.key, Distance: int8(active[n])}) {
+			return false
+		}
+	}
+	return true
+}
+
+// step returns the active nodes for a prefix followed by r, given the active
+// nodes for the prefix. From a node v at distance dist, deleting r from the
+// prefix stays at v and matching or substituting r moves to a child of v.
+// Inserting runes after r moves further down, see close.
+func (g *neighborGraph) step(active map[*node]int, r rune) map[*node]int {
+	next := make(map[*node]int, len(active))
+	for v, dist := range active {
+		if dist < g.d {
+			relax(next, v, dist+1)
+		}
+		for cr, child := range v.child {
+			if cr == r {
+				relax(next, child, dist)
+			} else if dist < g.d {
+				relax(next, child, dist+1)
+			}
+		}
+	}
+	g.close(next)
+	return next
+}
+
+// close adds the nodes below each active node that are within distance d
+// of the prefix by inserting the runes on the path to them.
+func (g *neighborGraph) close(active map[*node]int) {
+	var ns []*node
+	for n := range active {
+		ns = append(ns, n)
+	}
+	for _, n := range ns {
+		if dist := active[n]; dist < g.d {
+			below(n, 0, g.d-dist, func(w *node, depth int) {
+				relax(active, w, dist+depth)
+			})
+		}
+	}
+}
+
+// relax sets the distance of n in active to dist if it's smaller.
+func relax(active map[*node]int, n *node, dist int) {
+	if old, ok := active[n]; !ok || dist < old {
+		active[n] = dist
+	}
+}
+
+// below calls fn with each node at most limit levels below n, along with how
+// many levels below n it is.
+func below(n *node, depth, limit int, fn func(n *node, depth int)) {
+	if depth > 0 {
+		fn(n, depth)
+	}
+	if depth == limit {
+		return
+	}
+	for _, child := range n.child {
+		below(child, depth+1, limit, fn)
+	}
+}
+
+// WriteNeighborGraph writes the Edges that NearestNeighbors finds to w in the
+// given format, for loading the graph of nearby keys into other tools.
+func (t *Trie) WriteNeighborGraph(w io.Writer, format EdgeFormat, k int, d int8) error {
+	var write func(e Edge) error
+	var flush func() error
+	switch format {
+	case EdgesCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"from", "to", "distance"}); err != nil {
+			return err
+		}
+		write = func(e Edge) error {
+			return cw.Write([]string{e.From, e.To, strconv.Itoa(int(e.Distance))})
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case EdgesJSONL:
+		enc := json.NewEncoder(w)
+		write = func(e Edge) error { return enc.Encode(e) }
+		flush = func() error { return nil }
+	default:
+		return fmt.Errorf("levtrie: unknown EdgeFormat %d", format)
+	}
+	var err error
+	t.NearestNeighbors(k, d, func(e Edge) bool {
+		err = write(e)
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// NearestNeighbors calls fn with an Edge from each key in the SyncTrie to
+// each of its k nearest keys within edit distance d. See
+// Trie.NearestNeighbors.
+func (s *SyncTrie) NearestNeighbors(k int, d int8, fn func(e Edge) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.t.NearestNeighbors(k, d, fn)
+}
+
+// WriteNeighborGraph writes the nearest-neighbor graph of the SyncTrie to w.
+// See Trie.WriteNeighborGraph.
+func (s *SyncTrie) WriteNeighborGraph(w io.Writer, format EdgeFormat, k int, d int8) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.WriteNeighborGraph(w, format, k, d)
+}
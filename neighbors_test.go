@@ -0,0 +1,98 @@
+package levtrie
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// naiveNeighbors returns the Edges that NearestNeighbors should find, using a
+// search for each key.
+func naiveNeighbors(r *Trie, k int, d int8) []Edge {
+	var edges []Edge
+	for _, kv := range r.Suggest("", 127, r.Len()+1) {
+		var near []Edge
+		r.SuggestFunc(kv.Key, d, func(n KV, dist int8) bool {
+			if n.Key != kv.Key {
+				near = append(near, Edge{From: kv.Key, To: n.Key, Distance: dist})
+			}
+			return true
+		})
+		sort.Slice(near, func(i, j int) bool {
+			if near[i].Distance != near[j].Distance {
+				return near[i].Distance < near[j].Distance
+			}
+			return near[i].To < near[j].To
+		})
+		if len(near) > k {
+			near = near[:k]
+		}
+		edges = append(edges, near...)
+	}
+	sort.SliceStable(edges, func(i, j int) bool { return edges[i].From < edges[j].From })
+	return edges
+}
+
+func TestNearestNeighbors(t *testing.T) {
+	rand.Seed(0)
+	r := New()
+	for _, key := range generateEdits(5, 300) {
+		r.Set(key, key)
+	}
+	for _, d := range []int8{0, 1, 2} {
+		for _, k := range []int{1, 3, 1000} {
+			var got []Edge
+			r.NearestNeighbors(k, d, func(e Edge) bool {
+				got = append(got, e)
+				return true
+			})
+			want := naiveNeighbors(r, k, d)
+			if len(got) != len(want) {
+				t.Fatalf("NearestNeighbors(%v, %v) found %v edges, want %v", k, d, len(got), len(want))
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("NearestNeighbors(%v, %v) edge %v = %v, want %v", k, d, i, got[i], want[i])
+				}
+			}
+		}
+	}
+	var got []Edge
+	r.NearestNeighbors(1000, 2, func(e Edge) bool {
+		got = append(got, e)
+		return len(got) < 5
+	})
+	if len(got) != 5 {
+		t.Errorf("Stopped NearestNeighbors found %v edges, want 5", len(got))
+	}
+}
+
+func TestWriteNeighborGraph(t *testing.T) {
+	r := New()
+	for _, key := range []string{"cat", "cot", "coat", "a,b", "a,c", "dog"} {
+		r.Set(key, "")
+	}
+	for _, test := range []struct {
+		format EdgeFormat
+		want   string
+	}{
+		{EdgesCSV, "from,to,distance\n\"a,b\",\"a,c\",1\n\"a,c\",\"a,b\",1\ncat,coat,1\ncoat,cat,1\ncot,cat,1\n"},
+		{EdgesJSONL, `{"from":"a,b","to":"a,c","distance":1}` + "\n" +
+			`{"from":"a,c","to":"a,b","distance":1}` + "\n" +
+			`{"from":"cat","to":"coat","distance":1}` + "\n" +
+			`{"from":"coat","to":"cat","distance":1}` + "\n" +
+			`{"from":"cot","to":"cat","distance":1}` + "\n"},
+	} {
+		var b bytes.Buffer
+		if err := r.WriteNeighborGraph(&b, test.format, 1, 1); err != nil {
+			t.Errorf("WriteNeighborGraph(%v) = %v", test.format, err)
+		}
+		if got := b.String(); got != test.want {
+			t.Errorf("WriteNeighborGraph(%v) wrote %q, want %q", test.format, got, test.want)
+		}
+	}
+	if err := r.WriteNeighborGraph(&bytes.Buffer{}, EdgeFormat(7), 1, 1); err == nil {
+		t.Errorf("WriteNeighborGraph with an unknown format succeeded")
+	}
+}
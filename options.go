@@ -0,0 +1,413 @@
+package levtrie
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Option configures a Trie. See New.
+type Option func(*Trie)
+
+// MaxKeys bounds the number of keys in a Trie to n. When adding a key would
+// make the Trie exceed n keys, an approximately least recently used key is
+// evicted from the Trie. A key is used when it's written or read through
+// Get, Has, Values, Count or any of the methods that write to the Trie, but
+// not when it's returned by one of the Suggest methods. If n isn't positive,
+// the number of keys in the Trie is unbounded, which is the default.
+func MaxKeys(n int) Option {
+	return func(t *Trie) {
+		if n < 0 {
+			n = 0
+		}
+		t.maxKeys = n
+	}
+}
+
+// InternValues makes a Trie store a single copy of each distinct value, no
+// matter how many keys it's associated with. This saves memory when many keys
+// share a small number of distinct values, like categories or language codes,
+// at the cost of a map lookup on each write. The savings are reported by
+// Trie.Stats.
+func InternValues() Option {
+	return func(t *Trie) {
+		if t.interned == nil {
+			t.interned = make(map[string]*internedValue)
+		}
+	}
+}
+
+// CompressValues makes a Trie compress each value that's at least minLen bytes
+// long with DEFLATE, using dict as a preset dictionary if it's not empty. A
+// good dictionary contains substrings that are common among values, like the
+// keys of JSON objects. Values are decompressed whenever they're read, so this
+// trades CPU for memory on tries with long values. Values that don't get any
+// shorter when compressed are stored as they are.
+func CompressValues(minLen int, dict []byte) Option {
+	return func(t *Trie) {
+		t.codec = newCodec(minLen, dict)
+	}
+}
+
+// BloomFilter makes a Trie keep a Bloom filter of its keys, so that looking
+// up a key that isn't in the Trie with Get, Has, Values or Count usually
+// returns without walking the Trie, which makes misses cheap for workloads
+// like spell checking where most lookups miss. The filter is sized for
+// expectedKeys keys with a false positive rate of fpRate, and it's rebuilt
+// with room for twice as many keys as the Trie holds whenever more keys have
+// been added to it than it was sized for. fpRate is clamped to [0.0001, 0.5].
+func BloomFilter(expectedKeys int, fpRate float64) Option {
+	return func(t *Trie) {
+		t.bloom = newBloom(expectedKeys, math.Min(0.5, math.Max(0.0001, fpRate)))
+	}
+}
+
+// NegativeCache makes a Trie remember up to size keys that were recently
+// looked up with Get, Has, Values or Count and found missing, so that looking
+// them up again returns without walking the Trie. It suits workloads like
+// spell checking, where the same misspellings are looked up over and over. A
+// key is forgotten as soon as it's added to the Trie, and the least recently
+// looked up key is forgotten when the cache is full. The cache has its own
+// lock, so lookups on a SyncTrie briefly contend on it.
+func NegativeCache(size int) Option {
+	return func(t *Trie) {
+		t.absent = newAbsentCache(size)
+	}
+}
+
+// TrigramIndex makes a Trie keep an index of the trigrams of its keys, which
+// Suggest uses instead of walking the Trie when d is at least ratio times the
+// number of runes in the query. Searches with an edit distance that's large
+// relative to the length of the query visit most of the Trie, while the index
+// only has to check keys that share enough trigrams with the query to be
+// within distance d of it. Results found with the index are ordered by edit
+// distance and then by key. The index costs memory proportional to the total
+// length of the keys. If ratio isn't positive, it defaults to 0.25.
+func TrigramIndex(ratio float64) Option {
+	return func(t *Trie) {
+		if ratio <= 0 {
+			ratio = 0.25
+		}
+		t.grams = newTrigramIndex(ratio)
+	}
+}
+
+// ReverseIndex makes a Trie keep a second tree of its keys with their runes in
+// reverse order, which makes SuggestEndsWith as fast as SuggestSuffixes at the
+// cost of roughly doubling the memory used for keys.
+func ReverseIndex() Option {
+	return func(t *Trie) {
+		t.rev = &node{child: make(map[rune]*node)}
+	}
+}
+
+// InfixIndex makes a Trie keep a tree of every suffix of its keys, which makes
+// SuggestInfix about as fast as SuggestSuffixes. The tree has a node for each
+// distinct substring of the keys, so its memory grows with the square of the
+// length of the keys. It suits dictionaries of words and names, not documents.
+func InfixIndex() Option {
+	return func(t *Trie) {
+		t.infix = newInfixIndex()
+	}
+}
+
+// AdaptiveDistance makes the Suggest methods of a Trie search within the
+// distance p gives the length of the query whenever they're called with a
+// negative distance, instead of DefaultDistancePolicy. This keeps the choice
+// of distance in one place rather than at every call site.
+func AdaptiveDistance(p DistancePolicy) Option {
+	return func(t *Trie) {
+		t.policy = p
+	}
+}
+
+// TransformValues makes a Trie map each value it returns through f, which is
+// passed the value's key along with the value as it was stored. This lets a
+// Trie decode, redact or annotate values in one place instead of at every
+// call site. f applies to the values returned by Get, Values, MGet, the
+// Suggest methods, Iterator and ToMap, but not to the values compared by
+// methods like CompareAndSwap and Remove or passed to a Filter, which are the
+// values as they were stored. f is called on every read, so it should be
+// cheap, and it must not modify the Trie.
+func TransformValues(f func(kv KV) string) Option {
+	return func(t *Trie) {
+		t.transform = f
+	}
+}
+
+// SuggestOption configures a single call to one of the Suggest methods.
+type SuggestOption func(*searchConfig)
+
+// searchConfig collects the effects of all SuggestOptions passed to a search.
+type searchConfig struct {
+	query string  // The query passed to the search.
+	ops   edits   // The edit operations allowed during the search.
+	costs costs   // The cost of each edit operation.
+	affix affixes // The allowance for edits at either end of a key.
+	// The maximum number of values returned for each key, or 0 for all.
+	valuesPerKey int
+	ordered      bool // Whether results are sorted by distance and key.
+	// The most keys taken from each distance before filling, see
+	// BucketCap, or 0 for no cap.
+	bucketCap int
+	byWeight  bool // Whether ties in distance are broken by count first.
+	// Maps keys to the form they're deduplicated by, see DedupeBy.
+	fold         func(string) string
+	sample       bool       // Whether results are sampled, see SampleByWeight.
+	rng          *rand.Rand // The source of the samples, or nil for math/rand.
+	excludeQuery bool       // Whether a key equal to the query is left out.
+	// The tags every key returned must have, see WithTags, and their
+	// bitmap, which is only set by Trie.config.
+	tags    []string
+	tagMask uint64
+	// Whether one of the tags isn't in the Trie, so nothing matches.
+	unknownTag bool
+	// Restricts matches to the keys it accepts, see Constrain.
+	acceptor Acceptor
+	// The state of acceptor at the root of the search and whether the
+	// search walks keys forward from there, so that acceptor can prune
+	// it, or whether acceptor ruled out every key below the root.
+	from     int
+	prune    bool
+	rejected bool
+	// Decides which matches are returned, see Filter, and decodes the
+	// values passed to it.
+	filter func(kv KV, dist int8) bool
+	decode func(string) string
+	// Ends the search early, see StopWhen.
+	stop func(kv KV, dist int8, weight int64) bool
+	// The distance of the match being visited, which is only kept up to
+	// date when filter, stop or a SuggestFunc callback need it.
+	dist     int8
+	withDist bool // Whether a SuggestFunc callback needs dist.
+	// Decays the weights of matches to now, see DecayWeights. Both are
+	// only set by Trie.config.
+	decay *decay
+	now   int64
+}
+
+// needsDist returns true if the distance of each match has to be computed.
+func (cfg *searchConfig) needsDist() bool {
+	return cfg.filter != nil || cfg.stop != nil || cfg.withDist
+}
+
+// kv returns a KV for the key of e and its first value.
+func (cfg *searchConfig) kv(e *entry) KV {
+	v := e.value()
+	if cfg.decode != nil {
+		v = cfg.decode(v)
+	}
+	return KV{Key: e.key, Value: v}
+}
+
+// stops returns true if the search should end after e, which was just added
+// to the results at distance cfg.dist.
+func (cfg *searchConfig) stops(e *entry) bool {
+	return cfg.stop != nil && cfg.stop(cfg.kv(e), cfg.dist, cfg.count(e))
+}
+
+// keep returns true if e, which matches the query at distance dist, passes
+// the filter of the search.
+func (cfg *searchConfig) keep(e *entry, dist int8) bool {
+	return cfg.filter == nil || cfg.filter(cfg.kv(e), dist)
+}
+
+// excluded returns true if the entry e shouldn't be returned by a search even
+// though it matches the query.
+func (cfg *searchConfig) excluded(e *entry) bool {
+	return cfg.excludeQuery && e.key == cfg.query || cfg.unknownTag || cfg.rejected ||
+		e.tags&cfg.tagMask != cfg.tagMask || !cfg.accepts(e.key)
+}
+
+// pruned returns true if a search doesn't need to explore below n at all.
+func (cfg *searchConfig) pruned(n *node) bool {
+	return cfg.prunedTags(n.tags)
+}
+
+// prunedTags returns true if a search doesn't need to explore below a node
+// whose keys have the tags in tags.
+func (cfg *searchConfig) prunedTags(tags uint64) bool {
+	return cfg.unknownTag || cfg.rejected || tags&cfg.tagMask != cfg.tagMask
+}
+
+// costs holds the cost of each kind of edit operation.
+type costs struct {
+	ins, del, sub int8
+}
+
+var unitCosts = costs{ins: 1, del: 1, sub: 1}
+
+// affixes describes how many edits at either end of a key get a special cost.
+type affixes struct {
+	prefix, suffix int
+	cost           int8
+}
+
+// config returns the searchConfig for a search of t for query.
+func (t *Trie) config(query string, opts []SuggestOption) *searchConfig {
+	if t.damerau {
+		opts = append([]SuggestOption{Transpositions(true)}, opts...)
+	}
+	cfg := newSearchConfig(query, opts)
+	cfg.decode = t.decode
+	cfg.decay, cfg.now = t.decay, t.decay.now()
+	if len(cfg.tags) > 0 {
+		var ok bool
+		cfg.tagMask, ok = t.tagMask(cfg.tags)
+		cfg.unknownTag = !ok
+	}
+	return cfg
+}
+
+func newSearchConfig(query string, opts []SuggestOption) *searchConfig {
+	cfg := &searchConfig{query: query, ops: allEdits, costs: unitCosts}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.acceptor != nil {
+		cfg.from, cfg.prune = cfg.acceptor.Start(), true
+	}
+	return cfg
+}
+
+// DeletionsOnly restricts a search to keys that can be obtained by deleting at
+// most d runes from the query, that is, keys that are subsequences of the
+// query. Example: Suggest("international", 9, 10, DeletionsOnly()) might
+// return "intl" and "intern" but not "internet".
+func DeletionsOnly() SuggestOption {
+	return func(cfg *searchConfig) {
+		cfg.ops = deletions
+	}
+}
+
+// InsertionsOnly restricts a search to keys that can be obtained by inserting
+// at most d runes into the query, that is, keys that contain the query as a
+// subsequence. This is useful for expanding abbreviations. Example:
+// Suggest("intl", 9, 10, InsertionsOnly()) might return "international" and
+// "initial" but not "intel".
+func InsertionsOnly() SuggestOption {
+	return func(cfg *searchConfig) {
+		cfg.ops = insertions
+	}
+}
+
+// Transpositions makes a search count a swap of two adjacent runes as one
+// edit when on is true, bounding the restricted Damerau-Levenshtein distance
+// (see DamerauDistance) instead, and turns them off for a Trie with Damerau
+// when on is false. Searches with it ignore EditCosts, AffixTolerance,
+// DeletionsOnly and InsertionsOnly, and don't use TrigramIndex.
+func Transpositions(on bool) SuggestOption {
+	return func(cfg *searchConfig) {
+		if on {
+			cfg.ops |= transpositions
+		} else {
+			cfg.ops &^= transpositions
+		}
+	}
+}
+
+// Damerau makes every search of a Trie count transpositions as single edits,
+// as if Transpositions(true) were passed to it first. Pass
+// Transpositions(false) to a search to turn them off for that search.
+func Damerau() Option {
+	return func(t *Trie) {
+		t.damerau = true
+	}
+}
+
+// EditCosts assigns a cost to each kind of edit operation, turning the edit
+// distance bound d of a search into a bound on the total cost of the edits
+// between the query and each key returned. An insertion is a rune that appears
+// in the key but is missing from the query, a deletion is a rune that appears
+// in the query but is missing from the key, and a substitution replaces a rune
+// in the query with a different rune in the key. Costs less than 1 are treated
+// as 1. Example: if users tend to leave characters out of their queries but
+// rarely type extra characters, Suggest("color", 2, 10, EditCosts(1, 3, 2))
+// can return "colour" but not "colr".
+func EditCosts(insert, delete, substitute int8) SuggestOption {
+	return func(cfg *searchConfig) {
+		cfg.costs = costs{ins: atLeastOne(insert), del: atLeastOne(delete), sub: atLeastOne(substitute)}
+	}
+}
+
+func atLeastOne(x int8) int8 {
+	if x < 1 {
+		return 1
+	}
+	return x
+}
+
+// AffixTolerance changes the cost of insertions and deletions at either end of
+// a key to cost, which is usually 0 or at least lower than the cost of other
+// edits. Up to prefix runes at the beginning of the query can be deleted and up
+// to prefix runes can be inserted at the beginning of the key at this cost, and
+// similarly for suffix runes at the end. This makes it possible to ignore
+// leading articles or inflectional suffixes like "s" or "ing" without spending
+// the edit budget on them, anywhere in the Trie. Example:
+// Suggest("walk", 0, 10, AffixTolerance(0, 3, 0)) might return "walk",
+// "walks", "walked", "walking", and "wa". Affix edits are still insertions and
+// deletions, so DeletionsOnly and InsertionsOnly restrict them too.
+func AffixTolerance(prefix, suffix int, cost int8) SuggestOption {
+	return func(cfg *searchConfig) {
+		if prefix < 0 {
+			prefix = 0
+		}
+		if suffix < 0 {
+			suffix = 0
+		}
+		if cost < 0 {
+			cost = 0
+		}
+		cfg.affix = affixes{prefix: prefix, suffix: suffix, cost: cost}
+	}
+}
+
+// ExcludeQuery leaves a key that's identical to the query out of the results
+// of a search, which is what a spelling correction UI wants when it offers
+// alternatives to the word that was typed. The key is left out before the
+// number of results is limited, so it doesn't take the place of another
+// result. Example: Suggest("form", 1, 3, ExcludeQuery()) might return "farm",
+// "fork" and "forms", but not "form".
+func ExcludeQuery() SuggestOption {
+	return func(cfg *searchConfig) {
+		cfg.excludeQuery = true
+	}
+}
+
+// Filter restricts a search to the matches for which keep returns true. keep
+// is called with the key and its first value, and with the distance at which
+// the key matches the query, as soon as the match is found, so matches that
+// are filtered out never take the place of other results the way they would
+// if results were filtered after the search. For SuggestSuffixes and its
+// variants, the distance is the distance of the prefix of the key where the
+// match was found, which isn't always the closest one unless the search is
+// Ordered. keep must not modify the Trie.
+func Filter(keep func(kv KV, dist int8) bool) SuggestOption {
+	return func(cfg *searchConfig) {
+		cfg.filter = keep
+	}
+}
+
+// StopWhen ends a search as soon as stop returns true, trading completeness
+// for latency. stop is called after each key is added to the results with
+// the key and its first value, the distance at which the key matches the
+// query and the key's weight (see Trie.IncrBy), and it can keep whatever
+// state it needs between calls. Since matches are found roughly in order of
+// increasing distance, stopping early usually leaves out the farthest
+// matches. Example: to stop once 3 results within distance 1 are found,
+// count the calls with dist <= 1 and return true on the third one. stop must
+// not modify the Trie.
+func StopWhen(stop func(kv KV, dist int8, weight int64) bool) SuggestOption {
+	return func(cfg *searchConfig) {
+		cfg.stop = stop
+	}
+}
+
+// ValuesPerKey limits the number of KVs returned for each key to at most n,
+// for keys that have more than one value associated with them (see Trie.Add).
+// The first n values associated with the key are returned. By default, all
+// values are returned.
+func ValuesPerKey(n int) SuggestOption {
+	return func(cfg *searchConfig) {
+		cfg.valuesPerKey = n
+	}
+}
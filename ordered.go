@@ -0,0 +1,103 @@
+package levtrie
+
+import "sort"
+
+// Ordered makes a search return the first n matches by increasing edit
+// distance from the query, or cost with EditCosts, and then by key, at the
+// cost of finding and sorting every match at the distance of the last result.
+// SuggestInfix and, without its index, SuggestEndsWith ignore it.
+func Ordered() SuggestOption {
+	return func(cfg *searchConfig) {
+		cfg.ordered = true
+	}
+}
+
+// ByWeight is like Ordered, but breaks ties between matches with the same
+// distance by their weight, the count associated with each key (see
+// Trie.IncrBy), with heavier keys first, and then by key. Like the rest of the
+// order, ties are broken before the number of results is limited, so that the
+// heaviest of the closest matches are never left out in favor of lighter ones.
+// Example: with "cat" counted 10 times and "cot" counted 50 times,
+// Suggest("cet", 1, 1, ByWeight()) returns "cot".
+func ByWeight() SuggestOption {
+	return func(cfg *searchConfig) {
+		cfg.ordered = true
+		cfg.byWeight = true
+	}
+}
+
+// before returns true if a comes before b among matches with the same
+// distance.
+func (cfg *searchConfig) before(a, b *entry) bool {
+	if cfg.byWeight {
+		if wa, wb := cfg.weight(a), cfg.weight(b); wa != wb {
+			return wa > wb
+		}
+	}
+	return a.key < b.key
+}
+
+// searchOrdered is search for the Ordered option. It explores the same frames
+// as search, in the same order, but records the distance at which each entry
+// is accepted instead of visiting it right away. Since the minimum distance
+// of a frame can't decrease as runes are read, once every frame with minimum
+// distance i has been explored, every entry within distance i has been
+// recorded with its final distance, so it's safe to sort the entries at
+// distance i and visit them before exploring frames at distance i+1.
+// Entries below an accepting node are recorded by process, which is called on
+// every accepting node without halting, so that an entry with a prefix that
+// matches more closely further down is recorded with the closer distance.
+func searchOrdered(process processAcceptingNode, root *node, runes []rune, d int8, cfg *searchConfig, visit func(*entry) bool) {
+	if cfg.exactOnly(d) {
+		searchExact(process, root, runes, cfg, visit)
+		return
+	}
+	n := newAutomaton(runes, d, cfg)
+	stacks := make([][]frame, int(d)+1)
+	stacks[0] = []frame{frame{n: root, s: n.start()}}
+	best := make(map[*entry]int8)
+	found := make([][]*entry, int(d)+1)
+	for i := range stacks {
+		for len(stacks[i]) > 0 {
+			var f frame
+			f, stacks[i] = stacks[i][len(stacks[i])-1], stacks[i][:len(stacks[i])-1]
+			if x := n.distance(f.s); x <= int(d) {
+				dist := int8(x)
+				process(f.n, func(e *entry) bool {
+					if !cfg.keep(e, dist) {
+						return true
+					}
+					if b, ok := best[e]; !ok || dist < b {
+						best[e] = dist
+						found[dist] = append(found[dist], e)
+					}
+					return true
+				})
+			}
+			for r, node := range f.n.child {
+				if cfg.pruned(node) {
+					continue
+				}
+				if ns, min := n.transition(f.s, r); min <= int(d) {
+					stacks[min] = append(stacks[min], frame{n: node, s: ns})
+				}
+			}
+		}
+		// An entry can be in found[i] more than once if it was recorded
+		// with a larger distance in between. It's visited at most once.
+		es := found[i][:0]
+		for _, e := range found[i] {
+			if best[e] == int8(i) {
+				best[e] = -1
+				es = append(es, e)
+			}
+		}
+		sort.Slice(es, func(a, b int) bool { return cfg.before(es[a], es[b]) })
+		cfg.dist = int8(i)
+		for _, e := range es {
+			if !visit(e) {
+				return
+			}
+		}
+	}
+}
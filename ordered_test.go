@@ -0,0 +1,103 @@
+package levtrie
+
+import (
+	"sort"
+	"testing"
+)
+
+// expectOrdered checks that got is the first n keys of data within distance d
+// of key, ordered by distance and then by key.
+func expectOrdered(t *testing.T, name string, got []KV, data []string, key string, d int8, n int, dist func(a, b string) int) {
+	t.Helper()
+	type match struct {
+		key  string
+		dist int
+	}
+	var want []match
+	for _, k := range data {
+		if x := dist(key, k); x <= int(d) {
+			want = append(want, match{k, x})
+		}
+	}
+	sort.Slice(want, func(i, j int) bool {
+		if want[i].dist != want[j].dist {
+			return want[i].dist < want[j].dist
+		}
+		return want[i].key < want[j].key
+	})
+	if len(want) > n {
+		want = want[:n]
+	}
+	if len(got) != len(want) {
+		t.Errorf("%v(%q, %v, %v) returned %v results, want %v", name, key, d, n, len(got), len(want))
+		return
+	}
+	for i := range got {
+		if got[i].Key != want[i].key {
+			t.Errorf("%v(%q, %v, %v)[%v] = %q, want %q", name, key, d, n, i, got[i].Key, want[i].key)
+			return
+		}
+	}
+}
+
+func TestOrdered(t *testing.T) {
+	data := generateEdits(5, 300)
+	for _, opts := range [][]Option{nil, {TrigramIndex(0.1)}} {
+		r := New(opts...)
+		for _, key := range data {
+			r.Set(key, "")
+		}
+		for i, key := range data[:30] {
+			d, n := int8(i%4), 1+i%7
+			expectOrdered(t, "Suggest", r.Suggest(key, d, n, Ordered()), data, key, d, n, Distance)
+			expectOrdered(t, "SuggestSuffixes", r.SuggestSuffixes(key, d, n, Ordered()), data, key, d, n, PrefixDistance)
+		}
+	}
+}
+
+func TestOrderedEditCosts(t *testing.T) {
+	data := []string{"color", "colour", "colr", "collar", "cooler", "dolor", "col"}
+	r := New(TrigramIndex(0.1))
+	for _, key := range data {
+		r.Set(key, "")
+	}
+	if got, want := ukeystr(r.Suggest("color", 3, 10, EditCosts(1, 3, 2), Ordered())), "color colour dolor collar colr cooler"; got != want {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	if got, want := ukeystr(r.Suggest("color", 3, 3, EditCosts(1, 3, 2), Ordered())), "color colour dolor"; got != want {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+}
+
+func TestOrderedBytes(t *testing.T) {
+	b := NewBytes()
+	for _, key := range []string{"cart", "cat", "at", "cot"} {
+		b.Set(key, []byte(key))
+	}
+	got := b.Suggest("cat", 1, 10, Ordered())
+	var keys []string
+	for _, kv := range got {
+		keys = append(keys, kv.Key)
+	}
+	if len(keys) != 4 || keys[0] != "cat" || keys[1] != "at" || keys[2] != "cart" || keys[3] != "cot" {
+		t.Errorf("Got %v, want [cat at cart cot]", keys)
+	}
+}
+
+func TestByWeight(t *testing.T) {
+	for _, opts := range [][]Option{nil, {TrigramIndex(0.1)}} {
+		r := New(opts...)
+		for key, count := range map[string]int64{"cat": 10, "cot": 50, "cut": 50, "cet": 1, "act": 100, "coat": 1000} {
+			r.IncrBy(key, count)
+		}
+		if got, want := ukeystr(r.Suggest("cet", 1, 10, ByWeight())), "cet cot cut cat"; got != want {
+			t.Errorf("Got %v, want %v", got, want)
+		}
+		if got, want := ukeystr(r.Suggest("cxt", 1, 2, ByWeight())), "cot cut"; got != want {
+			t.Errorf("Got %v, want %v", got, want)
+		}
+		if got, want := ukeystr(r.Suggest("cxt", 2, 10, ByWeight())), "cot cut cat cet coat act"; got != want {
+			t.Errorf("Got %v, want %v", got, want)
+		}
+	}
+}
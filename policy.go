@@ -0,0 +1,43 @@
+package levtrie
+
+import "math"
+
+// DistancePolicy maps the length of a query to the maximum edit distance to
+// search within for it. Short queries can't tolerate many edits before almost
+// every key in the Trie matches them, while long queries usually need more
+// than one edit to correct. The element at index i is the length in runes of
+// the longest query that's searched within distance i, and the elements must
+// be increasing. Queries longer than the last element are searched within
+// distance len(p). The Suggest methods of a Trie use its DistancePolicy
+// whenever they're called with a negative distance, see AdaptiveDistance.
+type DistancePolicy []int
+
+// DefaultDistancePolicy searches queries of up to 2 runes exactly, queries of
+// up to 5 runes within distance 1, queries of up to 8 runes within distance 2
+// and longer queries within distance 3.
+var DefaultDistancePolicy = DistancePolicy{2, 5, 8}
+
+// MaxDistance returns the edit distance for a query that's length runes long.
+func (p DistancePolicy) MaxDistance(length int) int8 {
+	for i, max := range p {
+		if length <= max {
+			return int8(i)
+		}
+	}
+	if len(p) > math.MaxInt8 {
+		return math.MaxInt8
+	}
+	return int8(len(p))
+}
+
+// distance returns d if it's not negative, or the distance the Trie's
+// DistancePolicy gives a query that's length runes long otherwise.
+func (t *Trie) distance(length int, d int8) int8 {
+	if d >= 0 {
+		return d
+	}
+	if t.policy != nil {
+		return t.policy.MaxDistance(length)
+	}
+	return DefaultDistancePolicy.MaxDistance(length)
+}
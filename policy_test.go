@@ -0,0 +1,54 @@
+package levtrie
+
+import "testing"
+
+func TestDistancePolicy(t *testing.T) {
+	for _, test := range []struct {
+		p      DistancePolicy
+		length int
+		want   int8
+	}{
+		{DefaultDistancePolicy, 0, 0},
+		{DefaultDistancePolicy, 2, 0},
+		{DefaultDistancePolicy, 3, 1},
+		{DefaultDistancePolicy, 5, 1},
+		{DefaultDistancePolicy, 8, 2},
+		{DefaultDistancePolicy, 9, 3},
+		{DefaultDistancePolicy, 100, 3},
+		{nil, 100, 0},
+		{DistancePolicy{-1, 4}, 0, 1},
+	} {
+		if got := test.p.MaxDistance(test.length); got != test.want {
+			t.Errorf("%v.MaxDistance(%v) = %v, want %v", test.p, test.length, got, test.want)
+		}
+	}
+}
+
+func TestAdaptiveDistance(t *testing.T) {
+	data := []string{"at", "ax", "cat", "coat", "banana", "bandana", "cabana"}
+	for _, test := range []struct {
+		opts []Option
+		key  string
+		want string
+	}{
+		{nil, "at", "at"},
+		{nil, "cot", "cat coat"},
+		{nil, "banaxa", "banana bandana"},
+		{nil, "bnana", "banana"},
+		{nil, "bandanaz", "banana bandana"},
+		{[]Option{AdaptiveDistance(DistancePolicy{1})}, "at", "at ax cat"},
+		{[]Option{AdaptiveDistance(DistancePolicy{1})}, "banaxa", "banana"},
+		{[]Option{AdaptiveDistance(DistancePolicy{10})}, "cot", ""},
+	} {
+		r := New(test.opts...)
+		for _, key := range data {
+			r.Set(key, "")
+		}
+		if got := keystr(r.Suggest(test.key, -1, 10)); got != test.want {
+			t.Errorf("Suggest(%q, -1) = %v, want %v", test.key, got, test.want)
+		}
+		if got := keystr(r.SuggestAfterExactPrefix(test.key, 0, -100, 10)); got != test.want {
+			t.Errorf("SuggestAfterExactPrefix(%q, 0, -100) = %v, want %v", test.key, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package levtrie
+
+import "strings"
+
+// CommonPrefix returns the longest prefix shared by all keys in the Trie, or
+// the empty string if the Trie is empty. Every key in the Trie has to start
+// with the prefix, so a search restricted to an exact prefix of at most that
+// many runes with one of the AfterExactPrefix methods loses nothing when the
+// query starts with it too.
+func (t *Trie) CommonPrefix() string {
+	return t.CommonPrefixAfter("")
+}
+
+// CommonPrefixAfter returns the longest prefix shared by all keys in the Trie
+// that start with prefix, or the empty string if there aren't any such keys.
+// The result always starts with prefix if it's not empty.
+func (t *Trie) CommonPrefixAfter(prefix string) string {
+	n := t.find(prefix)
+	if n == nil || (n.data == nil && len(n.child) == 0) {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(prefix)
+	// Follow the chain of nodes with a single child and no key, which are
+	// the nodes every key below n passes through.
+	for n.data == nil && len(n.child) == 1 {
+		for r, child := range n.child {
+			b.WriteRune(r)
+			n = child
+		}
+	}
+	return b.String()
+}
+
+// anchor follows the longest prefix of runes that's a path in the Trie and
+// returns the node it ends at along with the length of the prefix.
+func (t *Trie) anchor(runes []rune) (*node, int) {
+	n := t.tree()
+	for p, r := range runes {
+		child, ok := n.child[r]
+		if !ok {
+			return n, p
+		}
+		n = child
+	}
+	return n, len(runes)
+}
+
+// SuggestAnchored is like SuggestAfterExactPrefix, but instead of taking the
+// length of the exact prefix from the caller, it uses the longest prefix of key
+// that's also a prefix of some key in the Trie, and returns that length along
+// with the results. Every rune of the query that can be matched exactly is, so
+// the search is as narrow as it can be, but a typo that happens to continue a
+// path in the Trie is anchored too: with "html" in the Trie, the anchor for
+// "hte" is "ht", which rules out "the". Example: with "britney" and "brine" in
+// the Trie, SuggestAnchored("britnay", 1, 10) anchors on "britn" and returns
+// "britney" and an anchor length of 5.
+func (t *Trie) SuggestAnchored(key string, d int8, n int, opts ...SuggestOption) ([]KV, int) {
+	key, ok := t.preprocess(key)
+	if !ok {
+		return nil, 0
+	}
+	runes := extractRunes(key)
+	curr, p := t.anchor(runes)
+	cfg := t.config(key, opts)
+	cfg.after(runes[:p])
+	return t.suggest(doNotExpandSuffixes, curr, runes[p:], t.distance(len(runes), d), n, cfg), p
+}
+
+// SuggestSuffixesAnchored is like SuggestSuffixesAfterExactPrefix, but chooses
+// the length of the exact prefix the way SuggestAnchored does and returns it
+// along with the results.
+func (t *Trie) SuggestSuffixesAnchored(key string, d int8, n int, opts ...SuggestOption) ([]KV, int) {
+	key, ok := t.preprocess(key)
+	if !ok {
+		return nil, 0
+	}
+	runes := extractRunes(key)
+	curr, p := t.anchor(runes)
+	cfg := t.config(key, opts)
+	cfg.after(runes[:p])
+	return t.suggest(expandSuffixes, curr, runes[p:], t.distance(len(runes), d), n, cfg), p
+}
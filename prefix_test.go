@@ -0,0 +1,91 @@
+package levtrie
+
+import "testing"
+
+func TestCommonPrefix(t *testing.T) {
+	r := New()
+	if got := r.CommonPrefix(); got != "" {
+		t.Errorf("Got %q for an empty Trie, want \"\"", got)
+	}
+	r.Set("international", "")
+	if got := r.CommonPrefix(); got != "international" {
+		t.Errorf("Got %q, want \"international\"", got)
+	}
+	r.Set("internet", "")
+	r.Set("interned", "")
+	if got := r.CommonPrefix(); got != "intern" {
+		t.Errorf("Got %q, want \"intern\"", got)
+	}
+	r.Set("inter", "")
+	if got := r.CommonPrefix(); got != "inter" {
+		t.Errorf("Got %q, want \"inter\"", got)
+	}
+	r.Set("", "")
+	if got := r.CommonPrefix(); got != "" {
+		t.Errorf("Got %q, want \"\"", got)
+	}
+	r.Delete("")
+	r.Delete("inter")
+	r.Set("intérieur", "")
+	if got := r.CommonPrefix(); got != "int" {
+		t.Errorf("Got %q, want \"int\"", got)
+	}
+}
+
+func TestCommonPrefixAfter(t *testing.T) {
+	r := New()
+	for _, key := range []string{"apple", "application", "apply", "banana", "bandana"} {
+		r.Set(key, "")
+	}
+	tests := []struct {
+		prefix, want string
+	}{
+		{"", ""},
+		{"a", "appl"},
+		{"appli", "application"},
+		{"b", "ban"},
+		{"bana", "banana"},
+		{"c", ""},
+		{"applz", ""},
+	}
+	for _, test := range tests {
+		if got := r.CommonPrefixAfter(test.prefix); got != test.want {
+			t.Errorf("CommonPrefixAfter(%q) = %q, want %q", test.prefix, got, test.want)
+		}
+	}
+}
+
+func TestSuggestAnchored(t *testing.T) {
+	r := New()
+	for _, key := range []string{"britney", "brine", "briney", "html", "the", "intérieur"} {
+		r.Set(key, key)
+	}
+	for _, test := range []struct {
+		key      string
+		d        int8
+		suffixes bool
+		want     string
+		p        int
+	}{
+		{"britnay", 1, false, "britney", 5},
+		{"brone", 1, false, "brine", 2},
+		{"brin", 1, false, "brine", 4},
+		{"brin", 1, true, "brine briney", 4},
+		{"brit", 0, true, "britney", 4},
+		{"hte", 1, false, "", 2},
+		{"xhe", 1, false, "the", 0},
+		{"intérior", 2, false, "intérieur", 6},
+		{"", 0, true, "brine briney britney html intérieur the", 0},
+	} {
+		var got []KV
+		var p int
+		if test.suffixes {
+			got, p = r.SuggestSuffixesAnchored(test.key, test.d, 10)
+		} else {
+			got, p = r.SuggestAnchored(test.key, test.d, 10)
+		}
+		if keystr(got) != test.want || p != test.p {
+			t.Errorf("Anchored search for %q, %v = (%v, %v), want (%v, %v)", test.key, test.d, keystr(got), p, test.want, test.p)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package levtrie
+
+import (
+	"strings"
+	"unicode"
+)
+
+// QueryProcessor rewrites a query before a Trie looks it up, see
+// PreprocessQueries. It returns the rewritten query and true, or false to veto
+// the query, in which case the lookup finds nothing.
+type QueryProcessor func(query string) (string, bool)
+
+// PreprocessQueries makes a Trie pass every query through steps, in order,
+// before looking it up, so that queries are normalized the same way at every
+// call site. The steps run on the keys passed to Get, Values, Has, Count and
+// MGet and on the queries passed to the Suggest methods, including the ones
+// CorrectText and DidYouMean make, but not on the keys passed to methods that
+// write to the Trie, so keys should be normalized the same way before they're
+// added. If a step vetoes a query, the steps after it don't run and the
+// lookup behaves as if nothing matched. Exact prefix lengths, like p in
+// SuggestAfterExactPrefix, count the runes of the rewritten query. Using
+// PreprocessQueries more than once adds steps after the ones already there.
+func PreprocessQueries(steps ...QueryProcessor) Option {
+	return func(t *Trie) {
+		t.preprocessors = append(t.preprocessors, steps...)
+	}
+}
+
+// Built-in QueryProcessors.
+var (
+	// TrimQuery removes leading and trailing whitespace from queries.
+	TrimQuery QueryProcessor = func(query string) (string, bool) {
+		return strings.TrimSpace(query), true
+	}
+	// LowercaseQuery lowercases queries.
+	LowercaseQuery QueryProcessor = func(query string) (string, bool) {
+		return strings.ToLower(query), true
+	}
+	// StripSymbols removes symbols, like emoji and currency signs, control
+	// characters and the joiners and selectors that combine emoji from
+	// queries.
+	StripSymbols QueryProcessor = func(query string) (string, bool) {
+		return strings.Map(func(r rune) rune {
+			if unicode.IsSymbol(r) || unicode.IsControl(r) || r == '\u200d' || r == '\ufe0f' {
+				return -1
+			}
+			return r
+		}, query), true
+	}
+	// RejectEmptyQuery vetoes empty queries, which would otherwise match
+	// every key within the search distance of the empty string.
+	RejectEmptyQuery QueryProcessor = func(query string) (string, bool) {
+		return query, query != ""
+	}
+)
+
+// ExpandAbbreviations returns a QueryProcessor that replaces each
+// whitespace-separated word of a query that's a key of abbrevs with its value,
+// like "st" with "street". Words are separated by single spaces afterward.
+func ExpandAbbreviations(abbrevs map[string]string) QueryProcessor {
+	return func(query string) (string, bool) {
+		words := strings.Fields(query)
+		for i, w := range words {
+			if x, ok := abbrevs[w]; ok {
+				words[i] = x
+			}
+		}
+		return strings.Join(words, " "), true
+	}
+}
+
+// preprocess runs query through the Trie's QueryProcessors, returning the
+// rewritten query and true, or false if one of them vetoed it.
+func (t *Trie) preprocess(query string) (string, bool) {
+	for _, step := range t.preprocessors {
+		var ok bool
+		if query, ok = step(query); !ok {
+			return "", false
+		}
+	}
+	return query, true
+}
@@ -0,0 +1,70 @@
+package levtrie
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreprocessQueries(t *testing.T) {
+	var seen []string
+	record := func(query string) (string, bool) {
+		seen = append(seen, query)
+		return query, true
+	}
+	r := New(PreprocessQueries(TrimQuery, StripSymbols, LowercaseQuery, RejectEmptyQuery),
+		PreprocessQueries(ExpandAbbreviations(map[string]string{"st": "street"}), record))
+	for _, key := range []string{"main street", "main", "maine", "street"} {
+		r.Set(key, strings.ToUpper(key))
+	}
+	r.IncrBy("main", 3)
+	expectFound(t, r, "  Main 🎉 ", "MAIN")
+	if !r.Has("MAIN st") || r.Count(" main") != 3 || len(r.Values("Maine\t")) != 1 {
+		t.Error("Has, Count or Values didn't preprocess the key")
+	}
+	if got, want := keystr(r.Suggest(" Mainn ", 1, 10)), "main maine"; got != want {
+		t.Errorf("Suggest = %v, want %v", got, want)
+	}
+	if got, want := keystr(r.SuggestSuffixes("MAIN S", 0, 10)), "main street"; got != want {
+		t.Errorf("SuggestSuffixes = %v, want %v", got, want)
+	}
+	if got, want := keystr(r.SuggestEndsWith("REET ", 0, 10)), "main street street"; got != want {
+		t.Errorf("SuggestEndsWith = %v, want %v", got, want)
+	}
+	if got, want := keystr(r.SuggestAfterExactPrefix(" Mainr", 4, 1, 10)), "main maine"; got != want {
+		t.Errorf("SuggestAfterExactPrefix = %v, want %v", got, want)
+	}
+	if got := r.MGet([]string{" main", "MAINE"}); len(got) != 2 || got[0].Key != "main" || got[1].Key != "maine" {
+		t.Errorf("MGet = %v, want main and maine", got)
+	}
+	// Each query is preprocessed exactly once, even by methods that are
+	// built on other Suggest methods.
+	seen = nil
+	r.SuggestWithConfidence("Mian", 1, 10)
+	if len(seen) != 1 || seen[0] != "mian" {
+		t.Errorf("SuggestWithConfidence preprocessed %v, want [mian]", seen)
+	}
+	// Vetoed queries find nothing, and later steps don't see them.
+	seen = nil
+	if _, ok := r.Get("  🎉 "); ok {
+		t.Error("Get found a vetoed key")
+	}
+	if got := r.Suggest(" ", 5, 10); got != nil {
+		t.Errorf("Suggest = %v for a vetoed query, want nil", got)
+	}
+	if got, p := r.SuggestAnchored("", 1, 10); got != nil || p != 0 {
+		t.Errorf("SuggestAnchored = %v, %v for a vetoed query, want nil, 0", got, p)
+	}
+	if len(seen) != 0 {
+		t.Errorf("Steps after the veto saw %v", seen)
+	}
+	// Writes aren't preprocessed.
+	r.Set(" Padded ", "x")
+	if r.Has(" Padded ") {
+		t.Error("Has found a key that doesn't match its preprocessed form")
+	}
+	b := NewBytes(PreprocessQueries(LowercaseQuery))
+	b.Set("key", []byte("v"))
+	if _, ok := b.Get("KEY"); !ok || len(b.Suggest("KYE", 2, 10)) != 1 {
+		t.Error("BytesTrie didn't preprocess the query")
+	}
+}
@@ -0,0 +1,137 @@
+package levtrie
+
+import (
+	"io"
+	"io/fs"
+	"time"
+)
+
+// Progress is the progress of a load, see LoadProgress.
+type Progress struct {
+	Keys    int           // The number of keys loaded so far.
+	Bytes   int64         // The number of bytes read so far.
+	Total   int64         // The number of bytes to read, or 0 if it isn't known.
+	Elapsed time.Duration // The time since the load started.
+	Done    bool          // True in the last report of a load.
+	Err     error         // Why the load failed, in its last report.
+}
+
+// ETA returns an estimate of the time left until the load finishes, based on
+// the rate at which bytes have been read so far, and true, or 0 and false if
+// the total number of bytes isn't known or nothing has been read yet.
+func (p Progress) ETA() (time.Duration, bool) {
+	if p.Total <= 0 || p.Bytes <= 0 {
+		return 0, false
+	}
+	if p.Bytes >= p.Total {
+		return 0, true
+	}
+	return time.Duration(float64(p.Elapsed) * float64(p.Total-p.Bytes) / float64(p.Bytes)), true
+}
+
+// LoadProgress makes ReadWords, ReadWordsFS, ReadDAWG, ReadFrozenTrie, Load,
+// FromKVs and FromMap call fn with the progress of the load at most once per
+// interval, and once more with Done set when the load has finished, and Err
+// set if it failed, so a service can report how far along its startup is.
+// The total number of bytes is known when the reader has a Size method, like
+// a bytes.Reader, or a Stat method, like an os.File; for FromKVs, the bytes
+// are the lengths of the keys and values. fn is called from the goroutine
+// that's loading. The option has no effect on anything but loads.
+func LoadProgress(interval time.Duration, fn func(p Progress)) Option {
+	return func(t *Trie) {
+		t.progress = &progressConfig{interval: interval, fn: fn}
+	}
+}
+
+// progressConfig holds the arguments to LoadProgress.
+type progressConfig struct {
+	interval time.Duration
+	fn       func(p Progress)
+}
+
+// progressCheck is the number of keys loaded between checks of the time, so
+// that loading a key doesn't have to read the clock.
+const progressCheck = 1024
+
+// loadProgress tracks the progress of a single load. Its methods do nothing
+// on a nil loadProgress, which is what loads without LoadProgress get.
+type loadProgress struct {
+	cfg   *progressConfig
+	start time.Time
+	last  time.Time // When fn was last called.
+	p     Progress
+}
+
+// startLoad returns the loadProgress for a load of total bytes, or 0 if the
+// total isn't known, by a Trie configured with cfg, which may be nil.
+func startLoad(cfg *progressConfig, total int64) *loadProgress {
+	if cfg == nil {
+		return nil
+	}
+	now := time.Now()
+	return &loadProgress{cfg: cfg, start: now, last: now, p: Progress{Total: total}}
+}
+
+// startRead is startLoad for a load that reads r, and returns a reader that
+// counts the bytes read from r as well.
+func startRead(cfg *progressConfig, r io.Reader) (*loadProgress, io.Reader) {
+	if cfg == nil {
+		return nil, r
+	}
+	l := startLoad(cfg, readerSize(r))
+	return l, &progressReader{r: r, l: l}
+}
+
+// readerSize returns the number of bytes r holds, or 0 if it can't tell.
+func readerSize(r io.Reader) int64 {
+	switch r := r.(type) {
+	case interface{ Size() int64 }:
+		return r.Size()
+	case interface{ Stat() (fs.FileInfo, error) }:
+		if info, err := r.Stat(); err == nil && info.Mode().IsRegular() {
+			return info.Size()
+		}
+	}
+	return 0
+}
+
+// add records that keys keys and bytes bytes were loaded, and calls fn if
+// it's time to.
+func (l *loadProgress) add(keys int, bytes int64) {
+	if l == nil {
+		return
+	}
+	before := l.p.Keys
+	l.p.Keys += keys
+	l.p.Bytes += bytes
+	if l.p.Keys/progressCheck == before/progressCheck {
+		return
+	}
+	if now := time.Now(); now.Sub(l.last) >= l.cfg.interval {
+		l.last = now
+		l.p.Elapsed = now.Sub(l.start)
+		l.cfg.fn(l.p)
+	}
+}
+
+// finish calls fn with the final progress of a load, which failed with err
+// if it isn't nil.
+func (l *loadProgress) finish(err error) {
+	if l == nil {
+		return
+	}
+	l.p.Elapsed, l.p.Done, l.p.Err = time.Since(l.start), true, err
+	l.cfg.fn(l.p)
+}
+
+// progressReader counts the bytes read from r in the progress of l.
+type progressReader struct {
+	r io.Reader
+	l *loadProgress
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	pr.l.p.Bytes += int64(n)
+	return n, err
+}
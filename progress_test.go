@@ -0,0 +1,164 @@
+package levtrie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// recordProgress returns a LoadProgress option that reports every check and
+// a pointer to the reports it receives.
+func recordProgress() (Option, *[]Progress) {
+	var reports []Progress
+	return LoadProgress(0, func(p Progress) { reports = append(reports, p) }), &reports
+}
+
+func checkProgress(t *testing.T, name string, reports []Progress, keys int, total int64) {
+	t.Helper()
+	if len(reports) < 2 {
+		t.Fatalf("%v reported %v times, want at least twice", name, len(reports))
+	}
+	for i, p := range reports {
+		if i > 0 && (p.Keys < reports[i-1].Keys || p.Bytes < reports[i-1].Bytes) {
+			t.Errorf("%v reported %+v after %+v", name, p, reports[i-1])
+		}
+		if p.Done != (i == len(reports)-1) || p.Total != total {
+			t.Errorf("%v report %v = %+v, want Done only at the end and Total %v", name, i, p, total)
+		}
+	}
+	last := reports[len(reports)-1]
+	if last.Err != nil {
+		t.Errorf("%v finished with error %v", name, last.Err)
+	}
+	if last.Keys != keys || (total > 0 && last.Bytes != total) {
+		t.Errorf("%v finished with %+v, want %v keys and %v bytes", name, last, keys, total)
+	}
+	if eta, ok := last.ETA(); total > 0 && (!ok || eta != 0) {
+		t.Errorf("%v finished with an ETA of %v, %v, want 0, true", name, eta, ok)
+	}
+}
+
+func TestLoadProgress(t *testing.T) {
+	var words strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&words, "word%05d\n", i)
+	}
+	opt, reports := recordProgress()
+	if _, err := ReadWords(strings.NewReader(words.String()), opt); err != nil {
+		t.Fatal(err)
+	}
+	checkProgress(t, "ReadWords", *reports, 5000, int64(words.Len()))
+
+	opt, reports = recordProgress()
+	if _, err := ReadDAWG(strings.NewReader(words.String()), opt); err != nil {
+		t.Fatal(err)
+	}
+	checkProgress(t, "ReadDAWG", *reports, 5000, int64(words.Len()))
+
+	// A reader that can't tell its size.
+	opt, reports = recordProgress()
+	if _, err := ReadWords(io.MultiReader(strings.NewReader(words.String())), opt); err != nil {
+		t.Fatal(err)
+	}
+	checkProgress(t, "ReadWords without a size", *reports, 5000, 0)
+
+	var kvs []KV
+	var size int64
+	for i := 0; i < 3000; i++ {
+		kv := KV{Key: fmt.Sprintf("key%v", i), Value: "value"}
+		kvs = append(kvs, kv)
+		size += int64(len(kv.Key) + len(kv.Value))
+	}
+	opt, reports = recordProgress()
+	FromKVs(kvs, opt)
+	checkProgress(t, "FromKVs", *reports, 3000, size)
+	opt, reports = recordProgress()
+	r := FromKVs(kvs, MaxKeys(10000), opt)
+	checkProgress(t, "FromKVs with MaxKeys", *reports, 3000, size)
+
+	var buf bytes.Buffer
+	if _, err := r.Freeze().WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	opt, reports = recordProgress()
+	if _, err := ReadFrozenTrie(bytes.NewReader(buf.Bytes()), opt); err != nil {
+		t.Fatal(err)
+	}
+	checkProgress(t, "ReadFrozenTrie", *reports, 3000, int64(buf.Len()))
+
+	// With a long interval, only the end is reported.
+	calls := 0
+	ReadWords(strings.NewReader(words.String()), LoadProgress(time.Hour, func(p Progress) { calls++ }))
+	if calls != 1 {
+		t.Errorf("Got %v reports with an hour between them, want 1", calls)
+	}
+}
+
+func TestLoadProgressError(t *testing.T) {
+	var words strings.Builder
+	for i := 0; i < 3000; i++ {
+		fmt.Fprintf(&words, "word%05d\n", i)
+	}
+	errRead := errors.New("read failed")
+	failing := func() io.Reader {
+		return io.MultiReader(strings.NewReader(words.String()), iotest.ErrReader(errRead))
+	}
+	var saved, frozen bytes.Buffer
+	r := New()
+	r.Set("hello", "world")
+	r.Save(&saved)
+	r.Freeze().WriteTo(&frozen)
+	loads := map[string]func(opt Option) error{
+		"ReadWords": func(opt Option) error {
+			_, err := ReadWords(failing(), opt)
+			return err
+		},
+		"ReadDAWG": func(opt Option) error {
+			_, err := ReadDAWG(failing(), opt)
+			return err
+		},
+		"ReadFrozenTrie": func(opt Option) error {
+			_, err := ReadFrozenTrie(bytes.NewReader(frozen.Bytes()[:frozen.Len()-1]), opt)
+			return err
+		},
+		"Load": func(opt Option) error {
+			_, err := Load(bytes.NewReader(saved.Bytes()[:saved.Len()-1]), opt)
+			return err
+		},
+	}
+	for name, load := range loads {
+		opt, reports := recordProgress()
+		err := load(opt)
+		if err == nil {
+			t.Fatalf("%v succeeded, want an error", name)
+		}
+		if len(*reports) == 0 {
+			t.Fatalf("%v failed without a report", name)
+		}
+		if last := (*reports)[len(*reports)-1]; !last.Done || last.Err != err {
+			t.Errorf("%v finished with %+v, want Done and Err %v", name, last, err)
+		}
+	}
+}
+
+func TestProgressETA(t *testing.T) {
+	for _, test := range []struct {
+		p   Progress
+		eta time.Duration
+		ok  bool
+	}{
+		{Progress{Bytes: 25, Total: 100, Elapsed: time.Second}, 3 * time.Second, true},
+		{Progress{Bytes: 100, Total: 100, Elapsed: time.Second}, 0, true},
+		{Progress{Bytes: 0, Total: 100, Elapsed: time.Second}, 0, false},
+		{Progress{Bytes: 25, Elapsed: time.Second}, 0, false},
+	} {
+		if eta, ok := test.p.ETA(); eta != test.eta || ok != test.ok {
+			t.Errorf("%+v.ETA() = %v, %v, want %v, %v", test.p, eta, ok, test.eta, test.ok)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package levtrie
+
+// Reader makes lookups in a Trie that are correlated, like the Gets made as a
+// user types "applic", "applica", "applicat", faster by remembering the path
+// to the last key looked up and starting each lookup from the deepest node on
+// it that's also on the path to the next key. A Reader stays valid while the
+// Trie changes: it starts over from the root after a key is deleted, since
+// that may remove nodes on its path. A Reader isn't safe for concurrent use,
+// so give each goroutine or request handler its own. Don't create directly,
+// use Trie.Reader() instead.
+type Reader struct {
+	t       *Trie
+	c       *cursor
+	root    *node  // The root of the Trie when c was created.
+	deletes uint64 // The Trie's count of deletions when c was created.
+}
+
+// Reader returns a new Reader for the Trie.
+func (t *Trie) Reader() *Reader {
+	return &Reader{t: t}
+}
+
+// lookup is Trie.lookup, starting from the path to the previous key.
+func (r *Reader) lookup(key string) *entry {
+	t := r.t
+	if !t.mayContain(key) {
+		return nil
+	}
+	if r.c == nil || r.root != t.root || r.deletes != t.deletes {
+		r.c, r.root, r.deletes = newCursor(t.tree()), t.root, t.deletes
+	}
+	if n := r.c.seek(key, false); n != nil && n.data.live() {
+		t.touch(n.data)
+		return n.data
+	}
+	t.missed(key)
+	return nil
+}
+
+// Get returns the value stored at key and true, or the empty string and false
+// if key isn't in the Trie. See Trie.Get.
+func (r *Reader) Get(key string) (string, bool) {
+	key, ok := r.t.preprocess(key)
+	if !ok {
+		return "", false
+	}
+	if e := r.lookup(key); e != nil {
+		return r.t.output(key, e.value()), true
+	}
+	return "", false
+}
+
+// Values returns all values associated with key. See Trie.Values.
+func (r *Reader) Values(key string) []string {
+	key, ok := r.t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	if e := r.lookup(key); e != nil {
+		vals := make([]string, len(e.values))
+		for i, v := range e.values {
+			vals[i] = r.t.output(key, v)
+		}
+		return vals
+	}
+	return nil
+}
+
+// Has returns true exactly when key is in the Trie. See Trie.Has.
+func (r *Reader) Has(key string) bool {
+	key, ok := r.t.preprocess(key)
+	if !ok {
+		return false
+	}
+	return r.lookup(key) != nil
+}
+
+// SyncReader is a Reader for a SyncTrie. Like a Reader, it isn't safe for
+// concurrent use, but each goroutine can have its own SyncReader for the same
+// SyncTrie. Don't create directly, use SyncTrie.Reader() instead.
+type SyncReader struct {
+	s *SyncTrie
+	r *Reader
+}
+
+// Reader returns a new SyncReader for the SyncTrie.
+func (s *SyncTrie) Reader() *SyncReader {
+	return &SyncReader{s: s, r: s.t.Reader()}
+}
+
+// Get returns the value stored at key. See Reader.Get.
+func (r *SyncReader) Get(key string) (string, bool) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.r.Get(key)
+}
+
+// Values returns all values associated with key. See Reader.Values.
+func (r *SyncReader) Values(key string) []string {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.r.Values(key)
+}
+
+// Has returns true exactly when key is in the SyncTrie. See Reader.Has.
+func (r *SyncReader) Has(key string) bool {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.r.Has(key)
+}
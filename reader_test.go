@@ -0,0 +1,48 @@
+package levtrie
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	r := New(PreprocessQueries(LowercaseQuery))
+	for _, key := range []string{"app", "apple", "applicable", "application", "apply", "banana"} {
+		r.Set(key, strings.ToUpper(key))
+	}
+	reader := r.Reader()
+	for _, key := range []string{"a", "ap", "app", "Appl", "appli", "applic", "application", "applications", "apple", "b", "banana", ""} {
+		got, ok := reader.Get(key)
+		want, wantOK := r.Get(key)
+		if got != want || ok != wantOK || reader.Has(key) != wantOK {
+			t.Errorf("Get(%q) = %q, %v, want %q, %v", key, got, ok, want, wantOK)
+		}
+	}
+	// The path to application is stale once applicable and application
+	// are deleted, since the nodes after "applica" are removed.
+	reader.Get("application")
+	r.Delete("applicable")
+	r.Delete("application")
+	r.Set("application", "new")
+	if got := reader.Values("application"); len(got) != 1 || got[0] != "new" {
+		t.Errorf("Values = %v after a delete, want new", got)
+	}
+	r.Clear()
+	if reader.Has("application") {
+		t.Error("Reader found a key after Clear")
+	}
+	r.Set("apple", "red")
+	if got, ok := reader.Get("APPLE"); !ok || got != "red" {
+		t.Errorf("Get = %v, %v after Clear, want red", got, ok)
+	}
+}
+
+func TestSyncReader(t *testing.T) {
+	s := NewSync()
+	s.Set("tea", "green")
+	s.Add("tea", "black")
+	reader := s.Reader()
+	if got, ok := reader.Get("tea"); !ok || got != "green" || len(reader.Values("tea")) != 2 || reader.Has("te") {
+		t.Errorf("Get = %v, %v, want green", got, ok)
+	}
+}
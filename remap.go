@@ -0,0 +1,145 @@
+package levtrie
+
+import "sort"
+
+// RemapKeys replaces every key in the Trie with fn(key), keeping its values,
+// count, expiration time and tags, and returns the number of keys that
+// changed. It's meant for migrating a Trie to a new normalization of its
+// keys, like lowercasing them, and rebuilds the Trie in a single pass instead
+// of deleting and adding each key or exporting and reloading the Trie.
+//
+// When fn maps several keys to the same new key, resolve is called with the
+// new key and the colliding keys in sorted order, and returns the one whose
+// values, count, expiration time and tags the new key gets; the others are
+// dropped. If resolve is nil or returns a key that isn't one of them, the
+// first one is kept. Expired keys are dropped before fn is called.
+//
+// OnChange hooks see RemapKeys as an OpClear followed by an OpSet, OpAdd or
+// OpIncr for each value and count of each remapped key, the changes a
+// follower needs to apply with ApplyChanges to end up with the same keys.
+func (t *Trie) RemapKeys(fn func(key string) string, resolve func(newKey string, oldKeys []string) string) int {
+	var es []*entry
+	for stack := []*node{t.tree()}; len(stack) > 0; {
+		var x *node
+		x, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		if x.data != nil {
+			es = append(es, x.data)
+		}
+		for _, child := range x.child {
+			stack = append(stack, child)
+		}
+	}
+	sort.Slice(es, func(i, j int) bool { return es[i].key < es[j].key })
+	groups := make(map[string][]*entry)
+	var keys []string
+	changed := 0
+	for _, e := range es {
+		if !e.live() {
+			t.drop(e)
+			continue
+		}
+		key := fn(e.key)
+		if key != e.key {
+			changed++
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], e)
+	}
+	sort.Strings(keys)
+	t.reset()
+	c := newCursor(t.root)
+	kept := make([]*entry, len(keys))
+	for i, key := range keys {
+		e := t.resolveRemap(key, groups[key], resolve)
+		e.key = key
+		c.seek(key, true).data = e
+		t.size++
+		t.indexKey(e)
+		t.retag(key)
+		kept[i] = e
+	}
+	t.notify(Op{Kind: OpClear})
+	for _, e := range kept {
+		t.replay(e)
+	}
+	return changed
+}
+
+// resolveRemap returns the entry of es, the entries of the keys that RemapKeys
+// maps to key, whose data key gets, and drops the rest.
+func (t *Trie) resolveRemap(key string, es []*entry, resolve func(string, []string) string) *entry {
+	kept := es[0]
+	if len(es) > 1 && resolve != nil {
+		old := make([]string, len(es))
+		for i, e := range es {
+			old[i] = e.key
+		}
+		winner := resolve(key, old)
+		for _, e := range es {
+			if e.key == winner {
+				kept = e
+			}
+		}
+	}
+	for _, e := range es {
+		if e != kept {
+			t.drop(e)
+		}
+	}
+	return kept
+}
+
+// drop releases the values and slot of e, whose key is being removed from the
+// Trie by a rebuild rather than by delete.
+func (t *Trie) drop(e *entry) {
+	t.releaseAll(e.values)
+	t.removeSlot(e)
+}
+
+// reset empties the tree of the Trie and the structures kept alongside it so
+// that the entries it held can be added again, without touching the values,
+// slots and tags of the entries or notifying OnChange hooks.
+func (t *Trie) reset() {
+	t.root = &node{child: make(map[rune]*node)}
+	t.size = 0
+	t.deletes++
+	if t.bloom != nil {
+		t.bloom = newBloom(t.bloom.capacity, t.bloom.fpRate)
+	}
+	if t.absent != nil {
+		t.absent.clear()
+	}
+	if t.grams != nil {
+		t.grams = newTrigramIndex(t.grams.ratio)
+	}
+	if t.rev != nil {
+		t.rev = &node{child: make(map[rune]*node)}
+	}
+	if t.infix != nil {
+		t.infix = newInfixIndex()
+	}
+	if t.results != nil {
+		t.results.clear()
+	}
+	t.removed = 0
+	if t.maint != nil {
+		t.maint.inPass = false
+	}
+}
+
+// replay reports the values and count of e to the OnChange hooks as the
+// changes that would add them to a Trie without the key.
+func (t *Trie) replay(e *entry) {
+	for i, v := range e.values {
+		if i == 0 {
+			t.notify(Op{Kind: OpSet, Key: e.key, Value: t.decode(v), Expires: e.expiration()})
+		} else {
+			t.notify(Op{Kind: OpAdd, Key: e.key, Value: t.decode(v)})
+		}
+	}
+	if e.count != 0 || len(e.values) == 0 {
+		t.notify(Op{Kind: OpIncr, Key: e.key, Delta: e.count})
+	}
+}
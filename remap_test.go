@@ -0,0 +1,73 @@
+package levtrie
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRemapKeys(t *testing.T) {
+	r := New(BloomFilter(100, 0.01), ReverseIndex(), TrigramIndex(0.5), MaxKeys(100))
+	follower := New()
+	r.OnChange(func(op Op) { follower.ApplyChanges([]Op{op}) })
+	r.Set("Apple", "1")
+	r.Add("Apple", "2")
+	r.Set("apple", "3")
+	r.Set("BANANA", "4")
+	r.IncrBy("BANANA", 7)
+	r.IncrBy("Cherry", 2)
+	r.Set("date", "5")
+	if err := r.SetTags("BANANA", "fruit"); err != nil {
+		t.Fatal(err)
+	}
+	var collisions [][]string
+	resolve := func(key string, old []string) string {
+		collisions = append(collisions, append([]string{key}, old...))
+		return old[len(old)-1]
+	}
+	if got, want := r.RemapKeys(strings.ToLower, resolve), 3; got != want {
+		t.Errorf("RemapKeys changed %v keys, want %v", got, want)
+	}
+	if want := [][]string{{"apple", "Apple", "apple"}}; !reflect.DeepEqual(collisions, want) {
+		t.Errorf("Got collisions %v, want %v", collisions, want)
+	}
+	want := map[string]string{"apple": "3", "banana": "4", "cherry": "", "date": "5"}
+	if got := r.ToMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	if got := follower.ToMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Follower got %v, want %v", got, want)
+	}
+	if got, want := r.Count("banana"), int64(7); got != want {
+		t.Errorf("Got count %v, want %v", got, want)
+	}
+	if got, want := follower.Count("cherry"), int64(2); got != want {
+		t.Errorf("Follower got count %v, want %v", got, want)
+	}
+	if got, want := keystr(r.Suggest("banan", 1, 10, WithTags("fruit"))), "banana"; got != want {
+		t.Errorf("Got '%v', want '%v'", got, want)
+	}
+	if got, want := keystr(r.SuggestSuffixes("pple", 1, 10)), "apple"; got != want {
+		t.Errorf("Got '%v', want '%v'", got, want)
+	}
+	if r.Has("Apple") || r.Has("BANANA") {
+		t.Error("Old keys are still in the Trie")
+	}
+	if err := r.ValidateInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRemapKeysDefaultResolve(t *testing.T) {
+	r := FromMap(map[string]string{"a1": "x", "a2": "y", "b": "z"})
+	if got, want := r.RemapKeys(func(key string) string { return key[:1] }, nil), 2; got != want {
+		t.Errorf("RemapKeys changed %v keys, want %v", got, want)
+	}
+	want := map[string]string{"a": "x", "b": "z"}
+	if got := r.ToMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	if err := r.ValidateInvariants(); err != nil {
+		t.Error(err)
+	}
+}
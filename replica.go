@@ -0,0 +1,226 @@
+package levtrie
+
+import (
+	"crypto/sha256"
+	"sort"
+	"strings"
+)
+
+// Digest summarizes the keys of a Trie that start with Prefix, so that two
+// tries can find out where they differ without exchanging their keys. See
+// Trie.Digests.
+type Digest struct {
+	Prefix string
+	// Exact is true if the Digest only covers the key equal to Prefix, and
+	// not the keys that Prefix is a proper prefix of.
+	Exact bool
+	Keys  int      // The number of keys covered.
+	Hash  [32]byte // The hash of the keys covered, as Trie.Hash computes it.
+}
+
+// KeyValues is a key along with all of its values.
+type KeyValues struct {
+	Key    string
+	Values []string
+}
+
+// Replica is a Trie that another Trie can copy its contents from with
+// SyncFrom. It's typically a Trie on another server, reached through an RPC
+// whose handler calls Digests and Entries on the Trie there. LocalReplica
+// makes a Replica of a Trie in the same process.
+type Replica interface {
+	// Digests returns the result of Trie.Digests(prefix).
+	Digests(prefix string) ([]Digest, error)
+	// Entries returns the result of Trie.Entries(prefix, exact).
+	Entries(prefix string, exact bool) ([]KeyValues, error)
+}
+
+// LocalReplica returns a Replica that reads from t.
+func LocalReplica(t *Trie) Replica {
+	return localReplica{t}
+}
+
+type localReplica struct {
+	t *Trie
+}
+
+func (r localReplica) Digests(prefix string) ([]Digest, error) {
+	return r.t.Digests(prefix), nil
+}
+
+func (r localReplica) Entries(prefix string, exact bool) ([]KeyValues, error) {
+	return r.t.Entries(prefix, exact), nil
+}
+
+// syncEntriesKeys is the most keys SyncFrom copies at once instead of
+// comparing the Digests of smaller prefixes.
+const syncEntriesKeys = 64
+
+// Digests returns a Digest of the live key equal to prefix, if there is one,
+// followed by a Digest for each rune that follows prefix in a live key,
+// covering the keys that start with prefix and that rune, in order. The
+// Digests of a prefix have the same hashes in two tries exactly when the keys
+// and values under the prefix are the same, as with Trie.Hash. It walks every
+// key that starts with prefix.
+func (t *Trie) Digests(prefix string) []Digest {
+	n := t.find(prefix)
+	if n == nil {
+		return nil
+	}
+	var digests []Digest
+	if n.data.live() {
+		h := sha256.New()
+		hashEntry(h, n.data, t.decode)
+		d := Digest{Prefix: prefix, Exact: true, Keys: 1}
+		h.Sum(d.Hash[:0])
+		digests = append(digests, d)
+	}
+	for _, r := range newIterFrame(n).runes {
+		d := Digest{Prefix: prefix + string(r)}
+		h := sha256.New()
+		t.walkPrefix(d.Prefix, func(e *entry) {
+			hashEntry(h, e, t.decode)
+			d.Keys++
+		})
+		if d.Keys > 0 {
+			h.Sum(d.Hash[:0])
+			digests = append(digests, d)
+		}
+	}
+	return digests
+}
+
+// Entries returns the live key equal to prefix along with its values if exact
+// is true, or every live key that starts with prefix along with its values, in
+// sorted order. Values are returned as they were set, before TransformValues.
+func (t *Trie) Entries(prefix string, exact bool) []KeyValues {
+	var kvs []KeyValues
+	add := func(e *entry) {
+		kv := KeyValues{Key: e.key, Values: make([]string, len(e.values))}
+		for i, v := range e.values {
+			kv.Values[i] = t.decode(v)
+		}
+		kvs = append(kvs, kv)
+	}
+	if exact {
+		if n := t.find(prefix); n != nil && n.data.live() {
+			add(n.data)
+		}
+		return kvs
+	}
+	t.walkPrefix(prefix, add)
+	return kvs
+}
+
+// walkPrefix passes every live entry whose key starts with prefix to f, in
+// sorted order.
+func (t *Trie) walkPrefix(prefix string, f func(e *entry)) {
+	it := t.Iterator()
+	for ok := it.Seek(prefix); ok && strings.HasPrefix(it.e.key, prefix); ok = it.Next() {
+		f(it.e)
+	}
+}
+
+// SyncFrom changes the Trie to hold the same keys and values as r and returns
+// the number of keys it set or deleted. It compares the Digests of the two
+// tries from the root down, only descending into prefixes whose Digests
+// differ, and copies the Entries of a prefix once it has few enough keys, so
+// the amount of data exchanged depends on the number of differences, not the
+// size of the tries. Keys are changed with Set, Add and Delete, so hooks
+// registered with OnChange see each change. Counts, expiration times and
+// tags aren't part of the Digests, so they aren't copied. If r returns an
+// error, SyncFrom returns it along with the number of keys changed so far,
+// and calling SyncFrom again picks up where it left off.
+func (t *Trie) SyncFrom(r Replica) (int, error) {
+	changed := 0
+	for queue := []string{""}; len(queue) > 0; {
+		prefix := queue[0]
+		queue = queue[1:]
+		remote, err := r.Digests(prefix)
+		if err != nil {
+			return changed, err
+		}
+		local := t.Digests(prefix)
+		same := make(map[Digest]bool)
+		for _, d := range local {
+			same[d] = true
+		}
+		seen := make(map[string]bool)
+		for _, d := range remote {
+			seen[d.Prefix] = true
+			if same[d] {
+				continue
+			}
+			if d.Exact || d.Keys <= syncEntriesKeys {
+				kvs, err := r.Entries(d.Prefix, d.Exact)
+				if err != nil {
+					return changed, err
+				}
+				changed += t.syncEntries(d.Prefix, d.Exact, kvs)
+			} else {
+				queue = append(queue, d.Prefix)
+			}
+		}
+		// Delete whatever the replica has nothing of.
+		for _, d := range local {
+			if !seen[d.Prefix] {
+				changed += t.syncEntries(d.Prefix, d.Exact, nil)
+			}
+		}
+	}
+	return changed, nil
+}
+
+// syncEntries makes the keys of the Trie covered by prefix and exact, as in
+// Entries, hold exactly kvs, and returns the number of keys it set or deleted.
+func (t *Trie) syncEntries(prefix string, exact bool, kvs []KeyValues) int {
+	have := make(map[string]KeyValues)
+	for _, kv := range t.Entries(prefix, exact) {
+		have[kv.Key] = kv
+	}
+	changed := 0
+	for _, kv := range kvs {
+		old, ok := have[kv.Key]
+		delete(have, kv.Key)
+		if ok && equalStrings(old.Values, kv.Values) {
+			continue
+		}
+		changed++
+		if len(kv.Values) == 0 {
+			// A key with no values only has a count, like a key that was
+			// only ever incremented.
+			count := t.weight(kv.Key)
+			t.Delete(kv.Key)
+			t.IncrBy(kv.Key, count)
+			continue
+		}
+		t.Set(kv.Key, kv.Values[0])
+		for _, v := range kv.Values[1:] {
+			t.Add(kv.Key, v)
+		}
+	}
+	stale := make([]string, 0, len(have))
+	for key := range have {
+		stale = append(stale, key)
+	}
+	sort.Strings(stale)
+	for _, key := range stale {
+		t.Delete(key)
+		changed++
+	}
+	return changed
+}
+
+// equalStrings returns true if a and b hold the same strings in the same
+// order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package levtrie
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// countingReplica is a Replica that counts the keys it sends.
+type countingReplica struct {
+	Replica
+	sent int
+	fail bool
+}
+
+func (r *countingReplica) Entries(prefix string, exact bool) ([]KeyValues, error) {
+	if r.fail {
+		return nil, errors.New("unreachable")
+	}
+	kvs, err := r.Replica.Entries(prefix, exact)
+	r.sent += len(kvs)
+	return kvs, err
+}
+
+func TestSyncFrom(t *testing.T) {
+	rand.Seed(0)
+	a, b := New(), New()
+	for i, key := range generateEdits(6, 2000) {
+		a.Set(key, fmt.Sprint(i))
+		b.Set(key, fmt.Sprint(i))
+	}
+	// Make a few differences of every kind.
+	kvs := a.Entries("", false)
+	a.Delete(kvs[10].Key)
+	a.Set(kvs[500].Key, "changed")
+	a.Add(kvs[501].Key, "another")
+	a.Set("brand new", "1")
+	a.Set("", "empty")
+	a.Incr("counted only")
+	b.Set("zzz only here", "gone soon")
+	var ops []Op
+	b.OnChange(func(op Op) { ops = append(ops, op) })
+	r := &countingReplica{Replica: LocalReplica(a)}
+	changed, err := b.SyncFrom(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Hash() != b.Hash() {
+		t.Fatal("Tries have different hashes after SyncFrom")
+	}
+	if changed != 7 || len(ops) < changed {
+		t.Errorf("Changed %v keys with %v ops, want 7 keys", changed, len(ops))
+	}
+	if r.sent >= a.Len()/4 {
+		t.Errorf("Sent %v of %v keys, want only the ones near differences", r.sent, a.Len())
+	}
+	if err := b.ValidateInvariants(); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := b.SyncFrom(r); changed != 0 || err != nil {
+		t.Errorf("Second SyncFrom changed %v keys with error %v", changed, err)
+	}
+	r.fail = true
+	a.Set("one more", "1")
+	if _, err := b.SyncFrom(r); err == nil {
+		t.Error("SyncFrom didn't return the replica's error")
+	}
+}
+
+func TestDigests(t *testing.T) {
+	r := New()
+	for _, key := range []string{"to", "tea", "ten", "toe", "inn"} {
+		r.Set(key, strings.ToUpper(key))
+	}
+	var got []string
+	for _, d := range r.Digests("t") {
+		got = append(got, fmt.Sprintf("%v:%v:%v", d.Prefix, d.Exact, d.Keys))
+	}
+	if want := "te:false:2 to:false:2"; strings.Join(got, " ") != want {
+		t.Errorf("Digests = %v, want %v", got, want)
+	}
+	if ds := r.Digests("to"); len(ds) != 2 || !ds[0].Exact || ds[1].Prefix != "toe" {
+		t.Errorf("Digests(to) = %v, want to and toe", ds)
+	}
+	if got := r.Entries("te", false); len(got) != 2 || got[1].Key != "ten" || got[1].Values[0] != "TEN" {
+		t.Errorf("Entries = %v", got)
+	}
+	if r.Digests("x") != nil || r.Entries("te", true) != nil {
+		t.Error("Got digests or entries for missing keys")
+	}
+}
@@ -0,0 +1,78 @@
+package levtrie
+
+// reverse returns s with its runes in reverse order.
+func reverse(s string) string {
+	rs := extractRunes(s)
+	for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+		rs[i], rs[j] = rs[j], rs[i]
+	}
+	return string(rs)
+}
+
+// SuggestEndsWith returns up to n KVs, all of whose keys have a suffix that is
+// within edit distance d of the input key. It's the mirror image of
+// SuggestSuffixes. Example: SuggestEndsWith("ology", 1, 10) would return up to
+// 10 results which might include "biology", "geology" and "apologize". With
+// the ReverseIndex option, the search follows a tree of reversed keys like
+// any other search. Without it, every key in the Trie has to be checked.
+func (t *Trie) SuggestEndsWith(key string, d int8, n int, opts ...SuggestOption) []KV {
+	key, ok := t.preprocess(key)
+	if !ok {
+		return nil
+	}
+	runes := extractRunes(reverse(key))
+	cfg := t.config(key, opts)
+	d = t.distance(len(runes), d)
+	// Keys are walked from their last rune, so the Acceptor of Constrain
+	// checks each match instead of pruning.
+	cfg.prune = false
+	// The start of the reversed query is the end of the original.
+	cfg.affix.prefix, cfg.affix.suffix = cfg.affix.suffix, cfg.affix.prefix
+	if t.rev != nil {
+		return t.suggest(expandSuffixes, t.rev, runes, d, n, cfg)
+	}
+	var results []KV
+	if n <= 0 {
+		return results
+	}
+	a := newAutomaton(runes, d, cfg)
+	expandSuffixes(t.tree(), func(e *entry) bool {
+		if cfg.excluded(e) {
+			return true
+		}
+		if dist := prefixDistance(a, d, reverse(e.key)); dist <= int(d) && cfg.keep(e, int8(dist)) {
+			cfg.dist = int8(dist)
+			results = t.appendKVs(results, e, cfg.valuesPerKey)
+			return len(results) < n && !cfg.stops(e)
+		}
+		return true
+	})
+	if len(results) > n {
+		results = results[:n]
+	}
+	return results
+}
+
+// matchesPrefix returns true exactly when the automaton a, which has edit
+// distance d, accepts a prefix of key.
+func matchesPrefix(a automaton, d int8, key string) bool {
+	return prefixDistance(a, d, key) <= int(d)
+}
+
+// prefixDistance returns the smallest distance at which the automaton a, which
+// has edit distance d, accepts a prefix of key, or more than d if it doesn't
+// accept any.
+func prefixDistance(a automaton, d int8, key string) int {
+	s := a.start()
+	best := a.distance(s)
+	var min int
+	for _, r := range key {
+		if s, min = a.transition(s, r); min > int(d) || min >= best {
+			break
+		}
+		if dist := a.distance(s); dist < best {
+			best = dist
+		}
+	}
+	return best
+}
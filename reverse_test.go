@@ -0,0 +1,84 @@
+package levtrie
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReverse(t *testing.T) {
+	for _, test := range []struct{ s, want string }{{"", ""}, {"a", "a"}, {"abc", "cba"}, {"héllo", "olléh"}} {
+		if got := reverse(test.s); got != test.want {
+			t.Errorf("reverse(%q) = %q, want %q", test.s, got, test.want)
+		}
+	}
+}
+
+func TestSuggestEndsWith(t *testing.T) {
+	data := []string{"biology", "geology", "apologize", "ology", "logy", "biologist", "zoo"}
+	for _, opts := range [][]Option{nil, {ReverseIndex()}} {
+		r := New(opts...)
+		for _, key := range data {
+			r.Set(key, key)
+		}
+		if got, want := keystr(r.SuggestEndsWith("ology", 0, 10)), "biology geology ology"; got != want {
+			t.Errorf("Got %v, want %v", got, want)
+		}
+		if got, want := keystr(r.SuggestEndsWith("ology", 1, 10)), "biology geology logy ology"; got != want {
+			t.Errorf("Got %v, want %v", got, want)
+		}
+		if got, want := keystr(r.SuggestEndsWith("ologize", 1, 10)), "apologize"; got != want {
+			t.Errorf("Got %v, want %v", got, want)
+		}
+		if got := r.SuggestEndsWith("ology", 1, 2); len(got) != 2 {
+			t.Errorf("Got %v, want 2 results", got)
+		}
+		r.Delete("geology")
+		r.Rename("logy", "mythology")
+		if got, want := keystr(r.SuggestEndsWith("ology", 1, 10)), "biology mythology ology"; got != want {
+			t.Errorf("Got %v, want %v", got, want)
+		}
+		if got, want := keystr(r.SuggestEndsWith("", 0, 10)), "apologize biologist biology mythology ology zoo"; got != want {
+			t.Errorf("Got %v, want %v", got, want)
+		}
+		if got, want := keystr(r.SuggestEndsWith("ology", 0, 10, AffixTolerance(1, 0, 0))), "biology mythology ology"; got != want {
+			t.Errorf("Got %v, want %v", got, want)
+		}
+		if err := r.ValidateInvariants(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestSuggestEndsWithMatchesScan(t *testing.T) {
+	data := generateEdits(6, 1000)
+	r, s := New(ReverseIndex()), New()
+	for _, key := range data {
+		r.Set(key, "")
+		s.Set(key, "")
+	}
+	for i, key := range data[:20] {
+		d := int8(i % 3)
+		if got, want := keystr(r.SuggestEndsWith(key, d, len(data))), keystr(s.SuggestEndsWith(key, d, len(data))); got != want {
+			t.Errorf("SuggestEndsWith(%q, %v) with the index = %v, without = %v", key, d, got, want)
+		}
+	}
+}
+
+func TestReverseIndexExpiredKeys(t *testing.T) {
+	now := time.Unix(1000, 0)
+	clock = func() time.Time { return now }
+	defer func() { clock = time.Now }()
+	r := New(ReverseIndex())
+	r.SetWithTTL("biology", "1", time.Second)
+	now = now.Add(time.Minute)
+	if got := r.SuggestEndsWith("ology", 0, 10); len(got) != 0 {
+		t.Errorf("Got %v for an expired key, want nothing", got)
+	}
+	r.Set("biology", "2")
+	if got := r.SuggestEndsWith("ology", 0, 10); len(got) != 1 || got[0].Value != "2" {
+		t.Errorf("Got %v, want biology with value 2", got)
+	}
+	if err := r.ValidateInvariants(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,62 @@
+package levtrie
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// SampleByWeight makes a search return a random sample of its matches instead
+// of the first n. Matches are drawn one at a time without replacement, each
+// with probability proportional to its weight (see Trie.IncrBy) plus 1, so
+// that keys that have never been incremented can still be drawn, and are
+// returned in the order they're drawn. This suits exploring beyond the usual
+// top suggestions, like in an A/B experiment. Every match has to be found
+// before any can be drawn, so searches with SampleByWeight take time
+// proportional to the number of matches rather than to n. Draws use rng, or
+// the functions of math/rand if rng is nil; a *rand.Rand isn't safe for
+// concurrent use, so don't share one between concurrent searches. Results of
+// sampled searches aren't cached by SuggestCache.
+func SampleByWeight(rng *rand.Rand) SuggestOption {
+	return func(cfg *searchConfig) {
+		cfg.sample, cfg.rng = true, rng
+	}
+}
+
+// collectsAll returns true if every match of a search has to be found before
+// any results are chosen, as DedupeBy and SampleByWeight need.
+func (cfg *searchConfig) collectsAll() bool {
+	return cfg.fold != nil || cfg.sample
+}
+
+// choose applies DedupeBy and SampleByWeight to es, every match of a search.
+func (cfg *searchConfig) choose(es []*entry) []*entry {
+	if cfg.fold != nil {
+		es = dedupe(es, cfg.fold, cfg.weight)
+	}
+	if cfg.sample {
+		es = sampleByWeight(es, cfg.rng, cfg.weight)
+	}
+	return es
+}
+
+// sampleByWeight puts es in the order of a weighted random sample without
+// replacement, as described in SampleByWeight. Each entry gets the key
+// log(u)/w for a uniform random u and its weight w, and sorting by decreasing
+// key gives the order of the draws (Efraimidis and Spirakis, 2006).
+func sampleByWeight(es []*entry, rng *rand.Rand, weight func(*entry) float64) []*entry {
+	float := rand.Float64
+	if rng != nil {
+		float = rng.Float64
+	}
+	keys := make(map[*entry]float64, len(es))
+	for _, e := range es {
+		w := weight(e) + 1
+		if w < 1 {
+			w = 1
+		}
+		keys[e] = math.Log(1-float()) / w
+	}
+	sort.SliceStable(es, func(i, j int) bool { return keys[es[i]] > keys[es[j]] })
+	return es
+}
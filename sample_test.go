@@ -0,0 +1,49 @@
+package levtrie
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSampleByWeight(t *testing.T) {
+	r := New()
+	for _, key := range []string{"cat", "bat", "hat", "mat", "rat", "sat"} {
+		r.Set(key, key)
+	}
+	r.IncrBy("hat", 95)
+	rng := rand.New(rand.NewSource(1))
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		got := r.Suggest("cat", 1, 1, SampleByWeight(rng))
+		if len(got) != 1 {
+			t.Fatalf("Got %v, want 1 result", got)
+		}
+		counts[got[0].Key]++
+	}
+	// "hat" has weight 96 out of 101, so it should be drawn about 950 times,
+	// and each of the others about 10 times.
+	if counts["hat"] < 900 || counts["hat"] > 990 {
+		t.Errorf("Drew hat %v times out of 1000, want about 950", counts["hat"])
+	}
+	for _, key := range []string{"cat", "bat", "mat", "rat", "sat"} {
+		if counts[key] == 0 || counts[key] > 30 {
+			t.Errorf("Drew %v %v times out of 1000, want about 10", key, counts[key])
+		}
+	}
+	// A sample of every match is a permutation of the matches.
+	if got, want := keystr(r.Suggest("cat", 1, 10, SampleByWeight(rng))), keystr(r.Suggest("cat", 1, 10)); got != want {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	// Samples aren't cached.
+	c := New(SuggestCache(10))
+	for _, key := range []string{"cat", "bat", "hat", "mat", "rat", "sat"} {
+		c.Set(key, key)
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		seen[c.Suggest("cat", 1, 1, SampleByWeight(rng))[0].Key] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("Got the same sample every time: %v", seen)
+	}
+}
@@ -0,0 +1,199 @@
+package levtrie
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// The format Save writes starts with saveMagic, whose last digit is the
+// version of the format, and is followed by records, each starting with one
+// of the bytes below. Integers are varints as encoding/binary writes them and
+// strings are a length followed by their bytes, as in the file format of a
+// FrozenTrie. Unlike that format, it has no nodes: keys are written in sorted
+// order, each as the number of bytes it shares with the key before it and the
+// bytes that follow, so the prefixes that a Trie shares between keys are
+// shared in the file too, and Load adds them back in that order.
+const (
+	saveMagic = "levtrie 1\n"
+	// A tag and its bit, see SetTags. Tags come before keys.
+	saveTagRecord = 't'
+	// A key: the length of its prefix shared with the previous key, the
+	// rest of the key, its number of values, its values, count,
+	// expiration time and tags. Values are stored as they're returned,
+	// without CompressValues.
+	saveKeyRecord = 'k'
+	// The last record: the number of keys.
+	saveEndRecord = 'z'
+)
+
+var errSaveFormat = errors.New("levtrie: malformed saved Trie")
+
+// Save writes the live keys of the Trie, along with their values, counts,
+// expiration times and tags, to w in a compact binary format that Load reads
+// back. Keys are written in sorted order, each as the length of the prefix it
+// shares with the previous key followed by the rest of it, with every length
+// and number stored as a varint, so a dictionary of many keys with common
+// prefixes takes little more space than its distinct suffixes. The format is
+// versioned, so a file written by Save stays readable by later versions of
+// Load. Options aren't saved, since some of them are functions; pass them to
+// Load instead. With DecayWeights, counts are saved as they've decayed to the
+// time they're written. Save walks the entire Trie.
+func (t *Trie) Save(w io.Writer) error {
+	sw := &saveWriter{w: bufio.NewWriter(w)}
+	sw.writeString(saveMagic)
+	for _, tag := range t.sortedTags() {
+		sw.buf = append(sw.buf[:0], saveTagRecord)
+		sw.buf = appendString(sw.buf, tag)
+		sw.buf = binary.AppendUvarint(sw.buf, t.tagBits[tag])
+		sw.write()
+	}
+	now := t.decay.now()
+	var prev string
+	keys := 0
+	for it := t.Iterator(); it.Valid() && sw.err == nil; it.Next() {
+		e := it.e
+		shared := 0
+		for shared < len(prev) && shared < len(e.key) && prev[shared] == e.key[shared] {
+			shared++
+		}
+		sw.buf = append(sw.buf[:0], saveKeyRecord)
+		sw.buf = binary.AppendUvarint(sw.buf, uint64(shared))
+		sw.buf = appendString(sw.buf, e.key[shared:])
+		sw.buf = binary.AppendUvarint(sw.buf, uint64(len(e.values)))
+		for _, v := range e.values {
+			sw.buf = appendString(sw.buf, t.decode(v))
+		}
+		sw.buf = binary.AppendVarint(sw.buf, t.decay.count(e, now))
+		sw.buf = binary.AppendVarint(sw.buf, e.expires)
+		sw.buf = binary.AppendUvarint(sw.buf, e.tags)
+		sw.write()
+		prev = e.key
+		keys++
+	}
+	sw.buf = append(sw.buf[:0], saveEndRecord)
+	sw.buf = binary.AppendUvarint(sw.buf, uint64(keys))
+	sw.write()
+	if sw.err == nil {
+		sw.err = sw.w.Flush()
+	}
+	return sw.err
+}
+
+// saveWriter writes the records of Save, keeping the first error.
+type saveWriter struct {
+	w   *bufio.Writer
+	buf []byte
+	err error
+}
+
+func (sw *saveWriter) write() {
+	if sw.err == nil {
+		_, sw.err = sw.w.Write(sw.buf)
+	}
+}
+
+func (sw *saveWriter) writeString(s string) {
+	if sw.err == nil {
+		_, sw.err = sw.w.WriteString(s)
+	}
+}
+
+// Load returns a new Trie configured with the given options that holds the
+// keys written to r by Trie.Save, or an error if r can't be read or doesn't
+// hold a saved Trie. Keys are added in the sorted order they were saved in,
+// so each one only creates the nodes for the part of it that differs from
+// the key before it, and none of the work of Set is repeated for the parts
+// they share. Keys whose expiration time passed since they were saved aren't
+// added, and MaxKeys evicts as it would for any other additions. Load reports
+// its progress to LoadProgress.
+func Load(r io.Reader, opts ...Option) (_ *Trie, err error) {
+	t := New(opts...)
+	l, r := startRead(t.progress, r)
+	defer func() { l.finish(err) }()
+	if err := t.load(r, l); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// load adds the keys and tags saved in r to t, which has none, reporting each
+// key read to l.
+func (t *Trie) load(r io.Reader, l *loadProgress) error {
+	c := newCursor(t.ensureRoot())
+	return readSaved(r, func(tag string, bit uint64) {
+		if t.tagBits == nil {
+			t.tagBits = make(map[string]uint64)
+		}
+		t.tagBits[tag] = bit
+	}, func(e *entry) {
+		if e.live() {
+			t.restore(c, e, func(v string) string { return v })
+		}
+		l.add(1, 0)
+	})
+}
+
+// readSaved reads the records written by Save from r, passing each tag and
+// its bit to tag and then the entry of each key to visit, in order. The
+// values of the entries are as they're returned, not encoded for any Trie.
+// The same entry is reused for every key, so visit must not keep it.
+func readSaved(r io.Reader, tag func(tag string, bit uint64), visit func(e *entry)) error {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(saveMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != saveMagic {
+		return errSaveFormat
+	}
+	fr := frozenReader{r: br}
+	var prev string
+	var key []byte
+	e := &entry{}
+	keys, bits := uint64(0), uint64(0) // bits holds the bits of the tags read.
+	for {
+		kind, err := br.ReadByte()
+		if err != nil {
+			return errSaveFormat
+		}
+		switch kind {
+		case saveTagRecord:
+			name, bit := fr.string(), fr.uvarint()
+			// Each tag has a bit of its own.
+			if keys > 0 || fr.err != nil || bit == 0 || bit&(bit-1) != 0 || bits&bit != 0 {
+				return errSaveFormat
+			}
+			bits |= bit
+			tag(name, bit)
+		case saveKeyRecord:
+			shared := fr.uvarint()
+			if shared > uint64(len(prev)) {
+				return errSaveFormat
+			}
+			key = append(append(key[:0], prev[:shared]...), fr.bytes()...)
+			e.key, e.values = string(key), e.values[:0]
+			for i, k := uint64(0), fr.uvarint(); i < k && fr.err == nil; i++ {
+				e.values = append(e.values, fr.string())
+			}
+			e.count, e.expires, e.tags = fr.varint(), fr.varint(), fr.uvarint()
+			// Keys have to be in sorted order for the cursor that adds
+			// them, which also rules out repeated keys, and can only
+			// have the tags saved before them.
+			if fr.err != nil || keys > 0 && e.key <= prev || e.tags&^bits != 0 {
+				return errSaveFormat
+			}
+			visit(e)
+			prev = e.key
+			keys++
+		case saveEndRecord:
+			if n := fr.uvarint(); fr.err != nil || n != keys {
+				return errSaveFormat
+			}
+			return nil
+		default:
+			return errSaveFormat
+		}
+		if fr.err != nil {
+			return errSaveFormat
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package levtrie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveLoad(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	clock = func() time.Time { return now }
+	defer func() { clock = time.Now }()
+	rand.Seed(0)
+	keys := generateEdits(6, 300)
+	r := New(CompressValues(4, nil))
+	for i, key := range keys {
+		r.Set(key, strings.ToLower(key))
+		r.IncrBy(key, int64(i%7-3))
+		if i%3 == 0 {
+			r.SetTags(key, "third")
+		}
+	}
+	r.Add("tea", "green tea")
+	r.Add("tea", "black tea")
+	r.Incr("ten")
+	r.Set("", "empty")
+	r.SetWithTTL("to", "2", time.Minute)
+	r.SetWithTTL("toe", "gone", -time.Minute)
+	var buf bytes.Buffer
+	if err := r.Save(&buf); err != nil {
+		t.Fatalf("Save() = %v", err)
+	}
+	g, err := Load(bytes.NewReader(buf.Bytes()), TrigramIndex(0.1))
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	if g.Len() != r.Len()-1 || !reflect.DeepEqual(g.ToMap(), r.ToMap()) {
+		t.Fatalf("Load() has %v keys, want the %v live keys saved", g.Len(), r.Len()-1)
+	}
+	if got := g.Values("tea"); !reflect.DeepEqual(got, []string{"green tea", "black tea"}) {
+		t.Errorf("Values(tea) = %v, want [green tea black tea]", got)
+	}
+	for _, key := range append(keys[:50], "ten", "tea") {
+		if g.Count(key) != r.Count(key) || !reflect.DeepEqual(g.Tags(key), r.Tags(key)) {
+			t.Errorf("Got count %v and tags %v for %v, want %v and %v", g.Count(key), g.Tags(key), key, r.Count(key), r.Tags(key))
+		}
+	}
+	for _, key := range keys[:20] {
+		opts := []SuggestOption{WithTags("third")}
+		if got, want := keystr(g.Suggest(key, 2, 1000, opts...)), keystr(r.Suggest(key, 2, 1000, opts...)); got != want {
+			t.Errorf("Suggest(%v) = %v, want %v", key, got, want)
+		}
+	}
+	if err := g.ValidateInvariants(); err != nil {
+		t.Errorf("ValidateInvariants() = %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if g.Has("to") {
+		t.Errorf("Has(to) = true after its TTL passed")
+	}
+	// Shared prefixes make the format smaller than a FrozenTrie.
+	var frozen bytes.Buffer
+	if _, err := r.Freeze().WriteTo(&frozen); err != nil {
+		t.Fatalf("WriteTo() = %v", err)
+	}
+	if buf.Len() >= frozen.Len()/2 {
+		t.Errorf("Save() wrote %v bytes, want less than half of the %v of WriteTo", buf.Len(), frozen.Len())
+	}
+}
+
+func TestLoadMalformed(t *testing.T) {
+	r := New()
+	for _, key := range []string{"cat", "cot", "coat", "dog"} {
+		r.Set(key, key)
+		r.SetTags(key, "animal")
+	}
+	var buf bytes.Buffer
+	if err := r.Save(&buf); err != nil {
+		t.Fatalf("Save() = %v", err)
+	}
+	data := buf.Bytes()
+	if _, err := Load(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	// Every truncation is rejected, and every single-byte corruption is
+	// either rejected or loads a valid Trie.
+	for i := range data {
+		if _, err := Load(bytes.NewReader(data[:i])); err == nil {
+			t.Errorf("Load() of %v bytes succeeded", i)
+		}
+		bad := append([]byte(nil), data...)
+		bad[i] ^= 0x5a
+		if g, err := Load(bytes.NewReader(bad)); err == nil {
+			if err := g.ValidateInvariants(); err != nil {
+				t.Errorf("Load() with byte %v corrupted = %v", i, err)
+			}
+		}
+	}
+	if _, err := Load(strings.NewReader("levtrie 2\n")); err == nil {
+		t.Errorf("Load() of an unknown version succeeded")
+	}
+}
+
+func FuzzLoad(f *testing.F) {
+	r := New()
+	for _, key := range []string{"cat", "cot", "coat", "dog", ""} {
+		r.Set(key, key)
+		r.SetTags(key, "animal")
+	}
+	r.Add("cat", "feline")
+	var buf bytes.Buffer
+	r.Save(&buf)
+	f.Add(buf.Bytes())
+	// Lengths and counts too big for an int.
+	huge := binary.AppendUvarint(nil, 1<<63)
+	f.Add(append([]byte(saveMagic+"k\x00"), huge...))
+	f.Add(append([]byte(saveMagic+"k\x00\x01a"), huge...))
+	f.Add(append([]byte(saveMagic+"t"), binary.AppendUvarint(nil, 1<<64-1)...))
+	// Tags that weren't saved, and tags that share a bit.
+	f.Add([]byte(saveMagic + "k\x00\x01a\x00\x00\x00\x01z\x01"))
+	f.Add([]byte(saveMagic + "t\x01a\x01t\x01b\x01z\x00"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Corrupt input is either rejected or loads a valid Trie,
+		// without panicking.
+		if g, err := Load(bytes.NewReader(data)); err == nil {
+			if err := g.ValidateInvariants(); err != nil {
+				t.Errorf("Load() = %v", err)
+			}
+		}
+	})
+}
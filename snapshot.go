@@ -0,0 +1,135 @@
+package levtrie
+
+import "sort"
+
+// Snapshot returns a FrozenTrie holding the contents of the SyncTrie at the
+// time of the call, so that a long read, like exporting every key, sees a
+// single point in time while writes continue. The SyncTrie is only locked for
+// reading while it's copied, which takes time proportional to its size, so
+// writers wait for the copy but not for the reads of the snapshot. Each
+// method of a SyncTrie already sees a point-in-time view, so a snapshot is
+// only needed to make several reads, or iterate, without blocking writers;
+// View makes them without a copy but blocks writers until it returns.
+func (s *SyncTrie) Snapshot() *FrozenTrie {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Freeze()
+}
+
+// FrozenIterator visits the keys in a FrozenTrie in sorted order, starting
+// from any key, like an Iterator visits the keys of a Trie. Since a
+// FrozenTrie can't change, a FrozenIterator is never invalidated. Don't create
+// directly, use FrozenTrie.Iterator() instead.
+type FrozenIterator struct {
+	f     *FrozenTrie
+	stack []frozenIterFrame // The path from the root to the current key.
+	e     *entry            // The current entry, or nil if there isn't one.
+}
+
+// frozenIterFrame is a node on the path to the current key of a
+// FrozenIterator.
+type frozenIterFrame struct {
+	n    int32 // The index of the node.
+	next int32 // The index of the next child to visit.
+	self bool  // True once the node's own key has been visited.
+}
+
+func (f *FrozenTrie) newIterFrame(n int32) frozenIterFrame {
+	return frozenIterFrame{n: n, next: f.nodes[n].first}
+}
+
+// Iterator returns a FrozenIterator positioned at the first key in the
+// FrozenTrie.
+func (f *FrozenTrie) Iterator() *FrozenIterator {
+	it := &FrozenIterator{f: f}
+	it.Seek("")
+	return it
+}
+
+// Seek positions the FrozenIterator at the first key that's greater than or
+// equal to key, and returns true if there is such a key. See Iterator.Seek.
+func (it *FrozenIterator) Seek(key string) bool {
+	it.stack = append(it.stack[:0], it.f.newIterFrame(it.f.root))
+	for _, r := range key {
+		fr := &it.stack[len(it.stack)-1]
+		fr.self = true
+		x := &it.f.nodes[fr.n]
+		children := it.f.nodes[x.first : x.first+x.count]
+		i := sort.Search(len(children), func(i int) bool { return children[i].label >= r })
+		fr.next = x.first + int32(i)
+		if i == len(children) || children[i].label != r {
+			break
+		}
+		fr.next++
+		it.stack = append(it.stack, it.f.newIterFrame(x.first+int32(i)))
+	}
+	return it.advance()
+}
+
+// advance moves the FrozenIterator to the next live key in pre-order,
+// starting with the key at the top of the stack if it hasn't been visited.
+func (it *FrozenIterator) advance() bool {
+	for len(it.stack) > 0 {
+		fr := &it.stack[len(it.stack)-1]
+		x := &it.f.nodes[fr.n]
+		if !fr.self {
+			fr.self = true
+			if x.entry >= 0 && it.f.entries[x.entry].live() {
+				it.e = &it.f.entries[x.entry]
+				return true
+			}
+		}
+		if fr.next < x.first+x.count {
+			child := fr.next
+			fr.next++
+			it.stack = append(it.stack, it.f.newIterFrame(child))
+			continue
+		}
+		it.stack = it.stack[:len(it.stack)-1]
+	}
+	it.e = nil
+	return false
+}
+
+// Next moves the FrozenIterator to the next key and returns true if there is
+// one.
+func (it *FrozenIterator) Next() bool {
+	if it.e == nil {
+		return false
+	}
+	return it.advance()
+}
+
+// Valid returns true if the FrozenIterator is positioned at a key.
+func (it *FrozenIterator) Valid() bool {
+	return it.e != nil
+}
+
+// Key returns the current key, or the empty string if the FrozenIterator
+// isn't positioned at a key.
+func (it *FrozenIterator) Key() string {
+	if it.e == nil {
+		return ""
+	}
+	return it.e.key
+}
+
+// Value returns the value of the current key, as Get would return it.
+func (it *FrozenIterator) Value() string {
+	if it.e == nil {
+		return ""
+	}
+	return it.f.t.output(it.e.key, it.e.value())
+}
+
+// Values returns all values of the current key, as Values would return them.
+func (it *FrozenIterator) Values() []string {
+	if it.e == nil {
+		return nil
+	}
+	vals := make([]string, len(it.e.values))
+	for i, v := range it.e.values {
+		vals[i] = it.f.t.output(it.e.key, v)
+	}
+	return vals
+}
@@ -0,0 +1,69 @@
+package levtrie
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFrozenIterator(t *testing.T) {
+	r := New()
+	for _, key := range []string{"", "a", "ab", "abc", "b", "ba", "c", "cab"} {
+		r.Set(key, strings.ToUpper(key))
+	}
+	r.Delete("ab")
+	f := r.Freeze()
+	for _, seek := range []string{"", "a", "ab", "abz", "b", "bz", "caa", "d"} {
+		var want, got []string
+		it := r.Iterator()
+		for ok := it.Seek(seek); ok; ok = it.Next() {
+			want = append(want, it.Key()+"="+it.Value())
+		}
+		fit := f.Iterator()
+		for ok := fit.Seek(seek); ok; ok = fit.Next() {
+			got = append(got, fit.Key()+"="+strings.Join(fit.Values(), ","))
+		}
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("Seek(%q) visited %v, want %v", seek, got, want)
+		}
+	}
+	if it := New().Freeze().Iterator(); it.Valid() || it.Next() || it.Key() != "" {
+		t.Error("Iterator of an empty FrozenTrie has a key")
+	}
+}
+
+func TestSnapshotDuringWrites(t *testing.T) {
+	s := NewSync()
+	for i := 0; i < 1000; i++ {
+		s.Set(fmt.Sprintf("key%04d", i), "old")
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			s.Set(fmt.Sprintf("key%04d", i), "new")
+			s.Set(fmt.Sprintf("more%04d", i), "new")
+		}
+	}()
+	snap := s.Snapshot()
+	keys, updated, more := 0, 0, 0
+	for it := snap.Iterator(); it.Valid(); it.Next() {
+		keys++
+		if strings.HasPrefix(it.Key(), "more") {
+			more++
+		} else if it.Value() == "new" {
+			updated++
+		}
+	}
+	wg.Wait()
+	// Each key is updated before the next key is added, so a point in
+	// time has the same number of each or one more update.
+	if keys != snap.Len() || updated < more || updated > more+1 {
+		t.Errorf("Snapshot has %v updated keys and %v added keys, want a point in time", updated, more)
+	}
+	if got := snap.Len(); got != 1000+more {
+		t.Errorf("Snapshot has %v keys after the writes finished, want %v", got, 1000+more)
+	}
+}
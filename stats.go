@@ -0,0 +1,168 @@
+package levtrie
+
+import (
+	"sort"
+	"unsafe"
+)
+
+// Stats describes the contents of a Trie.
+type Stats struct {
+	Keys  int // The number of keys in the Trie.
+	Nodes int // The number of nodes in the Trie, including the root.
+	// The number of distinct values stored, if the Trie interns values.
+	InternedValues int
+	// The number of bytes of values that would have been stored but weren't
+	// because of interning.
+	InternedBytesSaved int
+}
+
+// Stats returns statistics about the Trie. Computing them requires a walk
+// over the entire Trie.
+func (t *Trie) Stats() Stats {
+	st := Stats{Keys: t.size}
+	stack := []*node{t.tree()}
+	for len(stack) > 0 {
+		var x *node
+		x, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		st.Nodes++
+		for _, child := range x.child {
+			stack = append(stack, child)
+		}
+	}
+	st.InternedValues = len(t.interned)
+	for _, iv := range t.interned {
+		st.InternedBytesSaved += (iv.refs - 1) * len(iv.s)
+	}
+	return st
+}
+
+// Usage estimates the memory used by the keys of a Trie that share a prefix.
+type Usage struct {
+	Prefix string // The prefix shared by the keys.
+	Keys   int    // The number of keys with the prefix.
+	// The number of nodes for the prefix and the keys with it, including the
+	// node at the end of the prefix.
+	Nodes int
+	// An estimate of the bytes used by those nodes and the keys and values
+	// stored at them. Values that are interned or stored in more than one
+	// place are counted in full for each key, and the bookkeeping of options
+	// like TrigramIndex and ReverseIndex isn't counted at all, so the estimate
+	// is best for comparing prefixes with each other.
+	Bytes int
+}
+
+// Estimates of the sizes of the parts of a map[rune]*node, which are
+// implementation details of the Go runtime.
+const (
+	mapBytes      = 48 // The header of a map.
+	mapEntryBytes = 20 // A key, a value and a share of the bucket overhead.
+)
+
+// nodeBytes estimates the bytes used by n, its map of children and its entry.
+func nodeBytes(n *node) int {
+	b := int(unsafe.Sizeof(*n)) + mapBytes + len(n.child)*mapEntryBytes
+	if e := n.data; e != nil {
+		b += int(unsafe.Sizeof(*e)) + len(e.key) + len(e.payload)
+		for _, v := range e.values {
+			b += int(unsafe.Sizeof(v)) + len(v)
+		}
+	}
+	return b
+}
+
+// usage returns the Usage of the subtree rooted at n, whose path is prefix.
+func usage(prefix string, n *node) Usage {
+	u := Usage{Prefix: prefix}
+	for stack := []*node{n}; len(stack) > 0; {
+		var x *node
+		x, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		u.Nodes++
+		u.Bytes += nodeBytes(x)
+		if x.data != nil {
+			u.Keys++
+		}
+		for _, child := range x.child {
+			stack = append(stack, child)
+		}
+	}
+	return u
+}
+
+// UsageOf returns the Usage of the keys in the Trie that start with prefix.
+// If no key starts with prefix, the Usage is zero except for Prefix. It walks
+// every key with the prefix.
+func (t *Trie) UsageOf(prefix string) Usage {
+	n := descend(t.tree(), extractRunes(prefix))
+	if n == nil {
+		return Usage{Prefix: prefix}
+	}
+	return usage(prefix, n)
+}
+
+// UsageByPrefix breaks down the memory used by the Trie by the first depth
+// runes of its keys, so it's easy to see which parts of a dictionary shared
+// by many tenants, or made of many sources, take up the most space. It
+// returns a Usage for each distinct prefix of depth runes, plus one for each
+// key shorter than depth runes that only covers the key itself, ordered by
+// Bytes from most to least, then by Prefix. The nodes for prefixes shorter
+// than depth are shared by the prefixes below them, so they aren't counted
+// in any Usage other than those of shorter keys. If depth isn't positive, the
+// result is a single Usage for the whole Trie. It walks the entire Trie.
+func (t *Trie) UsageByPrefix(depth int) []Usage {
+	if depth <= 0 {
+		return []Usage{usage("", t.tree())}
+	}
+	var usages []Usage
+	type item struct {
+		n    *node
+		path []rune
+	}
+	stack := []item{{n: t.tree()}}
+	for len(stack) > 0 {
+		var x item
+		x, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		if len(x.path) == depth {
+			usages = append(usages, usage(string(x.path), x.n))
+			continue
+		}
+		if x.n.data != nil {
+			usages = append(usages, Usage{Prefix: x.n.data.key, Keys: 1, Nodes: 1, Bytes: nodeBytes(x.n)})
+		}
+		for r, child := range x.n.child {
+			path := make([]rune, len(x.path)+1)
+			copy(path, x.path)
+			path[len(x.path)] = r
+			stack = append(stack, item{n: child, path: path})
+		}
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Bytes != usages[j].Bytes {
+			return usages[i].Bytes > usages[j].Bytes
+		}
+		return usages[i].Prefix < usages[j].Prefix
+	})
+	return usages
+}
+
+// Stats returns statistics about the SyncTrie. See Trie.Stats.
+func (s *SyncTrie) Stats() Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Stats()
+}
+
+// UsageOf returns the Usage of the keys that start with prefix. See
+// Trie.UsageOf.
+func (s *SyncTrie) UsageOf(prefix string) Usage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.UsageOf(prefix)
+}
+
+// UsageByPrefix returns the Usage of the keys under each prefix of the given
+// depth. See Trie.UsageByPrefix.
+func (s *SyncTrie) UsageByPrefix(depth int) []Usage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.UsageByPrefix(depth)
+}
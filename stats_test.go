@@ -0,0 +1,121 @@
+package levtrie
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	r := New()
+	if got, want := r.Stats(), (Stats{Keys: 0, Nodes: 1}); got != want {
+		t.Errorf("Got %+v, want %+v", got, want)
+	}
+	r.Set("tea", "1")
+	r.Set("ten", "2")
+	r.Set("to", "3")
+	r.Set("t", "4")
+	if got, want := r.Stats(), (Stats{Keys: 4, Nodes: 6}); got != want {
+		t.Errorf("Got %+v, want %+v", got, want)
+	}
+	r.Delete("ten")
+	if got, want := r.Stats(), (Stats{Keys: 3, Nodes: 5}); got != want {
+		t.Errorf("Got %+v, want %+v", got, want)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	r := New()
+	for _, key := range []string{"acme/apple", "acme/apply", "acme/ample", "beta/x", "a"} {
+		r.Set(key, "v")
+	}
+	r.Set("beta/y", strings.Repeat("v", 1000))
+	if got := r.UsageOf("acme/"); got.Keys != 3 || got.Nodes != 11 || got.Bytes <= 0 {
+		t.Errorf("UsageOf(acme/) = %+v, want 3 keys and 11 nodes", got)
+	}
+	if got, want := r.UsageOf("zzz"), (Usage{Prefix: "zzz"}); got != want {
+		t.Errorf("UsageOf(zzz) = %+v, want %+v", got, want)
+	}
+	if got, st := r.UsageOf(""), r.Stats(); got.Keys != st.Keys || got.Nodes != st.Nodes {
+		t.Errorf("UsageOf() = %+v, want the keys and nodes in %+v", got, st)
+	}
+	us := r.UsageByPrefix(4)
+	var prefixes []string
+	for _, u := range us {
+		prefixes = append(prefixes, u.Prefix)
+	}
+	if got, want := strings.Join(prefixes, " "), "beta acme a"; got != want {
+		t.Errorf("UsageByPrefix(4) has prefixes %v, want %v", got, want)
+	}
+	if us[0].Bytes < 1000 || us[0].Keys != 2 || us[2].Keys != 1 || us[2].Nodes != 1 {
+		t.Errorf("UsageByPrefix(4) = %+v", us)
+	}
+	if got := r.UsageByPrefix(0); len(got) != 1 || got[0] != r.UsageOf("") {
+		t.Errorf("UsageByPrefix(0) = %+v, want the usage of the whole Trie", got)
+	}
+}
+
+func TestChurnDoesntLeakNodes(t *testing.T) {
+	rand.Seed(0)
+	keys := generateEdits(4, 100)
+	r := New(ReverseIndex(), InfixIndex(), MaxKeys(40))
+	for i := 0; i < 20000; i++ {
+		key, other := keys[rand.Intn(len(keys))], keys[rand.Intn(len(keys))]
+		switch rand.Intn(6) {
+		case 0:
+			r.Set(key, "v")
+		case 1:
+			r.MSet([]KV{{Key: key, Value: "v"}, {Key: other, Value: "w"}})
+		case 2:
+			r.Rename(key, other)
+		case 3:
+			r.Incr(key)
+		default:
+			r.Delete(key)
+		}
+	}
+	// A Trie built from scratch with the same keys has exactly as many nodes.
+	fresh := New()
+	for key := range r.ToMap() {
+		fresh.Set(key, "v")
+	}
+	if got, want := r.Stats(), fresh.Stats(); got.Keys != want.Keys || got.Nodes != want.Nodes {
+		t.Errorf("Got %+v after churn, want %+v", got, want)
+	}
+	if err := r.ValidateInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeletingAllKeysReleasesNodes(t *testing.T) {
+	for _, opts := range [][]Option{nil, {MaxKeys(1000)}} {
+		r := New(opts...)
+		if !r.IsEmpty() {
+			t.Errorf("New Trie isn't empty")
+		}
+		keys := generateEdits(6, 500)
+		for _, key := range keys {
+			r.Set(key, key)
+			r.Incr(key + "x")
+		}
+		if r.IsEmpty() {
+			t.Errorf("Trie with %v keys is empty", r.Len())
+		}
+		for i, key := range keys {
+			r.Delete(key)
+			r.Delete(key + "x")
+			if err := r.ValidateInvariants(); err != nil {
+				t.Fatalf("After deleting %v keys: %v", i+1, err)
+			}
+		}
+		if !r.IsEmpty() {
+			t.Errorf("Got %v keys after deleting all of them, want 0", r.Len())
+		}
+		if got, want := r.Stats(), (Stats{Keys: 0, Nodes: 1}); got != want {
+			t.Errorf("Got %+v, want %+v", got, want)
+		}
+		if r.slots != nil {
+			t.Errorf("Got %v eviction slots, want none", len(r.slots))
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package levtrie
+
+import "context"
+
+// SuggestChan searches for keys within edit distance d of key, like Suggest,
+// and sends a KV for each match on the channel it returns as soon as the
+// match is found, so a consumer can start working on the first matches
+// before the search finishes. The channel is unbuffered: the search only
+// advances as fast as the KVs are received, and it stops, closing the
+// channel, when every match has been sent or ctx is done. There's no limit on
+// the number of KVs sent, so cancel ctx to stop early.
+//
+// The search runs in its own goroutine, so the Trie must not be changed until
+// the channel is closed. With the Ordered option, KVs are sent in order as
+// each distance is finished. With DedupeBy, the matches can only be
+// deduplicated once they've all been found, so they're sent at the end.
+// BucketCap has no cap to apply without a limit, since every match passed
+// over would be sent to fill the results anyway, so it only orders the KVs.
+func (t *Trie) SuggestChan(ctx context.Context, key string, d int8, opts ...SuggestOption) <-chan KV {
+	return t.suggestChan(ctx, key, d, opts, func() {})
+}
+
+// suggestChan is SuggestChan, calling done once the search has finished
+// reading the Trie, just before the channel is closed.
+func (t *Trie) suggestChan(ctx context.Context, key string, d int8, opts []SuggestOption, done func()) <-chan KV {
+	ch := make(chan KV)
+	key, ok := t.preprocess(key)
+	if !ok {
+		done()
+		close(ch)
+		return ch
+	}
+	runes, cfg := extractRunes(key), t.config(key, opts)
+	d = t.distance(len(runes), d)
+	find := search
+	if cfg.ordered {
+		find = searchOrdered
+	}
+	go func() {
+		defer close(ch)
+		defer done()
+		t.send(ctx, ch, cfg, func(visit func(*entry) bool) {
+			find(doNotExpandSuffixes, t.tree(), runes, d, cfg, visit)
+		})
+	}()
+	return ch
+}
+
+// send is collect for SuggestChan: it sends the KVs for the entries that find
+// passes to visit on ch until find returns or ctx is done.
+func (t *Trie) send(ctx context.Context, ch chan<- KV, cfg *searchConfig, find func(visit func(*entry) bool)) {
+	t.each(cfg, find, func(kv KV, dist int8) bool {
+		select {
+		case ch <- kv:
+			return ctx.Err() == nil
+		case <-ctx.Done():
+			return false
+		}
+	})
+}
+
+// SuggestChan sends a KV for each key within edit distance d of key on the
+// channel it returns. See Trie.SuggestChan. The SyncTrie is locked for
+// reading until the channel is closed, so writers wait for the consumer to
+// receive every KV or cancel ctx.
+func (s *SyncTrie) SuggestChan(ctx context.Context, key string, d int8, opts ...SuggestOption) <-chan KV {
+	s.mu.RLock()
+	return s.t.suggestChan(ctx, key, d, opts, s.mu.RUnlock)
+}
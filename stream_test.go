@@ -0,0 +1,58 @@
+package levtrie
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSuggestChan(t *testing.T) {
+	rand.Seed(0)
+	r := New()
+	for i, key := range generateEdits(5, 500) {
+		r.Set(key, key)
+		// Distinct weights make DedupeBy keep the same keys in any order.
+		r.IncrBy(key, int64(i))
+	}
+	optss := [][]SuggestOption{nil, {Ordered()}, {DedupeBy(func(s string) string { return strings.Trim(s, "A") })}, {BucketCap(1)}}
+	for _, query := range generateEdits(5, 20) {
+		for _, opts := range optss {
+			var got []KV
+			for kv := range r.SuggestChan(context.Background(), query, 2, opts...) {
+				got = append(got, kv)
+			}
+			if want := r.Suggest(query, 2, 1000, opts...); keystr(got) != keystr(want) {
+				t.Errorf("SuggestChan(%v) = %v, want %v", query, keystr(got), keystr(want))
+			}
+		}
+		var got []KV
+		for kv := range r.SuggestChan(context.Background(), query, 3, Ordered()) {
+			got = append(got, kv)
+		}
+		if want := r.Suggest(query, 3, 1000, Ordered()); ukeystr(got) != ukeystr(want) {
+			t.Errorf("Ordered SuggestChan(%v) = %v, want %v", query, ukeystr(got), ukeystr(want))
+		}
+	}
+}
+
+func TestSuggestChanCancel(t *testing.T) {
+	rand.Seed(0)
+	s := NewSync()
+	for _, key := range generateEdits(5, 500) {
+		s.Set(key, key)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := s.SuggestChan(ctx, "AAAAA", 4)
+	if _, ok := <-ch; !ok {
+		t.Fatal("Got no KVs")
+	}
+	cancel()
+	for range ch {
+	}
+	// The channel is closed, so the SyncTrie is unlocked.
+	s.Set("AAAAA", "x")
+	if got, _ := s.Get("AAAAA"); got != "x" {
+		t.Errorf("Got %v, want x", got)
+	}
+}
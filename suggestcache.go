@@ -0,0 +1,150 @@
+package levtrie
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+// SuggestCache makes a Trie keep an LRU cache of the results of up to size
+// distinct calls to Suggest, SuggestSuffixes, SuggestAfterExactPrefix and
+// SuggestSuffixesAfterExactPrefix, so that repeating a hot query returns its
+// results without searching the Trie. Calls are the same if they have the same
+// query, after PreprocessQueries, the same p, d and n and the same options.
+// Calls with Filter, StopWhen, DedupeBy or Constrain aren't cached, since the
+// functions and Acceptors passed to them can't be compared, and neither are
+// calls with ByWeight on a Trie with DecayWeights, whose order changes as
+// time passes. The cache is cleared by every change to the Trie that's
+// reported to OnChange hooks, by SetTags and by Maintenance when it decays
+// counts, but keys that expire may be returned from the cache until the Trie
+// next changes. The cache has its own lock, so searches on a
+// SyncTrie briefly contend on it.
+func SuggestCache(size int) Option {
+	return func(t *Trie) {
+		c := newSuggestCache(size)
+		t.results = c
+		t.OnChange(func(Op) { c.clear() })
+	}
+}
+
+// suggestKind identifies the Suggest method whose results are cached.
+type suggestKind uint8
+
+const (
+	suggestMatches suggestKind = iota
+	suggestSuffixes
+)
+
+// suggestCall holds everything that determines the results of a call to one of
+// the Suggest methods cached by SuggestCache.
+type suggestCall struct {
+	kind         suggestKind
+	query        string
+	p            int
+	d            int8
+	n            int
+	ops          edits
+	costs        costs
+	affix        affixes
+	valuesPerKey int
+	ordered      bool
+	bucketCap    int
+	byWeight     bool
+	excludeQuery bool
+	tags         string // The tags of WithTags, joined by NUL.
+}
+
+// newSuggestCall returns the suggestCall for a call with the given arguments
+// and false if the call can't be cached.
+func newSuggestCall(kind suggestKind, query string, p int, d int8, n int, opts []SuggestOption) (suggestCall, bool) {
+	cfg := newSearchConfig(query, opts)
+	if cfg.filter != nil || cfg.stop != nil || cfg.acceptor != nil || cfg.collectsAll() {
+		return suggestCall{}, false
+	}
+	return suggestCall{
+		kind: kind, query: query, p: p, d: d, n: n,
+		ops: cfg.ops, costs: cfg.costs, affix: cfg.affix,
+		valuesPerKey: cfg.valuesPerKey, ordered: cfg.ordered,
+		bucketCap: cfg.bucketCap,
+		byWeight:  cfg.byWeight, excludeQuery: cfg.excludeQuery,
+		tags: strings.Join(cfg.tags, "\x00"),
+	}, true
+}
+
+// suggestCache is an LRU cache of the results of calls to the Suggest methods,
+// see SuggestCache.
+type suggestCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List // Holds *cachedSuggest, most recently used first.
+	items map[suggestCall]*list.Element
+}
+
+type cachedSuggest struct {
+	call    suggestCall
+	results []KV
+}
+
+func newSuggestCache(size int) *suggestCache {
+	if size < 1 {
+		size = 1
+	}
+	return &suggestCache{size: size, ll: list.New(), items: make(map[suggestCall]*list.Element)}
+}
+
+func (c *suggestCache) get(call suggestCall) ([]KV, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[call]; ok {
+		c.ll.MoveToFront(el)
+		return el.Value.(*cachedSuggest).results, true
+	}
+	return nil, false
+}
+
+func (c *suggestCache) put(call suggestCall, results []KV) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[call]; ok {
+		el.Value.(*cachedSuggest).results = results
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[call] = c.ll.PushFront(&cachedSuggest{call: call, results: results})
+	if c.ll.Len() > c.size {
+		el := c.ll.Back()
+		c.ll.Remove(el)
+		delete(c.items, el.Value.(*cachedSuggest).call)
+	}
+}
+
+func (c *suggestCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ll.Len() > 0 {
+		c.ll.Init()
+		c.items = make(map[suggestCall]*list.Element)
+	}
+}
+
+// cached returns the results of the call described by the arguments from the
+// SuggestCache of t, calling suggest to find them if they aren't cached.
+func (t *Trie) cached(kind suggestKind, query string, p int, d int8, n int, opts []SuggestOption, suggest func() []KV) []KV {
+	if t.results == nil {
+		return suggest()
+	}
+	call, ok := newSuggestCall(kind, query, p, d, n, opts)
+	if !ok || call.byWeight && t.decay != nil {
+		return suggest()
+	}
+	results, ok := t.results.get(call)
+	if !ok {
+		results = suggest()
+		t.results.put(call, results)
+	}
+	// Callers own the slices they're returned.
+	if results == nil {
+		return nil
+	}
+	return append(make([]KV, 0, len(results)), results...)
+}
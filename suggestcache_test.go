@@ -0,0 +1,54 @@
+package levtrie
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSuggestCache(t *testing.T) {
+	r := New(SuggestCache(10), PreprocessQueries(LowercaseQuery))
+	for _, key := range []string{"cat", "cart", "cast", "hat"} {
+		r.Set(key, key)
+	}
+	searches := 0
+	count := Filter(func(KV, int8) bool { searches++; return true })
+	// Filtered searches aren't cached, so a second search with the filter
+	// only finds what the first did if it really searched.
+	if got := ukeystr(r.Suggest("cat", 1, 10, Ordered())); got != "cat cart cast hat" {
+		t.Fatalf("Suggest = %v", got)
+	}
+	r.Suggest("cat", 1, 10, count)
+	r.Suggest("cat", 1, 10, count)
+	if searches != 8 {
+		t.Errorf("Filtered searches visited %v matches, want 8", searches)
+	}
+	// Cached results are returned as copies, for the same call after
+	// preprocessing.
+	got := r.Suggest("CAT", 1, 10, Ordered())
+	got[0].Key = "changed"
+	if len(r.results.items) != 1 || ukeystr(r.Suggest("cat", 1, 10, Ordered())) != "cat cart cast hat" {
+		t.Errorf("Cache has %v calls, want 1 with unchanged results", len(r.results.items))
+	}
+	for _, d := range []int8{0, 1} {
+		r.Suggest("cat", d, 10)
+		r.SuggestSuffixes("cat", d, 10)
+		r.SuggestAfterExactPrefix("cat", 1, d, 10)
+		r.SuggestSuffixesAfterExactPrefix("cat", 1, d, 10, WithTags("x"))
+	}
+	if got := len(r.results.items); got != 9 {
+		t.Errorf("Cache has %v calls, want 9", got)
+	}
+	r.Set("bat", "bat")
+	if got := ukeystr(r.Suggest("cat", 1, 10, Ordered())); got != "cat bat cart cast hat" {
+		t.Errorf("Suggest = %v after Set, want bat", got)
+	}
+	r.SetTags("bat", "x")
+	if got := keystr(r.SuggestSuffixesAfterExactPrefix("bat", 0, 0, 10, WithTags("x"))); got != "bat" {
+		t.Errorf("Suggest = %v after SetTags, want bat", got)
+	}
+	r.SetWithTTL("bat", "bat", time.Minute)
+	r.Delete("bat")
+	if got := ukeystr(r.Suggest("cat", 1, 10, Ordered())); got != "cat cart cast hat" {
+		t.Errorf("Suggest = %v after Delete, want no bat", got)
+	}
+}
@@ -0,0 +1,166 @@
+// Package symspell implements levtrie.Index with the symmetric delete
+// algorithm popularized by SymSpell. Each key is stored along with every
+// string that can be made by deleting up to a fixed number of runes from it,
+// and a search looks up the strings made by deleting runes from the query.
+// Two strings within Levenshtein distance d of each other always have such a
+// deletion in common, so a search only has to check the keys that share one
+// with the query. This makes searches with small edit distances very fast
+// at the cost of a lot of memory and slower writes, especially for long keys.
+//
+// Importing this package registers a Dictionary with a maximum edit distance
+// of 2 as "symspell" with levtrie.RegisterIndex.
+package symspell
+
+import (
+	"sort"
+
+	"github.com/aaw/levtrie"
+)
+
+func init() {
+	levtrie.RegisterIndex("symspell", func() levtrie.Index { return New(2) })
+}
+
+// Dictionary is a map from strings to strings that supports searches within
+// an edit distance using precomputed deletions. Don't create directly, use
+// symspell.New() instead.
+type Dictionary struct {
+	maxDistance int
+	values      map[string]string
+	// deletes maps each string made by deleting up to maxDistance runes
+	// from a key, including the key itself, to all such keys.
+	deletes map[string][]string
+}
+
+var _ levtrie.Index = (*Dictionary)(nil)
+
+// New returns a new, empty Dictionary that precomputes deletions for searches
+// within edit distance maxDistance. Searches with a larger edit distance work
+// but have to check every key.
+func New(maxDistance int) *Dictionary {
+	if maxDistance < 0 {
+		maxDistance = 0
+	}
+	return &Dictionary{
+		maxDistance: maxDistance,
+		values:      make(map[string]string),
+		deletes:     make(map[string][]string),
+	}
+}
+
+// Len returns the number of keys in the Dictionary.
+func (s *Dictionary) Len() int {
+	return len(s.values)
+}
+
+// Get returns the value associated with key and true, or the empty string and
+// false if there's no such key in the Dictionary.
+func (s *Dictionary) Get(key string) (string, bool) {
+	val, ok := s.values[key]
+	return val, ok
+}
+
+// Set associates key with val in the Dictionary, replacing any previous
+// value.
+func (s *Dictionary) Set(key string, val string) {
+	if _, ok := s.values[key]; !ok {
+		for v := range deletions(key, s.maxDistance) {
+			s.deletes[v] = append(s.deletes[v], key)
+		}
+	}
+	s.values[key] = val
+}
+
+// Delete removes key and its value from the Dictionary.
+func (s *Dictionary) Delete(key string) {
+	if _, ok := s.values[key]; !ok {
+		return
+	}
+	delete(s.values, key)
+	for v := range deletions(key, s.maxDistance) {
+		keys := s.deletes[v]
+		for i, k := range keys {
+			if k == key {
+				keys[i] = keys[len(keys)-1]
+				keys = keys[:len(keys)-1]
+				break
+			}
+		}
+		if len(keys) == 0 {
+			delete(s.deletes, v)
+		} else {
+			s.deletes[v] = keys
+		}
+	}
+}
+
+// deletions returns the set of strings that can be made by deleting up to d
+// runes from s, including s itself.
+func deletions(s string, d int) map[string]bool {
+	result := map[string]bool{s: true}
+	level := []string{s}
+	for i := 0; i < d; i++ {
+		var next []string
+		for _, x := range level {
+			rs := []rune(x)
+			for j := range rs {
+				y := string(rs[:j]) + string(rs[j+1:])
+				if !result[y] {
+					result[y] = true
+					next = append(next, y)
+				}
+			}
+		}
+		level = next
+	}
+	return result
+}
+
+// Suggest returns up to n KVs with keys that are within edit distance d of
+// key, ordered by edit distance and then by key. SuggestOptions restrict the
+// results the same way they do for levtrie.Trie.Suggest, except that keys
+// that are only within d of the query because of free edits allowed by
+// levtrie.AffixTolerance aren't found, since candidates are found by
+// Levenshtein distance.
+func (s *Dictionary) Suggest(key string, d int8, n int, opts ...levtrie.SuggestOption) []levtrie.KV {
+	if d < 0 || n <= 0 {
+		return nil
+	}
+	candidates := make(map[string]bool)
+	if int(d) > s.maxDistance {
+		for k := range s.values {
+			candidates[k] = true
+		}
+	} else {
+		for v := range deletions(key, int(d)) {
+			for _, k := range s.deletes[v] {
+				candidates[k] = true
+			}
+		}
+	}
+	type match struct {
+		key  string
+		dist int
+	}
+	var found []match
+	for k := range candidates {
+		dist := levtrie.Distance(key, k)
+		if dist <= int(d) && (len(opts) == 0 || levtrie.Within(key, k, d, opts...)) {
+			found = append(found, match{key: k, dist: dist})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].dist != found[j].dist {
+			return found[i].dist < found[j].dist
+		}
+		return found[i].key < found[j].key
+	})
+	if len(found) > n {
+		found = found[:n]
+	}
+	results := make([]levtrie.KV, len(found))
+	for i, m := range found {
+		results[i] = levtrie.KV{Key: m.key, Value: s.values[m.key]}
+	}
+	return results
+}
@@ -0,0 +1,113 @@
+package symspell
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/aaw/levtrie"
+)
+
+func randWord(r *rand.Rand) string {
+	alphabet := []rune("abcdé")
+	rs := make([]rune, 1+r.Intn(7))
+	for i := range rs {
+		rs[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return string(rs)
+}
+
+func TestGetSetDelete(t *testing.T) {
+	dict := New(2)
+	dict.Set("hello", "1")
+	dict.Set("help", "2")
+	dict.Set("hello", "3")
+	if got, ok := dict.Get("hello"); !ok || got != "3" {
+		t.Errorf("Got (%v, %v), want (3, true)", got, ok)
+	}
+	if dict.Len() != 2 {
+		t.Errorf("Got Len() = %v, want 2", dict.Len())
+	}
+	dict.Delete("hello")
+	dict.Delete("hello")
+	if _, ok := dict.Get("hello"); ok {
+		t.Errorf("Got deleted key hello")
+	}
+	if got, ok := dict.Get("help"); !ok || got != "2" {
+		t.Errorf("Got (%v, %v), want (2, true)", got, ok)
+	}
+	if dict.Len() != 1 {
+		t.Errorf("Got Len() = %v, want 1", dict.Len())
+	}
+	dict.Set("hello", "4")
+	if got, ok := dict.Get("hello"); !ok || got != "4" {
+		t.Errorf("Got (%v, %v), want (4, true)", got, ok)
+	}
+}
+
+func TestSuggestMatchesTrie(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	dict, trie := New(2), levtrie.New()
+	for i := 0; i < 2000; i++ {
+		w := randWord(r)
+		dict.Set(w, w)
+		trie.Set(w, w)
+	}
+	for i := 0; i < 1000; i++ {
+		w := randWord(r)
+		dict.Delete(w)
+		trie.Delete(w)
+	}
+	if dict.Len() != trie.Len() {
+		t.Fatalf("Got Len() = %v, want %v", dict.Len(), trie.Len())
+	}
+	for i := 0; i < 50; i++ {
+		q, d := randWord(r), int8(r.Intn(4))
+		got := dict.Suggest(q, d, trie.Len())
+		want := trie.Suggest(q, d, trie.Len())
+		if len(got) != len(want) {
+			t.Fatalf("Suggest(%q, %v): got %v, want %v", q, d, got, want)
+		}
+		for j, kv := range got {
+			if j > 0 && levtrie.Distance(q, got[j-1].Key) > levtrie.Distance(q, kv.Key) {
+				t.Errorf("Suggest(%q, %v) isn't ordered by distance: %v", q, d, got)
+			}
+			if v, ok := trie.Get(kv.Key); !ok || v != kv.Value {
+				t.Errorf("Suggest(%q, %v) returned %v, which isn't in the Trie", q, d, kv)
+			}
+		}
+	}
+}
+
+func TestSuggestOptions(t *testing.T) {
+	dict := New(2)
+	for _, w := range []string{"int", "intl", "intern", "internet", "tern"} {
+		dict.Set(w, "")
+	}
+	got := dict.Suggest("intern", 4, 10, levtrie.DeletionsOnly())
+	want := []levtrie.KV{{Key: "intern"}, {Key: "tern"}, {Key: "int"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	if got := dict.Suggest("intern", 4, 2); len(got) != 2 || got[0].Key != "intern" {
+		t.Errorf("Got %v, want intern and one other key", got)
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	x, err := levtrie.NewIndex("symspell")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := x.(*Dictionary); !ok {
+		t.Errorf("Got %T, want *Dictionary", x)
+	}
+}
+
+func TestDeletions(t *testing.T) {
+	got := deletions("abc", 2)
+	want := map[string]bool{"abc": true, "bc": true, "ac": true, "ab": true, "a": true, "b": true, "c": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+}
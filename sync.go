@@ -0,0 +1,441 @@
+package levtrie
+
+import (
+	"sync"
+	"time"
+)
+
+// SyncTrie is a Trie that's safe for concurrent use by multiple goroutines.
+// Methods that only read from the Trie, including the Suggest methods, can
+// run concurrently with each other, while methods that write to the Trie run
+// exclusively. This suits read-mostly workloads like serving suggestions
+// while the dictionary is occasionally updated. Each method sees the SyncTrie
+// as of a single point in time, between writes: a Suggest never sees half of
+// a write, and a write made during a Suggest is either seen in full or not at
+// all. Several reads see the same point in time if they're made in View or
+// on a Snapshot. Don't create directly, use levtrie.NewSync() instead.
+type SyncTrie struct {
+	mu     sync.RWMutex
+	t      *Trie
+	stop   chan struct{} // Closed to stop the maintenance goroutine.
+	done   chan struct{} // Closed when the maintenance goroutine returns.
+	closed sync.Once
+}
+
+// NewSync returns a new SyncTrie configured with the given options. If the
+// options include Maintenance, it starts a goroutine that maintains the
+// SyncTrie until Close is called.
+func NewSync(opts ...Option) *SyncTrie {
+	s := &SyncTrie{t: New(opts...)}
+	if m := s.t.maint; m != nil && m.background {
+		s.stop, s.done = make(chan struct{}), make(chan struct{})
+		go s.maintain(m.opts.Interval)
+	}
+	return s
+}
+
+// maintain calls Maintain every interval until Close is called.
+func (s *SyncTrie) maintain(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			s.t.Maintain()
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Close stops the maintenance goroutine started by the Maintenance option and
+// waits for it to return. The SyncTrie can still be used afterward, without
+// maintenance. Close is safe to call more than once and does nothing if
+// there's no maintenance goroutine.
+func (s *SyncTrie) Close() {
+	s.closed.Do(func() {
+		if s.stop != nil {
+			close(s.stop)
+			<-s.done
+		}
+	})
+}
+
+// Maintain runs a slice of maintenance. See Trie.Maintain.
+func (s *SyncTrie) Maintain() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t.Maintain()
+}
+
+// View calls f with the underlying Trie while holding a read lock, so that f
+// can make several reads that see a consistent view of the Trie. f must not
+// modify the Trie.
+func (s *SyncTrie) View(f func(t *Trie)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f(s.t)
+}
+
+// Update calls f with the underlying Trie while holding a write lock, so that
+// f can make several changes that are seen by readers all at once.
+func (s *SyncTrie) Update(f func(t *Trie)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(s.t)
+}
+
+// Len returns the number of keys in the SyncTrie. See Trie.Len.
+func (s *SyncTrie) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Len()
+}
+
+// IsEmpty returns true exactly when the SyncTrie has no keys. See
+// Trie.IsEmpty.
+func (s *SyncTrie) IsEmpty() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.IsEmpty()
+}
+
+// Get returns the value stored at the given key. See Trie.Get.
+func (s *SyncTrie) Get(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Get(key)
+}
+
+// Has returns true exactly when key is in the SyncTrie. See Trie.Has.
+func (s *SyncTrie) Has(key string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Has(key)
+}
+
+// MGet returns a KV for each of the keys that's in the SyncTrie. See
+// Trie.MGet.
+func (s *SyncTrie) MGet(keys []string) []KV {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.MGet(keys)
+}
+
+// Values returns all values associated with the given key. See Trie.Values.
+func (s *SyncTrie) Values(key string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Values(key)
+}
+
+// Count returns the count associated with the given key. See Trie.Count.
+func (s *SyncTrie) Count(key string) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Count(key)
+}
+
+// Tags returns the tags attached to key. See Trie.Tags.
+func (s *SyncTrie) Tags(key string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Tags(key)
+}
+
+// CommonPrefix returns the longest prefix shared by all keys. See
+// Trie.CommonPrefix.
+func (s *SyncTrie) CommonPrefix() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.CommonPrefix()
+}
+
+// CommonPrefixAfter returns the longest prefix shared by all keys that start
+// with prefix. See Trie.CommonPrefixAfter.
+func (s *SyncTrie) CommonPrefixAfter(prefix string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.CommonPrefixAfter(prefix)
+}
+
+// ToMap returns a map of the contents of the SyncTrie. See Trie.ToMap.
+func (s *SyncTrie) ToMap() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.ToMap()
+}
+
+// ToMapN returns a map of at most n keys of the SyncTrie. See Trie.ToMapN.
+func (s *SyncTrie) ToMapN(n int) (map[string]string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.ToMapN(n)
+}
+
+// Set associates key with val. See Trie.Set.
+func (s *SyncTrie) Set(key string, val string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.t.Set(key, val)
+}
+
+// MSet sets each KV in kvs, in order, under a single lock. See Trie.MSet.
+func (s *SyncTrie) MSet(kvs []KV) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.t.MSet(kvs)
+}
+
+// SetWithTTL associates key with val until ttl has passed. See
+// Trie.SetWithTTL.
+func (s *SyncTrie) SetWithTTL(key string, val string, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.t.SetWithTTL(key, val, ttl)
+}
+
+// Add associates val with key in addition to any other values. See Trie.Add.
+func (s *SyncTrie) Add(key string, val string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.t.Add(key, val)
+}
+
+// Remove disassociates val from key. See Trie.Remove.
+func (s *SyncTrie) Remove(key string, val string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.t.Remove(key, val)
+}
+
+// Incr increments the count associated with key. See Trie.Incr.
+func (s *SyncTrie) Incr(key string) int64 {
+	return s.IncrBy(key, 1)
+}
+
+// IncrBy adds delta to the count associated with key. See Trie.IncrBy.
+func (s *SyncTrie) IncrBy(key string, delta int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t.IncrBy(key, delta)
+}
+
+// Delete removes the key. See Trie.Delete.
+func (s *SyncTrie) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.t.Delete(key)
+}
+
+// CompareAndSwap sets the value of key to new if its value is old. See
+// Trie.CompareAndSwap.
+func (s *SyncTrie) CompareAndSwap(key string, old string, new string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t.CompareAndSwap(key, old, new)
+}
+
+// CompareAndDelete deletes key if its value is old. See
+// Trie.CompareAndDelete.
+func (s *SyncTrie) CompareAndDelete(key string, old string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t.CompareAndDelete(key, old)
+}
+
+// Rename moves the contents of oldKey to newKey. See Trie.Rename.
+func (s *SyncTrie) Rename(oldKey string, newKey string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t.Rename(oldKey, newKey)
+}
+
+// SetTags replaces the tags attached to key. See Trie.SetTags.
+func (s *SyncTrie) SetTags(key string, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t.SetTags(key, tags...)
+}
+
+// Clear removes all keys. See Trie.Clear.
+func (s *SyncTrie) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.t.Clear()
+}
+
+// Compact rebuilds the maps of the nodes of the SyncTrie. See Trie.Compact.
+func (s *SyncTrie) Compact() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.t.Compact()
+}
+
+// RemoveExpired removes all expired keys. See Trie.RemoveExpired.
+func (s *SyncTrie) RemoveExpired() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t.RemoveExpired()
+}
+
+// OnChange registers f to be called after each change. f is called while the
+// SyncTrie is locked for writing, so it must not call any methods of the
+// SyncTrie. See Trie.OnChange.
+func (s *SyncTrie) OnChange(f func(Op)) (remove func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.t.OnChange(f)
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		r()
+	}
+}
+
+// Hash returns a digest of the contents of the SyncTrie. See Trie.Hash.
+func (s *SyncTrie) Hash() [32]byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Hash()
+}
+
+// Suggest returns up to n KVs with keys within edit distance d of key. See
+// Trie.Suggest.
+func (s *SyncTrie) Suggest(key string, d int8, n int, opts ...SuggestOption) []KV {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Suggest(key, d, n, opts...)
+}
+
+// SuggestByDistance is like Suggest but groups the results by their edit
+// distance from key. See Trie.SuggestByDistance.
+func (s *SyncTrie) SuggestByDistance(key string, d int8, n int, opts ...SuggestOption) [][]KV {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.SuggestByDistance(key, d, n, opts...)
+}
+
+// SuggestWithConfidence is like Suggest but scores each result with a
+// confidence. See Trie.SuggestWithConfidence.
+func (s *SyncTrie) SuggestWithConfidence(key string, d int8, n int, opts ...SuggestOption) []ScoredKV {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.SuggestWithConfidence(key, d, n, opts...)
+}
+
+// SuggestSuffixes returns up to n KVs with a prefix within edit distance d of
+// key. See Trie.SuggestSuffixes.
+func (s *SyncTrie) SuggestSuffixes(key string, d int8, n int, opts ...SuggestOption) []KV {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.SuggestSuffixes(key, d, n, opts...)
+}
+
+// SuggestEndsWith returns up to n KVs whose keys have a suffix within edit
+// distance d of key. See Trie.SuggestEndsWith.
+func (s *SyncTrie) SuggestEndsWith(key string, d int8, n int, opts ...SuggestOption) []KV {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.SuggestEndsWith(key, d, n, opts...)
+}
+
+// SuggestInfix returns up to n KVs whose keys have a substring within edit
+// distance d of key. See Trie.SuggestInfix.
+func (s *SyncTrie) SuggestInfix(key string, d int8, n int, opts ...SuggestOption) []KV {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.SuggestInfix(key, d, n, opts...)
+}
+
+// SuggestAfterExactPrefix is like Suggest but keys must share an exact prefix
+// of length p with key. See Trie.SuggestAfterExactPrefix.
+func (s *SyncTrie) SuggestAfterExactPrefix(key string, p int, d int8, n int, opts ...SuggestOption) []KV {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.SuggestAfterExactPrefix(key, p, d, n, opts...)
+}
+
+// SuggestSuffixesAfterExactPrefix is like SuggestSuffixes but keys must share
+// an exact prefix of length p with key. See
+// Trie.SuggestSuffixesAfterExactPrefix.
+func (s *SyncTrie) SuggestSuffixesAfterExactPrefix(key string, p int, d int8, n int, opts ...SuggestOption) []KV {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.SuggestSuffixesAfterExactPrefix(key, p, d, n, opts...)
+}
+
+// SuggestAnchored is like SuggestAfterExactPrefix but chooses the length of
+// the exact prefix itself. See Trie.SuggestAnchored.
+func (s *SyncTrie) SuggestAnchored(key string, d int8, n int, opts ...SuggestOption) ([]KV, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.SuggestAnchored(key, d, n, opts...)
+}
+
+// SuggestSuffixesAnchored is like SuggestSuffixesAfterExactPrefix but chooses
+// the length of the exact prefix itself. See Trie.SuggestSuffixesAnchored.
+func (s *SyncTrie) SuggestSuffixesAnchored(key string, d int8, n int, opts ...SuggestOption) ([]KV, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.SuggestSuffixesAnchored(key, d, n, opts...)
+}
+
+// Digests returns a Digest of each part of the SyncTrie under prefix. See
+// Trie.Digests.
+func (s *SyncTrie) Digests(prefix string) []Digest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Digests(prefix)
+}
+
+// Entries returns the keys equal to or starting with prefix along with their
+// values. See Trie.Entries.
+func (s *SyncTrie) Entries(prefix string, exact bool) []KeyValues {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Entries(prefix, exact)
+}
+
+// SyncFrom changes the SyncTrie to hold the same keys and values as r. See
+// Trie.SyncFrom. Other goroutines can't use the SyncTrie until it returns,
+// including while it waits on r.
+func (s *SyncTrie) SyncFrom(r Replica) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t.SyncFrom(r)
+}
+
+// ApplyChanges makes the changes described by ops to the SyncTrie. See
+// Trie.ApplyChanges.
+func (s *SyncTrie) ApplyChanges(ops []Op) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t.ApplyChanges(ops)
+}
+
+// Applied returns the Seq of the last change applied by ApplyChanges. See
+// Trie.Applied.
+func (s *SyncTrie) Applied() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.Applied()
+}
+
+// SetApplied sets the Seq of the last change applied. See Trie.SetApplied.
+func (s *SyncTrie) SetApplied(seq uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.t.SetApplied(seq)
+}
+
+// RemapKeys replaces every key in the SyncTrie with fn(key). See
+// Trie.RemapKeys.
+func (s *SyncTrie) RemapKeys(fn func(key string) string, resolve func(newKey string, oldKeys []string) string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.t.RemapKeys(fn, resolve)
+}
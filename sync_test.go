@@ -0,0 +1,173 @@
+package levtrie
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSyncTrie(t *testing.T) {
+	s := NewSync()
+	s.Set("hello", "1")
+	s.Add("hello", "2")
+	s.Remove("hello", "1")
+	s.Incr("hello")
+	if got, ok := s.Get("hello"); !ok || got != "2" {
+		t.Errorf("Get(hello) = (%v, %v), want (2, true)", got, ok)
+	}
+	if got := s.Count("hello"); got != 1 {
+		t.Errorf("Count(hello) = %v, want 1", got)
+	}
+	if got := keystr(s.Suggest("helo", 1, 10)); got != "hello" {
+		t.Errorf("Suggest(helo) = %v, want hello", got)
+	}
+	s.Update(func(t *Trie) {
+		t.Set("help", "3")
+		t.Set("helm", "4")
+	})
+	s.View(func(r *Trie) {
+		if r.Len() != 3 {
+			t.Errorf("Got %v keys, want 3", r.Len())
+		}
+	})
+	s.Delete("hello")
+	if s.Has("hello") || !s.Has("help") {
+		t.Errorf("Has(hello) = %v, Has(help) = %v, want false, true", s.Has("hello"), s.Has("help"))
+	}
+	if got := keystr(s.SuggestSuffixes("hel", 0, 10)); got != "helm help" {
+		t.Errorf("SuggestSuffixes(hel) = %v, want helm help", got)
+	}
+	if got := s.CommonPrefix(); got != "hel" {
+		t.Errorf("CommonPrefix() = %v, want hel", got)
+	}
+	if got := s.CommonPrefixAfter("helm"); got != "helm" {
+		t.Errorf("CommonPrefixAfter(helm) = %v, want helm", got)
+	}
+	if got := s.ToMap(); len(got) != 2 || got["helm"] != "4" {
+		t.Errorf("ToMap() = %v, want helm and help", got)
+	}
+	if got, complete := s.ToMapN(1); len(got) != 1 || complete {
+		t.Errorf("ToMapN(1) = (%v, %v), want one key and false", got, complete)
+	}
+	s.Clear()
+	if s.Len() != 0 {
+		t.Errorf("Got %v keys after Clear, want 0", s.Len())
+	}
+}
+
+// TestSyncTrieConcurrent is meant to be run with -race.
+func TestSyncTrieConcurrent(t *testing.T) {
+	s := NewSync(MaxKeys(50))
+	ops := 0
+	remove := s.OnChange(func(Op) { ops++ })
+	defer remove()
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(2)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				key := fmt.Sprintf("key%d", (g*200+i)%100)
+				s.Set(key, "v")
+				if i%10 == 0 {
+					s.Delete(key)
+				}
+			}
+		}(g)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				s.Get(fmt.Sprintf("key%d", i%100))
+				s.SuggestAfterExactPrefix("key1", 3, 1, 10)
+				s.Len()
+			}
+		}()
+	}
+	wg.Wait()
+	if s.Len() > 50 {
+		t.Errorf("Got %v keys, want at most 50", s.Len())
+	}
+	if ops == 0 {
+		t.Errorf("No changes were reported")
+	}
+}
+
+// TestSyncTrieRefresh serves reads while a writer refreshes the dictionary.
+// It's meant to be run with -race.
+func TestSyncTrieRefresh(t *testing.T) {
+	s := NewSync(NegativeCache(10), SuggestCache(10), Damerau())
+	for i := 0; i < 100; i++ {
+		s.Set("word"+strconv.Itoa(i), "v")
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 500; i++ {
+			key := "word" + strconv.Itoa(i%150)
+			if i%3 == 0 {
+				s.Delete(key)
+			} else {
+				s.Set(key, "v")
+				s.Incr(key)
+			}
+		}
+		close(done)
+	}()
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				s.Get("word7")
+				s.Suggest("wrod7", 2, 5, BucketCap(2))
+				s.DidYouMean("wrod12")
+				s.CorrectText("a wrod", CorrectOptions{})
+				s.UsageOf("word1")
+				s.Stats()
+			}
+		}()
+	}
+	wg.Wait()
+	if err := s.ValidateInvariants(); err != nil {
+		t.Errorf("ValidateInvariants() = %v", err)
+	}
+	if got, ok := s.Analyze("  Word1 "); !ok || got != "  Word1 " {
+		t.Errorf("Analyze() = (%q, %v), want the key unchanged", got, ok)
+	}
+}
+
+func TestSyncTrieCompareAndSwap(t *testing.T) {
+	s := NewSync()
+	s.Set("n", "0")
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				for {
+					old, _ := s.Get("n")
+					n, _ := strconv.Atoi(old)
+					if s.CompareAndSwap("n", old, strconv.Itoa(n+1)) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if got, _ := s.Get("n"); got != "400" {
+		t.Errorf("Got %v after 400 increments, want 400", got)
+	}
+	if s.CompareAndDelete("n", "399") || !s.CompareAndDelete("n", "400") {
+		t.Errorf("CompareAndDelete didn't compare the value")
+	}
+}
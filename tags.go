@@ -0,0 +1,141 @@
+package levtrie
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// maxTags is the number of distinct tags a Trie can hold, one for each bit of
+// the bitmaps kept in entries and nodes.
+const maxTags = 64
+
+// SetTags replaces the tags attached to key with tags, which can be used to
+// restrict searches to keys with certain tags (see WithTags), like "brand" or
+// "category:en". A Trie can hold up to 64 distinct tags, counting every tag
+// that has been attached to any key since the Trie was created or cleared.
+// SetTags returns an error if key isn't in the Trie or if tags would exceed
+// the limit, and leaves the Trie alone in either case. Tags don't count as a
+// change to the key for OnChange hooks and are kept when the key is renamed.
+func (t *Trie) SetTags(key string, tags ...string) error {
+	e := t.lookup(key)
+	if e == nil {
+		return fmt.Errorf("levtrie: can't tag %q, which isn't in the Trie", key)
+	}
+	fresh := 0
+	for _, tag := range tags {
+		if _, ok := t.tagBits[tag]; !ok {
+			fresh++
+		}
+	}
+	if len(t.tagBits)+fresh > maxTags {
+		return fmt.Errorf("levtrie: can't tag %q, the Trie already has %v distinct tags", key, len(t.tagBits))
+	}
+	if t.tagBits == nil {
+		t.tagBits = make(map[string]uint64)
+	}
+	e.tags = 0
+	for _, tag := range tags {
+		bit, ok := t.tagBits[tag]
+		if !ok {
+			bit = 1 << len(t.tagBits)
+			t.tagBits[tag] = bit
+		}
+		e.tags |= bit
+	}
+	t.retag(key)
+	if t.results != nil {
+		t.results.clear()
+	}
+	return nil
+}
+
+// Tags returns the tags attached to key in sorted order, or nil if key isn't
+// in the Trie or has no tags.
+func (t *Trie) Tags(key string) []string {
+	e := t.lookup(key)
+	if e == nil || e.tags == 0 {
+		return nil
+	}
+	var tags []string
+	for tag, bit := range t.tagBits {
+		if e.tags&bit != 0 {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// sortedTags returns the tags of the Trie in sorted order.
+func (t *Trie) sortedTags() []string {
+	tags := make([]string, 0, len(t.tagBits))
+	for tag := range t.tagBits {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// WithTags restricts a search to keys that have all of the given tags (see
+// Trie.SetTags). Every node of the Trie keeps the union of the tags of the
+// keys below it, so the search skips parts of the Trie without any matching
+// keys instead of filtering results after they're found. Keys are filtered
+// before the number of results is limited.
+func WithTags(tags ...string) SuggestOption {
+	return func(cfg *searchConfig) {
+		cfg.tags = append(cfg.tags, tags...)
+	}
+}
+
+// tagMask returns the bitmap of the given tags and true, or false if one of
+// them has never been attached to a key, in which case nothing matches.
+func (t *Trie) tagMask(tags []string) (uint64, bool) {
+	var mask uint64
+	for _, tag := range tags {
+		bit, ok := t.tagBits[tag]
+		if !ok {
+			return 0, false
+		}
+		mask |= bit
+	}
+	return mask, true
+}
+
+// retag recomputes the tag bitmaps of the nodes on the path to key, in the
+// Trie and in the reverse index, after the tags of key have changed or key has
+// been removed. It does nothing if no key has ever been tagged.
+func (t *Trie) retag(key string) {
+	if t.tagBits == nil {
+		return
+	}
+	retagPath(t.root, key)
+	if t.rev != nil {
+		retagPath(t.rev, reverse(key))
+	}
+}
+
+// retagPath recomputes the tag bitmaps of the nodes on the path to key below
+// root, or on as much of the path as exists, from the bottom up.
+func retagPath(root *node, key string) {
+	path := []*node{root}
+	for i, w := 0, 0; i < len(key); i += w {
+		var r rune
+		r, w = utf8.DecodeRuneInString(key[i:])
+		child, ok := path[len(path)-1].child[r]
+		if !ok {
+			break
+		}
+		path = append(path, child)
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		n := path[i]
+		n.tags = 0
+		if n.data != nil {
+			n.tags = n.data.tags
+		}
+		for _, child := range n.child {
+			n.tags |= child.tags
+		}
+	}
+}
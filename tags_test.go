@@ -0,0 +1,127 @@
+package levtrie
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	r := New(ReverseIndex())
+	for _, key := range []string{"apple", "apply", "ample", "maple", "applet"} {
+		r.Set(key, key)
+	}
+	if err := r.SetTags("apple", "brand", "fruit"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetTags("maple", "fruit"); err != nil {
+		t.Fatal(err)
+	}
+	r.SetTags("applet", "brand")
+	if err := r.SetTags("pear", "fruit"); err == nil {
+		t.Errorf("Tagging a missing key succeeded")
+	}
+	if got, want := r.Tags("apple"), []string{"brand", "fruit"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tags(apple) = %v, want %v", got, want)
+	}
+	if got := r.Tags("apply"); got != nil {
+		t.Errorf("Tags(apply) = %v, want nil", got)
+	}
+	for _, test := range []struct {
+		tags []string
+		want string
+	}{
+		{nil, "ample apple applet apply maple"},
+		{[]string{"fruit"}, "apple maple"},
+		{[]string{"brand"}, "apple applet"},
+		{[]string{"brand", "fruit"}, "apple"},
+		{[]string{"vegetable"}, ""},
+	} {
+		if got := keystr(r.Suggest("apple", 2, 10, WithTags(test.tags...))); got != test.want {
+			t.Errorf("Suggest with tags %v = %v, want %v", test.tags, got, test.want)
+		}
+	}
+	if got, want := keystr(r.SuggestSuffixes("app", 0, 10, WithTags("brand"))), "apple applet"; got != want {
+		t.Errorf("SuggestSuffixes = %v, want %v", got, want)
+	}
+	if got, want := keystr(r.SuggestEndsWith("ple", 0, 10, WithTags("fruit"))), "apple maple"; got != want {
+		t.Errorf("SuggestEndsWith = %v, want %v", got, want)
+	}
+	if got, want := keystr(r.Suggest("apple", 2, 1, WithTags("fruit"), Ordered())), "apple"; got != want {
+		t.Errorf("Ordered Suggest = %v, want %v", got, want)
+	}
+	r.SetTags("apple")
+	r.Rename("maple", "mapel")
+	r.Delete("applet")
+	if got, want := keystr(r.Suggest("maple", 2, 10, WithTags("fruit"))), "mapel"; got != want {
+		t.Errorf("Suggest after changes = %v, want %v", got, want)
+	}
+	if got := r.root.child['a'].tags; got != 0 {
+		t.Errorf("The subtree of a has tags %b after its tags were removed", got)
+	}
+	if err := r.ValidateInvariants(); err != nil {
+		t.Error(err)
+	}
+	r.Clear()
+	if r.Tags("mapel") != nil || r.tagBits != nil {
+		t.Errorf("Clear kept tags")
+	}
+}
+
+func TestTooManyTags(t *testing.T) {
+	r := New()
+	r.Set("a", "")
+	for i := 0; i < maxTags; i++ {
+		if err := r.SetTags("a", fmt.Sprint(i)); err != nil {
+			t.Fatalf("Tag %v: %v", i, err)
+		}
+	}
+	if err := r.SetTags("a", "one too many"); err == nil {
+		t.Errorf("Got no error for tag %v", maxTags+1)
+	}
+	if got := r.Tags("a"); len(got) != 1 || got[0] != fmt.Sprint(maxTags-1) {
+		t.Errorf("Got tags %v after a failed SetTags, want the last tag set", got)
+	}
+}
+
+func TestTagsFuzz(t *testing.T) {
+	rand.Seed(0)
+	data := generateEdits(5, 300)
+	r := New()
+	tags := []string{"x", "y", "z"}
+	want := make(map[string][]string)
+	for _, key := range data {
+		r.Set(key, "")
+		var ts []string
+		for _, tag := range tags {
+			if rand.Intn(3) == 0 {
+				ts = append(ts, tag)
+			}
+		}
+		r.SetTags(key, ts...)
+		want[key] = ts
+	}
+	for _, key := range data[:100] {
+		r.Delete(key)
+		delete(want, key)
+	}
+	if err := r.ValidateInvariants(); err != nil {
+		t.Fatal(err)
+	}
+	for _, query := range data[:20] {
+		for _, tag := range tags {
+			var expected []KV
+			for _, kv := range r.Suggest(query, 2, len(data)) {
+				for _, x := range want[kv.Key] {
+					if x == tag {
+						expected = append(expected, kv)
+					}
+				}
+			}
+			if got := keystr(r.Suggest(query, 2, len(data), WithTags(tag))); got != keystr(expected) {
+				t.Errorf("Suggest(%q, 2) with tag %v = %v, want %v", query, tag, got, keystr(expected))
+			}
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package levtrie
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// gram is a sequence of three runes from a key padded with two boundary runes
+// on each side. The boundary rune is -1, which can't appear in a key.
+type gram [3]rune
+
+const boundary rune = -1
+
+// grams returns the trigrams of the runes rs padded with boundary runes, in
+// order and with repetitions. There are always len(rs) + 2 of them.
+func grams(rs []rune) []gram {
+	padded := make([]rune, 0, len(rs)+4)
+	padded = append(padded, boundary, boundary)
+	padded = append(padded, rs...)
+	padded = append(padded, boundary, boundary)
+	gs := make([]gram, len(padded)-2)
+	for i := range gs {
+		gs[i] = gram{padded[i], padded[i+1], padded[i+2]}
+	}
+	return gs
+}
+
+// gramCounts returns the number of times each trigram of rs appears in it.
+func gramCounts(rs []rune) map[gram]int {
+	counts := make(map[gram]int)
+	for _, g := range grams(rs) {
+		counts[g]++
+	}
+	return counts
+}
+
+// trigramIndex maps the trigrams of all keys in a Trie to the keys that
+// contain them. A single edit changes at most three of the trigrams of a key,
+// so a key within edit distance d of a query shares at least
+// max(len(query), len(key)) + 2 - 3d trigrams with it. When that bound is
+// positive, counting the trigrams each key shares with the query rules out
+// most keys without walking the Trie at all.
+type trigramIndex struct {
+	ratio    float64                 // Use the index when d >= ratio * len(query).
+	postings map[gram]map[string]int // Keys containing each trigram, with counts.
+	lengths  map[int]map[string]bool // Keys by length in runes.
+}
+
+func newTrigramIndex(ratio float64) *trigramIndex {
+	return &trigramIndex{
+		ratio:    ratio,
+		postings: make(map[gram]map[string]int),
+		lengths:  make(map[int]map[string]bool),
+	}
+}
+
+func (x *trigramIndex) add(key string) {
+	rs := extractRunes(key)
+	for g, c := range gramCounts(rs) {
+		if x.postings[g] == nil {
+			x.postings[g] = make(map[string]int)
+		}
+		x.postings[g][key] = c
+	}
+	if x.lengths[len(rs)] == nil {
+		x.lengths[len(rs)] = make(map[string]bool)
+	}
+	x.lengths[len(rs)][key] = true
+}
+
+func (x *trigramIndex) remove(key string) {
+	rs := extractRunes(key)
+	for g := range gramCounts(rs) {
+		delete(x.postings[g], key)
+		if len(x.postings[g]) == 0 {
+			delete(x.postings, g)
+		}
+	}
+	delete(x.lengths[len(rs)], key)
+	if len(x.lengths[len(rs)]) == 0 {
+		delete(x.lengths, len(rs))
+	}
+}
+
+// covers returns true if the index should answer a search for runes within
+// edit distance d. Zero-cost affix edits don't count toward d, so searches
+// with AffixTolerance always use the Trie. Results from the index are ordered
+// by Levenshtein distance, which is only the order Ordered asks for when all
+// edits cost 1. A transposition can break four trigrams, more than the index
+// allows for each edit, so searches with Transpositions use the Trie too, as
+// do searches with BucketCap, which the index doesn't collect by distance.
+func (x *trigramIndex) covers(runes []rune, d int8, cfg *searchConfig) bool {
+	return x != nil && d > 0 && cfg.affix == (affixes{}) && cfg.ops&transpositions == 0 && cfg.bucketCap == 0 && (!cfg.ordered || cfg.costs == unitCosts) && float64(d) >= x.ratio*float64(len(runes))
+}
+
+// candidates returns every key that might be within edit distance d of runes.
+func (x *trigramIndex) candidates(runes []rune, d int8) map[string]bool {
+	shared := make(map[string]int)
+	for g, qc := range gramCounts(runes) {
+		for key, kc := range x.postings[g] {
+			shared[key] += minInt(qc, kc)
+		}
+	}
+	// threshold returns the number of trigrams a key of length n has to
+	// share with the query to be within edit distance d of it.
+	threshold := func(n int) int {
+		if n < len(runes) {
+			n = len(runes)
+		}
+		return n + 2 - 3*int(d)
+	}
+	cands := make(map[string]bool)
+	for key, c := range shared {
+		n := utf8.RuneCountInString(key)
+		if n-len(runes) <= int(d) && len(runes)-n <= int(d) && c >= threshold(n) {
+			cands[key] = true
+		}
+	}
+	// Keys that don't have to share any trigrams with the query might not
+	// appear in the postings at all, so all keys of those lengths are
+	// candidates.
+	for n := len(runes) - int(d); n <= len(runes)+int(d); n++ {
+		if threshold(n) > 0 {
+			continue
+		}
+		for key := range x.lengths[n] {
+			cands[key] = true
+		}
+	}
+	return cands
+}
+
+// matches returns true exactly when the automaton a, which has edit distance
+// d, accepts key.
+func matches(a automaton, d int8, key string) bool {
+	return matchDistance(a, d, key) <= int(d)
+}
+
+// matchDistance returns the distance at which the automaton a, which has edit
+// distance d, accepts key, or more than d if it doesn't accept it.
+func matchDistance(a automaton, d int8, key string) int {
+	s := a.start()
+	var min int
+	for _, r := range key {
+		if s, min = a.transition(s, r); min > int(d) {
+			return int(d) + 1
+		}
+	}
+	return a.distance(s)
+}
+
+// suggestByTrigrams collects up to limit KVs with keys within edit distance d
+// of runes by verifying each candidate from the trigram index with an
+// automaton. Results are ordered by Levenshtein distance, then as ByWeight
+// asks or by key.
+func (t *Trie) suggestByTrigrams(runes []rune, d int8, limit int, cfg *searchConfig) []KV {
+	a := newAutomaton(runes, d, cfg)
+	type match struct {
+		e    *entry
+		dist int  // The Levenshtein distance, which orders the results.
+		cost int8 // The distance at which the automaton accepts the key.
+	}
+	var found []match
+	query := string(runes)
+	for key := range t.grams.candidates(runes, d) {
+		n := t.find(key)
+		if n == nil || !n.data.live() || cfg.excluded(n.data) {
+			continue
+		}
+		if cost := matchDistance(a, d, key); cost <= int(d) && cfg.keep(n.data, int8(cost)) {
+			found = append(found, match{e: n.data, dist: Distance(query, key), cost: int8(cost)})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].dist != found[j].dist {
+			return found[i].dist < found[j].dist
+		}
+		return cfg.before(found[i].e, found[j].e)
+	})
+	es := make([]*entry, len(found))
+	costs := make(map[*entry]int8, len(found))
+	for i, m := range found {
+		es[i] = m.e
+		costs[m.e] = m.cost
+	}
+	es = cfg.choose(es)
+	var results []KV
+	for _, e := range es {
+		if len(results) >= limit {
+			break
+		}
+		results = t.appendKVs(results, e, cfg.valuesPerKey)
+		if cfg.dist = costs[e]; cfg.stops(e) {
+			break
+		}
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
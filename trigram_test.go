@@ -0,0 +1,89 @@
+package levtrie
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestGrams(t *testing.T) {
+	gs := grams([]rune("ab"))
+	want := []gram{
+		{boundary, boundary, 'a'},
+		{boundary, 'a', 'b'},
+		{'a', 'b', boundary},
+		{'b', boundary, boundary},
+	}
+	if len(gs) != len(want) {
+		t.Fatalf("Got %v, want %v", gs, want)
+	}
+	for i := range gs {
+		if gs[i] != want[i] {
+			t.Errorf("Got %v, want %v", gs, want)
+		}
+	}
+}
+
+func TestTrigramIndexFuzz(t *testing.T) {
+	rand.Seed(0)
+	r := New(TrigramIndex(0.01))
+	haystack := generateEdits(8, 3000)
+	for _, s := range haystack {
+		r.Set(s, s)
+	}
+	for dist := int8(1); dist < 6; dist++ {
+		for i := 0; i < 5; i++ {
+			needle := haystack[rand.Intn(len(haystack))]
+			results := keystr(r.Suggest(needle, dist, len(haystack)))
+			var want []KV
+			for _, s := range haystack {
+				if Distance(s, needle) <= int(dist) {
+					want = append(want, KV{Key: s})
+				}
+			}
+			expected := keystr(want)
+			if results != expected {
+				t.Errorf("When asking for strings edit distance %v away from %v,"+
+					"got:\n%v\nbut want:\n%v", dist, needle, results, expected)
+			}
+		}
+	}
+}
+
+func TestTrigramIndexOrder(t *testing.T) {
+	r := New(TrigramIndex(0.1))
+	for _, key := range []string{"help", "hello", "helm", "yellow", "hell", "shell"} {
+		r.Set(key, key)
+	}
+	if got, want := ukeystr(r.Suggest("hell", 2, 10)), "hell hello helm help shell"; got != want {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	if got, want := ukeystr(r.Suggest("hell", 2, 3)), "hell hello helm"; got != want {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+}
+
+func TestTrigramIndexDelete(t *testing.T) {
+	r := New(TrigramIndex(0.1))
+	r.Set("cat", "1")
+	r.Set("cart", "2")
+	r.Delete("cat")
+	if got, want := keystr(r.Suggest("cat", 1, 10)), "cart"; got != want {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	if len(r.grams.lengths[3]) != 0 || len(r.grams.postings[gram{boundary, 'c', 'a'}]) != 1 {
+		t.Errorf("Deleted key is still in the index: %+v", r.grams)
+	}
+}
+
+func TestTrigramIndexOptions(t *testing.T) {
+	r := New(TrigramIndex(0.1))
+	for _, key := range []string{"int", "intl", "intern", "internet", "tern"} {
+		r.Set(key, key)
+	}
+	if got, want := keystr(r.Suggest("intern", 4, 10, DeletionsOnly())), "int intern tern"; got != want {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+	if got, want := keystr(r.Suggest("intl", 2, 10, EditCosts(1, 3, 3))), "intl"; got != want {
+		t.Errorf("Got %v, want %v", got, want)
+	}
+}
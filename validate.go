@@ -0,0 +1,186 @@
+package levtrie
+
+import (
+	"fmt"
+)
+
+// ValidateInvariants checks the structure of the Trie and returns an error
+// describing the first problem it finds, or nil if the Trie is healthy. It
+// checks that every key is stored at the node reached by following its runes
+// from the root, that every node other than the root stores a key or has
+// children, in the Trie and in the trees kept by ReverseIndex and InfixIndex,
+// so that deletions haven't left behind any nodes that lead nowhere, that the
+// number of keys matches Len, and that the bookkeeping for options like
+// MaxKeys, InternValues, BloomFilter, NegativeCache and TrigramIndex agrees
+// with the keys in the Trie. It walks the entire Trie, so it's meant for
+// tests and for checking a Trie after recovering it from storage, not for
+// regular use.
+func (t *Trie) ValidateInvariants() error {
+	type item struct {
+		n    *node
+		path []rune
+	}
+	keys := 0
+	refs := make(map[string]int)
+	root := t.tree()
+	stack := []item{{n: root}}
+	for len(stack) > 0 {
+		var x item
+		x, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		if x.n.child == nil {
+			return fmt.Errorf("levtrie: node at %q has a nil child map", string(x.path))
+		}
+		if x.n != root && x.n.data == nil && len(x.n.child) == 0 {
+			return fmt.Errorf("levtrie: node at %q has no key and no children", string(x.path))
+		}
+		if e := x.n.data; e != nil {
+			keys++
+			if e.key != string(x.path) {
+				return fmt.Errorf("levtrie: key %q is stored at %q", e.key, string(x.path))
+			}
+			if err := t.validateEntry(e); err != nil {
+				return err
+			}
+			if e.tags&^x.n.tags != 0 {
+				return fmt.Errorf("levtrie: node at %q is missing the tags of its key", string(x.path))
+			}
+			for _, v := range e.values {
+				if v != "" {
+					refs[v]++
+				}
+			}
+		}
+		for r, child := range x.n.child {
+			if child == nil {
+				return fmt.Errorf("levtrie: node at %q has a nil child for %q", string(x.path), r)
+			}
+			if child.tags&^x.n.tags != 0 {
+				return fmt.Errorf("levtrie: node at %q is missing the tags of its child %q", string(x.path), r)
+			}
+			path := make([]rune, len(x.path)+1)
+			copy(path, x.path)
+			path[len(x.path)] = r
+			stack = append(stack, item{n: child, path: path})
+		}
+	}
+	if keys != t.size {
+		return fmt.Errorf("levtrie: found %v keys but Len is %v", keys, t.size)
+	}
+	if t.maxKeys > 0 {
+		if t.size > t.maxKeys {
+			return fmt.Errorf("levtrie: %v keys exceed the maximum of %v", t.size, t.maxKeys)
+		}
+		if len(t.slots) != t.size {
+			return fmt.Errorf("levtrie: %v eviction slots for %v keys", len(t.slots), t.size)
+		}
+	}
+	if t.interned != nil {
+		if len(refs) != len(t.interned) {
+			return fmt.Errorf("levtrie: %v interned values for %v distinct values", len(t.interned), len(refs))
+		}
+		for v, n := range refs {
+			if iv, ok := t.interned[v]; !ok || iv.refs != n {
+				return fmt.Errorf("levtrie: value %q is stored %v times but isn't interned with that many references", v, n)
+			}
+		}
+	}
+	if t.rev != nil {
+		reversed, err := countTree(t.rev, "reverse index")
+		if err != nil {
+			return err
+		}
+		if reversed != t.size {
+			return fmt.Errorf("levtrie: %v keys in the reverse index for %v keys", reversed, t.size)
+		}
+	}
+	if t.infix != nil {
+		indexed, err := countTree(t.infix.root, "infix index")
+		if err != nil {
+			return err
+		}
+		if indexed != len(t.infix.owners) {
+			return fmt.Errorf("levtrie: %v suffixes in the infix index tree for %v suffixes", indexed, len(t.infix.owners))
+		}
+		for suffix, keys := range t.infix.owners {
+			if len(keys) == 0 {
+				return fmt.Errorf("levtrie: suffix %q in the infix index has no keys", suffix)
+			}
+			for k := range keys {
+				if n := t.find(k); n == nil || n.data == nil {
+					return fmt.Errorf("levtrie: the infix index has %q for key %q, which isn't in the Trie", suffix, k)
+				}
+			}
+		}
+	}
+	if t.grams != nil {
+		indexed := 0
+		for _, keys := range t.grams.lengths {
+			indexed += len(keys)
+		}
+		if indexed != t.size {
+			return fmt.Errorf("levtrie: %v keys in the trigram index for %v keys", indexed, t.size)
+		}
+	}
+	return nil
+}
+
+// countTree returns the number of entries in the tree of nodes under root, or
+// an error if a node other than root leads to no entries. name describes the
+// tree in the error.
+func countTree(root *node, name string) (int, error) {
+	entries := 0
+	for stack := []*node{root}; len(stack) > 0; {
+		var x *node
+		x, stack = stack[len(stack)-1], stack[:len(stack)-1]
+		if x.data != nil {
+			entries++
+		} else if x != root && len(x.child) == 0 {
+			return 0, fmt.Errorf("levtrie: the %v has a node with no key and no children", name)
+		}
+		for _, child := range x.child {
+			stack = append(stack, child)
+		}
+	}
+	return entries, nil
+}
+
+// validateEntry checks the bookkeeping for the single entry e.
+func (t *Trie) validateEntry(e *entry) error {
+	if t.maxKeys > 0 && (e.slot < 0 || e.slot >= len(t.slots) || t.slots[e.slot] != e) {
+		return fmt.Errorf("levtrie: key %q isn't in its eviction slot %v", e.key, e.slot)
+	}
+	if t.bloom != nil && !t.bloom.mayContain(e.key) {
+		return fmt.Errorf("levtrie: key %q is missing from the Bloom filter", e.key)
+	}
+	if t.absent != nil && t.absent.has(e.key) {
+		return fmt.Errorf("levtrie: key %q is in the negative cache", e.key)
+	}
+	if t.grams != nil && !t.grams.lengths[len(extractRunes(e.key))][e.key] {
+		return fmt.Errorf("levtrie: key %q is missing from the trigram index", e.key)
+	}
+	if t.rev != nil {
+		if n := descend(t.rev, extractRunes(reverse(e.key))); n == nil || n.data != e {
+			return fmt.Errorf("levtrie: key %q is missing from the reverse index", e.key)
+		}
+	}
+	if t.infix != nil {
+		var err error
+		suffixes(e.key, func(suffix string) {
+			if n := descend(t.infix.root, extractRunes(suffix)); err == nil && (n == nil || n.data == nil || !t.infix.owners[suffix][e.key]) {
+				err = fmt.Errorf("levtrie: suffix %q of key %q is missing from the infix index", suffix, e.key)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateInvariants checks the internal invariants of the SyncTrie. See
+// Trie.ValidateInvariants.
+func (s *SyncTrie) ValidateInvariants() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.t.ValidateInvariants()
+}
@@ -0,0 +1,67 @@
+package levtrie
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestValidateInvariantsRandomOps(t *testing.T) {
+	rand.Seed(0)
+	keys := generateEdits(4, 200)
+	for _, opts := range [][]Option{
+		nil,
+		{MaxKeys(50)},
+		{InternValues(), CompressValues(4, nil)},
+		{BloomFilter(10, 0.01), TrigramIndex(0.5)},
+		{ReverseIndex(), InfixIndex(), MaxKeys(30)},
+	} {
+		r := New(opts...)
+		for i := 0; i < 5000; i++ {
+			key := keys[rand.Intn(len(keys))]
+			switch rand.Intn(6) {
+			case 0:
+				r.Set(key, string(rune('a'+i%3)))
+			case 1:
+				r.Add(key, strings.Repeat(key, 2))
+			case 2:
+				r.Remove(key, strings.Repeat(key, 2))
+			case 3:
+				r.Incr(key)
+			default:
+				r.Delete(key)
+			}
+			if err := r.ValidateInvariants(); err != nil {
+				t.Fatalf("After %v operations: %v", i+1, err)
+			}
+		}
+	}
+}
+
+func TestValidateInvariantsDetectsProblems(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(r *Trie)
+		want    string
+	}{
+		{"orphan", func(r *Trie) { r.insert("zzz") }, "no key and no children"},
+		{"misplaced", func(r *Trie) { r.find("tea").data.key = "tee" }, `key "tee" is stored at "tea"`},
+		{"size", func(r *Trie) { r.size++ }, "found 3 keys but Len is 4"},
+		{"interned", func(r *Trie) { r.interned["1"].refs++ }, `value "1"`},
+		{"reverse orphan", func(r *Trie) { insertAt(r.rev, "zzz") }, "reverse index has a node with no key"},
+		{"infix orphan", func(r *Trie) { insertAt(r.infix.root, "zzz") }, "infix index has a node with no key"},
+	}
+	for _, test := range tests {
+		r := New(InternValues(), ReverseIndex(), InfixIndex())
+		r.Set("tea", "1")
+		r.Set("ten", "1")
+		r.Set("to", "2")
+		if err := r.ValidateInvariants(); err != nil {
+			t.Fatalf("%v: got %v before corrupting the Trie", test.name, err)
+		}
+		test.corrupt(r)
+		if err := r.ValidateInvariants(); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%v: got %v, want an error containing %q", test.name, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package levtrie
+
+// wnfa is a Levenshtein NFA whose edit operations have costs other than 1.
+//
+// The weighted NFA looks like the NFA pictured in the package documentation
+// except that rows are indexed by cost instead of by edit count: a vertical
+// transition climbs as many rows as an insertion costs and a diagonal
+// transition climbs as many rows as a deletion or substitution costs. Since
+// deletions no longer climb exactly one row per column, the lowest active state
+// on a diagonal doesn't summarize the states above it anymore. Instead, we
+// keep track of the lowest active state in each column. After reading t runes,
+// only columns t - d/ci through t + d/cd can contain active states, where ci
+// and cd are the costs of insertion and deletion, so the simulation slides a
+// window of d/ci + d/cd + 1 columns along the word, which reduces to the 2d + 1
+// diagonals of the unweighted NFA when all costs are 1.
+//
+// Edits at the ends of a key can also be given their own cost (see
+// AffixTolerance). Leading edits are handled by seeding the columns of the
+// first few rows with the cost of reaching them through leading edits alone,
+// which widens the window by the number of leading edits allowed. Trailing
+// runes of the key are handled by a short chain of extra states after the last
+// column, stored after the window in each state.
+type wnfa struct {
+	rs  []rune // The word this NFA matches, split into runes.
+	d   int8   // The maximum total cost of edits accepted by the NFA.
+	ops edits  // The edit operations represented by transitions.
+	ci  int    // The cost of an insertion.
+	cd  int    // The cost of a deletion.
+	cs  int    // The cost of a substitution.
+	pre int    // The number of leading edits with cost ca.
+	suf int    // The number of trailing edits with cost ca.
+	ca  int    // The cost of an edit at either end of a key.
+}
+
+func newWnfa(rs []rune, d int8, ops edits, c costs, a affixes) *wnfa {
+	return &wnfa{
+		rs: rs, d: d, ops: ops,
+		ci: int(c.ins), cd: int(c.del), cs: int(c.sub),
+		pre: a.prefix, suf: a.suffix, ca: int(a.cost),
+	}
+}
+
+// lag returns the maximum number of columns that the active states of the
+// NFA can trail the number of runes read.
+func (n wnfa) lag() int {
+	return int(n.d)/n.ci + n.pre
+}
+
+// width returns the number of columns in the sliding window of the NFA.
+func (n wnfa) width() int {
+	return n.lag() + int(n.d)/n.cd + n.pre + 1
+}
+
+// inactive is the cost stored for columns that have no active states.
+func (n wnfa) inactive() int16 {
+	return int16(n.d) + 1
+}
+
+// leading returns the cost of reaching column c after reading t runes using
+// only leading edits, or the inactive cost if that isn't possible. Leading
+// edits are still deletions and insertions, so they're only allowed if ops is.
+func (n wnfa) leading(c int, t int) int {
+	if c < 0 || c > n.pre || c > len(n.rs) || t > n.pre {
+		return int(n.inactive())
+	}
+	if (c > 0 && n.ops&deletions == 0) || (t > 0 && n.ops&insertions == 0) {
+		return int(n.inactive())
+	}
+	return (c + t) * n.ca
+}
+
+// trailing returns the cost of accepting from column c with cost x by deleting
+// the rest of the word, or the inactive cost if that isn't possible. Up to suf
+// of those deletions cost ca instead of cd.
+func (n wnfa) trailing(c int, x int) int {
+	rem := len(n.rs) - c
+	if rem > 0 && n.ops&deletions == 0 {
+		return int(n.inactive())
+	}
+	if rem <= n.suf {
+		return x + rem*n.ca
+	}
+	return x + minInt(rem*n.cd, (rem-n.suf)*n.cd+n.suf*n.ca)
+}
+
+// cap replaces costs over budget with the inactive cost.
+func (n wnfa) cap(x int) int16 {
+	if x > int(n.d) {
+		return n.inactive()
+	}
+	return int16(x)
+}
+
+// start returns the start state of the NFA. The window starts lag columns
+// to the left of the first column so that the columns reachable by insertions
+// remain in the window as runes are read.
+func (n wnfa) start() state {
+	s := state{offset: -n.lag(), arr: make([]int16, n.width()+n.suf)}
+	for k := range s.arr {
+		s.arr[k] = n.inactive()
+	}
+	for k := 0; k < n.width(); k++ {
+		c := s.offset + k
+		if c < 0 || c > len(n.rs) {
+			continue
+		}
+		x := n.leading(c, 0)
+		if n.ops&deletions != 0 && k > 0 {
+			x = minInt(x, int(s.arr[k-1])+n.cd)
+		}
+		s.arr[k] = n.cap(x)
+	}
+	return s
+}
+
+// accepts returns true exactly when the NFA state passed is accepting.
+func (n wnfa) accepts(s state) bool {
+	for k, x := range s.arr[:n.width()] {
+		c := s.offset + k
+		if int(x) > int(n.d) || c < 0 || c > len(n.rs) {
+			continue
+		}
+		if n.trailing(c, int(x)) <= int(n.d) {
+			return true
+		}
+	}
+	for _, x := range s.arr[n.width():] {
+		if int(x) <= int(n.d) {
+			return true
+		}
+	}
+	return false
+}
+
+// distance returns the smallest cost at which the NFA state passed is
+// accepting, or the inactive cost if it isn't accepting.
+func (n wnfa) distance(s state) int {
+	min := int(n.inactive())
+	for k, x := range s.arr[:n.width()] {
+		c := s.offset + k
+		if int(x) > int(n.d) || c < 0 || c > len(n.rs) {
+			continue
+		}
+		min = minInt(min, n.trailing(c, int(x)))
+	}
+	for _, x := range s.arr[n.width():] {
+		min = minInt(min, int(x))
+	}
+	return int(n.cap(min))
+}
+
+// transition computes the effect of a rune transition on a set of NFA states.
+// It returns the new set of states along with the minimum cost among them.
+func (n wnfa) transition(s state, r rune) (state, int) {
+	ns := state{offset: s.offset + 1, arr: make([]int16, len(s.arr))}
+	t := ns.offset + n.lag() // The number of runes read so far.
+	min := int(n.inactive())
+	// The cheapest way to start the trailing chain of states is from the
+	// columns that are accepting before reading r.
+	tail := int(n.inactive())
+	for k, x := range s.arr[:n.width()] {
+		if c := s.offset + k; int(x) <= int(n.d) && c >= 0 && c <= len(n.rs) {
+			tail = minInt(tail, n.trailing(c, int(x)))
+		}
+	}
+	for k := 0; k < n.width(); k++ {
+		c := ns.offset + k
+		val := n.leading(c, t)
+		if c >= 0 && c <= len(n.rs) {
+			// Column c - 1 of s is at index k and column c of s is
+			// at index k + 1.
+			if c > 0 && int(s.arr[k]) <= int(n.d) {
+				if n.rs[c-1] == r {
+					val = minInt(val, int(s.arr[k]))
+				} else if n.ops&substitutions != 0 {
+					val = minInt(val, int(s.arr[k])+n.cs)
+				}
+			}
+			if n.ops&insertions != 0 && k+1 < n.width() && int(s.arr[k+1]) <= int(n.d) {
+				val = minInt(val, int(s.arr[k+1])+n.ci)
+			}
+			if n.ops&deletions != 0 && k > 0 {
+				val = minInt(val, int(ns.arr[k-1])+n.cd)
+			}
+		}
+		ns.arr[k] = n.cap(val)
+		min = minInt(min, int(ns.arr[k]))
+	}
+	for j := n.width(); j < len(s.arr); j++ {
+		// Each state in the trailing chain follows the previous one
+		// after reading one more rune.
+		val := int(n.inactive())
+		if n.ops&insertions != 0 {
+			val = minInt(tail+n.ca, int(s.arr[j])+n.ci)
+		}
+		tail = int(s.arr[j])
+		ns.arr[j] = n.cap(val)
+		min = minInt(min, int(ns.arr[j]))
+	}
+	return ns, min
+}
+
+func minInt(x int, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,239 @@
+package levtrie
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Returns the minimum total cost of edits that transform s into t, where
+// insertions are runes in t missing from s and deletions are runes in s missing
+// from t.
+func weightedEditDistance(s string, t string, c costs) int {
+	rs, rt := extractRunes(s), extractRunes(t)
+	prev := make([]int, len(rs)+1)
+	for i := range prev {
+		prev[i] = i * int(c.del)
+	}
+	for j := 1; j <= len(rt); j++ {
+		curr := make([]int, len(rs)+1)
+		curr[0] = j * int(c.ins)
+		for i := 1; i <= len(rs); i++ {
+			curr[i] = prev[i] + int(c.ins)
+			if x := curr[i-1] + int(c.del); x < curr[i] {
+				curr[i] = x
+			}
+			sub := int(c.sub)
+			if rs[i-1] == rt[j-1] {
+				sub = 0
+			}
+			if x := prev[i-1] + sub; x < curr[i] {
+				curr[i] = x
+			}
+		}
+		prev = curr
+	}
+	return prev[len(rs)]
+}
+
+func TestSuggestEditCosts(t *testing.T) {
+	data := []string{
+		"color", "colour", "colr", "colon", "cooler", "collar", "dolor", "col",
+	}
+	r := New()
+	var got, want string
+	unlimited := len(data) + 1
+	for _, key := range data {
+		r.Set(key, key)
+	}
+	got = keystr(r.Suggest("color", 2, unlimited, EditCosts(1, 3, 2)))
+	want = "colon color colour dolor"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	got = keystr(r.Suggest("color", 2, unlimited, EditCosts(3, 1, 3)))
+	want = "col color colr"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	got = keystr(r.Suggest("color", 3, unlimited, EditCosts(2, 2, 1)))
+	want = "collar colon color colour colr cooler dolor"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	got = keystr(r.Suggest("color", 1, unlimited, EditCosts(0, -1, 1)))
+	want = "colon color colour colr dolor"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+}
+
+func TestSuggestEditCostsFuzz(t *testing.T) {
+	rand.Seed(0)
+	r := New()
+	haystack := generateEdits(6, 3000)
+	for _, s := range haystack {
+		r.Set(s, s)
+	}
+	for i := 0; i < 20; i++ {
+		c := costs{
+			ins: int8(1 + rand.Intn(3)),
+			del: int8(1 + rand.Intn(3)),
+			sub: int8(1 + rand.Intn(3)),
+		}
+		dist := int8(rand.Intn(8))
+		needle := haystack[rand.Intn(len(haystack))]
+		var want []KV
+		for _, s := range haystack {
+			if weightedEditDistance(needle, s, c) <= int(dist) {
+				want = append(want, KV{Key: s, Value: s})
+			}
+		}
+		opt := EditCosts(c.ins, c.del, c.sub)
+		results := keystr(r.Suggest(needle, dist, len(haystack), opt))
+		if expected := keystr(want); results != expected {
+			t.Errorf("When asking for strings within cost %v of %v with "+
+				"costs %+v, got:\n%v\nbut want:\n%v", dist, needle, c,
+				results, expected)
+		}
+	}
+}
+
+func TestSuggestScaledEditCostsMatchUnitCosts(t *testing.T) {
+	rand.Seed(0)
+	r := New()
+	haystack := generateEdits(5, 2000)
+	for _, s := range haystack {
+		r.Set(s, s)
+	}
+	for dist := int8(0); dist < 5; dist++ {
+		needle := haystack[rand.Intn(len(haystack))]
+		for _, mode := range []SuggestOption{nil, DeletionsOnly(), InsertionsOnly()} {
+			opts := []SuggestOption{EditCosts(3, 3, 3)}
+			if mode != nil {
+				opts = append(opts, mode)
+			}
+			want := keystr(r.Suggest(needle, dist, len(haystack), opts[1:]...))
+			got := keystr(r.Suggest(needle, 3*dist, len(haystack), opts...))
+			if got != want {
+				t.Errorf("When asking for strings within %v of %v, got:\n"+
+					"%v\nbut want:\n%v", dist, needle, got, want)
+			}
+		}
+	}
+}
+
+func TestSuggestAffixTolerance(t *testing.T) {
+	data := []string{
+		"wa", "walk", "walks", "walked", "walking", "walkingly", "talk",
+		"the walk", "a walk", "sidewalk", "sidewalks",
+	}
+	r := New()
+	var got, want string
+	unlimited := len(data) + 1
+	for _, key := range data {
+		r.Set(key, key)
+	}
+	got = keystr(r.Suggest("walk", 0, unlimited, AffixTolerance(0, 3, 0)))
+	want = "wa walk walked walking walks"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	got = keystr(r.Suggest("walk", 0, unlimited, AffixTolerance(4, 0, 0)))
+	want = "a walk sidewalk talk the walk wa walk"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	got = keystr(r.Suggest("walk", 1, unlimited, AffixTolerance(4, 1, 0)))
+	want = "a walk sidewalk sidewalks talk the walk wa walk walked walks"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+	got = keystr(r.Suggest("walks", 2, unlimited, AffixTolerance(0, 3, 1)))
+	want = "talk walk walked walks"
+	if got != want {
+		t.Errorf("Got '%v', want '%v'\n", got, want)
+	}
+}
+
+func TestSuggestAffixToleranceEditModes(t *testing.T) {
+	data := []string{
+		"wa", "walk", "walks", "walked", "walking", "talk", "the walk",
+		"sidewalk", "alk",
+	}
+	r := New()
+	unlimited := len(data) + 1
+	for _, key := range data {
+		r.Set(key, key)
+	}
+	tests := []struct {
+		opts []SuggestOption
+		want string
+	}{
+		{[]SuggestOption{AffixTolerance(0, 3, 0), DeletionsOnly()}, "wa walk"},
+		{[]SuggestOption{AffixTolerance(0, 3, 0), InsertionsOnly()}, "walk walked walking walks"},
+		{[]SuggestOption{AffixTolerance(4, 0, 0), DeletionsOnly()}, "alk walk"},
+		{[]SuggestOption{AffixTolerance(4, 0, 0), InsertionsOnly()}, "sidewalk the walk walk"},
+	}
+	for _, test := range tests {
+		if got := keystr(r.Suggest("walk", 0, unlimited, test.opts...)); got != test.want {
+			t.Errorf("Got '%v', want '%v'\n", got, test.want)
+		}
+	}
+}
+
+// Returns the minimum cost of transforming s into t when up to pre runes
+// can be removed from the beginning of either string and up to suf runes can
+// be removed from the end of either string for cost ca each.
+func affixEditDistance(s string, t string, c costs, pre int, suf int, ca int) int {
+	rs, rt := extractRunes(s), extractRunes(t)
+	best := -1
+	for i := 0; i <= pre && i <= len(rs); i++ {
+		for j := 0; j <= pre && j <= len(rt); j++ {
+			for k := 0; k <= suf && i+k <= len(rs); k++ {
+				for l := 0; l <= suf && j+l <= len(rt); l++ {
+					x := (i+j+k+l)*ca + weightedEditDistance(
+						string(rs[i:len(rs)-k]), string(rt[j:len(rt)-l]), c)
+					if best < 0 || x < best {
+						best = x
+					}
+				}
+			}
+		}
+	}
+	return best
+}
+
+func TestSuggestAffixToleranceFuzz(t *testing.T) {
+	rand.Seed(0)
+	r := New()
+	haystack := generateEdits(6, 2000)
+	for _, s := range haystack {
+		r.Set(s, s)
+	}
+	for i := 0; i < 30; i++ {
+		c := costs{
+			ins: int8(1 + rand.Intn(2)),
+			del: int8(1 + rand.Intn(2)),
+			sub: int8(1 + rand.Intn(2)),
+		}
+		pre, suf, ca := rand.Intn(3), rand.Intn(3), rand.Intn(2)
+		dist := int8(rand.Intn(4))
+		needle := haystack[rand.Intn(len(haystack))]
+		var want []KV
+		for _, s := range haystack {
+			if affixEditDistance(needle, s, c, pre, suf, ca) <= int(dist) {
+				want = append(want, KV{Key: s, Value: s})
+			}
+		}
+		opts := []SuggestOption{
+			EditCosts(c.ins, c.del, c.sub),
+			AffixTolerance(pre, suf, int8(ca)),
+		}
+		results := keystr(r.Suggest(needle, dist, len(haystack), opts...))
+		if expected := keystr(want); results != expected {
+			t.Errorf("When asking for strings within cost %v of %v with "+
+				"costs %+v and affixes (%v, %v, %v), got:\n%v\nbut want:\n%v",
+				dist, needle, c, pre, suf, ca, results, expected)
+		}
+	}
+}